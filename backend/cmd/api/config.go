@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ingsw3-tp08/internal/config"
+)
+
+// runConfigPrint implementa "api config print [flags]": muestra la configuración
+// efectiva, de dónde salió cada valor y los secretos ocultos. Si la configuración
+// no es válida lo informa después de imprimirla.
+func runConfigPrint(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("configuración inválida:\n%w", err)
+	}
+	return nil
+}
@@ -1,55 +1,296 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/config"
 	"ingsw3-tp08/internal/database"
 	"ingsw3-tp08/internal/handlers"
+	"ingsw3-tp08/internal/health"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/mail"
+	"ingsw3-tp08/internal/maintenance"
+	"ingsw3-tp08/internal/metrics"
+	"ingsw3-tp08/internal/ratelimit"
 	"ingsw3-tp08/internal/repository"
 	"ingsw3-tp08/internal/router"
+	"ingsw3-tp08/internal/server"
 	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/internal/tracing"
 )
 
 func main() {
-	// Obtener URL de base de datos desde variable de entorno
-	databaseURL := os.Getenv("DATABASE_URL")
-	if databaseURL == "" {
-		log.Fatal("DATABASE_URL environment variable is required")
+	// Subcomando para revisar la configuración efectiva: "api config print [flags]"
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		if err := runConfigPrint(os.Args[3:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
-	// Inicializar base de datos
-	db, err := database.InitDB(databaseURL)
+	// Subcomandos que solo necesitan la base: la configuración sale del archivo y el entorno
+	if len(os.Args) > 1 && (os.Args[1] == "migrate" || os.Args[1] == "role") {
+		cfg, err := config.Load(nil)
+		if err != nil {
+			log.Fatal("Error en la configuración:", err)
+		}
+		if cfg.Database.URL == "" {
+			log.Fatal("database.url es requerido (DATABASE_URL)")
+		}
+
+		// "api migrate up|down|status"
+		if os.Args[1] == "migrate" {
+			if err := runMigrate(cfg.Database.URL, cfg.DatabaseOptions(), os.Args[2:]); err != nil {
+				log.Fatal("Error en las migraciones:", err)
+			}
+			return
+		}
+
+		// Asignar el primer admin: "api role <email> <rol>"
+		if err := runRole(cfg.Database.URL, cfg.DatabaseOptions(), os.Args[2:]); err != nil {
+			log.Fatal("Error al asignar el rol:", err)
+		}
+		return
+	}
+
+	// Configuración: defaults < archivo < entorno < flags, validada completa antes de arrancar
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("Error en la configuración:", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Configuración inválida:\n%v", err)
+	}
+
+	// Logs en JSON. slog.SetDefault también redirige el paquete log,
+	// así que los log.Fatal de abajo salen en el mismo formato.
+	logger, err := logging.New(os.Stderr, cfg.Log.Level)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	// Inicializar base de datos: espera a que PostgreSQL responda y aplica las migraciones pendientes
+	db, err := database.InitDB(context.Background(), cfg.Database.URL, cfg.DatabaseOptions())
 	if err != nil {
 		log.Fatal("Error al inicializar la base de datos:", err)
 	}
-	defer db.Close()
 
-	// Crear repositorios
-	userRepo := repository.NewPostgreSQLUserRepository(db)
-	postRepo := repository.NewPostgreSQLPostRepository(db)
+	// Crear repositorios (las lecturas se reintentan ante errores transitorios)
+	readRetry := cfg.ReadRetry()
+	userRepo := repository.NewPostgreSQLUserRepository(db).WithReadRetry(readRetry)
+	postRepo := repository.NewPostgreSQLPostRepository(db).WithReadRetry(readRetry)
+	sessionRepo := repository.NewPostgreSQLSessionRepository(db).WithReadRetry(readRetry)
+	searchRepo := repository.NewPostgreSQLSearchRepository(db).WithReadRetry(readRetry)
+	loginThrottleRepo := repository.NewPostgreSQLLoginThrottleRepository(db).WithReadRetry(readRetry)
+	userTokenRepo := repository.NewPostgreSQLUserTokenRepository(db).WithReadRetry(readRetry)
+	twoFactorRepo := repository.NewPostgreSQLTwoFactorRepository(db).WithReadRetry(readRetry)
+	apiTokenRepo := repository.NewPostgreSQLAPITokenRepository(db).WithReadRetry(readRetry)
+
+	// Costo de bcrypt (0 = bcrypt.DefaultCost)
+	hasher := auth.NewBcryptHasher(cfg.Auth.BcryptCost)
+
+	// Firmador de tokens de acceso
+	tokenManager, err := newTokenManager(cfg.Auth)
+	if err != nil {
+		log.Fatal("Error al configurar los tokens JWT:", err)
+	}
+
+	// Trazas OpenTelemetry
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingSetup())
+	if err != nil {
+		log.Fatal("Error al configurar las trazas:", err)
+	}
 
 	// Crear servicios
-	authService := services.NewAuthService(userRepo)
-	postService := services.NewPostService(postRepo, userRepo)
+	// Métricas HTTP, del pool de conexiones y de eventos de negocio (GET /metrics)
+	appMetrics := metrics.New(db)
+
+	// Demoras y bloqueo ante logins fallidos, por cuenta y por IP
+	var loginThrottle *services.LoginThrottle
+	if cfg.Login.Enabled {
+		loginThrottle = services.NewLoginThrottle(loginThrottleRepo, cfg.LoginThrottlePolicy())
+	}
+
+	// Mails de recuperación de contraseña y de verificación del email
+	mailer, err := newMailer(&cfg.Config)
+	if err != nil {
+		log.Fatal("Error al configurar el envío de mails:", err)
+	}
+	passwordReset := services.NewPasswordReset(userTokenRepo, sessionRepo, mailer, cfg.PasswordResetConfig()).
+		WithAPITokenRepository(apiTokenRepo)
+	// Con el bloqueo de logins, el dueño puede levantarlo con un link por mail
+	var accountUnlock *services.AccountUnlock
+	if loginThrottle != nil {
+		accountUnlock = services.NewAccountUnlock(userTokenRepo, mailer, cfg.AccountUnlockConfig())
+	}
+	// Los links de verificación van firmados: no se guardan en la base
+	linkSigner, err := auth.NewEmailLinkSigner([]byte(cfg.Auth.EmailVerificationSecret))
+	if err != nil {
+		log.Fatal("Error al configurar la verificación del email:", err)
+	}
+	emailVerification := services.NewEmailVerification(linkSigner, mailer, cfg.EmailVerificationConfig())
+
+	// Verificación en dos pasos: los secretos TOTP se guardan cifrados
+	secretBox, err := auth.NewSecretBox(cfg.MFAEncryptionKey())
+	if err != nil {
+		log.Fatal("Error al configurar la verificación en dos pasos:", err)
+	}
+	twoFactor := services.NewTwoFactor(twoFactorRepo, userTokenRepo, secretBox, cfg.TwoFactorConfig())
+
+	authService := services.NewAuthService(userRepo, hasher).
+		WithEvents(appMetrics).
+		WithLoginThrottle(loginThrottle).
+		WithPasswordReset(passwordReset).
+		WithAccountUnlock(accountUnlock).
+		WithEmailVerification(emailVerification).
+		WithTwoFactor(twoFactor)
+	postService := services.NewPostService(postRepo, userRepo).
+		WithEvents(appMetrics).
+		WithMaxCommentDepth(cfg.Posts.MaxCommentDepth).
+		WithRequireVerifiedEmail(cfg.Posts.RequireVerifiedEmail).
+		WithMFARequiredRoles(cfg.MFA.RequiredRoles...)
+	// Tokens de API personales: el middleware los acepta como alternativa a la sesión
+	apiTokenService := services.NewAPITokenService(apiTokenRepo, cfg.APITokenConfig())
+	sessionService := services.NewSessionService(sessionRepo, tokenManager, cfg.Auth.RefreshTokenTTL).
+		WithAPITokens(apiTokenService)
+	searchService := services.NewSearchService(searchRepo)
+	adminService := services.NewAdminService(userRepo, sessionRepo).
+		WithLoginThrottle(loginThrottle).
+		WithAPITokenRepository(apiTokenRepo).
+		WithMFARequiredRoles(cfg.MFA.RequiredRoles...)
 
 	// Crear handlers
-	authHandler := handlers.NewAuthHandler(authService)
+	authHandler := handlers.NewAuthHandler(authService, sessionService)
 	postHandler := handlers.NewPostHandler(postService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	adminHandler := handlers.NewAdminHandler(adminService)
+	apiTokenHandler := handlers.NewAPITokenHandler(apiTokenService)
+
+	// Servidor HTTP con timeouts y apagado ordenado
+	srv := server.New(cfg.HTTPServer())
+
+	// Chequeos de /readyz
+	healthHandler, err := newHealthHandler(db, srv, cfg.Health)
+	if err != nil {
+		log.Fatal("Error en la configuración de los health checks:", err)
+	}
+
+	// Rate limiting: en memoria con una instancia, en PostgreSQL con varias
+	rateLimitStore := newRateLimitStore(db, cfg.RateLimit.Backend)
 
 	// Configurar rutas
-	r := router.Setup(authHandler, postHandler)
+	r := router.Setup(authHandler, postHandler, searchHandler, adminHandler, apiTokenHandler, healthHandler, sessionService, router.Options{
+		RequestTimeout: cfg.Server.RequestTimeout,
+		Logger:         logger,
+		Metrics:        appMetrics,
+		CORS:           cfg.RouterCORS(),
+		RateLimit:      cfg.RouterRateLimit(rateLimitStore),
+		TrustedProxies: cfg.TrustedProxies(),
+	})
+
+	// SIGTERM (docker stop, Kubernetes) o Ctrl+C inician el apagado ordenado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Definir puerto desde variable de entorno o default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	if rateLimitStore != nil {
+		go maintenance.PruneEvery(ctx, rateLimitStore, cfg.RateLimit.PruneInterval, func(err error) {
+			slog.Warn("Error al limpiar el rate limiter", "error", err)
+		})
+	}
+	// Borra los links vencidos de user_tokens, de recuperación y de verificación
+	go maintenance.PruneEvery(ctx, passwordReset, time.Hour, func(err error) {
+		slog.Warn("Error al limpiar los links vencidos", "error", err)
+	})
+	if loginThrottle != nil {
+		go maintenance.PruneEvery(ctx, loginThrottle, cfg.Login.PruneInterval, func(err error) {
+			slog.Warn("Error al limpiar los logins fallidos", "error", err)
+		})
+	}
+
+	slog.Info("🚀 Servidor corriendo", "addr", cfg.Addr())
+	runErr := srv.Run(ctx, r)
+
+	// Los requests ya terminaron: se envían los últimos spans y recién
+	// entonces se cierra el pool de conexiones
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("Error al cerrar el exportador de trazas", "error", err)
+	}
+	cancel()
+	if err := db.Close(); err != nil {
+		slog.Error("Error al cerrar la base de datos", "error", err)
 	}
+	if runErr != nil {
+		log.Fatal("Error del servidor:", runErr)
+	}
+}
+
+// newMailer elige cómo salen los mails: por SMTP, a archivos .eml o solo al log
+func newMailer(cfg *config.Config) (mail.Mailer, error) {
+	switch cfg.Mail.Backend {
+	case config.MailSMTP:
+		return mail.NewSMTPMailer(cfg.SMTP()), nil
+	case config.MailFile:
+		return mail.NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	default:
+		slog.Warn("Los mails no se envían, solo se registran en el log (MAIL_BACKEND=log)")
+		return mail.NewLogMailer(), nil
+	}
+}
+
+// newRateLimitStore elige dónde se guardan los buckets del rate limiter
+func newRateLimitStore(db *sql.DB, backend string) ratelimit.Store {
+	switch backend {
+	case config.RateLimitPostgres:
+		return ratelimit.NewPostgresStore(db)
+	case config.RateLimitNone:
+		return nil
+	default:
+		return ratelimit.NewMemoryStore()
+	}
+}
+
+// newHealthHandler arma los chequeos de readiness
+func newHealthHandler(db *sql.DB, srv *server.Server, cfg config.HealthConfig) (*handlers.HealthHandler, error) {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return handlers.NewHealthHandler(srv.Ready, cfg.CheckTimeout,
+		health.Ping(db),
+		health.Migrations(migrator.Pending),
+		health.Pool(db.Stats, cfg.PoolSaturation),
+	), nil
+}
 
-	// Iniciar servidor
-	log.Printf("🚀 Servidor corriendo en http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatal("Error al iniciar el servidor:", err)
+// newTokenManager arma el firmador JWT: HS256 con un secreto compartido
+// o EdDSA con una clave privada Ed25519 en PEM
+func newTokenManager(cfg config.AuthConfig) (*auth.TokenManager, error) {
+	switch cfg.JWTAlgorithm {
+	case auth.AlgorithmHS256:
+		return auth.NewHS256TokenManager([]byte(cfg.JWTSecret), cfg.JWTIssuer, cfg.AccessTokenTTL)
+	case auth.AlgorithmEdDSA:
+		pemBytes, err := os.ReadFile(cfg.JWTPrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		privateKey, err := auth.ParseEdDSAPrivateKey(pemBytes)
+		if err != nil {
+			return nil, err
+		}
+		return auth.NewEdDSATokenManager(privateKey, cfg.JWTIssuer, cfg.AccessTokenTTL)
+	default:
+		return nil, fmt.Errorf("algoritmo JWT no soportado: %q", cfg.JWTAlgorithm)
 	}
 }
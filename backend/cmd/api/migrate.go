@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"ingsw3-tp08/internal/database"
+)
+
+// runMigrate implementa el subcomando de migraciones:
+//
+//	api migrate up        aplica las migraciones pendientes
+//	api migrate down [N]  revierte las últimas N migraciones (default 1)
+//	api migrate status    lista las migraciones y cuándo se aplicaron
+func runMigrate(databaseURL string, opts database.Options, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("uso: migrate up | down [N] | status")
+	}
+
+	db, err := database.Open(context.Background(), databaseURL, opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migraciones aplicadas\n", len(applied))
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps <= 0 {
+				return fmt.Errorf("cantidad de pasos inválida: %q", args[1])
+			}
+		}
+		reverted, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migraciones revertidas\n", len(reverted))
+		return nil
+
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSIÓN\tNOMBRE\tAPLICADA")
+		for _, status := range statuses {
+			appliedAt := "pendiente"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("subcomando desconocido %q: uso migrate up | down [N] | status", args[0])
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// runRole asigna un rol sin pasar por la API. Sirve para crear el primer admin,
+// que después puede asignar roles desde /api/admin/users/{id}/role:
+//
+//	api role <email> <user|moderator|admin>
+func runRole(databaseURL string, opts database.Options, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("uso: role <email> <user|moderator|admin>")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(args[0]))
+	role := args[1]
+	if !models.IsValidRole(role) {
+		return fmt.Errorf("rol inválido: %q", role)
+	}
+
+	ctx := context.Background()
+	db, err := database.InitDB(ctx, databaseURL, opts)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userRepo := repository.NewPostgreSQLUserRepository(db)
+	user, err := userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf("no existe un usuario con email %s", email)
+	}
+
+	if err := userRepo.UpdateRole(ctx, user.ID, role); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s (%s) ahora es %s\n", user.Username, user.Email, role)
+	return nil
+}
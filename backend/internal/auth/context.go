@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// Principal es la identidad autenticada de quien hace el request:
+// una sesión (SessionID) o un token de API (APITokenID y Scopes)
+type Principal struct {
+	UserID     int
+	SessionID  string
+	APITokenID int
+	Scopes     []string
+}
+
+// Allows indica si la identidad puede usar una ruta que pide scope.
+// Las sesiones pueden todo; los tokens de API solo lo que declaran sus scopes,
+// y nunca las rutas sin scope (scope vacío), que quedan para las sesiones.
+func (p *Principal) Allows(scope string) bool {
+	if p.APITokenID == 0 {
+		return true
+	}
+	if scope == "" {
+		return false
+	}
+	if slices.Contains(p.Scopes, scope) {
+		return true
+	}
+	// El scope de escritura incluye el de lectura del mismo recurso
+	resource, found := strings.CutSuffix(scope, ":read")
+	return found && slices.Contains(p.Scopes, resource+":write")
+}
+
+// Authenticator valida un bearer token y devuelve la identidad asociada
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+type contextKey struct{}
+
+// WithPrincipal guarda la identidad autenticada en el contexto
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// PrincipalFromContext obtiene la identidad autenticada del contexto
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(contextKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+// UserIDFromContext obtiene el ID del usuario autenticado del contexto
+func UserIDFromContext(ctx context.Context) (int, bool) {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return 0, false
+	}
+	return principal.UserID, true
+}
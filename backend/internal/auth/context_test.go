@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_Allows(t *testing.T) {
+	session := &Principal{UserID: 1, SessionID: "sesion-1"}
+	token := &Principal{UserID: 1, APITokenID: 7, Scopes: []string{"posts:write", "comments:read"}}
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		scope     string
+		want      bool
+	}{
+		{"la sesión puede todo", session, "comments:write", true},
+		{"la sesión usa rutas sin scope", session, "", true},
+		{"el token usa sus scopes", token, "posts:write", true},
+		{"escritura incluye lectura", token, "posts:read", true},
+		{"lectura no incluye escritura", token, "comments:write", false},
+		{"el token no usa rutas sin scope", token, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.principal.Allows(tt.scope))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailLinkSigner_SignAndVerify(t *testing.T) {
+	signer, err := NewEmailLinkSigner(bytes.Repeat([]byte{1}, MinSecretLength))
+	require.NoError(t, err)
+	expiresAt := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+
+	token := signer.Sign(7, "ana@example.com", expiresAt)
+	assert.NotContains(t, token, "ana")
+
+	link, err := ParseEmailLink(token)
+	require.NoError(t, err)
+	assert.Equal(t, 7, link.UserID)
+	assert.True(t, link.ExpiresAt.Equal(expiresAt))
+
+	assert.True(t, signer.Verify(token, "ana@example.com"))
+	assert.True(t, signer.Verify(token, " ANA@example.com"))
+}
+
+func TestEmailLinkSigner_Rechazos(t *testing.T) {
+	signer, err := NewEmailLinkSigner(bytes.Repeat([]byte{1}, MinSecretLength))
+	require.NoError(t, err)
+	other, err := NewEmailLinkSigner(bytes.Repeat([]byte{2}, MinSecretLength))
+	require.NoError(t, err)
+	expiresAt := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	token := signer.Sign(7, "ana@example.com", expiresAt)
+
+	// Otro email (el usuario lo cambió) u otra clave
+	assert.False(t, signer.Verify(token, "otra@example.com"))
+	assert.False(t, other.Verify(token, "ana@example.com"))
+
+	// Cambiar el usuario o el vencimiento rompe la firma
+	parts := strings.Split(token, ".")
+	otherUser := "8." + parts[1] + "." + parts[2]
+	extended := parts[0] + "." + strconv.FormatInt(expiresAt.Add(time.Hour).Unix(), 10) + "." + parts[2]
+	assert.False(t, signer.Verify(otherUser, "ana@example.com"))
+	assert.False(t, signer.Verify(extended, "ana@example.com"))
+
+	for _, malformed := range []string{"", "abc", "7.123", "x.123.firma", "0.123.firma", "7.x.firma"} {
+		_, err := ParseEmailLink(malformed)
+		assert.ErrorIs(t, err, ErrInvalidEmailLink, malformed)
+	}
+	assert.False(t, signer.Verify("7.123.no-es-base64!", "ana@example.com"))
+}
+
+func TestNewEmailLinkSigner_ClaveCorta(t *testing.T) {
+	_, err := NewEmailLinkSigner([]byte("corta"))
+	assert.Error(t, err)
+}
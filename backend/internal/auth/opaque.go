@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// NewOpaqueToken genera un token aleatorio de 256 bits apto para URLs.
+// Se entrega una sola vez al cliente; en la base se guarda solo su hash.
+func NewOpaqueToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// APITokenPrefix distingue los tokens de API de los JWT de sesión
+const APITokenPrefix = "pat_"
+
+// apiTokenVisibleLength es cuánto del token se guarda en claro para reconocerlo
+const apiTokenVisibleLength = len(APITokenPrefix) + 8
+
+// NewAPIToken genera un token de API ("pat_" + token opaco) y su prefijo visible
+func NewAPIToken() (token string, prefix string, err error) {
+	opaque, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	token = APITokenPrefix + opaque
+	return token, token[:apiTokenVisibleLength], nil
+}
+
+// IsAPIToken indica si el bearer token es un token de API y no un JWT de sesión
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+// NewRandomID genera un identificador público aleatorio de 128 bits
+func NewRandomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// HashToken devuelve el SHA-256 de un token opaco.
+// Alcanza con un hash rápido porque el token ya tiene 256 bits de entropía.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
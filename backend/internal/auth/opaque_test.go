@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAPIToken(t *testing.T) {
+	token, prefix, err := NewAPIToken()
+
+	require.NoError(t, err)
+	assert.True(t, IsAPIToken(token))
+	assert.True(t, strings.HasPrefix(token, prefix))
+	assert.Len(t, prefix, 12)
+	assert.Greater(t, len(token), 40)
+
+	other, _, err := NewAPIToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestIsAPIToken_NoConfundeJWT(t *testing.T) {
+	assert.False(t, IsAPIToken("eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.firma"))
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxPasswordLength es el máximo de bytes que bcrypt puede hashear
+const MaxPasswordLength = 72
+
+// ErrPasswordTooLong indica una contraseña de más de MaxPasswordLength bytes
+var ErrPasswordTooLong = errors.New("la contraseña supera los 72 bytes")
+
+// PasswordHasher define cómo se hashean y verifican las contraseñas
+// INTERFACE: permite cambiar el algoritmo o usar uno más barato en tests
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Compare(hash string, password string) (bool, error)
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher implementa PasswordHasher usando bcrypt
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher crea un hasher con el costo indicado.
+// Si el costo está fuera del rango permitido por bcrypt se usa el default.
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+// Cost devuelve el costo configurado
+func (h *BcryptHasher) Cost() int {
+	return h.cost
+}
+
+// Hash genera el hash bcrypt de una contraseña
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > MaxPasswordLength {
+		return "", ErrPasswordTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Compare verifica una contraseña contra su hash.
+// Devuelve false (sin error) cuando la contraseña no coincide.
+func (h *BcryptHasher) Compare(hash string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// NeedsRehash indica si el hash fue generado con un costo distinto al actual
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
+
+// IsHashed indica si el valor almacenado ya es un hash bcrypt.
+// Las filas viejas de users.password guardan la contraseña en texto plano.
+func IsHashed(stored string) bool {
+	return len(stored) == 60 &&
+		(strings.HasPrefix(stored, "$2a$") ||
+			strings.HasPrefix(stored, "$2b$") ||
+			strings.HasPrefix(stored, "$2y$"))
+}
+
+// ComparePlaintext compara una contraseña legacy en texto plano en tiempo constante
+func ComparePlaintext(stored string, password string) bool {
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcryptHasher_HashAndCompare(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+
+	hash, err := hasher.Hash("secreto123")
+	assert.NoError(t, err)
+	assert.True(t, IsHashed(hash))
+	assert.NotContains(t, hash, "secreto123")
+
+	ok, err := hasher.Compare(hash, "secreto123")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = hasher.Compare(hash, "otra")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestBcryptHasher_PasswordDemasiadoLarga(t *testing.T) {
+	hasher := NewBcryptHasher(bcrypt.MinCost)
+
+	hash, err := hasher.Hash(strings.Repeat("a", MaxPasswordLength+1))
+	assert.ErrorIs(t, err, ErrPasswordTooLong)
+	assert.Empty(t, hash)
+
+	// 72 bytes es el máximo que sí se hashea
+	hash, err = hasher.Hash(strings.Repeat("a", MaxPasswordLength))
+	assert.NoError(t, err)
+	assert.True(t, IsHashed(hash))
+}
+
+func TestBcryptHasher_CostoInvalidoUsaDefault(t *testing.T) {
+	assert.Equal(t, bcrypt.DefaultCost, NewBcryptHasher(0).Cost())
+	assert.Equal(t, bcrypt.DefaultCost, NewBcryptHasher(bcrypt.MaxCost+1).Cost())
+	assert.Equal(t, bcrypt.MinCost, NewBcryptHasher(bcrypt.MinCost).Cost())
+}
+
+func TestBcryptHasher_NeedsRehash(t *testing.T) {
+	oldHasher := NewBcryptHasher(bcrypt.MinCost)
+	newHasher := NewBcryptHasher(bcrypt.MinCost + 1)
+
+	hash, err := oldHasher.Hash("secreto123")
+	assert.NoError(t, err)
+
+	assert.False(t, oldHasher.NeedsRehash(hash))
+	assert.True(t, newHasher.NeedsRehash(hash))
+	assert.True(t, newHasher.NeedsRehash("texto-plano"))
+}
+
+func TestIsHashed(t *testing.T) {
+	assert.False(t, IsHashed("123456"))
+	assert.False(t, IsHashed("$2a$short"))
+	assert.True(t, IsHashed("$2a$10$"+strings.Repeat("a", 53)))
+	assert.True(t, IsHashed("$2b$10$"+strings.Repeat("a", 53)))
+}
+
+func TestComparePlaintext(t *testing.T) {
+	assert.True(t, ComparePlaintext("123456", "123456"))
+	assert.False(t, ComparePlaintext("123456", "1234567"))
+}
@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// SecretKeyLength es el largo de la clave de SecretBox (AES-256)
+const SecretKeyLength = 32
+
+// SecretBox cifra con AES-256-GCM los secretos que hay que poder leer de nuevo,
+// como el secreto TOTP: a diferencia de las contraseñas, no alcanza con un hash
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox crea una nueva instancia con una clave de 32 bytes
+func NewSecretBox(key []byte) (*SecretBox, error) {
+	if len(key) != SecretKeyLength {
+		return nil, fmt.Errorf("la clave debe tener %d bytes, tiene %d", SecretKeyLength, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal cifra plaintext con un nonce aleatorio y devuelve nonce+cifrado en base64
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open descifra lo que devolvió Seal. Falla si se cifró con otra clave o se alteró.
+func (b *SecretBox) Open(ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < b.aead.NonceSize() {
+		return "", errors.New("texto cifrado demasiado corto")
+	}
+	nonce, sealed := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
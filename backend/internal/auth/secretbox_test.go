@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretBox_SealAndOpen(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{1}, SecretKeyLength))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal(rfcSecret)
+	require.NoError(t, err)
+	assert.NotContains(t, sealed, rfcSecret)
+
+	// El nonce es aleatorio: el mismo secreto no se cifra igual dos veces
+	again, err := box.Seal(rfcSecret)
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, rfcSecret, opened)
+}
+
+func TestSecretBox_OtraClaveNoAbre(t *testing.T) {
+	box, err := NewSecretBox(bytes.Repeat([]byte{1}, SecretKeyLength))
+	require.NoError(t, err)
+	other, err := NewSecretBox(bytes.Repeat([]byte{2}, SecretKeyLength))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal(rfcSecret)
+	require.NoError(t, err)
+
+	_, err = other.Open(sealed)
+	assert.Error(t, err)
+}
+
+func TestNewSecretBox_ClaveCorta(t *testing.T) {
+	_, err := NewSecretBox([]byte("corta"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algoritmos de firma soportados
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// MinSecretLength es el largo mínimo del secreto para HS256 (256 bits)
+const MinSecretLength = 32
+
+// ErrInvalidToken se devuelve cuando el token no es válido o expiró
+var ErrInvalidToken = errors.New("token inválido o expirado")
+
+// TokenIssuer emite tokens de acceso para una sesión de un usuario
+type TokenIssuer interface {
+	Issue(userID int, sessionID string) (string, time.Time, error)
+}
+
+// accessClaims son los datos firmados dentro del token de acceso
+type accessClaims struct {
+	SessionID string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager firma y valida tokens JWT
+type TokenManager struct {
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+	issuer    string
+	ttl       time.Duration
+}
+
+// NewHS256TokenManager crea un TokenManager que firma con HMAC-SHA256
+func NewHS256TokenManager(secret []byte, issuer string, ttl time.Duration) (*TokenManager, error) {
+	if len(secret) < MinSecretLength {
+		return nil, errors.New("el secreto JWT debe tener al menos 32 bytes")
+	}
+	return &TokenManager{
+		method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+		issuer:    issuer,
+		ttl:       ttl,
+	}, nil
+}
+
+// NewEdDSATokenManager crea un TokenManager que firma con Ed25519
+func NewEdDSATokenManager(privateKey ed25519.PrivateKey, issuer string, ttl time.Duration) (*TokenManager, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("la clave privada Ed25519 es inválida")
+	}
+	return &TokenManager{
+		method:    jwt.SigningMethodEdDSA,
+		signKey:   privateKey,
+		verifyKey: privateKey.Public(),
+		issuer:    issuer,
+		ttl:       ttl,
+	}, nil
+}
+
+// ParseEdDSAPrivateKey lee una clave privada Ed25519 en formato PEM (PKCS#8)
+func ParseEdDSAPrivateKey(pemBytes []byte) (ed25519.PrivateKey, error) {
+	key, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("la clave no es Ed25519")
+	}
+	return privateKey, nil
+}
+
+// TTL devuelve la duración de los tokens emitidos
+func (m *TokenManager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Issue firma un token de acceso para el usuario y su sesión
+func (m *TokenManager) Issue(userID int, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(m.ttl)
+
+	claims := accessClaims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(userID),
+			Issuer:    m.issuer,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// Authenticate valida la firma, el emisor y la expiración del token
+// y devuelve la identidad que contiene. No accede a la base, así que ignora el contexto.
+func (m *TokenManager) Authenticate(_ context.Context, tokenString string) (*Principal, error) {
+	claims := &accessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return m.verifyKey, nil
+	},
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil || userID <= 0 {
+		return nil, ErrInvalidToken
+	}
+
+	return &Principal{UserID: userID, SessionID: claims.SessionID}, nil
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testSecret = []byte("test-secret-de-al-menos-32-bytes!!")
+
+func TestNewHS256TokenManager_SecretoCorto(t *testing.T) {
+	_, err := NewHS256TokenManager([]byte("corto"), "test", time.Minute)
+	assert.Error(t, err)
+}
+
+func TestTokenManager_HS256_IssueAndAuthenticate(t *testing.T) {
+	tokens, err := NewHS256TokenManager(testSecret, "test", time.Minute)
+	assert.NoError(t, err)
+
+	token, expiresAt, err := tokens.Issue(7, "sesion-1")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, 2*time.Second)
+
+	principal, err := tokens.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, principal.UserID)
+	assert.Equal(t, "sesion-1", principal.SessionID)
+}
+
+func TestTokenManager_EdDSA_IssueAndAuthenticate(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	assert.NoError(t, err)
+	parsed, err := ParseEdDSAPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	assert.NoError(t, err)
+
+	tokens, err := NewEdDSATokenManager(parsed, "test", time.Minute)
+	assert.NoError(t, err)
+
+	token, _, err := tokens.Issue(3, "sesion-1")
+	assert.NoError(t, err)
+
+	principal, err := tokens.Authenticate(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, principal.UserID)
+}
+
+func TestTokenManager_RechazaTokenExpirado(t *testing.T) {
+	tokens, err := NewHS256TokenManager(testSecret, "test", -time.Minute)
+	assert.NoError(t, err)
+
+	token, _, err := tokens.Issue(1, "sesion-1")
+	assert.NoError(t, err)
+
+	_, err = tokens.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_RechazaOtroSecretoYOtroEmisor(t *testing.T) {
+	tokens, err := NewHS256TokenManager(testSecret, "test", time.Minute)
+	assert.NoError(t, err)
+	token, _, err := tokens.Issue(1, "sesion-1")
+	assert.NoError(t, err)
+
+	otherSecret, err := NewHS256TokenManager([]byte("otro-secreto-de-al-menos-32-bytes!"), "test", time.Minute)
+	assert.NoError(t, err)
+	_, err = otherSecret.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	otherIssuer, err := NewHS256TokenManager(testSecret, "otro", time.Minute)
+	assert.NoError(t, err)
+	_, err = otherIssuer.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManager_RechazaAlgoritmoDistinto(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	edTokens, err := NewEdDSATokenManager(privateKey, "test", time.Minute)
+	assert.NoError(t, err)
+	token, _, err := edTokens.Issue(1, "sesion-1")
+	assert.NoError(t, err)
+
+	hsTokens, err := NewHS256TokenManager(testSecret, "test", time.Minute)
+	assert.NoError(t, err)
+	_, err = hsTokens.Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret es "12345678901234567890" en base32, el secreto de los vectores del RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_VectoresRFC6238(t *testing.T) {
+	// El RFC da códigos de 8 dígitos: los de 6 son los últimos 6
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tt := range tests {
+		code, err := TOTPCode(rfcSecret, TOTPStep(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, tt.code, code, "t=%d", tt.unix)
+	}
+}
+
+func TestVerifyTOTP_ToleraUnPaso(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	previous, err := TOTPCode(rfcSecret, TOTPStep(now)-1)
+	require.NoError(t, err)
+	tooOld, err := TOTPCode(rfcSecret, TOTPStep(now)-2)
+	require.NoError(t, err)
+
+	step, ok := VerifyTOTP(rfcSecret, previous, now)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now)-1, step)
+
+	_, ok = VerifyTOTP(rfcSecret, tooOld, now)
+	assert.False(t, ok)
+
+	_, ok = VerifyTOTP(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestNewTOTPSecret_GeneraCodigosVerificables(t *testing.T) {
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	now := time.Now()
+	code, err := TOTPCode(secret, TOTPStep(now))
+	require.NoError(t, err)
+	_, ok := VerifyTOTP(secret, code, now)
+	assert.True(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := TOTPURI("Blog", "ana@example.com", rfcSecret)
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/Blog:ana@example.com", parsed.Path)
+	assert.Equal(t, rfcSecret, parsed.Query().Get("secret"))
+	assert.Equal(t, "Blog", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+}
+
+func TestRecoveryCode_SeNormalizaComoLoTipeaElUsuario(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+	assert.Regexp(t, `^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`, code)
+
+	typed := strings.ToUpper(strings.ReplaceAll(code, "-", " "))
+	assert.Equal(t, NormalizeRecoveryCode(code), NormalizeRecoveryCode(typed))
+}
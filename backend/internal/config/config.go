@@ -0,0 +1,509 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	netmail "net/mail"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/mail"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/ratelimit"
+	"ingsw3-tp08/internal/router"
+	"ingsw3-tp08/internal/server"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/internal/tracing"
+)
+
+// Config es toda la configuración de la API.
+//
+// Cada campo declara su clave en el archivo (key), su variable de entorno (env),
+// su valor por defecto (default) y si es un secreto que no se imprime (secret).
+// El flag de línea de comandos es la clave completa: -server.port=9090.
+type Config struct {
+	Database  DatabaseConfig  `key:"database"`
+	Log       LogConfig       `key:"log"`
+	Server    ServerConfig    `key:"server"`
+	Auth      AuthConfig      `key:"auth"`
+	Posts     PostsConfig     `key:"posts"`
+	CORS      CORSConfig      `key:"cors"`
+	Health    HealthConfig    `key:"health"`
+	Tracing   TracingConfig   `key:"tracing"`
+	RateLimit RateLimitConfig `key:"rate_limit"`
+	Login     LoginConfig     `key:"login_throttle"`
+	Mail      MailConfig      `key:"mail"`
+	MFA       MFAConfig       `key:"mfa"`
+}
+
+// DatabaseConfig es la conexión con PostgreSQL, el pool y los reintentos
+type DatabaseConfig struct {
+	URL                   string        `key:"url" env:"DATABASE_URL" secret:"url" desc:"URL de conexión a PostgreSQL"`
+	MaxOpenConns          int           `key:"max_open_conns" env:"DB_MAX_OPEN_CONNS" default:"25" desc:"Conexiones abiertas como máximo (0 = sin límite)"`
+	MaxIdleConns          int           `key:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" default:"10" desc:"Conexiones ociosas que se conservan"`
+	ConnMaxLifetime       time.Duration `key:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m" desc:"Vida máxima de una conexión (0 = sin límite)"`
+	ConnMaxIdleTime       time.Duration `key:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" default:"5m" desc:"Tiempo máximo de una conexión ociosa (0 = sin límite)"`
+	ConnectTimeout        time.Duration `key:"connect_timeout" env:"DB_CONNECT_TIMEOUT" default:"60s" desc:"Espera máxima a que PostgreSQL responda al arrancar (0 = un intento)"`
+	ConnectInitialBackoff time.Duration `key:"connect_initial_backoff" env:"DB_CONNECT_INITIAL_BACKOFF" default:"500ms" desc:"Primera espera entre intentos de conexión"`
+	ConnectMaxBackoff     time.Duration `key:"connect_max_backoff" env:"DB_CONNECT_MAX_BACKOFF" default:"5s" desc:"Espera máxima entre intentos de conexión"`
+	ReadAttempts          int           `key:"read_attempts" env:"DB_READ_ATTEMPTS" default:"3" desc:"Intentos de una lectura ante errores transitorios (1 = sin reintentos)"`
+	ReadRetryBackoff      time.Duration `key:"read_retry_backoff" env:"DB_READ_RETRY_BACKOFF" default:"50ms" desc:"Primera espera entre reintentos de lectura"`
+}
+
+// LogConfig controla los logs
+type LogConfig struct {
+	Level string `key:"level" env:"LOG_LEVEL" default:"info" desc:"debug, info, warn o error"`
+}
+
+// ServerConfig son los timeouts y límites del servidor HTTP
+type ServerConfig struct {
+	Port              int           `key:"port" env:"PORT" default:"8080" desc:"Puerto HTTP"`
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"5s" desc:"Plazo para leer los headers"`
+	ReadTimeout       time.Duration `key:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"15s" desc:"Plazo para leer el request completo"`
+	WriteTimeout      time.Duration `key:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"30s" desc:"Plazo para escribir la respuesta"`
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"60s" desc:"Tiempo máximo de una conexión keep-alive ociosa"`
+	MaxHeaderBytes    int           `key:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES" default:"1048576" desc:"Tamaño máximo de los headers"`
+	RequestTimeout    time.Duration `key:"request_timeout" env:"REQUEST_TIMEOUT" default:"10s" desc:"Plazo de cada request (0 = sin límite)"`
+	DrainDelay        time.Duration `key:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY" default:"5s" desc:"Espera con readiness en falla antes de cerrar"`
+	ShutdownTimeout   time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"20s" desc:"Máximo que se espera a los requests en curso"`
+	TrustedProxies    []string      `key:"trusted_proxies" env:"TRUSTED_PROXIES" desc:"IPs o CIDRs de proxies cuyo X-Forwarded-For se respeta"`
+}
+
+// AuthConfig son los tokens y el hash de contraseñas
+type AuthConfig struct {
+	JWTAlgorithm      string        `key:"jwt_algorithm" env:"JWT_ALGORITHM" default:"HS256" desc:"HS256 o EdDSA"`
+	JWTSecret         string        `key:"jwt_secret" env:"JWT_SECRET" secret:"true" desc:"Secreto HS256 (mínimo 32 bytes)"`
+	JWTPrivateKeyFile string        `key:"jwt_private_key_file" env:"JWT_PRIVATE_KEY_FILE" desc:"Clave privada Ed25519 en PEM para EdDSA"`
+	JWTIssuer         string        `key:"jwt_issuer" env:"JWT_ISSUER" default:"ingsw3-tp08" desc:"Emisor de los tokens"`
+	AccessTokenTTL    time.Duration `key:"access_token_ttl" env:"ACCESS_TOKEN_TTL" default:"15m" desc:"Duración del token de acceso"`
+	RefreshTokenTTL   time.Duration `key:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" default:"720h" desc:"Duración de la sesión (refresh token)"`
+	BcryptCost        int           `key:"bcrypt_cost" env:"BCRYPT_COST" default:"0" desc:"Costo de bcrypt (0 = default de la librería)"`
+	PasswordResetTTL  time.Duration `key:"password_reset_ttl" env:"PASSWORD_RESET_TTL" default:"1h" desc:"Vigencia del link para restablecer la contraseña"`
+	PasswordResetURL  string        `key:"password_reset_url" env:"PASSWORD_RESET_URL" default:"http://localhost:3000/reset-password" desc:"Página del frontend que recibe ?token="`
+	AccountUnlockTTL  time.Duration `key:"account_unlock_ttl" env:"ACCOUNT_UNLOCK_TTL" default:"1h" desc:"Vigencia del link para desbloquear la cuenta"`
+	AccountUnlockURL  string        `key:"account_unlock_url" env:"ACCOUNT_UNLOCK_URL" default:"http://localhost:8080/api/auth/unlock" desc:"URL del link de desbloqueo, recibe ?token="`
+
+	EmailVerificationTTL      time.Duration `key:"email_verification_ttl" env:"EMAIL_VERIFICATION_TTL" default:"24h" desc:"Vigencia del link para verificar el email"`
+	EmailVerificationURL      string        `key:"email_verification_url" env:"EMAIL_VERIFICATION_URL" default:"http://localhost:8080/api/auth/verify" desc:"URL del link de verificación, recibe ?token="`
+	EmailVerificationCooldown time.Duration `key:"email_verification_cooldown" env:"EMAIL_VERIFICATION_RESEND_COOLDOWN" default:"1m" desc:"Espera mínima entre reenvíos del mail de verificación"`
+	EmailVerificationSecret   string        `key:"email_verification_secret" env:"EMAIL_VERIFICATION_SECRET" secret:"true" desc:"Clave HMAC que firma los links de verificación (mínimo 32 bytes)"`
+
+	APITokenDefaultTTL time.Duration `key:"api_token_default_ttl" env:"API_TOKEN_DEFAULT_TTL" default:"720h" desc:"Vigencia de los tokens de API creados sin expires_in_days"`
+	APITokenMaxTTL     time.Duration `key:"api_token_max_ttl" env:"API_TOKEN_MAX_TTL" default:"8760h" desc:"Vigencia máxima de un token de API"`
+}
+
+// PostsConfig son las reglas de posts y comentarios
+type PostsConfig struct {
+	MaxCommentDepth      int  `key:"max_comment_depth" env:"MAX_COMMENT_DEPTH" default:"5" desc:"Profundidad máxima de respuestas"`
+	RequireVerifiedEmail bool `key:"require_verified_email" env:"REQUIRE_VERIFIED_EMAIL" default:"false" desc:"Solo los usuarios con el email verificado publican y comentan"`
+}
+
+// CORSConfig es la política CORS
+type CORSConfig struct {
+	AllowedOrigins   []string      `key:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" default:"http://localhost:3000" desc:"Orígenes permitidos, separados por coma"`
+	AllowCredentials bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" default:"false" desc:"Permitir cookies y credenciales"`
+	MaxAge           time.Duration `key:"max_age" env:"CORS_MAX_AGE" default:"10m" desc:"Cache del preflight"`
+}
+
+// HealthConfig son los chequeos de /readyz
+type HealthConfig struct {
+	CheckTimeout   time.Duration `key:"check_timeout" env:"HEALTH_CHECK_TIMEOUT" default:"2s" desc:"Plazo de cada chequeo"`
+	PoolSaturation float64       `key:"pool_saturation" env:"HEALTH_POOL_SATURATION" default:"0.9" desc:"Proporción del pool en uso que saca a la instancia del balanceo"`
+}
+
+// TracingConfig son las trazas OpenTelemetry
+type TracingConfig struct {
+	Exporter    string `key:"exporter" env:"OTEL_TRACES_EXPORTER" default:"none" desc:"none, otlp o stdout"`
+	ServiceName string `key:"service_name" env:"OTEL_SERVICE_NAME" default:"ingsw3-api" desc:"Nombre del servicio en las trazas"`
+}
+
+// Backends del rate limiter
+const (
+	RateLimitMemory   = "memory"
+	RateLimitPostgres = "postgres"
+	RateLimitNone     = "none"
+)
+
+// RateLimitConfig son los límites por usuario o IP. Cada política es "requests/período"
+// (un token bucket que admite ráfagas de hasta "requests"); "0" la desactiva.
+type RateLimitConfig struct {
+	Backend       string        `key:"backend" env:"RATE_LIMIT_BACKEND" default:"memory" desc:"memory (una instancia), postgres (varias) o none"`
+	Auth          string        `key:"auth" env:"RATE_LIMIT_AUTH" default:"10/1m" desc:"Registro, login y refresh, por IP"`
+	Posts         string        `key:"posts" env:"RATE_LIMIT_POSTS" default:"10/1m" desc:"Crear y editar posts, por usuario"`
+	Comments      string        `key:"comments" env:"RATE_LIMIT_COMMENTS" default:"30/1m" desc:"Crear comentarios, por usuario"`
+	Search        string        `key:"search" env:"RATE_LIMIT_SEARCH" default:"60/1m" desc:"Búsqueda, por IP"`
+	PruneInterval time.Duration `key:"prune_interval" env:"RATE_LIMIT_PRUNE_INTERVAL" default:"1m" desc:"Cada cuánto se borran los buckets llenos"`
+}
+
+// LoginConfig son las demoras y el bloqueo ante logins fallidos
+type LoginConfig struct {
+	Enabled          bool          `key:"enabled" env:"LOGIN_THROTTLE_ENABLED" default:"true" desc:"Frenar la fuerza bruta sobre el login"`
+	FreeAttempts     int           `key:"free_attempts" env:"LOGIN_FREE_ATTEMPTS" default:"3" desc:"Fallos por cuenta sin demora"`
+	BaseDelay        time.Duration `key:"base_delay" env:"LOGIN_BASE_DELAY" default:"1s" desc:"Demora después del primer fallo extra (se duplica con cada fallo)"`
+	MaxDelay         time.Duration `key:"max_delay" env:"LOGIN_MAX_DELAY" default:"30s" desc:"Demora máxima entre intentos"`
+	AccountLockAfter int           `key:"account_lock_after" env:"LOGIN_ACCOUNT_LOCK_AFTER" default:"10" desc:"Fallos que bloquean la cuenta (0 = nunca)"`
+	IPLockAfter      int           `key:"ip_lock_after" env:"LOGIN_IP_LOCK_AFTER" default:"50" desc:"Fallos desde una IP que la bloquean (0 = nunca)"`
+	LockDuration     time.Duration `key:"lock_duration" env:"LOGIN_LOCK_DURATION" default:"15m" desc:"Duración del bloqueo"`
+	Window           time.Duration `key:"window" env:"LOGIN_FAILURE_WINDOW" default:"1h" desc:"Después de este tiempo sin fallos se olvidan"`
+	PruneInterval    time.Duration `key:"prune_interval" env:"LOGIN_PRUNE_INTERVAL" default:"10m" desc:"Cada cuánto se borran los registros vencidos"`
+}
+
+// Backends de mail
+const (
+	MailLog  = "log"
+	MailFile = "file"
+	MailSMTP = "smtp"
+)
+
+// MailConfig es el envío de mails (recuperación de contraseña y verificación del email)
+type MailConfig struct {
+	Backend      string        `key:"backend" env:"MAIL_BACKEND" default:"log" desc:"log (solo se registra), file (archivos .eml) o smtp"`
+	From         string        `key:"from" env:"MAIL_FROM" default:"no-reply@localhost" desc:"Remitente"`
+	Dir          string        `key:"dir" env:"MAIL_DIR" default:"mail" desc:"Directorio de los .eml con el backend file"`
+	SMTPHost     string        `key:"smtp_host" env:"SMTP_HOST" desc:"Servidor SMTP"`
+	SMTPPort     int           `key:"smtp_port" env:"SMTP_PORT" default:"587" desc:"Puerto SMTP"`
+	SMTPUsername string        `key:"smtp_username" env:"SMTP_USERNAME" desc:"Usuario SMTP (vacío = sin autenticación)"`
+	SMTPPassword string        `key:"smtp_password" env:"SMTP_PASSWORD" secret:"true" desc:"Contraseña SMTP"`
+	SMTPTLS      string        `key:"smtp_tls" env:"SMTP_TLS" default:"starttls" desc:"starttls, tls o none (solo servidores locales)"`
+	Timeout      time.Duration `key:"timeout" env:"MAIL_TIMEOUT" default:"10s" desc:"Plazo para entregar cada mail"`
+}
+
+// MFAConfig es la verificación en dos pasos con TOTP
+type MFAConfig struct {
+	EncryptionKey string        `key:"encryption_key" env:"MFA_ENCRYPTION_KEY" secret:"true" desc:"Clave AES-256 en base64 (32 bytes) que cifra los secretos TOTP"`
+	Issuer        string        `key:"issuer" env:"MFA_ISSUER" default:"ingsw3-tp08" desc:"Nombre que muestran las apps de autenticación"`
+	ChallengeTTL  time.Duration `key:"challenge_ttl" env:"MFA_CHALLENGE_TTL" default:"5m" desc:"Plazo para ingresar el código después de la contraseña"`
+	RequiredRoles []string      `key:"required_roles" env:"MFA_REQUIRED_ROLES" default:"admin" desc:"Roles que deben activar 2FA para administrar usuarios y moderar, separados por coma"`
+}
+
+// Validate revisa toda la configuración y devuelve todos los problemas juntos,
+// para no tener que corregirlos de a uno reiniciando la API
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key string, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.Database.URL != "", "database.url", "es requerido (DATABASE_URL)")
+	check(c.Database.MaxOpenConns >= 0, "database.max_open_conns", "no puede ser negativo")
+	check(c.Database.MaxIdleConns >= 0, "database.max_idle_conns", "no puede ser negativo")
+	if c.Database.MaxOpenConns > 0 {
+		check(c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+			"database.max_idle_conns", "(%d) no puede superar a database.max_open_conns (%d)",
+			c.Database.MaxIdleConns, c.Database.MaxOpenConns)
+	}
+	check(c.Database.ConnMaxLifetime >= 0, "database.conn_max_lifetime", "no puede ser negativo")
+	check(c.Database.ConnMaxIdleTime >= 0, "database.conn_max_idle_time", "no puede ser negativo")
+	check(c.Database.ConnectTimeout >= 0, "database.connect_timeout", "no puede ser negativo")
+	check(c.Database.ConnectInitialBackoff > 0, "database.connect_initial_backoff", "debe ser mayor a 0")
+	check(c.Database.ConnectMaxBackoff >= c.Database.ConnectInitialBackoff,
+		"database.connect_max_backoff", "no puede ser menor que database.connect_initial_backoff")
+	check(c.Database.ReadAttempts >= 1, "database.read_attempts", "debe ser al menos 1")
+	check(c.Database.ReadRetryBackoff >= 0, "database.read_retry_backoff", "no puede ser negativo")
+
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Log.Level)) == nil, "log.level", "%q no es un nivel válido", c.Log.Level)
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port", "%d fuera de rango", c.Server.Port)
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout", "no puede ser negativo")
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout", "no puede ser negativo")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout", "no puede ser negativo")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout", "no puede ser negativo")
+	check(c.Server.MaxHeaderBytes > 0, "server.max_header_bytes", "debe ser mayor a 0")
+	check(c.Server.RequestTimeout >= 0, "server.request_timeout", "no puede ser negativo")
+	check(c.Server.DrainDelay >= 0, "server.shutdown_drain_delay", "no puede ser negativo")
+	check(c.Server.ShutdownTimeout >= 0, "server.shutdown_timeout", "no puede ser negativo")
+	if _, err := ratelimit.ParseTrustedProxies(c.Server.TrustedProxies); err != nil {
+		check(false, "server.trusted_proxies", "%v", err)
+	}
+	if c.Server.WriteTimeout > 0 {
+		// Si no, el servidor corta la respuesta antes de que el handler devuelva el error de timeout
+		check(c.Server.RequestTimeout < c.Server.WriteTimeout,
+			"server.request_timeout", "(%s) debe ser menor que server.write_timeout (%s)",
+			c.Server.RequestTimeout, c.Server.WriteTimeout)
+	}
+
+	switch c.Auth.JWTAlgorithm {
+	case auth.AlgorithmHS256:
+		check(len(c.Auth.JWTSecret) >= auth.MinSecretLength, "auth.jwt_secret",
+			"es requerido para %s y debe tener al menos %d bytes", auth.AlgorithmHS256, auth.MinSecretLength)
+	case auth.AlgorithmEdDSA:
+		check(c.Auth.JWTPrivateKeyFile != "", "auth.jwt_private_key_file", "es requerido para %s", auth.AlgorithmEdDSA)
+		if c.Auth.JWTPrivateKeyFile != "" {
+			_, err := os.Stat(c.Auth.JWTPrivateKeyFile)
+			check(err == nil, "auth.jwt_private_key_file", "no se puede leer: %v", err)
+		}
+	default:
+		check(false, "auth.jwt_algorithm", "%q no soportado (%s o %s)", c.Auth.JWTAlgorithm, auth.AlgorithmHS256, auth.AlgorithmEdDSA)
+	}
+	check(c.Auth.JWTIssuer != "", "auth.jwt_issuer", "no puede estar vacío")
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl", "debe ser mayor a 0")
+	check(c.Auth.RefreshTokenTTL > 0, "auth.refresh_token_ttl", "debe ser mayor a 0")
+	check(c.Auth.BcryptCost == 0 || (c.Auth.BcryptCost >= 4 && c.Auth.BcryptCost <= 31),
+		"auth.bcrypt_cost", "%d fuera de rango (0 o entre 4 y 31)", c.Auth.BcryptCost)
+
+	check(c.Posts.MaxCommentDepth >= 0, "posts.max_comment_depth", "no puede ser negativo")
+
+	if err := c.RouterCORS().Validate(); err != nil {
+		check(false, "cors", "%v", err)
+	}
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout", "debe ser mayor a 0")
+	check(c.Health.PoolSaturation > 0 && c.Health.PoolSaturation <= 1,
+		"health.pool_saturation", "%v debe estar entre 0 y 1", c.Health.PoolSaturation)
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterOTLP, tracing.ExporterStdout:
+	default:
+		check(false, "tracing.exporter", "%q no soportado (none, otlp o stdout)", c.Tracing.Exporter)
+	}
+	check(strings.TrimSpace(c.Tracing.ServiceName) != "", "tracing.service_name", "no puede estar vacío")
+
+	switch c.RateLimit.Backend {
+	case RateLimitMemory, RateLimitPostgres, RateLimitNone:
+	default:
+		check(false, "rate_limit.backend", "%q no soportado (memory, postgres o none)", c.RateLimit.Backend)
+	}
+	for name, spec := range c.rateLimitSpecs() {
+		if _, err := ratelimit.ParsePolicy(name, spec); err != nil {
+			check(false, "rate_limit."+name, "%v", err)
+		}
+	}
+	check(c.RateLimit.PruneInterval > 0, "rate_limit.prune_interval", "debe ser mayor a 0")
+
+	check(c.Auth.PasswordResetTTL > 0, "auth.password_reset_ttl", "debe ser mayor a 0")
+	check(isAbsoluteURL(c.Auth.PasswordResetURL), "auth.password_reset_url", "%q debe ser una URL absoluta", c.Auth.PasswordResetURL)
+	check(c.Auth.AccountUnlockTTL > 0, "auth.account_unlock_ttl", "debe ser mayor a 0")
+	check(isAbsoluteURL(c.Auth.AccountUnlockURL), "auth.account_unlock_url", "%q debe ser una URL absoluta", c.Auth.AccountUnlockURL)
+	check(c.Auth.EmailVerificationTTL > 0, "auth.email_verification_ttl", "debe ser mayor a 0")
+	check(isAbsoluteURL(c.Auth.EmailVerificationURL), "auth.email_verification_url", "%q debe ser una URL absoluta", c.Auth.EmailVerificationURL)
+	check(c.Auth.EmailVerificationCooldown >= 0, "auth.email_verification_cooldown", "no puede ser negativo")
+	check(len(c.Auth.EmailVerificationSecret) >= auth.MinSecretLength, "auth.email_verification_secret",
+		"es requerido (EMAIL_VERIFICATION_SECRET) y debe tener al menos %d bytes", auth.MinSecretLength)
+	check(c.Auth.APITokenDefaultTTL > 0, "auth.api_token_default_ttl", "debe ser mayor a 0")
+	check(c.Auth.APITokenMaxTTL >= c.Auth.APITokenDefaultTTL, "auth.api_token_max_ttl", "no puede ser menor que auth.api_token_default_ttl")
+
+	switch c.Mail.Backend {
+	case MailLog:
+	case MailFile:
+		check(strings.TrimSpace(c.Mail.Dir) != "", "mail.dir", "es requerido con el backend file")
+	case MailSMTP:
+		check(strings.TrimSpace(c.Mail.SMTPHost) != "", "mail.smtp_host", "es requerido con el backend smtp (SMTP_HOST)")
+		check(c.Mail.SMTPPort > 0 && c.Mail.SMTPPort <= 65535, "mail.smtp_port", "%d fuera de rango", c.Mail.SMTPPort)
+		switch c.Mail.SMTPTLS {
+		case mail.TLSStartTLS, mail.TLSImplicit, mail.TLSNone:
+		default:
+			check(false, "mail.smtp_tls", "%q no soportado (starttls, tls o none)", c.Mail.SMTPTLS)
+		}
+	default:
+		check(false, "mail.backend", "%q no soportado (log, file o smtp)", c.Mail.Backend)
+	}
+	if _, err := netmail.ParseAddress(c.Mail.From); err != nil {
+		check(false, "mail.from", "%q no es una dirección válida", c.Mail.From)
+	}
+	check(c.Mail.Timeout > 0, "mail.timeout", "debe ser mayor a 0")
+
+	key, err := base64.StdEncoding.DecodeString(c.MFA.EncryptionKey)
+	check(err == nil && len(key) == auth.SecretKeyLength, "mfa.encryption_key",
+		"es requerida (MFA_ENCRYPTION_KEY) y debe ser base64 de %d bytes", auth.SecretKeyLength)
+	check(strings.TrimSpace(c.MFA.Issuer) != "", "mfa.issuer", "no puede estar vacío")
+	check(c.MFA.ChallengeTTL > 0, "mfa.challenge_ttl", "debe ser mayor a 0")
+	for _, role := range c.MFA.RequiredRoles {
+		check(models.IsValidRole(role), "mfa.required_roles", "%q no es un rol válido", role)
+	}
+
+	if c.Login.Enabled {
+		check(c.Login.FreeAttempts >= 0, "login_throttle.free_attempts", "no puede ser negativo")
+		check(c.Login.BaseDelay > 0, "login_throttle.base_delay", "debe ser mayor a 0")
+		check(c.Login.MaxDelay >= c.Login.BaseDelay, "login_throttle.max_delay", "no puede ser menor que base_delay")
+		check(c.Login.AccountLockAfter >= 0, "login_throttle.account_lock_after", "no puede ser negativo")
+		check(c.Login.IPLockAfter >= 0, "login_throttle.ip_lock_after", "no puede ser negativo")
+		check(c.Login.LockDuration > 0, "login_throttle.lock_duration", "debe ser mayor a 0")
+		check(c.Login.Window >= c.Login.LockDuration, "login_throttle.window", "no puede ser menor que lock_duration")
+		check(c.Login.PruneInterval > 0, "login_throttle.prune_interval", "debe ser mayor a 0")
+	}
+
+	return errors.Join(errs...)
+}
+
+// isAbsoluteURL indica si raw tiene esquema y host, como necesitan los links de los mails
+func isAbsoluteURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// DatabaseOptions arma el pool y los reintentos de conexión del paquete database
+func (c *Config) DatabaseOptions() database.Options {
+	return database.Options{
+		Pool: database.PoolConfig{
+			MaxOpenConns:    c.Database.MaxOpenConns,
+			MaxIdleConns:    c.Database.MaxIdleConns,
+			ConnMaxLifetime: c.Database.ConnMaxLifetime,
+			ConnMaxIdleTime: c.Database.ConnMaxIdleTime,
+		},
+		Retry: database.ConnectRetry{
+			InitialBackoff: c.Database.ConnectInitialBackoff,
+			MaxBackoff:     c.Database.ConnectMaxBackoff,
+			MaxWait:        c.Database.ConnectTimeout,
+		},
+	}
+}
+
+// ReadRetry arma los reintentos de lectura de los repositorios
+func (c *Config) ReadRetry() database.ReadRetry {
+	return database.ReadRetry{
+		Attempts: c.Database.ReadAttempts,
+		Backoff:  c.Database.ReadRetryBackoff,
+	}
+}
+
+// Addr es la dirección en la que escucha el servidor
+func (c *Config) Addr() string {
+	return fmt.Sprintf(":%d", c.Server.Port)
+}
+
+// HTTPServer arma la configuración del paquete server
+func (c *Config) HTTPServer() server.Config {
+	return server.Config{
+		Addr:              c.Addr(),
+		ReadHeaderTimeout: c.Server.ReadHeaderTimeout,
+		ReadTimeout:       c.Server.ReadTimeout,
+		WriteTimeout:      c.Server.WriteTimeout,
+		IdleTimeout:       c.Server.IdleTimeout,
+		MaxHeaderBytes:    c.Server.MaxHeaderBytes,
+		DrainDelay:        c.Server.DrainDelay,
+		ShutdownTimeout:   c.Server.ShutdownTimeout,
+	}
+}
+
+// RouterCORS arma la política CORS del router
+func (c *Config) RouterCORS() router.CORSConfig {
+	return router.CORSConfig{
+		AllowedOrigins:   router.ParseOrigins(strings.Join(c.CORS.AllowedOrigins, ",")),
+		AllowCredentials: c.CORS.AllowCredentials,
+		MaxAge:           c.CORS.MaxAge,
+	}
+}
+
+// TracingSetup arma la configuración del paquete tracing
+func (c *Config) TracingSetup() tracing.Config {
+	return tracing.Config{
+		Exporter:    c.Tracing.Exporter,
+		ServiceName: c.Tracing.ServiceName,
+	}
+}
+
+// rateLimitSpecs asocia cada política del router con su valor en la configuración
+func (c *Config) rateLimitSpecs() map[string]string {
+	return map[string]string{
+		ratelimit.PolicyAuth:     c.RateLimit.Auth,
+		ratelimit.PolicyPosts:    c.RateLimit.Posts,
+		ratelimit.PolicyComments: c.RateLimit.Comments,
+		ratelimit.PolicySearch:   c.RateLimit.Search,
+	}
+}
+
+// RouterRateLimit arma los límites del router con el store indicado.
+// Supone una configuración ya validada.
+func (c *Config) RouterRateLimit(store ratelimit.Store) router.RateLimitConfig {
+	policies := make(map[string]ratelimit.Policy)
+	for name, spec := range c.rateLimitSpecs() {
+		policy, _ := ratelimit.ParsePolicy(name, spec)
+		policies[name] = policy
+	}
+
+	return router.RateLimitConfig{
+		Store:    store,
+		Policies: policies,
+	}
+}
+
+// TrustedProxies son los proxies de confianza ya interpretados.
+// Supone una configuración ya validada.
+func (c *Config) TrustedProxies() []netip.Prefix {
+	trusted, _ := ratelimit.ParseTrustedProxies(c.Server.TrustedProxies)
+	return trusted
+}
+
+// LoginThrottlePolicy arma las demoras y el bloqueo de logins fallidos
+func (c *Config) LoginThrottlePolicy() services.LoginThrottlePolicy {
+	return services.LoginThrottlePolicy{
+		FreeAttempts:     c.Login.FreeAttempts,
+		BaseDelay:        c.Login.BaseDelay,
+		MaxDelay:         c.Login.MaxDelay,
+		AccountLockAfter: c.Login.AccountLockAfter,
+		IPLockAfter:      c.Login.IPLockAfter,
+		LockDuration:     c.Login.LockDuration,
+		Window:           c.Login.Window,
+	}
+}
+
+// PasswordResetConfig arma la vigencia y la URL de los links de recuperación
+func (c *Config) PasswordResetConfig() services.PasswordResetConfig {
+	return services.PasswordResetConfig{
+		TTL: c.Auth.PasswordResetTTL,
+		URL: c.Auth.PasswordResetURL,
+	}
+}
+
+// AccountUnlockConfig arma la vigencia y la URL de los links de desbloqueo
+func (c *Config) AccountUnlockConfig() services.AccountUnlockConfig {
+	return services.AccountUnlockConfig{
+		TTL: c.Auth.AccountUnlockTTL,
+		URL: c.Auth.AccountUnlockURL,
+	}
+}
+
+// EmailVerificationConfig arma la vigencia, la URL y la espera entre reenvíos de los links de verificación
+func (c *Config) EmailVerificationConfig() services.EmailVerificationConfig {
+	return services.EmailVerificationConfig{
+		TTL:            c.Auth.EmailVerificationTTL,
+		URL:            c.Auth.EmailVerificationURL,
+		ResendCooldown: c.Auth.EmailVerificationCooldown,
+	}
+}
+
+// APITokenConfig arma la vigencia por defecto y máxima de los tokens de API
+func (c *Config) APITokenConfig() services.APITokenConfig {
+	return services.APITokenConfig{
+		DefaultTTL: c.Auth.APITokenDefaultTTL,
+		MaxTTL:     c.Auth.APITokenMaxTTL,
+	}
+}
+
+// TwoFactorConfig arma el emisor y la vigencia del desafío de la verificación en dos pasos
+func (c *Config) TwoFactorConfig() services.TwoFactorConfig {
+	return services.TwoFactorConfig{
+		Issuer:       c.MFA.Issuer,
+		ChallengeTTL: c.MFA.ChallengeTTL,
+	}
+}
+
+// MFAEncryptionKey es la clave que cifra los secretos TOTP.
+// Supone una configuración ya validada.
+func (c *Config) MFAEncryptionKey() []byte {
+	key, _ := base64.StdEncoding.DecodeString(c.MFA.EncryptionKey)
+	return key
+}
+
+// SMTP arma la configuración del paquete mail para el backend smtp
+func (c *Config) SMTP() mail.SMTPConfig {
+	return mail.SMTPConfig{
+		Host:     c.Mail.SMTPHost,
+		Port:     c.Mail.SMTPPort,
+		Username: c.Mail.SMTPUsername,
+		Password: c.Mail.SMTPPassword,
+		From:     c.Mail.From,
+		TLS:      c.Mail.SMTPTLS,
+		Timeout:  c.Mail.Timeout,
+	}
+}
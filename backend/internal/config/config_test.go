@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "un-secreto-de-prueba-de-32-bytes!!"
+
+// testMFAKey es base64 de 32 bytes
+const testMFAKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+
+// clearEnv deja vacías todas las variables que lee Config, para que el
+// entorno de quien corre los tests no cambie el resultado
+func clearEnv(t *testing.T) {
+	t.Setenv(ConfigFileEnv, "")
+	for _, s := range settings(&Config{}) {
+		if s.env != "" {
+			t.Setenv(s.env, "")
+		}
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// validConfig devuelve una configuración cargada con defaults y los requeridos completos
+func validConfig(t *testing.T) *Loaded {
+	clearEnv(t)
+	t.Setenv("DATABASE_URL", "postgres://app:clave@db:5432/app?sslmode=disable")
+	t.Setenv("JWT_SECRET", testSecret)
+	t.Setenv("MFA_ENCRYPTION_KEY", testMFAKey)
+	t.Setenv("EMAIL_VERIFICATION_SECRET", testSecret)
+	loaded, err := Load(nil)
+	require.NoError(t, err)
+	return loaded
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	clearEnv(t)
+
+	loaded, err := Load(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, loaded.Server.Port)
+	assert.Equal(t, "info", loaded.Log.Level)
+	assert.Equal(t, 10*time.Second, loaded.Server.RequestTimeout)
+	assert.Equal(t, 720*time.Hour, loaded.Auth.RefreshTokenTTL)
+	assert.Equal(t, 5, loaded.Posts.MaxCommentDepth)
+	assert.Equal(t, []string{"http://localhost:3000"}, loaded.CORS.AllowedOrigins)
+	assert.Equal(t, 0.9, loaded.Health.PoolSaturation)
+	assert.Equal(t, SourceDefault, loaded.Sources["server.port"])
+	assert.Empty(t, loaded.File)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	clearEnv(t)
+	file := writeFile(t, "api.yaml", `
+server:
+  port: 7000
+  request_timeout: 5s
+log:
+  level: debug
+posts:
+  max_comment_depth: 2
+cors:
+  allowed_origins:
+    - https://a.example.com
+    - https://b.example.com
+`)
+	t.Setenv(ConfigFileEnv, file)
+	t.Setenv("PORT", "7500")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	loaded, err := Load([]string{"-server.port=9090"})
+	require.NoError(t, err)
+
+	// flag > env > archivo > default
+	assert.Equal(t, 9090, loaded.Server.Port)
+	assert.Equal(t, SourceFlag, loaded.Sources["server.port"])
+	assert.Equal(t, "warn", loaded.Log.Level)
+	assert.Equal(t, SourceEnv, loaded.Sources["log.level"])
+	assert.Equal(t, 5*time.Second, loaded.Server.RequestTimeout)
+	assert.Equal(t, 2, loaded.Posts.MaxCommentDepth)
+	assert.Equal(t, []string{"https://a.example.com", "https://b.example.com"}, loaded.CORS.AllowedOrigins)
+	assert.Equal(t, SourceFile, loaded.Sources["cors.allowed_origins"])
+	assert.Equal(t, SourceDefault, loaded.Sources["auth.jwt_issuer"])
+	assert.Equal(t, file, loaded.File)
+}
+
+func TestLoad_TOMLFromFlag(t *testing.T) {
+	clearEnv(t)
+	file := writeFile(t, "api.toml", `
+[auth]
+access_token_ttl = "5m"
+bcrypt_cost = 12
+
+[health]
+pool_saturation = 0.75
+`)
+
+	loaded, err := Load([]string{"-config", file})
+	require.NoError(t, err)
+
+	assert.Equal(t, 5*time.Minute, loaded.Auth.AccessTokenTTL)
+	assert.Equal(t, 12, loaded.Auth.BcryptCost)
+	assert.Equal(t, 0.75, loaded.Health.PoolSaturation)
+}
+
+func TestLoad_EmptyEnvIsUnset(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("PORT", "")
+
+	loaded, err := Load(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, loaded.Server.Port)
+	assert.Equal(t, SourceDefault, loaded.Sources["server.port"])
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		file    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "clave desconocida en el archivo",
+			file:    "server:\n  prot: 9090\n",
+			wantErr: `clave desconocida "server.prot"`,
+		},
+		{
+			name:    "tipo inválido en el archivo",
+			file:    "server:\n  port: ochenta\n",
+			wantErr: "server.port",
+		},
+		{
+			name:    "duración inválida en el entorno",
+			env:     map[string]string{"REQUEST_TIMEOUT": "10"},
+			wantErr: "REQUEST_TIMEOUT",
+		},
+		{
+			name:    "flag desconocido",
+			args:    []string{"-server.prot=1"},
+			wantErr: "server.prot",
+		},
+		{
+			name:    "argumentos sobrantes",
+			args:    []string{"serve"},
+			wantErr: "argumentos inesperados",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			if tt.file != "" {
+				t.Setenv(ConfigFileEnv, writeFile(t, "api.yaml", tt.file))
+			}
+
+			_, err := Load(tt.args)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	loaded := validConfig(t)
+
+	assert.NoError(t, loaded.Validate())
+}
+
+func TestValidate_ReportsEveryProblem(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("PORT", "70000")
+	t.Setenv("LOG_LEVEL", "verbose")
+	t.Setenv("REQUEST_TIMEOUT", "1m")
+	t.Setenv("CORS_ALLOWED_ORIGINS", "*")
+	t.Setenv("CORS_ALLOW_CREDENTIALS", "true")
+	t.Setenv("OTEL_TRACES_EXPORTER", "jaeger")
+	t.Setenv("DB_MAX_OPEN_CONNS", "5")
+	t.Setenv("DB_READ_ATTEMPTS", "0")
+	t.Setenv("LOGIN_MAX_DELAY", "500ms")
+	t.Setenv("LOGIN_FAILURE_WINDOW", "5m")
+	t.Setenv("MAIL_BACKEND", "smtp")
+	t.Setenv("SMTP_TLS", "ssl")
+	t.Setenv("PASSWORD_RESET_URL", "/reset-password")
+	t.Setenv("EMAIL_VERIFICATION_URL", "api/auth/verify")
+	t.Setenv("ACCOUNT_UNLOCK_URL", "/api/auth/unlock")
+	t.Setenv("MFA_REQUIRED_ROLES", "admin,root")
+	t.Setenv("API_TOKEN_MAX_TTL", "24h")
+
+	loaded, err := Load(nil)
+	require.NoError(t, err)
+
+	err = loaded.Validate()
+	require.Error(t, err)
+	for _, key := range []string{
+		"database.url",
+		"database.max_idle_conns",
+		"database.read_attempts",
+		"log.level",
+		"server.port",
+		"server.request_timeout",
+		"auth.jwt_secret",
+		"cors",
+		"tracing.exporter",
+		"login_throttle.max_delay",
+		"login_throttle.window",
+		"mail.smtp_host",
+		"mail.smtp_tls",
+		"auth.password_reset_url",
+		"auth.email_verification_url",
+		"auth.account_unlock_url",
+		"auth.email_verification_secret",
+		"mfa.encryption_key",
+		"mfa.required_roles",
+		"auth.api_token_max_ttl",
+	} {
+		assert.Contains(t, err.Error(), key+":")
+	}
+}
+
+func TestValidate_EdDSARequiresKeyFile(t *testing.T) {
+	loaded := validConfig(t)
+	loaded.Auth.JWTAlgorithm = "EdDSA"
+
+	err := loaded.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "auth.jwt_private_key_file")
+	assert.NotContains(t, err.Error(), "auth.jwt_secret")
+}
+
+func TestValidate_MFAKeyMustBe32Bytes(t *testing.T) {
+	loaded := validConfig(t)
+	loaded.MFA.EncryptionKey = "c29sby0xNi1ieXRlcy0hIQ=="
+
+	err := loaded.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "mfa.encryption_key")
+}
+
+func TestPrint_RedactsSecrets(t *testing.T) {
+	loaded := validConfig(t)
+
+	var out bytes.Buffer
+	require.NoError(t, loaded.Print(&out))
+
+	assert.NotContains(t, out.String(), testSecret)
+	assert.NotContains(t, out.String(), testMFAKey)
+	assert.NotContains(t, out.String(), "clave")
+	assert.Contains(t, out.String(), "postgres://app:"+redactedValue+"@db:5432/app?sslmode=disable")
+	assert.Regexp(t, `auth\.jwt_secret\s+\*{8}\s+env\s+JWT_SECRET`, out.String())
+	assert.Regexp(t, `server\.port\s+8080\s+default\s+PORT`, out.String())
+}
+
+func TestConfig_Adapters(t *testing.T) {
+	loaded := validConfig(t)
+	loaded.CORS.AllowedOrigins = []string{"HTTPS://App.Example.com/"}
+
+	assert.Equal(t, ":8080", loaded.HTTPServer().Addr)
+	assert.Equal(t, 30*time.Second, loaded.HTTPServer().WriteTimeout)
+	assert.Equal(t, []string{"https://app.example.com"}, loaded.RouterCORS().AllowedOrigins)
+	assert.Equal(t, "ingsw3-api", loaded.TracingSetup().ServiceName)
+	assert.Equal(t, 25, loaded.DatabaseOptions().Pool.MaxOpenConns)
+	assert.Equal(t, time.Minute, loaded.DatabaseOptions().Retry.MaxWait)
+	assert.Equal(t, 3, loaded.ReadRetry().Attempts)
+	assert.Equal(t, 10, loaded.LoginThrottlePolicy().AccountLockAfter)
+	assert.Equal(t, 15*time.Minute, loaded.LoginThrottlePolicy().LockDuration)
+	assert.Equal(t, time.Hour, loaded.PasswordResetConfig().TTL)
+	assert.Equal(t, time.Hour, loaded.AccountUnlockConfig().TTL)
+	assert.Equal(t, "http://localhost:8080/api/auth/unlock", loaded.AccountUnlockConfig().URL)
+	assert.Equal(t, 24*time.Hour, loaded.EmailVerificationConfig().TTL)
+	assert.Equal(t, time.Minute, loaded.EmailVerificationConfig().ResendCooldown)
+	assert.False(t, loaded.Posts.RequireVerifiedEmail)
+	assert.Equal(t, 587, loaded.SMTP().Port)
+	assert.Equal(t, 5*time.Minute, loaded.TwoFactorConfig().ChallengeTTL)
+	assert.Equal(t, "ingsw3-tp08", loaded.TwoFactorConfig().Issuer)
+	assert.Len(t, loaded.MFAEncryptionKey(), 32)
+	assert.Equal(t, 30*24*time.Hour, loaded.APITokenConfig().DefaultTTL)
+	assert.Equal(t, 365*24*time.Hour, loaded.APITokenConfig().MaxTTL)
+	assert.Equal(t, []string{"admin"}, loaded.MFA.RequiredRoles)
+}
@@ -0,0 +1,299 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Origen de cada valor, de menor a mayor precedencia
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// ConfigFileEnv es la variable con la ruta del archivo de configuración
+// (alternativa al flag -config)
+const ConfigFileEnv = "CONFIG_FILE"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Loaded es la configuración junto con el origen de cada valor
+type Loaded struct {
+	Config
+	File    string
+	Sources map[string]string
+}
+
+// setting es un campo hoja de Config con sus metadatos
+type setting struct {
+	key    string
+	env    string
+	def    string
+	desc   string
+	secret string
+	value  reflect.Value
+}
+
+// settings recorre Config y devuelve sus campos en orden de declaración
+func settings(c *Config) []setting {
+	var out []setting
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key := prefix + field.Tag.Get("key")
+			if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			out = append(out, setting{
+				key:    key,
+				env:    field.Tag.Get("env"),
+				def:    field.Tag.Get("default"),
+				desc:   field.Tag.Get("desc"),
+				secret: field.Tag.Get("secret"),
+				value:  v.Field(i),
+			})
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return out
+}
+
+// Load arma la configuración con esta precedencia (la última gana):
+//
+//	default < archivo (-config o CONFIG_FILE, .yaml/.yml/.toml) < variables de entorno < flags
+//
+// args son los argumentos de línea de comandos sin el nombre del programa.
+// No valida: eso lo hace Validate, así los subcomandos que solo necesitan
+// la base no exigen los secretos de la API.
+func Load(args []string) (*Loaded, error) {
+	loaded := &Loaded{Sources: make(map[string]string)}
+	all := settings(&loaded.Config)
+
+	for _, s := range all {
+		if err := setValue(s.value, s.def); err != nil {
+			return nil, fmt.Errorf("default inválido para %s: %w", s.key, err)
+		}
+		loaded.Sources[s.key] = SourceDefault
+	}
+
+	// Los flags se parsean primero para conocer -config, pero se aplican al final
+	flags := flag.NewFlagSet("api", flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(ConfigFileEnv), "archivo de configuración (.yaml, .yml o .toml)")
+	flagValues := make(map[string]*string, len(all))
+	for _, s := range all {
+		flagValues[s.key] = flags.String(s.key, "", s.desc)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("argumentos inesperados: %v", flags.Args())
+	}
+
+	if *configFile != "" {
+		values, err := readFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		byKey := make(map[string]setting, len(all))
+		for _, s := range all {
+			byKey[s.key] = s
+		}
+		for _, key := range sortedKeys(values) {
+			s, ok := byKey[key]
+			if !ok {
+				return nil, fmt.Errorf("%s: clave desconocida %q", *configFile, key)
+			}
+			if err := setValue(s.value, values[key]); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", *configFile, key, err)
+			}
+			loaded.Sources[key] = SourceFile
+		}
+		loaded.File = *configFile
+	}
+
+	for _, s := range all {
+		raw := os.Getenv(s.env)
+		if s.env == "" || raw == "" {
+			continue
+		}
+		if err := setValue(s.value, raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.env, err)
+		}
+		loaded.Sources[s.key] = SourceEnv
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		value, ok := flagValues[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		s := findSetting(all, f.Name)
+		if err := setValue(s.value, *value); err != nil {
+			flagErr = fmt.Errorf("-%s: %w", f.Name, err)
+			return
+		}
+		loaded.Sources[f.Name] = SourceFlag
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	return loaded, nil
+}
+
+func findSetting(all []setting, key string) setting {
+	for _, s := range all {
+		if s.key == key {
+			return s
+		}
+	}
+	panic("config: clave desconocida " + key)
+}
+
+// readFile lee un archivo YAML o TOML y lo aplana a claves "seccion.campo"
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]interface{})
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		if err := decoder.Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("%s: formato no soportado (usar .yaml, .yml o .toml)", path)
+	}
+
+	values := make(map[string]string)
+	flatten(raw, "", values)
+	return values, nil
+}
+
+func flatten(raw map[string]interface{}, prefix string, out map[string]string) {
+	for key, value := range raw {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(v, prefix+key+".", out)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			out[prefix+key] = strings.Join(items, ",")
+		case nil:
+			// Clave sin valor: se mantiene el default
+		default:
+			out[prefix+key] = fmt.Sprint(v)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setValue convierte raw al tipo del campo
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Type() == durationType {
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q no es una duración válida (ej. 15s, 10m)", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q no es un número entero", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q no es un número", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q no es un booleano (true/false)", raw)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("tipo no soportado: %s", v.Type())
+	}
+	return nil
+}
+
+// formatValue es la inversa de setValue, para imprimir la configuración
+func formatValue(v reflect.Value) string {
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+)
+
+// redactedValue reemplaza a los secretos al imprimir
+const redactedValue = "********"
+
+// Print escribe la configuración efectiva, con el origen de cada valor y los
+// secretos ocultos. Es la salida de "api config print".
+func (l *Loaded) Print(w io.Writer) error {
+	if l.File != "" {
+		fmt.Fprintf(w, "# archivo: %s\n", l.File)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CLAVE\tVALOR\tORIGEN\tVARIABLE")
+	for _, s := range settings(&l.Config) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.key, redact(s), l.Sources[s.key], s.env)
+	}
+	return tw.Flush()
+}
+
+// redact oculta los secretos. En las URLs solo se oculta la contraseña,
+// así se puede verificar a qué host y base apunta.
+func redact(s setting) string {
+	value := formatValue(s.value)
+	if value == "" {
+		return ""
+	}
+
+	switch s.secret {
+	case "true":
+		return redactedValue
+	case "url":
+		parsed, err := url.Parse(value)
+		if err != nil || parsed.User == nil {
+			return redactedValue
+		}
+		if _, hasPassword := parsed.User.Password(); !hasPassword {
+			return value
+		}
+		// url.UserPassword escaparía los asteriscos: se inserta a mano después del usuario
+		parsed.User = url.User(parsed.User.Username())
+		return strings.Replace(parsed.String(), "@", ":"+redactedValue+"@", 1)
+	default:
+		return value
+	}
+}
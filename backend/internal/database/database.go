@@ -1,70 +1,53 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"log/slog"
 
 	_ "github.com/lib/pq"
 )
 
-// InitDB inicializa la base de datos PostgreSQL
-func InitDB(connectionString string) (*sql.DB, error) {
-	// Abrir conexión con PostgreSQL
+// Open abre la conexión con PostgreSQL, configura el pool y espera a que
+// responda según opts.Retry (en docker-compose la base puede tardar en arrancar)
+func Open(ctx context.Context, connectionString string, opts Options) (*sql.DB, error) {
 	db, err := sql.Open("postgres", connectionString)
 	if err != nil {
 		return nil, err
 	}
+	opts.Pool.apply(db)
 
-	// Verificar que la conexión funcione
-	if err = db.Ping(); err != nil {
+	if err = waitForDB(ctx, db.PingContext, opts.Retry); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	// Crear las tablas
-	if err = createTables(db); err != nil {
-		return nil, err
-	}
-
-	log.Printf("Base de datos PostgreSQL inicializada correctamente")
 	return db, nil
 }
 
-// createTables crea el schema de la base de datos PostgreSQL
-func createTables(db *sql.DB) error {
-	schema := `
-	-- Tabla de usuarios
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		email TEXT UNIQUE NOT NULL,
-		password TEXT NOT NULL,
-		username TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
+// InitDB inicializa la base de datos PostgreSQL y aplica las migraciones pendientes
+func InitDB(ctx context.Context, connectionString string, opts Options) (*sql.DB, error) {
+	db, err := Open(ctx, connectionString, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	-- Tabla de posts
-	CREATE TABLE IF NOT EXISTS posts (
-		id SERIAL PRIMARY KEY,
-		title TEXT NOT NULL,
-		content TEXT NOT NULL,
-		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
+	if err = Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
 
-	-- Tabla de comentarios
-	CREATE TABLE IF NOT EXISTS comments (
-		id SERIAL PRIMARY KEY,
-		post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
-		user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
-		content TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
+	slog.Info("Base de datos PostgreSQL inicializada correctamente")
+	return db, nil
+}
 
-	-- Índices para mejorar rendimiento
-	CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id);
-	CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
-	CREATE INDEX IF NOT EXISTS idx_comments_user_id ON comments(user_id);
-	`
+// Migrate lleva el schema a la última versión
+func Migrate(db *sql.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
 
-	_, err := db.Exec(schema)
+	_, err = migrator.Up()
 	return err
 }
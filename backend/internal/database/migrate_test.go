@@ -0,0 +1,58 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles, "migrations")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+
+	// Versiones consecutivas, todas reversibles
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, migration.Name)
+		assert.NotEmpty(t, migration.Up, migration.Name)
+		assert.NotEmpty(t, migration.Down, migration.Name)
+	}
+}
+
+func TestLoadMigrations_OrdenaPorVersion(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0002_segunda.up.sql":   {Data: []byte("SELECT 2;")},
+		"m/0001_primera.up.sql":   {Data: []byte("SELECT 1;")},
+		"m/0001_primera.down.sql": {Data: []byte("SELECT -1;")},
+		"m/README.md":             {Data: []byte("no es una migración")},
+	}
+
+	migrations, err := loadMigrations(fsys, "m")
+
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, Migration{Version: 1, Name: "primera", Up: "SELECT 1;", Down: "SELECT -1;"}, migrations[0])
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.Empty(t, migrations[1].Down)
+}
+
+func TestLoadMigrations_Invalidas(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"nombre sin dirección": {"m/0001_primera.sql": {Data: []byte("SELECT 1;")}},
+		"sin número":           {"m/primera.up.sql": {Data: []byte("SELECT 1;")}},
+		"versión duplicada": {
+			"m/0001_primera.up.sql": {Data: []byte("SELECT 1;")},
+			"m/0001_otra.up.sql":    {Data: []byte("SELECT 1;")},
+		},
+		"solo down": {"m/0001_primera.down.sql": {Data: []byte("SELECT 1;")}},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := loadMigrations(fsys, "m")
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+-- Versión y fecha de edición de cada post
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+UPDATE posts SET updated_at = created_at WHERE updated_at IS NULL;
+
+-- Historial de versiones anteriores de cada post
+CREATE TABLE IF NOT EXISTS post_revisions (
+	id SERIAL PRIMARY KEY,
+	post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	UNIQUE (post_id, version)
+);
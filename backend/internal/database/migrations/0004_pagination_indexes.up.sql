@@ -0,0 +1,3 @@
+-- Índices para la paginación por cursor sobre (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_posts_created_at_id ON posts(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_created_at_id ON comments(post_id, created_at, id);
@@ -0,0 +1,18 @@
+-- Búsqueda de texto completo: el contenido mezcla español e inglés,
+-- así que cada documento se indexa con las dos configuraciones
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		setweight(to_tsvector('spanish', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+		setweight(to_tsvector('spanish', coalesce(content, '')), 'B') ||
+		setweight(to_tsvector('english', coalesce(content, '')), 'B')
+	) STORED;
+
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS search_vector tsvector
+	GENERATED ALWAYS AS (
+		to_tsvector('spanish', coalesce(content, '')) ||
+		to_tsvector('english', coalesce(content, ''))
+	) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_comments_search_vector ON comments USING GIN (search_vector);
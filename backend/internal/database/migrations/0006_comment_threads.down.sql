@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_comments_post_id_path;
+DROP INDEX IF EXISTS idx_comments_parent_id;
+
+-- Sin hilos, las respuestas y los comentarios eliminados no tienen sentido
+DELETE FROM comments WHERE parent_id IS NOT NULL OR deleted_at IS NOT NULL;
+
+ALTER TABLE comments DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS path;
+ALTER TABLE comments DROP COLUMN IF EXISTS depth;
+ALTER TABLE comments DROP COLUMN IF EXISTS parent_id;
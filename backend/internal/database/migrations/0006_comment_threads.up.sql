@@ -0,0 +1,15 @@
+-- Respuestas anidadas: cada comentario guarda su padre, su profundidad
+-- y un camino materializado (ids con ceros a la izquierda separados por ".")
+-- que ordena el hilo completo con un simple ORDER BY path.
+-- Borrar un padre no arrastra sus respuestas: el repositorio decide si el
+-- comentario queda como "[deleted]" o se elimina del todo
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments(id) ON DELETE SET NULL;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS depth INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS path TEXT;
+ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+UPDATE comments SET path = lpad(id::text, 10, '0') WHERE path IS NULL;
+ALTER TABLE comments ALTER COLUMN path SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_post_id_path ON comments(post_id, path);
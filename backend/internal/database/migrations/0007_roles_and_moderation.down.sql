@@ -0,0 +1,3 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS locked_at;
+ALTER TABLE users DROP COLUMN IF EXISTS banned_at;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,9 @@
+-- Roles para la moderación de contenido
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'
+	CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Usuarios suspendidos por un moderador
+ALTER TABLE users ADD COLUMN IF NOT EXISTS banned_at TIMESTAMP;
+
+-- Posts cerrados a nuevos comentarios
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS locked_at TIMESTAMP;
@@ -0,0 +1,12 @@
+-- Logins fallidos por cuenta (email) y por IP, para demorar y bloquear la fuerza bruta.
+-- Se guarda el email aunque no exista la cuenta: así el bloqueo no revela qué emails están registrados.
+CREATE TABLE IF NOT EXISTS login_throttles (
+	scope TEXT NOT NULL CHECK (scope IN ('account', 'ip')),
+	subject TEXT NOT NULL,
+	failures INTEGER NOT NULL DEFAULT 0,
+	last_failure_at TIMESTAMPTZ NOT NULL,
+	locked_until TIMESTAMPTZ,
+	PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure_at ON login_throttles (last_failure_at);
@@ -0,0 +1,10 @@
+-- Verificación del email al registrarse.
+-- Las cuentas que ya existían se dan por verificadas: exigirles verificar de golpe
+-- las dejaría sin poder publicar hasta que lean un mail que nunca pidieron.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Los links de verificación van firmados con HMAC y no se guardan.
+-- verification_sent_at registra el último envío para aplicar la espera entre reenvíos
+-- con un único UPDATE condicional, sin carreras entre pedidos simultáneos.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verification_sent_at TIMESTAMPTZ;
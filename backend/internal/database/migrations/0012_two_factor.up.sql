@@ -0,0 +1,23 @@
+-- Verificación en dos pasos con TOTP (RFC 6238).
+-- El secreto va cifrado con MFA_ENCRYPTION_KEY: hay que poder leerlo para validar códigos.
+CREATE TABLE IF NOT EXISTS user_totp (
+	user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+	secret TEXT NOT NULL,
+	confirmed_at TIMESTAMPTZ, -- NULL = enrolamiento sin confirmar
+	last_used_step BIGINT NOT NULL DEFAULT 0, -- un código no se acepta dos veces
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Códigos de recuperación de un solo uso; se guarda solo el hash
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+	code_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id);
+
+-- El desafío del segundo paso del login usa la tabla de tokens de un solo uso
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+	CHECK (purpose IN ('password_reset', 'mfa_challenge'));
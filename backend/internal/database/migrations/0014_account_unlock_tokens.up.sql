@@ -0,0 +1,4 @@
+-- El desbloqueo por logins fallidos se hace con un link de un solo uso por mail
+ALTER TABLE user_tokens DROP CONSTRAINT IF EXISTS user_tokens_purpose_check;
+ALTER TABLE user_tokens ADD CONSTRAINT user_tokens_purpose_check
+	CHECK (purpose IN ('password_reset', 'mfa_challenge', 'account_unlock'));
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"syscall"
+	"time"
+
+	"ingsw3-tp08/internal/logging"
+
+	"github.com/lib/pq"
+)
+
+// PoolConfig limita y recicla las conexiones del pool. 0 deja el default de database/sql.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// apply configura el pool de db
+func (p PoolConfig) apply(db *sql.DB) {
+	db.SetMaxOpenConns(p.MaxOpenConns)
+	if p.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(p.MaxIdleConns)
+	}
+	db.SetConnMaxLifetime(p.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(p.ConnMaxIdleTime)
+}
+
+// ConnectRetry define cuánto se espera a que PostgreSQL acepte conexiones al arrancar.
+// La espera entre intentos arranca en InitialBackoff y se duplica hasta MaxBackoff;
+// después de MaxWait se abandona. MaxWait 0 hace un único intento.
+type ConnectRetry struct {
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	MaxWait        time.Duration
+}
+
+// Options es la configuración de Open
+type Options struct {
+	Pool  PoolConfig
+	Retry ConnectRetry
+}
+
+// waitForDB llama a ping hasta que responda, con backoff exponencial.
+// Los errores que no son transitorios (contraseña incorrecta, base inexistente)
+// se devuelven enseguida: reintentar no los arregla.
+func waitForDB(ctx context.Context, ping func(context.Context) error, retry ConnectRetry) error {
+	deadline := time.Now().Add(retry.MaxWait)
+	backoff := retry.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+		if !IsTransient(err) {
+			return err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return fmt.Errorf("PostgreSQL no respondió después de %d intentos: %w", attempt, err)
+		}
+		wait := min(backoff, remaining)
+		slog.Warn("PostgreSQL no está disponible, reintentando", "attempt", attempt, "retry_in", wait.String(), "error", err)
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+		backoff = min(backoff*2, retry.MaxBackoff)
+	}
+}
+
+// ReadRetry define los reintentos de una lectura idempotente ante un error transitorio
+// (conexión cortada, failover, pool de PostgreSQL lleno). Attempts es la cantidad total
+// de intentos; la espera arranca en Backoff y se duplica en cada reintento.
+type ReadRetry struct {
+	Attempts int
+	Backoff  time.Duration
+}
+
+// DefaultReadRetry son los reintentos de lectura si no se configuran otros
+var DefaultReadRetry = ReadRetry{Attempts: 3, Backoff: 50 * time.Millisecond}
+
+// Do ejecuta fn y la repite mientras falle con un error transitorio.
+// Solo se debe usar con operaciones que se pueden repetir sin efectos (SELECT).
+func (r ReadRetry) Do(ctx context.Context, fn func() error) error {
+	backoff := r.Backoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= r.Attempts || !IsTransient(err) {
+			return err
+		}
+
+		logging.FromContext(ctx).Warn("Reintentando lectura por un error transitorio", "attempt", attempt, "error", err)
+		if sleepErr := sleep(ctx, backoff); sleepErr != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// Códigos de PostgreSQL que indican una falla pasajera del servidor
+var transientCodes = map[pq.ErrorCode]bool{
+	"40001": true, // serialization_failure
+	"40P01": true, // deadlock_detected
+	"53300": true, // too_many_connections
+	"57P01": true, // admin_shutdown
+	"57P02": true, // crash_shutdown
+	"57P03": true, // cannot_connect_now (el servidor está arrancando)
+}
+
+// IsTransient indica si vale la pena reintentar una operación que falló con err.
+// Un contexto cancelado o vencido nunca es transitorio: el request ya terminó.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// Clase 08: connection_exception
+		return pqErr.Code.Class() == "08" || transientCodes[pqErr.Code]
+	}
+
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	// Errores de red: DNS que todavía no resuelve, dial rechazado, timeout
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// sleep espera d o hasta que se cancele ctx
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
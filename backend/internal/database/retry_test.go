@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetry = ConnectRetry{InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond, MaxWait: time.Second}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"conexión inválida", driver.ErrBadConn, true},
+		{"conexión rechazada", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{"conexión reseteada envuelta", fmt.Errorf("query: %w", syscall.ECONNRESET), true},
+		{"DNS sin resolver", &net.DNSError{Name: "db", IsNotFound: true}, true},
+		{"servidor arrancando", &pq.Error{Code: "57P03"}, true},
+		{"clase 08", &pq.Error{Code: "08006"}, true},
+		{"demasiadas conexiones", &pq.Error{Code: "53300"}, true},
+		{"serialización", &pq.Error{Code: "40001"}, true},
+		{"contraseña incorrecta", &pq.Error{Code: "28P01"}, false},
+		{"unique violation", &pq.Error{Code: "23505"}, false},
+		{"sin filas", sql.ErrNoRows, false},
+		{"contexto cancelado", context.Canceled, false},
+		{"plazo vencido", fmt.Errorf("query: %w", context.DeadlineExceeded), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTransient(tt.err))
+		})
+	}
+}
+
+func TestWaitForDB_ReintentaHastaQueResponda(t *testing.T) {
+	calls := 0
+	ping := func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &pq.Error{Code: "57P03"}
+		}
+		return nil
+	}
+
+	err := waitForDB(context.Background(), ping, fastRetry)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+}
+
+func TestWaitForDB_ErrorNoTransitorioNoSeReintenta(t *testing.T) {
+	calls := 0
+	authErr := &pq.Error{Code: "28P01"}
+	ping := func(context.Context) error {
+		calls++
+		return authErr
+	}
+
+	err := waitForDB(context.Background(), ping, fastRetry)
+
+	assert.ErrorIs(t, err, authErr)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWaitForDB_AbandonaDespuesDeMaxWait(t *testing.T) {
+	retry := ConnectRetry{InitialBackoff: 5 * time.Millisecond, MaxBackoff: 5 * time.Millisecond, MaxWait: 30 * time.Millisecond}
+	ping := func(context.Context) error { return driver.ErrBadConn }
+
+	start := time.Now()
+	err := waitForDB(context.Background(), ping, retry)
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, driver.ErrBadConn)
+	assert.Contains(t, err.Error(), "intentos")
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWaitForDB_SinMaxWaitHaceUnSoloIntento(t *testing.T) {
+	calls := 0
+	ping := func(context.Context) error {
+		calls++
+		return driver.ErrBadConn
+	}
+
+	err := waitForDB(context.Background(), ping, ConnectRetry{InitialBackoff: time.Millisecond})
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestWaitForDB_RespetaElContexto(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ping := func(context.Context) error {
+		cancel()
+		return driver.ErrBadConn
+	}
+
+	err := waitForDB(ctx, ping, ConnectRetry{InitialBackoff: time.Hour, MaxBackoff: time.Hour, MaxWait: time.Hour})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestReadRetry_Do(t *testing.T) {
+	retry := ReadRetry{Attempts: 3, Backoff: time.Millisecond}
+
+	t.Run("reintenta errores transitorios", func(t *testing.T) {
+		calls := 0
+		err := retry.Do(context.Background(), func() error {
+			calls++
+			if calls == 1 {
+				return driver.ErrBadConn
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("se detiene al agotar los intentos", func(t *testing.T) {
+		calls := 0
+		err := retry.Do(context.Background(), func() error {
+			calls++
+			return driver.ErrBadConn
+		})
+
+		assert.ErrorIs(t, err, driver.ErrBadConn)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("no reintenta otros errores", func(t *testing.T) {
+		calls := 0
+		notTransient := errors.New("syntax error")
+		err := retry.Do(context.Background(), func() error {
+			calls++
+			return notTransient
+		})
+
+		assert.ErrorIs(t, err, notTransient)
+		assert.Equal(t, 1, calls)
+	})
+}
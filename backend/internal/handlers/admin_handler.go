@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler maneja las peticiones HTTP de administración de usuarios
+type AdminHandler struct {
+	adminService services.AdminServiceInterface
+}
+
+// NewAdminHandler crea una nueva instancia
+func NewAdminHandler(adminService services.AdminServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+// AssignRole maneja PUT /api/admin/users/{id}/role
+func (h *AdminHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	var req models.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	if !models.IsValidRole(req.Role) {
+		respondWithError(w, http.StatusBadRequest, "Rol inválido: usar user, moderator o admin")
+		return
+	}
+
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	user, err := h.adminService.AssignRole(r.Context(), actorID, targetID, req.Role)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
+
+// BanUser maneja POST /api/admin/users/{id}/ban
+func (h *AdminHandler) BanUser(w http.ResponseWriter, r *http.Request) {
+	h.setBanned(w, r, h.adminService.BanUser)
+}
+
+// UnbanUser maneja DELETE /api/admin/users/{id}/ban
+func (h *AdminHandler) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	h.setBanned(w, r, h.adminService.UnbanUser)
+}
+
+// UnlockUser maneja DELETE /api/admin/users/{id}/lockout
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	h.setBanned(w, r, h.adminService.UnlockUser)
+}
+
+// setBanned comparte el manejo de suspender, rehabilitar y desbloquear
+func (h *AdminHandler) setBanned(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, actorID int, targetID int) (*models.User, error)) {
+	targetID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	actorID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	user, err := action(r.Context(), actorID, targetID)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, user)
+}
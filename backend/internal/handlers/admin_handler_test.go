@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminHandler_AssignRole_Success(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	mockAdminService.On("AssignRole", mock.Anything, 1, 2, models.RoleModerator).
+		Return(&models.User{ID: 2, Role: models.RoleModerator}, nil)
+
+	body, _ := json.Marshal(models.AssignRoleRequest{Role: models.RoleModerator})
+	httpReq := httptest.NewRequest(http.MethodPut, "/api/admin/users/2/role", bytes.NewBuffer(body))
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "2"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.AssignRole(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAdminService.AssertExpectations(t)
+
+	var response models.User
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, models.RoleModerator, response.Role)
+}
+
+func TestAdminHandler_AssignRole_InvalidRole(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	httpReq := httptest.NewRequest(http.MethodPut, "/api/admin/users/2/role", bytes.NewBufferString(`{"role":"root"}`))
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "2"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.AssignRole(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAdminService.AssertNotCalled(t, "AssignRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminHandler_AssignRole_Forbidden(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	mockAdminService.On("AssignRole", mock.Anything, 1, 2, models.RoleAdmin).
+		Return(nil, domainError(services.ErrForbidden, "no tienes permiso para cambiar roles"))
+
+	httpReq := httptest.NewRequest(http.MethodPut, "/api/admin/users/2/role", bytes.NewBufferString(`{"role":"admin"}`))
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "2"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.AssignRole(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockAdminService.AssertExpectations(t)
+}
+
+func TestAdminHandler_BanUser_Success(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	mockAdminService.On("BanUser", mock.Anything, 1, 2).Return(&models.User{ID: 2}, nil)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/admin/users/2/ban", nil)
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "2"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.BanUser(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAdminService.AssertExpectations(t)
+}
+
+func TestAdminHandler_UnbanUser_InvalidID(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	httpReq := httptest.NewRequest(http.MethodDelete, "/api/admin/users/abc/ban", nil)
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "abc"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.UnbanUser(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAdminService.AssertNotCalled(t, "UnbanUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminHandler_BanUser_MissingUserID(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/admin/users/2/ban", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "2"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.BanUser(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAdminHandler_UnlockUser_Success(t *testing.T) {
+	// ARRANGE
+	mockAdminService := new(mocks.MockAdminService)
+	adminHandler := NewAdminHandler(mockAdminService)
+
+	mockAdminService.On("UnlockUser", mock.Anything, 1, 2).Return(&models.User{ID: 2}, nil)
+
+	httpReq := httptest.NewRequest(http.MethodDelete, "/api/admin/users/2/lockout", nil)
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "2"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	adminHandler.UnlockUser(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAdminService.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// APITokenHandler maneja las peticiones HTTP de los tokens de API personales
+type APITokenHandler struct {
+	apiTokenService services.APITokenServiceInterface
+}
+
+// NewAPITokenHandler crea una nueva instancia
+func NewAPITokenHandler(apiTokenService services.APITokenServiceInterface) *APITokenHandler {
+	return &APITokenHandler{
+		apiTokenService: apiTokenService,
+	}
+}
+
+// CreateToken maneja POST /api/me/tokens (el token completo solo viene en esta respuesta)
+func (h *APITokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	var req models.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	token, err := h.apiTokenService.Create(r.Context(), userID, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, token)
+}
+
+// ListTokens maneja GET /api/me/tokens?limit=20&cursor=...
+func (h *APITokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.apiTokenService.List(r.Context(), userID, params)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithPage(w, r, params, page)
+}
+
+// RevokeToken maneja DELETE /api/me/tokens/{id}
+func (h *APITokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	if err := h.apiTokenService.Revoke(r.Context(), userID, id); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Token revocado"})
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPITokenHandler_CreateToken_Success(t *testing.T) {
+	// ARRANGE
+	mockService := new(mocks.MockAPITokenService)
+	handler := NewAPITokenHandler(mockService)
+
+	req := models.CreateAPITokenRequest{Name: "bot de CI", Scopes: []string{models.ScopePostsWrite}, ExpiresInDays: 30}
+	mockService.On("Create", mock.Anything, 1, &req).Return(&models.CreatedAPIToken{
+		APIToken: &models.APIToken{ID: 5, UserID: 1, Name: "bot de CI", Prefix: "pat_abcdefgh", Scopes: req.Scopes},
+		Token:    "pat_abcdefgh-resto",
+	}, nil)
+
+	body, _ := json.Marshal(req)
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/me/tokens", bytes.NewBuffer(body)), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	handler.CreateToken(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "pat_abcdefgh-resto", response["token"])
+	assert.Equal(t, "pat_abcdefgh", response["prefix"])
+	assert.Equal(t, float64(5), response["id"])
+	assert.NotContains(t, response, "user_id")
+}
+
+func TestAPITokenHandler_CreateToken_ScopeInvalido(t *testing.T) {
+	// ARRANGE
+	mockService := new(mocks.MockAPITokenService)
+	handler := NewAPITokenHandler(mockService)
+
+	mockService.On("Create", mock.Anything, 1, mock.Anything).
+		Return(nil, &services.Error{Kind: services.ErrValidation, Field: "scopes", Message: "scope desconocido: admin:write"})
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/me/tokens", bytes.NewBufferString(`{"name":"bot","scopes":["admin:write"]}`)), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	handler.CreateToken(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), CodeValidation)
+}
+
+func TestAPITokenHandler_ListTokens_Success(t *testing.T) {
+	// ARRANGE
+	mockService := new(mocks.MockAPITokenService)
+	handler := NewAPITokenHandler(mockService)
+
+	mockService.On("List", mock.Anything, 1, firstPage).Return(&pagination.Page[*models.APIToken]{Items: []*models.APIToken{
+		{ID: 5, Name: "bot de CI", Prefix: "pat_abcdefgh", Scopes: []string{models.ScopePostsWrite}},
+	}}, nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodGet, "/api/me/tokens", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	handler.ListTokens(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("Link"))
+	assert.Contains(t, w.Body.String(), `"prefix":"pat_abcdefgh"`)
+	assert.NotContains(t, w.Body.String(), `"token"`)
+
+	var response pagination.Page[models.APIToken]
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Items, 1)
+	assert.Empty(t, response.NextCursor)
+}
+
+func TestAPITokenHandler_ListTokens_NextPageLink(t *testing.T) {
+	// ARRANGE
+	mockService := new(mocks.MockAPITokenService)
+	handler := NewAPITokenHandler(mockService)
+
+	page := &pagination.Page[*models.APIToken]{
+		Items:      []*models.APIToken{{ID: 5, Name: "bot de CI", Prefix: "pat_abcdefgh"}},
+		NextCursor: "siguiente",
+	}
+	mockService.On("List", mock.Anything, 1, pagination.Params{Limit: 1}).Return(page, nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodGet, "/api/me/tokens?limit=1", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	handler.ListTokens(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `</api/me/tokens?cursor=siguiente&limit=1>; rel="next"`, w.Header().Get("Link"))
+	assert.Contains(t, w.Body.String(), `"next_cursor":"siguiente"`)
+}
+
+func TestAPITokenHandler_ListTokens_InvalidCursor(t *testing.T) {
+	mockService := new(mocks.MockAPITokenService)
+	handler := NewAPITokenHandler(mockService)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodGet, "/api/me/tokens?cursor=basura", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	handler.ListTokens(w, httpReq)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAPITokenHandler_RevokeToken(t *testing.T) {
+	tests := []struct {
+		name       string
+		id         string
+		serviceErr error
+		wantStatus int
+	}{
+		{"revocado", "5", nil, http.StatusOK},
+		{"ajeno o inexistente", "6", &services.Error{Kind: services.ErrNotFound, Message: services.ErrAPITokenNotFound}, http.StatusNotFound},
+		{"ID inválido", "abc", nil, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(mocks.MockAPITokenService)
+			handler := NewAPITokenHandler(mockService)
+			mockService.On("Revoke", mock.Anything, 1, mock.Anything).Return(tt.serviceErr)
+
+			httpReq := withSession(httptest.NewRequest(http.MethodDelete, "/api/me/tokens/"+tt.id, nil), 1, "sesion-1")
+			httpReq = mux.SetURLVars(httpReq, map[string]string{"id": tt.id})
+			w := httptest.NewRecorder()
+
+			handler.RevokeToken(w, httpReq)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestAPITokenHandler_MissingUser(t *testing.T) {
+	mockService := new(mocks.MockAPITokenService)
+	handler := NewAPITokenHandler(mockService)
+
+	w := httptest.NewRecorder()
+	handler.ListTokens(w, httptest.NewRequest(http.MethodGet, "/api/me/tokens", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockService.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -2,21 +2,28 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 
+	"ingsw3-tp08/internal/auth"
 	"ingsw3-tp08/internal/models"
 	"ingsw3-tp08/internal/services"
+
+	"github.com/gorilla/mux"
 )
 
 // AuthHandler maneja las peticiones HTTP de autenticación
 type AuthHandler struct {
-	authService services.AuthServiceInterface
+	authService    services.AuthServiceInterface
+	sessionService services.SessionServiceInterface
 }
 
 // NewAuthHandler crea una nueva instancia
-func NewAuthHandler(authService services.AuthServiceInterface) *AuthHandler {
+func NewAuthHandler(authService services.AuthServiceInterface, sessionService services.SessionServiceInterface) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
+		authService:    authService,
+		sessionService: sessionService,
 	}
 }
 
@@ -25,14 +32,14 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	// Decodificar el body JSON
 	var req models.RegisterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "JSON inválido")
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
 		return
 	}
 
 	// Llamar al servicio
-	user, err := h.authService.Register(&req)
+	user, err := h.authService.Register(r.Context(), &req)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
@@ -40,24 +47,333 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	respondWithJSON(w, http.StatusCreated, user)
 }
 
-// Login maneja POST /api/auth/login
+// Login maneja POST /api/auth/login.
+// Si la cuenta tiene verificación en dos pasos responde el desafío para /api/auth/login/mfa.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// Decodificar el body JSON
 	var creds models.Credentials
 	if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
-		respondWithError(w, http.StatusBadRequest, "JSON inválido")
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
 		return
 	}
 
 	// Llamar al servicio
-	user, err := h.authService.Login(&creds)
+	client := clientInfo(r)
+	user, err := h.authService.Login(r.Context(), &creds, client)
+	var mfaErr *services.MFARequired
+	if errors.As(err, &mfaErr) {
+		respondWithJSON(w, http.StatusOK, mfaErr.Challenge)
+		return
+	}
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	h.startSession(w, r, user, client)
+}
+
+// LoginMFA maneja POST /api/auth/login/mfa (segundo paso del login)
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req models.MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	client := clientInfo(r)
+	user, err := h.authService.VerifyMFA(r.Context(), &req, client)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	h.startSession(w, r, user, client)
+}
+
+// startSession abre una sesión nueva para este dispositivo y responde el usuario con sus tokens
+func (h *AuthHandler) startSession(w http.ResponseWriter, r *http.Request, user *models.User, client models.ClientInfo) {
+	tokens, err := h.sessionService.Start(r.Context(), user.ID, client)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "No se pudo iniciar la sesión")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, &models.LoginResponse{
+		User:      user,
+		TokenPair: tokens,
+	})
+}
+
+// Refresh maneja POST /api/auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	tokens, err := h.sessionService.Refresh(r.Context(), req.RefreshToken, clientInfo(r))
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, tokens)
+}
+
+// ForgotPassword maneja POST /api/auth/password/forgot.
+// Responde 202 exista o no la cuenta, para no revelar qué emails están registrados.
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	if err := h.authService.ForgotPassword(r.Context(), &req); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Si el email está registrado, te enviamos un enlace para restablecer la contraseña",
+	})
+}
+
+// ResetPassword maneja POST /api/auth/password/reset
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), &req); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Contraseña actualizada: iniciá sesión de nuevo"})
+}
+
+// VerifyEmail maneja GET /api/auth/verify?token=... (el link del mail de verificación)
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.VerifyEmail(r.Context(), r.URL.Query().Get("token")); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Email verificado"})
+}
+
+// RequestUnlock maneja POST /api/auth/unlock.
+// Responde 202 exista o no la cuenta, para no revelar qué emails están registrados ni bloqueados.
+func (h *AuthHandler) RequestUnlock(w http.ResponseWriter, r *http.Request) {
+	var req models.UnlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	if err := h.authService.RequestUnlock(r.Context(), &req); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{
+		"message": "Si la cuenta está bloqueada, te enviamos un enlace para desbloquearla",
+	})
+}
+
+// UnlockAccount maneja GET /api/auth/unlock?token=... (el link del mail de desbloqueo)
+func (h *AuthHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	if err := h.authService.UnlockAccount(r.Context(), r.URL.Query().Get("token")); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Cuenta desbloqueada"})
+}
+
+// ResendVerification maneja POST /api/auth/verify/resend (manda un link nuevo al usuario autenticado)
+func (h *AuthHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	if err := h.authService.ResendVerification(r.Context(), userID); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, map[string]string{"message": "Te enviamos un nuevo enlace de verificación"})
+}
+
+// EnrollTOTP maneja POST /api/auth/mfa/totp (genera el secreto para la app de autenticación)
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	enrollment, err := h.authService.BeginTOTPEnrollment(r.Context(), userID)
 	if err != nil {
-		respondWithError(w, http.StatusUnauthorized, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
-	// Responder con el usuario autenticado
-	respondWithJSON(w, http.StatusOK, user)
+	respondWithJSON(w, http.StatusOK, enrollment)
+}
+
+// ConfirmTOTP maneja POST /api/auth/mfa/totp/confirm (activa el segundo factor
+// y responde los códigos de recuperación)
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	codes, err := h.authService.ConfirmTOTPEnrollment(r.Context(), userID, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, codes)
+}
+
+// DisableTOTP maneja DELETE /api/auth/mfa/totp
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	if err := h.authService.DisableTOTP(r.Context(), userID, &req); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Verificación en dos pasos desactivada"})
+}
+
+// RegenerateRecoveryCodes maneja POST /api/auth/mfa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(r.Context(), userID, &req)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, codes)
+}
+
+// Logout maneja POST /api/auth/logout (cierra la sesión actual)
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	if err := h.sessionService.Logout(r.Context(), principal.UserID, principal.SessionID); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Sesión cerrada"})
+}
+
+// LogoutAll maneja POST /api/auth/logout-all (cierra todas las sesiones)
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	if err := h.sessionService.LogoutAll(r.Context(), userID); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Todas las sesiones fueron cerradas"})
+}
+
+// ListSessions maneja GET /api/auth/sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.PrincipalFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(r.Context(), principal.UserID, principal.SessionID)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession maneja DELETE /api/auth/sessions/{id} (cierra otro dispositivo)
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	if err := h.sessionService.Logout(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Sesión cerrada"})
+}
+
+// clientInfo identifica el dispositivo que hace el request
+func clientInfo(r *http.Request) models.ClientInfo {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+
+	return models.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: ip,
+	}
 }
 
 // Funciones auxiliares para responder JSON
@@ -68,7 +384,3 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.WriteHeader(code)
 	w.Write(response)
 }
-
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
-}
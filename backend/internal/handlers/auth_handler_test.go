@@ -6,10 +6,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
 	"ingsw3-tp08/tests/mocks"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -17,7 +20,7 @@ import (
 func TestAuthHandler_Register_Success(t *testing.T) {
 	// ARRANGE
 	mockAuthService := new(mocks.MockAuthService)
-	authHandler := NewAuthHandler(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
 
 	req := models.RegisterRequest{
 		Email:    "test@example.com",
@@ -31,7 +34,7 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 		Username: "testuser",
 	}
 
-	mockAuthService.On("Register", &req).Return(expectedUser, nil)
+	mockAuthService.On("Register", mock.Anything, &req).Return(expectedUser, nil)
 
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBuffer(body))
@@ -57,7 +60,7 @@ func TestAuthHandler_Register_Success(t *testing.T) {
 func TestAuthHandler_Register_InvalidJSON(t *testing.T) {
 	// ARRANGE
 	mockAuthService := new(mocks.MockAuthService)
-	authHandler := NewAuthHandler(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
 
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBufferString("invalid json"))
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -74,15 +77,15 @@ func TestAuthHandler_Register_InvalidJSON(t *testing.T) {
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
-	assert.Equal(t, "JSON inválido", response["error"])
+	assert.Equal(t, ErrInvalidJSON, response["error"])
 
-	mockAuthService.AssertNotCalled(t, "Register", mock.Anything)
+	mockAuthService.AssertNotCalled(t, "Register", mock.Anything, mock.Anything)
 }
 
 func TestAuthHandler_Register_ServiceError(t *testing.T) {
 	// ARRANGE
 	mockAuthService := new(mocks.MockAuthService)
-	authHandler := NewAuthHandler(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
 
 	req := models.RegisterRequest{
 		Email:    "test@example.com",
@@ -90,7 +93,7 @@ func TestAuthHandler_Register_ServiceError(t *testing.T) {
 		Username: "testuser",
 	}
 
-	mockAuthService.On("Register", &req).Return(nil, assert.AnError)
+	mockAuthService.On("Register", mock.Anything, &req).Return(nil, domainError(services.ErrConflict, "el email ya está registrado"))
 
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/register", bytes.NewBuffer(body))
@@ -102,12 +105,13 @@ func TestAuthHandler_Register_ServiceError(t *testing.T) {
 	authHandler.Register(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusConflict, w.Code)
 
 	var response map[string]string
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, assert.AnError.Error(), response["error"])
+	assert.Equal(t, "el email ya está registrado", response["error"])
+	assert.Equal(t, CodeConflict, response["code"])
 
 	mockAuthService.AssertExpectations(t)
 }
@@ -115,7 +119,8 @@ func TestAuthHandler_Register_ServiceError(t *testing.T) {
 func TestAuthHandler_Login_Success(t *testing.T) {
 	// ARRANGE
 	mockAuthService := new(mocks.MockAuthService)
-	authHandler := NewAuthHandler(mockAuthService)
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(mockAuthService, mockSessionService)
 
 	creds := models.Credentials{
 		Email:    "test@example.com",
@@ -128,7 +133,16 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 		Username: "testuser",
 	}
 
-	mockAuthService.On("Login", &creds).Return(expectedUser, nil)
+	expectedTokens := &models.TokenPair{
+		SessionID:    "sesion-1",
+		AccessToken:  "access",
+		TokenType:    "Bearer",
+		ExpiresAt:    time.Now().Add(time.Minute),
+		RefreshToken: "refresh",
+	}
+
+	mockAuthService.On("Login", mock.Anything, &creds, mock.Anything).Return(expectedUser, nil)
+	mockSessionService.On("Start", mock.Anything, 1, mock.AnythingOfType("models.ClientInfo")).Return(expectedTokens, nil)
 
 	body, _ := json.Marshal(creds)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
@@ -142,6 +156,7 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 	// ASSERT
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockAuthService.AssertExpectations(t)
+	mockSessionService.AssertExpectations(t)
 
 	var response models.User
 	err := json.Unmarshal(w.Body.Bytes(), &response)
@@ -149,12 +164,48 @@ func TestAuthHandler_Login_Success(t *testing.T) {
 	assert.Equal(t, expectedUser.ID, response.ID)
 	assert.Equal(t, expectedUser.Email, response.Email)
 	assert.Equal(t, expectedUser.Username, response.Username)
+
+	// Los tokens de la sesión van en el mismo nivel que el usuario
+	var tokens models.TokenPair
+	err = json.Unmarshal(w.Body.Bytes(), &tokens)
+	assert.NoError(t, err)
+	assert.Equal(t, "access", tokens.AccessToken)
+	assert.Equal(t, "refresh", tokens.RefreshToken)
+	assert.Equal(t, "sesion-1", tokens.SessionID)
+}
+
+func TestAuthHandler_Login_SessionError(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(mockAuthService, mockSessionService)
+
+	creds := models.Credentials{
+		Email:    "test@example.com",
+		Password: "password123",
+	}
+
+	mockAuthService.On("Login", mock.Anything, &creds, mock.Anything).Return(&models.User{ID: 1, Email: creds.Email}, nil)
+	mockSessionService.On("Start", mock.Anything, 1, mock.AnythingOfType("models.ClientInfo")).Return(nil, assert.AnError)
+
+	body, _ := json.Marshal(creds)
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Login(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockAuthService.AssertExpectations(t)
 }
 
 func TestAuthHandler_Login_InvalidJSON(t *testing.T) {
 	// ARRANGE
 	mockAuthService := new(mocks.MockAuthService)
-	authHandler := NewAuthHandler(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
 
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBufferString("invalid json"))
 	httpReq.Header.Set("Content-Type", "application/json")
@@ -171,22 +222,22 @@ func TestAuthHandler_Login_InvalidJSON(t *testing.T) {
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
-	assert.Equal(t, "JSON inválido", response["error"])
+	assert.Equal(t, ErrInvalidJSON, response["error"])
 
-	mockAuthService.AssertNotCalled(t, "Login", mock.Anything)
+	mockAuthService.AssertNotCalled(t, "Login", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestAuthHandler_Login_ServiceError(t *testing.T) {
 	// ARRANGE
 	mockAuthService := new(mocks.MockAuthService)
-	authHandler := NewAuthHandler(mockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
 
 	creds := models.Credentials{
 		Email:    "test@example.com",
 		Password: "password123",
 	}
 
-	mockAuthService.On("Login", &creds).Return(nil, assert.AnError)
+	mockAuthService.On("Login", mock.Anything, &creds, mock.Anything).Return(nil, domainError(services.ErrUnauthorized, "credenciales inválidas"))
 
 	body, _ := json.Marshal(creds)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
@@ -204,7 +255,548 @@ func TestAuthHandler_Login_ServiceError(t *testing.T) {
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
-	assert.Equal(t, assert.AnError.Error(), response["error"])
+	assert.Equal(t, "credenciales inválidas", response["error"])
 
 	mockAuthService.AssertExpectations(t)
 }
+
+func TestAuthHandler_Refresh_Success(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	expectedTokens := &models.TokenPair{SessionID: "sesion-1", AccessToken: "nuevo", RefreshToken: "nuevo-refresh"}
+	mockSessionService.On("Refresh", mock.Anything, "viejo-refresh", models.ClientInfo{UserAgent: "Firefox", IPAddress: "10.0.0.1"}).
+		Return(expectedTokens, nil)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBufferString(`{"refresh_token":"viejo-refresh"}`))
+	httpReq.Header.Set("User-Agent", "Firefox")
+	httpReq.RemoteAddr = "10.0.0.1:5555"
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Refresh(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSessionService.AssertExpectations(t)
+
+	var response models.TokenPair
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "nuevo", response.AccessToken)
+	assert.Equal(t, "nuevo-refresh", response.RefreshToken)
+}
+
+func TestAuthHandler_Refresh_InvalidJSON(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBufferString("invalid json"))
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Refresh(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSessionService.AssertNotCalled(t, "Refresh", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_Refresh_ServiceError(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	mockSessionService.On("Refresh", mock.Anything, "reusado", mock.Anything).Return(nil, domainError(services.ErrUnauthorized, services.ErrRefreshTokenReused))
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewBufferString(`{"refresh_token":"reusado"}`))
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Refresh(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout_Success(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	mockSessionService.On("Logout", mock.Anything, 1, "sesion-1").Return(nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Logout(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Logout_MissingUser(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/logout", nil)
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Logout(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSessionService.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_LogoutAll_Success(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	mockSessionService.On("LogoutAll", mock.Anything, 1).Return(nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/logout-all", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.LogoutAll(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestAuthHandler_ListSessions_Success(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	mockSessionService.On("ListSessions", mock.Anything, 1, "sesion-1").Return([]*models.Session{
+		{ID: "sesion-1", UserAgent: "Firefox", Current: true},
+	}, nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodGet, "/api/auth/sessions", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ListSessions(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSessionService.AssertExpectations(t)
+
+	var response []models.Session
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response, 1)
+	assert.True(t, response[0].Current)
+}
+
+func TestAuthHandler_RevokeSession_NotFound(t *testing.T) {
+	// ARRANGE
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(new(mocks.MockAuthService), mockSessionService)
+
+	mockSessionService.On("Logout", mock.Anything, 1, "ajena").Return(domainError(services.ErrNotFound, services.ErrSessionNotFound))
+
+	httpReq := withSession(httptest.NewRequest(http.MethodDelete, "/api/auth/sessions/ajena", nil), 1, "sesion-1")
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "ajena"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.RevokeSession(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestAuthHandler_Login_PasaLaIPDelCliente(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	creds := models.Credentials{Email: "test@example.com", Password: "password123"}
+	client := models.ClientInfo{IPAddress: "203.0.113.7", UserAgent: "test-agent"}
+	mockAuthService.On("Login", mock.Anything, &creds, client).
+		Return(nil, &services.Error{Kind: services.ErrTooManyTries, Message: "demasiados intentos fallidos", RetryAfter: 30 * time.Second})
+
+	body, _ := json.Marshal(creds)
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body))
+	httpReq.RemoteAddr = "203.0.113.7:51234"
+	httpReq.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Login(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	assert.Equal(t, CodeTooManyTries, decodeErrorResponse(t, w).Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RequestUnlock_Accepted(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	req := models.UnlockRequest{Email: "test@example.com"}
+	mockAuthService.On("RequestUnlock", mock.Anything, &req).Return(nil)
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+
+	// ACT: sin sesión, porque la cuenta bloqueada no puede iniciarla
+	authHandler.RequestUnlock(w, httptest.NewRequest(http.MethodPost, "/api/auth/unlock", bytes.NewBuffer(body)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_RequestUnlock_InvalidJSON(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.RequestUnlock(w, httptest.NewRequest(http.MethodPost, "/api/auth/unlock", bytes.NewBufferString("{")))
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAuthService.AssertNotCalled(t, "RequestUnlock", mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_UnlockAccount_Success(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("UnlockAccount", mock.Anything, "token-del-mail").Return(nil)
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.UnlockAccount(w, httptest.NewRequest(http.MethodGet, "/api/auth/unlock?token=token-del-mail", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_UnlockAccount_TokenInvalido(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("UnlockAccount", mock.Anything, "usado").
+		Return(&services.Error{Kind: services.ErrValidation, Message: services.ErrInvalidUnlockToken, Field: "token"})
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.UnlockAccount(w, httptest.NewRequest(http.MethodGet, "/api/auth/unlock?token=usado", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, services.ErrInvalidUnlockToken, decodeErrorResponse(t, w).Error)
+}
+
+func TestAuthHandler_ForgotPassword_Accepted(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	req := models.ForgotPasswordRequest{Email: "test@example.com"}
+	mockAuthService.On("ForgotPassword", mock.Anything, &req).Return(nil)
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/password/forgot", bytes.NewBuffer(body)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_ForgotPassword_InvalidJSON(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ForgotPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/password/forgot", bytes.NewBufferString("{")))
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockAuthService.AssertNotCalled(t, "ForgotPassword", mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_ResetPassword_Success(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	req := models.ResetPasswordRequest{Token: "token-del-mail", Password: "nueva-clave"}
+	mockAuthService.On("ResetPassword", mock.Anything, &req).Return(nil)
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/password/reset", bytes.NewBuffer(body)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_ResetPassword_TokenInvalido(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	req := models.ResetPasswordRequest{Token: "vencido", Password: "nueva-clave"}
+	mockAuthService.On("ResetPassword", mock.Anything, &req).
+		Return(&services.Error{Kind: services.ErrValidation, Message: services.ErrInvalidResetToken, Field: "token"})
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ResetPassword(w, httptest.NewRequest(http.MethodPost, "/api/auth/password/reset", bytes.NewBuffer(body)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response := decodeErrorResponse(t, w)
+	assert.Equal(t, CodeValidation, response.Code)
+	assert.Equal(t, services.ErrInvalidResetToken, response.Error)
+}
+
+func TestAuthHandler_VerifyEmail_Success(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("VerifyEmail", mock.Anything, "token-del-mail").Return(nil)
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.VerifyEmail(w, httptest.NewRequest(http.MethodGet, "/api/auth/verify?token=token-del-mail", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_VerifyEmail_TokenInvalido(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("VerifyEmail", mock.Anything, "vencido").
+		Return(&services.Error{Kind: services.ErrValidation, Message: services.ErrInvalidVerificationToken, Field: "token"})
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.VerifyEmail(w, httptest.NewRequest(http.MethodGet, "/api/auth/verify?token=vencido", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response := decodeErrorResponse(t, w)
+	assert.Equal(t, services.ErrInvalidVerificationToken, response.Error)
+}
+
+func TestAuthHandler_ResendVerification_Accepted(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("ResendVerification", mock.Anything, 1).Return(nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/verify/resend", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ResendVerification(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mockAuthService.AssertExpectations(t)
+}
+
+func TestAuthHandler_ResendVerification_Espera(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("ResendVerification", mock.Anything, 1).
+		Return(&services.Error{Kind: services.ErrTooManyTries, Message: "esperá", RetryAfter: 40 * time.Second})
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/verify/resend", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ResendVerification(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "40", w.Header().Get("Retry-After"))
+}
+
+func TestAuthHandler_ResendVerification_MissingUser(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ResendVerification(w, httptest.NewRequest(http.MethodPost, "/api/auth/verify/resend", nil))
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockAuthService.AssertNotCalled(t, "ResendVerification", mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_Login_DesafioMFA(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(mockAuthService, mockSessionService)
+
+	creds := models.Credentials{Email: "test@example.com", Password: "password123"}
+	challenge := &models.MFAChallenge{MFARequired: true, Token: "desafio", ExpiresAt: time.Now().Add(5 * time.Minute)}
+	mockAuthService.On("Login", mock.Anything, &creds, mock.Anything).Return(nil, &services.MFARequired{Challenge: challenge})
+
+	body, _ := json.Marshal(creds)
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.Login(w, httptest.NewRequest(http.MethodPost, "/api/auth/login", bytes.NewBuffer(body)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.MFAChallenge
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.MFARequired)
+	assert.Equal(t, "desafio", response.Token)
+	mockSessionService.AssertNotCalled(t, "Start", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_LoginMFA_Success(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(mockAuthService, mockSessionService)
+
+	req := models.MFALoginRequest{Token: "desafio", Code: "123456"}
+	mockAuthService.On("VerifyMFA", mock.Anything, &req, mock.AnythingOfType("models.ClientInfo")).
+		Return(&models.User{ID: 1, Email: "test@example.com", MFAEnabled: true}, nil)
+	mockSessionService.On("Start", mock.Anything, 1, mock.AnythingOfType("models.ClientInfo")).
+		Return(&models.TokenPair{SessionID: "sesion-1", AccessToken: "access", RefreshToken: "refresh"}, nil)
+
+	body, _ := json.Marshal(req)
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.LoginMFA(w, httptest.NewRequest(http.MethodPost, "/api/auth/login/mfa", bytes.NewBuffer(body)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tokens models.TokenPair
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tokens))
+	assert.Equal(t, "access", tokens.AccessToken)
+	mockSessionService.AssertExpectations(t)
+}
+
+func TestAuthHandler_LoginMFA_CodigoInvalido(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	mockSessionService := new(mocks.MockSessionService)
+	authHandler := NewAuthHandler(mockAuthService, mockSessionService)
+
+	mockAuthService.On("VerifyMFA", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &services.Error{Kind: services.ErrUnauthorized, Message: services.ErrInvalidMFACode})
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.LoginMFA(w, httptest.NewRequest(http.MethodPost, "/api/auth/login/mfa", bytes.NewBufferString(`{"mfa_token":"desafio","code":"000000"}`)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockSessionService.AssertNotCalled(t, "Start", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthHandler_EnrollTOTP_Success(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("BeginTOTPEnrollment", mock.Anything, 1).
+		Return(&models.TOTPEnrollment{Secret: "SECRETO", URI: "otpauth://totp/x"}, nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/mfa/totp", nil), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.EnrollTOTP(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"secret":"SECRETO"`)
+}
+
+func TestAuthHandler_ConfirmTOTP_DevuelveCodigos(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	mockAuthService.On("ConfirmTOTPEnrollment", mock.Anything, 1, &models.MFACodeRequest{Code: "123456"}).
+		Return(&models.RecoveryCodes{Codes: []string{"abcd-efgh-ijkl-mnop"}}, nil)
+
+	httpReq := withSession(httptest.NewRequest(http.MethodPost, "/api/auth/mfa/totp/confirm", bytes.NewBufferString(`{"code":"123456"}`)), 1, "sesion-1")
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.ConfirmTOTP(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"recovery_codes":["abcd-efgh-ijkl-mnop"]`)
+}
+
+func TestAuthHandler_DisableTOTP_MissingUser(t *testing.T) {
+	// ARRANGE
+	mockAuthService := new(mocks.MockAuthService)
+	authHandler := NewAuthHandler(mockAuthService, new(mocks.MockSessionService))
+
+	w := httptest.NewRecorder()
+
+	// ACT
+	authHandler.DisableTOTP(w, httptest.NewRequest(http.MethodDelete, "/api/auth/mfa/totp", bytes.NewBufferString(`{"code":"123456"}`)))
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockAuthService.AssertNotCalled(t, "DisableTOTP", mock.Anything, mock.Anything, mock.Anything)
+}
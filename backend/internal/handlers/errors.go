@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/services"
+)
+
+// Códigos de error estables que puede interpretar el cliente
+const (
+	CodeInvalidRequest   = "invalid_request"
+	CodeValidation       = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeEmailNotVerified = "email_not_verified"
+	CodeMFASetupRequired = "mfa_setup_required"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeTimeout          = "timeout"
+	CodeRateLimited      = "rate_limited"
+	CodeTooManyTries     = "too_many_attempts"
+	CodeInternal         = "internal_error"
+)
+
+// Mensajes de los errores que no vienen de un service
+const (
+	ErrInternal    = "Error interno del servidor"
+	ErrTimeout     = "La operación tardó demasiado"
+	ErrRateLimited = "Demasiados requests, probá de nuevo en unos segundos"
+)
+
+// ErrorResponse es el formato JSON de todos los errores de la API.
+// "error" sigue siendo el texto legible que ya usa el frontend.
+type ErrorResponse struct {
+	Error   string       `json:"error"`
+	Code    string       `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// FieldError indica qué campo de la entrada es inválido
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// respondWithError responde un error detectado en el propio handler
+// (JSON mal formado, parámetros de la URL, falta de autenticación)
+func respondWithError(w http.ResponseWriter, status int, message string) {
+	respondWithJSON(w, status, ErrorResponse{Error: message, Code: codeForStatus(status)})
+}
+
+// respondWithServiceError traduce un error de los services a su código HTTP.
+// Los errores sin categoría (base de datos, etc.) se registran con el logger
+// del request y responden 500 sin exponer el detalle.
+func respondWithServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		// Venció el plazo del request o el cliente se fue: no es una falla del servidor
+		respondWithError(w, http.StatusServiceUnavailable, ErrTimeout)
+		return
+	}
+
+	var domainErr *services.Error
+	if !errors.As(err, &domainErr) {
+		logging.FromContext(r.Context()).Error("Error interno", "error", err)
+		respondWithError(w, http.StatusInternalServerError, ErrInternal)
+		return
+	}
+
+	status, code := statusForKind(domainErr.Kind)
+	response := ErrorResponse{Error: domainErr.Message, Code: code}
+	if domainErr.Field != "" {
+		response.Details = []FieldError{{Field: domainErr.Field, Message: domainErr.Message}}
+	}
+
+	if domainErr.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(domainErr.RetryAfter.Seconds()))))
+	}
+	respondWithJSON(w, status, response)
+}
+
+// statusForKind asocia cada categoría de error de dominio con su código HTTP
+func statusForKind(kind error) (int, string) {
+	switch {
+	case errors.Is(kind, services.ErrValidation):
+		return http.StatusBadRequest, CodeValidation
+	case errors.Is(kind, services.ErrUnauthorized):
+		return http.StatusUnauthorized, CodeUnauthorized
+	case errors.Is(kind, services.ErrForbidden):
+		return http.StatusForbidden, CodeForbidden
+	case errors.Is(kind, services.ErrEmailNotVerified):
+		return http.StatusForbidden, CodeEmailNotVerified
+	case errors.Is(kind, services.ErrMFASetupRequired):
+		return http.StatusForbidden, CodeMFASetupRequired
+	case errors.Is(kind, services.ErrNotFound):
+		return http.StatusNotFound, CodeNotFound
+	case errors.Is(kind, services.ErrConflict):
+		return http.StatusConflict, CodeConflict
+	case errors.Is(kind, services.ErrTooManyTries):
+		return http.StatusTooManyRequests, CodeTooManyTries
+	default:
+		return http.StatusInternalServerError, CodeInternal
+	}
+}
+
+// codeForStatus elige el código de los errores que arma el handler
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusServiceUnavailable:
+		return CodeTimeout
+	case http.StatusInternalServerError:
+		return CodeInternal
+	default:
+		return CodeInvalidRequest
+	}
+}
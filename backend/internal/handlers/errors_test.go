@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// domainError arma un error como los que devuelven los services
+func domainError(kind error, message string) error {
+	return &services.Error{Kind: kind, Message: message}
+}
+
+func decodeErrorResponse(t *testing.T, w *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+	var response ErrorResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestRespondWithServiceError_MapeaCategorias(t *testing.T) {
+	tests := []struct {
+		kind       error
+		wantStatus int
+		wantCode   string
+	}{
+		{services.ErrValidation, http.StatusBadRequest, CodeValidation},
+		{services.ErrUnauthorized, http.StatusUnauthorized, CodeUnauthorized},
+		{services.ErrForbidden, http.StatusForbidden, CodeForbidden},
+		{services.ErrEmailNotVerified, http.StatusForbidden, CodeEmailNotVerified},
+		{services.ErrMFASetupRequired, http.StatusForbidden, CodeMFASetupRequired},
+		{services.ErrNotFound, http.StatusNotFound, CodeNotFound},
+		{services.ErrConflict, http.StatusConflict, CodeConflict},
+		{services.ErrTooManyTries, http.StatusTooManyRequests, CodeTooManyTries},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantCode, func(t *testing.T) {
+			w := httptest.NewRecorder()
+
+			respondWithServiceError(w, httptest.NewRequest(http.MethodGet, "/", nil), domainError(tt.kind, "mensaje"))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			response := decodeErrorResponse(t, w)
+			assert.Equal(t, tt.wantCode, response.Code)
+			assert.Equal(t, "mensaje", response.Error)
+		})
+	}
+}
+
+func TestRespondWithServiceError_DetallesDeValidacion(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	respondWithServiceError(w, httptest.NewRequest(http.MethodGet, "/", nil), &services.Error{
+		Kind:    services.ErrValidation,
+		Message: "el título es requerido",
+		Field:   "title",
+	})
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	response := decodeErrorResponse(t, w)
+	assert.Equal(t, []FieldError{{Field: "title", Message: "el título es requerido"}}, response.Details)
+}
+
+func TestRespondWithServiceError_RetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	respondWithServiceError(w, httptest.NewRequest(http.MethodPost, "/", nil), &services.Error{
+		Kind:       services.ErrTooManyTries,
+		Message:    "demasiados intentos fallidos",
+		RetryAfter: 1500 * time.Millisecond,
+	})
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	// Se redondea para arriba: el cliente nunca reintenta antes de tiempo
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+}
+
+func TestRespondWithServiceError_ErrorInternoNoSeFiltra(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	respondWithServiceError(w, httptest.NewRequest(http.MethodGet, "/", nil), errors.New(`pq: relation "posts" does not exist`))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	response := decodeErrorResponse(t, w)
+	assert.Equal(t, CodeInternal, response.Code)
+	assert.Equal(t, ErrInternal, response.Error)
+	assert.NotContains(t, w.Body.String(), "pq:")
+}
+
+func TestRespondWithServiceError_RegistraConElLoggerDelRequest(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil)).With("request_id", "req-1")
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(logging.WithLogger(r.Context(), logger))
+
+	respondWithServiceError(httptest.NewRecorder(), r, errors.New("conexión rechazada"))
+
+	// El detalle que no llega al cliente queda en el log, con el ID del request
+	assert.Contains(t, logs.String(), `"request_id":"req-1"`)
+	assert.Contains(t, logs.String(), "conexión rechazada")
+}
+
+func TestRespondWithError_CodigoSegunStatus(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+
+	response := decodeErrorResponse(t, w)
+	assert.Equal(t, CodeInvalidRequest, response.Code)
+	assert.Equal(t, ErrInvalidJSON, response.Error)
+	assert.Empty(t, response.Details)
+}
+
+func TestRespondWithServiceError_PlazoVencido(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	respondWithServiceError(w, httptest.NewRequest(http.MethodGet, "/", nil), fmt.Errorf("consulta cancelada: %w", context.DeadlineExceeded))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	response := decodeErrorResponse(t, w)
+	assert.Equal(t, CodeTimeout, response.Code)
+	assert.Equal(t, ErrTimeout, response.Error)
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"ingsw3-tp08/internal/health"
+)
+
+// HealthHandler responde las sondas del orquestador de contenedores
+type HealthHandler struct {
+	ready   func() bool
+	timeout time.Duration
+	checks  []health.Check
+}
+
+// NewHealthHandler crea una nueva instancia.
+// ready indica si el servidor acepta tráfico (falso durante el apagado);
+// timeout es el plazo de cada chequeo de dependencias.
+func NewHealthHandler(ready func() bool, timeout time.Duration, checks ...health.Check) *HealthHandler {
+	return &HealthHandler{
+		ready:   ready,
+		timeout: timeout,
+		checks:  checks,
+	}
+}
+
+// Live maneja GET /healthz.
+// Solo confirma que el proceso responde: no consulta dependencias para que
+// una caída de la base no provoque reinicios en cadena.
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	respondWithJSON(w, http.StatusOK, health.Report{Status: health.StatusOK})
+}
+
+// Ready maneja GET /readyz con el detalle de cada dependencia
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	if !h.ready() {
+		respondWithJSON(w, http.StatusServiceUnavailable, health.Report{Status: health.StatusDraining})
+		return
+	}
+
+	report := health.RunChecks(r.Context(), h.timeout, h.checks)
+	if !report.Healthy() {
+		respondWithJSON(w, http.StatusServiceUnavailable, report)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, report)
+}
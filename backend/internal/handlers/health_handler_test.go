@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHealthReport(t *testing.T, w *httptest.ResponseRecorder) health.Report {
+	t.Helper()
+	var report health.Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return report
+}
+
+func TestHealthHandler_Live(t *testing.T) {
+	// La liveness no depende de la base
+	failing := health.Check{Name: "database", Run: func(ctx context.Context) error { return errors.New("caída") }}
+	healthHandler := NewHealthHandler(func() bool { return false }, time.Second, failing)
+
+	w := httptest.NewRecorder()
+	healthHandler.Live(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, health.StatusOK, decodeHealthReport(t, w).Status)
+}
+
+func TestHealthHandler_Ready(t *testing.T) {
+	ready := true
+	var dbErr error
+	database := health.Check{Name: "database", Run: func(ctx context.Context) error { return dbErr }}
+	healthHandler := NewHealthHandler(func() bool { return ready }, time.Second, database)
+
+	w := httptest.NewRecorder()
+	healthHandler.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decodeHealthReport(t, w)
+	assert.Equal(t, health.StatusOK, report.Status)
+	assert.Equal(t, health.StatusOK, report.Checks["database"].Status)
+
+	// Una dependencia caída saca a la instancia del balanceo, sin exponer el error
+	dbErr = errors.New("connection refused")
+	w = httptest.NewRecorder()
+	healthHandler.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	report = decodeHealthReport(t, w)
+	assert.Equal(t, health.StatusUnavailable, report.Status)
+	assert.Equal(t, health.StatusFail, report.Checks["database"].Status)
+	assert.Equal(t, health.ErrorUnavailable, report.Checks["database"].Error)
+	assert.NotContains(t, w.Body.String(), "connection refused")
+
+	// Durante el apagado el orquestador debe dejar de mandar tráfico
+	ready = false
+	w = httptest.NewRecorder()
+	healthHandler.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, health.StatusDraining, decodeHealthReport(t, w).Status)
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"ingsw3-tp08/internal/pagination"
+)
+
+// pageParams lee ?limit=N&cursor=X. Si son inválidos responde 400 y devuelve false.
+func pageParams(w http.ResponseWriter, r *http.Request) (pagination.Params, bool) {
+	params, err := pagination.ParseParams(r.URL.Query())
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return pagination.Params{}, false
+	}
+	return params, true
+}
+
+// respondWithPage responde una página y agrega el header Link con la página siguiente
+func respondWithPage[T any](w http.ResponseWriter, r *http.Request, params pagination.Params, page *pagination.Page[T]) {
+	if page.NextCursor != "" {
+		w.Header().Set("Link", nextPageLink(r, params, page.NextCursor))
+	}
+	respondWithJSON(w, http.StatusOK, page)
+}
+
+// nextPageLink arma el header Link (RFC 8288) conservando el resto de la query string
+func nextPageLink(r *http.Request, params pagination.Params, cursor string) string {
+	next := *r.URL
+	query := next.Query()
+	query.Set("cursor", cursor)
+	query.Set("limit", strconv.Itoa(params.Limit))
+	next.RawQuery = query.Encode()
+
+	return fmt.Sprintf(`<%s>; rel="next"`, next.RequestURI())
+}
@@ -5,17 +5,16 @@ import (
 	"net/http"
 	"strconv"
 
+	"ingsw3-tp08/internal/auth"
 	"ingsw3-tp08/internal/models"
 	"ingsw3-tp08/internal/services"
 
 	"github.com/gorilla/mux"
 )
 
-// Constantes para headers y mensajes de error
+// Constantes para mensajes de error
 const (
-	HeaderUserID            = "X-User-ID"
 	ErrUserNotAuthenticated = "Usuario no autenticado"
-	ErrInvalidUserID        = "User ID inválido"
 	ErrInvalidID            = "ID inválido"
 	ErrInvalidJSON          = "JSON inválido"
 )
@@ -40,36 +39,35 @@ func (h *PostHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.Header.Get(HeaderUserID)
-	if userIDStr == "" {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
 		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
+	post, err := h.postService.CreatePost(r.Context(), &req, userID)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, ErrInvalidUserID)
-		return
-	}
-
-	post, err := h.postService.CreatePost(&req, userID)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, post)
 }
 
-// GetAllPosts maneja GET /api/posts
+// GetAllPosts maneja GET /api/posts?limit=N&cursor=X
 func (h *PostHandler) GetAllPosts(w http.ResponseWriter, r *http.Request) {
-	posts, err := h.postService.GetAllPosts()
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.postService.GetAllPosts(r.Context(), params)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, posts)
+	respondWithPage(w, r, params, page)
 }
 
 // GetPostByID maneja GET /api/posts/{id}
@@ -81,15 +79,112 @@ func (h *PostHandler) GetPostByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	post, err := h.postService.GetPostByID(id)
+	post, err := h.postService.GetPostByID(r.Context(), id)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, post)
+}
+
+// UpdatePost maneja PUT y PATCH /api/posts/{id}.
+// PUT reemplaza título y contenido; PATCH solo cambia los campos enviados.
+func (h *PostHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	var req models.UpdatePostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidJSON)
+		return
+	}
+
+	if r.Method == http.MethodPut && (req.Title == nil || req.Content == nil) {
+		respondWithError(w, http.StatusBadRequest, "PUT requiere título y contenido")
+		return
+	}
+
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	post, err := h.postService.UpdatePost(r.Context(), id, &req, userID)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusOK, post)
 }
 
+// GetRevisions maneja GET /api/posts/{id}/revisions?limit=N&cursor=X
+func (h *PostHandler) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.postService.GetPostRevisions(r.Context(), id, params)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithPage(w, r, params, page)
+}
+
+// DiffRevisions maneja GET /api/posts/{id}/revisions/diff?from=N&to=M
+func (h *PostHandler) DiffRevisions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
+		return
+	}
+
+	from, err := optionalIntParam(r, "from")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Versión 'from' inválida")
+		return
+	}
+	to, err := optionalIntParam(r, "to")
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Versión 'to' inválida")
+		return
+	}
+
+	diff, err := h.postService.DiffPostVersions(r.Context(), id, from, to)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, diff)
+}
+
+// optionalIntParam lee un query param numérico; si no viene devuelve 0
+func optionalIntParam(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(value)
+}
+
 // DeletePost maneja DELETE /api/posts/{id}
 func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -99,25 +194,53 @@ func (h *PostHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.Header.Get(HeaderUserID)
-	if userIDStr == "" {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
 		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
+	err = h.postService.DeletePost(r.Context(), id, userID)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, ErrInvalidUserID)
+		respondWithServiceError(w, r, err)
 		return
 	}
 
-	err = h.postService.DeletePost(id, userID)
+	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Post eliminado"})
+}
+
+// LockPost maneja POST /api/posts/{id}/lock
+func (h *PostHandler) LockPost(w http.ResponseWriter, r *http.Request) {
+	h.setLocked(w, r, true)
+}
+
+// UnlockPost maneja DELETE /api/posts/{id}/lock
+func (h *PostHandler) UnlockPost(w http.ResponseWriter, r *http.Request) {
+	h.setLocked(w, r, false)
+}
+
+// setLocked comparte el manejo de cerrar y reabrir un post
+func (h *PostHandler) setLocked(w http.ResponseWriter, r *http.Request, locked bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		respondWithError(w, http.StatusForbidden, err.Error())
+		respondWithError(w, http.StatusBadRequest, ErrInvalidID)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, map[string]string{"message": "Post eliminado"})
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
+		return
+	}
+
+	post, err := h.postService.SetPostLocked(r.Context(), id, locked, userID)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, post)
 }
 
 // CreateComment maneja POST /api/posts/{id}/comments
@@ -135,28 +258,24 @@ func (h *PostHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.Header.Get(HeaderUserID)
-	if userIDStr == "" {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
 		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
 		return
 	}
 
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, ErrInvalidUserID)
-		return
-	}
-
-	comment, err := h.postService.CreateComment(postID, &req, userID)
+	comment, err := h.postService.CreateComment(r.Context(), postID, &req, userID)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
 	respondWithJSON(w, http.StatusCreated, comment)
 }
 
-// GetComments maneja GET /api/posts/{id}/comments
+// GetComments maneja GET /api/posts/{id}/comments?view=flat|tree&limit=N&cursor=X.
+// La vista "flat" (default) lista todos los comentarios con su profundidad;
+// "tree" pagina los hilos de primer nivel con las respuestas anidadas.
 func (h *PostHandler) GetComments(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	postID, err := strconv.Atoi(vars["id"])
@@ -165,13 +284,29 @@ func (h *PostHandler) GetComments(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	comments, err := h.postService.GetCommentsByPostID(postID)
+	view := r.URL.Query().Get("view")
+	if view != "" && view != "flat" && view != "tree" {
+		respondWithError(w, http.StatusBadRequest, "Vista inválida: usar flat o tree")
+		return
+	}
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	getComments := h.postService.GetCommentsByPostID
+	if view == "tree" {
+		getComments = h.postService.GetCommentTree
+	}
+
+	page, err := getComments(r.Context(), postID, params)
 	if err != nil {
-		respondWithError(w, http.StatusNotFound, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, comments)
+	respondWithPage(w, r, params, page)
 }
 
 // DeleteComment handles DELETE /api/posts/{postId}/comments/{commentId}
@@ -188,20 +323,15 @@ func (h *PostHandler) DeleteComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userIDStr := r.Header.Get(HeaderUserID)
-	if userIDStr == "" {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
 		respondWithError(w, http.StatusUnauthorized, ErrUserNotAuthenticated)
 		return
 	}
-	userID, err := strconv.Atoi(userIDStr)
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, ErrInvalidUserID)
-		return
-	}
 
-	err = h.postService.DeleteComment(postID, commentID, userID)
+	err = h.postService.DeleteComment(r.Context(), postID, commentID, userID)
 	if err != nil {
-		respondWithError(w, http.StatusForbidden, err.Error())
+		respondWithServiceError(w, r, err)
 		return
 	}
 
@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"ingsw3-tp08/internal/auth"
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/services"
 	"ingsw3-tp08/tests/mocks"
 
 	"github.com/gorilla/mux"
@@ -15,6 +20,19 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+// firstPage es la página que piden los handlers cuando no llegan limit ni cursor
+var firstPage = pagination.Params{Limit: pagination.DefaultLimit}
+
+// withUser simula lo que hace el middleware de autenticación
+func withUser(r *http.Request, userID int) *http.Request {
+	return withSession(r, userID, "sesion-de-prueba")
+}
+
+// withSession simula un usuario autenticado en una sesión concreta
+func withSession(r *http.Request, userID int, sessionID string) *http.Request {
+	return r.WithContext(auth.WithPrincipal(r.Context(), &auth.Principal{UserID: userID, SessionID: sessionID}))
+}
+
 func TestPostHandler_CreatePost_Success(t *testing.T) {
 	// ARRANGE
 	mockPostService := new(mocks.MockPostService)
@@ -33,12 +51,12 @@ func TestPostHandler_CreatePost_Success(t *testing.T) {
 		Username: "testuser",
 	}
 
-	mockPostService.On("CreatePost", &req, 1).Return(expectedPost, nil)
+	mockPostService.On("CreatePost", mock.Anything, &req, 1).Return(expectedPost, nil)
 
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 
 	w := httptest.NewRecorder()
 
@@ -81,7 +99,7 @@ func TestPostHandler_CreatePost_InvalidJSON(t *testing.T) {
 	}
 	assert.Equal(t, ErrInvalidJSON, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_CreatePost_MissingUserID(t *testing.T) {
@@ -97,7 +115,7 @@ func TestPostHandler_CreatePost_MissingUserID(t *testing.T) {
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	// No X-User-ID
+	// Sin token: el middleware no puso identidad en el contexto
 
 	w := httptest.NewRecorder()
 
@@ -112,10 +130,10 @@ func TestPostHandler_CreatePost_MissingUserID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestPostHandler_CreatePost_InvalidUserID(t *testing.T) {
+func TestPostHandler_CreatePost_XUserIDHeaderIgnored(t *testing.T) {
 	// ARRANGE
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
@@ -128,7 +146,7 @@ func TestPostHandler_CreatePost_InvalidUserID(t *testing.T) {
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "abc") // Invalid int
+	httpReq.Header.Set("X-User-ID", "1") // El header viejo ya no identifica a nadie
 
 	w := httptest.NewRecorder()
 
@@ -136,14 +154,14 @@ func TestPostHandler_CreatePost_InvalidUserID(t *testing.T) {
 	postHandler.CreatePost(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
 	var response map[string]string
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, ErrInvalidUserID, response["error"])
+	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_GetAllPosts_Success(t *testing.T) {
@@ -156,7 +174,7 @@ func TestPostHandler_GetAllPosts_Success(t *testing.T) {
 		{ID: 2, Title: "Post 2", Content: "Content 2"},
 	}
 
-	mockPostService.On("GetAllPosts").Return(expectedPosts, nil)
+	mockPostService.On("GetAllPosts", mock.Anything, firstPage).Return(&pagination.Page[*models.Post]{Items: expectedPosts}, nil)
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
 	w := httptest.NewRecorder()
@@ -168,12 +186,13 @@ func TestPostHandler_GetAllPosts_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockPostService.AssertExpectations(t)
 
-	var response []*models.Post
+	var response pagination.Page[*models.Post]
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
-	assert.Len(t, response, 2)
-	assert.Equal(t, "Post 1", response[0].Title)
+	assert.Len(t, response.Items, 2)
+	assert.Equal(t, "Post 1", response.Items[0].Title)
+	assert.Empty(t, w.Header().Get("Link"))
 }
 
 func TestPostHandler_GetAllPosts_ServiceError(t *testing.T) {
@@ -181,7 +200,7 @@ func TestPostHandler_GetAllPosts_ServiceError(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("GetAllPosts").Return(nil, assert.AnError)
+	mockPostService.On("GetAllPosts", mock.Anything, firstPage).Return(nil, assert.AnError)
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts", nil)
 	w := httptest.NewRecorder()
@@ -205,7 +224,7 @@ func TestPostHandler_GetPostByID_Success(t *testing.T) {
 		Content: "Test Content",
 	}
 
-	mockPostService.On("GetPostByID", 1).Return(expectedPost, nil)
+	mockPostService.On("GetPostByID", mock.Anything, 1).Return(expectedPost, nil)
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
@@ -244,7 +263,7 @@ func TestPostHandler_GetPostByID_InvalidID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrInvalidID, response["error"])
 
-	mockPostService.AssertNotCalled(t, "GetPostByID", mock.Anything)
+	mockPostService.AssertNotCalled(t, "GetPostByID", mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeletePost_Success(t *testing.T) {
@@ -252,10 +271,10 @@ func TestPostHandler_DeletePost_Success(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("DeletePost", 1, 1).Return(nil)
+	mockPostService.On("DeletePost", mock.Anything, 1, 1).Return(nil)
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 	w := httptest.NewRecorder()
 
@@ -277,10 +296,10 @@ func TestPostHandler_DeletePost_PermissionDenied(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("DeletePost", 1, 2).Return(assert.AnError) // Different user
+	mockPostService.On("DeletePost", mock.Anything, 1, 2).Return(domainError(services.ErrForbidden, "no tienes permiso para eliminar este post")) // Different user
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
-	httpReq.Header.Set("X-User-ID", "2")
+	httpReq = withUser(httpReq, 2)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 	w := httptest.NewRecorder()
 
@@ -309,12 +328,12 @@ func TestPostHandler_CreateComment_Success(t *testing.T) {
 		Content:  "Test Comment",
 	}
 
-	mockPostService.On("CreateComment", 1, &req, 1).Return(expectedComment, nil)
+	mockPostService.On("CreateComment", mock.Anything, 1, &req, 1).Return(expectedComment, nil)
 
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 
 	w := httptest.NewRecorder()
@@ -354,7 +373,7 @@ func TestPostHandler_CreateComment_InvalidJSON(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrInvalidJSON, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_CreateComment_InvalidPostID(t *testing.T) {
@@ -369,7 +388,7 @@ func TestPostHandler_CreateComment_InvalidPostID(t *testing.T) {
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts/abc/comments", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 
 	w := httptest.NewRecorder()
 
@@ -384,7 +403,7 @@ func TestPostHandler_CreateComment_InvalidPostID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrInvalidID, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_GetComments_Success(t *testing.T) {
@@ -397,7 +416,7 @@ func TestPostHandler_GetComments_Success(t *testing.T) {
 		{ID: 2, PostID: 1, UserID: 2, Username: "user2", Content: "Comment 2"},
 	}
 
-	mockPostService.On("GetCommentsByPostID", 1).Return(expectedComments, nil)
+	mockPostService.On("GetCommentsByPostID", mock.Anything, 1, firstPage).Return(&pagination.Page[*models.Comment]{Items: expectedComments}, nil)
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments", nil)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
@@ -410,11 +429,11 @@ func TestPostHandler_GetComments_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	mockPostService.AssertExpectations(t)
 
-	var response []*models.Comment
+	var response pagination.Page[*models.Comment]
 	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
 		t.Fatalf("failed to unmarshal response: %v", err)
 	}
-	assert.Len(t, response, 2)
+	assert.Len(t, response.Items, 2)
 }
 
 func TestPostHandler_GetComments_InvalidPostID(t *testing.T) {
@@ -436,7 +455,7 @@ func TestPostHandler_GetComments_InvalidPostID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrInvalidID, response["error"])
 
-	mockPostService.AssertNotCalled(t, "GetCommentsByPostID", mock.Anything)
+	mockPostService.AssertNotCalled(t, "GetCommentsByPostID", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeleteComment_Success(t *testing.T) {
@@ -444,10 +463,10 @@ func TestPostHandler_DeleteComment_Success(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("DeleteComment", 1, 1, 1).Return(nil)
+	mockPostService.On("DeleteComment", mock.Anything, 1, 1, 1).Return(nil)
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/1", nil)
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"postId": "1", "commentId": "1"})
 	w := httptest.NewRecorder()
 
@@ -485,7 +504,7 @@ func TestPostHandler_DeleteComment_InvalidPostID(t *testing.T) {
 	}
 	assert.Equal(t, "Post ID inválido", response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeleteComment_InvalidCommentID(t *testing.T) {
@@ -509,7 +528,7 @@ func TestPostHandler_DeleteComment_InvalidCommentID(t *testing.T) {
 	}
 	assert.Equal(t, "Comment ID inválido", response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeleteComment_MissingUserID(t *testing.T) {
@@ -519,7 +538,7 @@ func TestPostHandler_DeleteComment_MissingUserID(t *testing.T) {
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/1", nil)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"postId": "1", "commentId": "1"})
-	// No X-User-ID header
+	// Sin token: el middleware no puso identidad en el contexto
 	w := httptest.NewRecorder()
 
 	// ACT
@@ -533,16 +552,16 @@ func TestPostHandler_DeleteComment_MissingUserID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestPostHandler_DeleteComment_InvalidUserID(t *testing.T) {
+func TestPostHandler_DeleteComment_XUserIDHeaderIgnored(t *testing.T) {
 	// ARRANGE
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/1", nil)
-	httpReq.Header.Set("X-User-ID", "abc") // Invalid int
+	httpReq.Header.Set("X-User-ID", "1") // El header viejo ya no identifica a nadie
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"postId": "1", "commentId": "1"})
 	w := httptest.NewRecorder()
 
@@ -550,15 +569,14 @@ func TestPostHandler_DeleteComment_InvalidUserID(t *testing.T) {
 	postHandler.DeleteComment(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
 	var response map[string]string
-	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-		t.Fatalf("failed to unmarshal response: %v", err)
-	}
-	assert.Equal(t, ErrInvalidUserID, response["error"])
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeleteComment_ServiceError(t *testing.T) {
@@ -566,10 +584,10 @@ func TestPostHandler_DeleteComment_ServiceError(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("DeleteComment", 1, 1, 1).Return(assert.AnError)
+	mockPostService.On("DeleteComment", mock.Anything, 1, 1, 1).Return(domainError(services.ErrForbidden, "no tienes permiso para eliminar este comentario"))
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1/comments/1", nil)
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"postId": "1", "commentId": "1"})
 	w := httptest.NewRecorder()
 
@@ -591,12 +609,12 @@ func TestPostHandler_CreatePost_ServiceError(t *testing.T) {
 		Content: "Test Content",
 	}
 
-	mockPostService.On("CreatePost", &req, 1).Return(nil, assert.AnError)
+	mockPostService.On("CreatePost", mock.Anything, &req, 1).Return(nil, domainError(services.ErrValidation, "el título es requerido"))
 
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 
 	w := httptest.NewRecorder()
 
@@ -613,7 +631,7 @@ func TestPostHandler_GetPostByID_ServiceError(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("GetPostByID", 1).Return(nil, assert.AnError)
+	mockPostService.On("GetPostByID", mock.Anything, 1).Return(nil, assert.AnError) // Falla de la base de datos
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1", nil)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
@@ -623,7 +641,7 @@ func TestPostHandler_GetPostByID_ServiceError(t *testing.T) {
 	postHandler.GetPostByID(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	mockPostService.AssertExpectations(t)
 }
 
@@ -646,7 +664,7 @@ func TestPostHandler_DeletePost_InvalidID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrInvalidID, response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeletePost_MissingUserID(t *testing.T) {
@@ -656,7 +674,7 @@ func TestPostHandler_DeletePost_MissingUserID(t *testing.T) {
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
-	// No X-User-ID
+	// Sin token: el middleware no puso identidad en el contexto
 	w := httptest.NewRecorder()
 
 	// ACT
@@ -670,16 +688,16 @@ func TestPostHandler_DeletePost_MissingUserID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestPostHandler_DeletePost_InvalidUserID(t *testing.T) {
+func TestPostHandler_DeletePost_XUserIDHeaderIgnored(t *testing.T) {
 	// ARRANGE
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
-	httpReq.Header.Set("X-User-ID", "abc") // Invalid int
+	httpReq.Header.Set("X-User-ID", "1") // El header viejo ya no identifica a nadie
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 	w := httptest.NewRecorder()
 
@@ -687,14 +705,14 @@ func TestPostHandler_DeletePost_InvalidUserID(t *testing.T) {
 	postHandler.DeletePost(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
 	var response map[string]string
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, ErrInvalidUserID, response["error"])
+	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_DeletePost_ServiceError(t *testing.T) {
@@ -702,10 +720,10 @@ func TestPostHandler_DeletePost_ServiceError(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("DeletePost", 1, 1).Return(assert.AnError)
+	mockPostService.On("DeletePost", mock.Anything, 1, 1).Return(domainError(services.ErrNotFound, "post no encontrado"))
 
 	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1", nil)
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 	w := httptest.NewRecorder()
 
@@ -713,7 +731,7 @@ func TestPostHandler_DeletePost_ServiceError(t *testing.T) {
 	postHandler.DeletePost(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, http.StatusNotFound, w.Code)
 	mockPostService.AssertExpectations(t)
 }
 
@@ -730,7 +748,7 @@ func TestPostHandler_CreateComment_MissingUserID(t *testing.T) {
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
-	// No X-User-ID
+	// Sin token: el middleware no puso identidad en el contexto
 
 	w := httptest.NewRecorder()
 
@@ -745,10 +763,10 @@ func TestPostHandler_CreateComment_MissingUserID(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
-func TestPostHandler_CreateComment_InvalidUserID(t *testing.T) {
+func TestPostHandler_CreateComment_XUserIDHeaderIgnored(t *testing.T) {
 	// ARRANGE
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
@@ -760,7 +778,7 @@ func TestPostHandler_CreateComment_InvalidUserID(t *testing.T) {
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "abc") // Invalid int
+	httpReq.Header.Set("X-User-ID", "1") // El header viejo ya no identifica a nadie
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 
 	w := httptest.NewRecorder()
@@ -769,14 +787,14 @@ func TestPostHandler_CreateComment_InvalidUserID(t *testing.T) {
 	postHandler.CreateComment(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 
 	var response map[string]string
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	assert.NoError(t, err)
-	assert.Equal(t, ErrInvalidUserID, response["error"])
+	assert.Equal(t, ErrUserNotAuthenticated, response["error"])
 
-	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestPostHandler_CreateComment_ServiceError(t *testing.T) {
@@ -788,12 +806,12 @@ func TestPostHandler_CreateComment_ServiceError(t *testing.T) {
 		Content: "Test Comment",
 	}
 
-	mockPostService.On("CreateComment", 1, &req, 1).Return(nil, assert.AnError)
+	mockPostService.On("CreateComment", mock.Anything, 1, &req, 1).Return(nil, domainError(services.ErrConflict, "el post está cerrado a nuevos comentarios"))
 
 	body, _ := json.Marshal(req)
 	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts/1/comments", bytes.NewBuffer(body))
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-User-ID", "1")
+	httpReq = withUser(httpReq, 1)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
 
 	w := httptest.NewRecorder()
@@ -802,7 +820,7 @@ func TestPostHandler_CreateComment_ServiceError(t *testing.T) {
 	postHandler.CreateComment(w, httpReq)
 
 	// ASSERT
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusConflict, w.Code)
 	mockPostService.AssertExpectations(t)
 }
 
@@ -811,7 +829,7 @@ func TestPostHandler_GetComments_ServiceError(t *testing.T) {
 	mockPostService := new(mocks.MockPostService)
 	postHandler := NewPostHandler(mockPostService)
 
-	mockPostService.On("GetCommentsByPostID", 1).Return(nil, assert.AnError)
+	mockPostService.On("GetCommentsByPostID", mock.Anything, 1, firstPage).Return(nil, domainError(services.ErrNotFound, "post no encontrado"))
 
 	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments", nil)
 	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
@@ -824,3 +842,318 @@ func TestPostHandler_GetComments_ServiceError(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	mockPostService.AssertExpectations(t)
 }
+
+func TestPostHandler_UpdatePost_Patch(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	title := "Nuevo titulo"
+	expectedPost := &models.Post{ID: 1, Title: title, Content: "Contenido", UserID: 1, Version: 2}
+	mockPostService.On("UpdatePost", mock.Anything, 1, &models.UpdatePostRequest{Title: &title}, 1).Return(expectedPost, nil)
+
+	httpReq := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`{"title":"Nuevo titulo"}`))
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	httpReq = withUser(httpReq, 1)
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.UpdatePost(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockPostService.AssertExpectations(t)
+
+	var response models.Post
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.Version)
+}
+
+func TestPostHandler_UpdatePost_PutIncompleto(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	httpReq := httptest.NewRequest(http.MethodPut, "/api/posts/1", bytes.NewBufferString(`{"title":"Solo titulo"}`))
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	httpReq = withUser(httpReq, 1)
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.UpdatePost(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockPostService.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_UpdatePost_MissingUserID(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	httpReq := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`{"title":"Nuevo"}`))
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.UpdatePost(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockPostService.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_UpdatePost_ServiceError(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	mockPostService.On("UpdatePost", mock.Anything, 1, mock.Anything, 2).Return(nil, domainError(services.ErrForbidden, "no tienes permiso para editar este post"))
+
+	httpReq := httptest.NewRequest(http.MethodPatch, "/api/posts/1", bytes.NewBufferString(`{"title":"Nuevo"}`))
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	httpReq = withUser(httpReq, 2)
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.UpdatePost(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockPostService.AssertExpectations(t)
+}
+
+func TestPostHandler_GetRevisions_Success(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	revisions := []*models.PostRevision{{PostID: 1, Version: 1, Title: "Viejo"}}
+	mockPostService.On("GetPostRevisions", mock.Anything, 1, firstPage).Return(&pagination.Page[*models.PostRevision]{Items: revisions}, nil)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetRevisions(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response pagination.Page[models.PostRevision]
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Items, 1)
+}
+
+func TestPostHandler_DiffRevisions_Success(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	expectedDiff := &models.PostDiff{PostID: 1, From: 1, To: 2}
+	mockPostService.On("DiffPostVersions", mock.Anything, 1, 1, 2).Return(expectedDiff, nil)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/diff?from=1&to=2", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.DiffRevisions(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockPostService.AssertExpectations(t)
+}
+
+func TestPostHandler_DiffRevisions_InvalidParam(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/revisions/diff?from=abc", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.DiffRevisions(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockPostService.AssertNotCalled(t, "DiffPostVersions", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_GetAllPosts_NextPageLink(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	page := &pagination.Page[*models.Post]{
+		Items:      []*models.Post{{ID: 5, Title: "Post 5"}},
+		NextCursor: "siguiente",
+	}
+	mockPostService.On("GetAllPosts", mock.Anything, pagination.Params{Limit: 1}).Return(page, nil)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts?limit=1", nil)
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetAllPosts(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `</api/posts?cursor=siguiente&limit=1>; rel="next"`, w.Header().Get("Link"))
+
+	var response pagination.Page[*models.Post]
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, "siguiente", response.NextCursor)
+}
+
+func TestPostHandler_GetAllPosts_InvalidLimit(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts?limit=-3", nil)
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetAllPosts(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockPostService.AssertNotCalled(t, "GetAllPosts", mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_GetComments_InvalidCursor(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments?cursor=no-es-un-cursor", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetComments(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockPostService.AssertNotCalled(t, "GetCommentsByPostID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPostHandler_GetComments_TreeView(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	tree := []*models.Comment{{ID: 1, PostID: 1, Replies: []*models.Comment{{ID: 2, PostID: 1, Depth: 1}}}}
+	mockPostService.On("GetCommentTree", mock.Anything, 1, firstPage).Return(&pagination.Page[*models.Comment]{Items: tree}, nil)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments?view=tree", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetComments(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockPostService.AssertExpectations(t)
+	mockPostService.AssertNotCalled(t, "GetCommentsByPostID", mock.Anything, mock.Anything, mock.Anything)
+
+	var response pagination.Page[*models.Comment]
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Items[0].Replies, 1)
+}
+
+func TestPostHandler_GetComments_InvalidView(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1/comments?view=grafo", nil)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetComments(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestPostHandler_LockPost_Success(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	mockPostService.On("SetPostLocked", mock.Anything, 1, true, 2).Return(&models.Post{ID: 1, Locked: true}, nil)
+
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/posts/1/lock", nil)
+	httpReq = withUser(httpReq, 2)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.LockPost(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockPostService.AssertExpectations(t)
+
+	var response models.Post
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, response.Locked)
+}
+
+func TestPostHandler_UnlockPost_PermissionDenied(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	mockPostService.On("SetPostLocked", mock.Anything, 1, false, 1).Return(nil, domainError(services.ErrForbidden, "no tienes permiso para cerrar este post"))
+
+	httpReq := httptest.NewRequest(http.MethodDelete, "/api/posts/1/lock", nil)
+	httpReq = withUser(httpReq, 1)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.UnlockPost(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	mockPostService.AssertExpectations(t)
+}
+
+func TestPostHandler_GetPostByID_PropagaElContexto(t *testing.T) {
+	// ARRANGE
+	mockPostService := new(mocks.MockPostService)
+	postHandler := NewPostHandler(mockPostService)
+
+	// El service debe recibir el contexto del request (con su deadline)
+	withDeadline := mock.MatchedBy(func(ctx context.Context) bool {
+		_, ok := ctx.Deadline()
+		return ok
+	})
+	mockPostService.On("GetPostByID", withDeadline, 1).Return(&models.Post{ID: 1}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/posts/1", nil).WithContext(ctx)
+	httpReq = mux.SetURLVars(httpReq, map[string]string{"id": "1"})
+	w := httptest.NewRecorder()
+
+	// ACT
+	postHandler.GetPostByID(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockPostService.AssertExpectations(t)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+)
+
+// SearchHandler maneja las peticiones HTTP de búsqueda
+type SearchHandler struct {
+	searchService services.SearchServiceInterface
+}
+
+// NewSearchHandler crea una nueva instancia
+func NewSearchHandler(searchService services.SearchServiceInterface) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search maneja GET /api/search?q=texto&type=post|comment&author=usuario&from=AAAA-MM-DD&to=AAAA-MM-DD
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+
+	query := models.SearchQuery{
+		Text:   values.Get("q"),
+		Type:   values.Get("type"),
+		Author: values.Get("author"),
+	}
+
+	from, err := parseDateParam(values.Get("from"), false)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Fecha 'from' inválida")
+		return
+	}
+	to, err := parseDateParam(values.Get("to"), true)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Fecha 'to' inválida")
+		return
+	}
+	query.From = from
+	query.To = to
+
+	params, ok := pageParams(w, r)
+	if !ok {
+		return
+	}
+
+	page, err := h.searchService.Search(r.Context(), query, params)
+	if err != nil {
+		respondWithServiceError(w, r, err)
+		return
+	}
+
+	respondWithPage(w, r, params, page)
+}
+
+// parseDateParam acepta AAAA-MM-DD o RFC 3339.
+// Una fecha sin hora usada como límite superior incluye el día completo.
+func parseDateParam(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if date, err := time.Parse(time.DateOnly, value); err == nil {
+		if endOfDay {
+			date = date.AddDate(0, 0, 1)
+		}
+		return &date, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	date = date.UTC()
+	return &date, nil
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchHandler_Search_Success(t *testing.T) {
+	// ARRANGE
+	mockSearchService := new(mocks.MockSearchService)
+	searchHandler := NewSearchHandler(mockSearchService)
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) // "to" sin hora incluye el día completo
+	expectedQuery := models.SearchQuery{Text: "golang", Type: "post", Author: "ana", From: &from, To: &to}
+
+	page := &pagination.Page[*models.SearchResult]{
+		Items: []*models.SearchResult{{Type: models.SearchTypePost, ID: 1, Title: "Golang"}},
+	}
+	mockSearchService.On("Search", mock.Anything, expectedQuery, firstPage).Return(page, nil)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/search?q=golang&type=post&author=ana&from=2025-05-01&to=2025-05-31", nil)
+	w := httptest.NewRecorder()
+
+	// ACT
+	searchHandler.Search(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockSearchService.AssertExpectations(t)
+
+	var response pagination.Page[*models.SearchResult]
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Len(t, response.Items, 1)
+}
+
+func TestSearchHandler_Search_InvalidDate(t *testing.T) {
+	// ARRANGE
+	mockSearchService := new(mocks.MockSearchService)
+	searchHandler := NewSearchHandler(mockSearchService)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/search?q=golang&from=ayer", nil)
+	w := httptest.NewRecorder()
+
+	// ACT
+	searchHandler.Search(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSearchService.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSearchHandler_Search_ServiceError(t *testing.T) {
+	// ARRANGE
+	mockSearchService := new(mocks.MockSearchService)
+	searchHandler := NewSearchHandler(mockSearchService)
+
+	mockSearchService.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(nil, domainError(services.ErrValidation, "el texto a buscar es requerido"))
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+	w := httptest.NewRecorder()
+
+	// ACT
+	searchHandler.Search(w, httpReq)
+
+	// ASSERT
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSearchService.AssertExpectations(t)
+}
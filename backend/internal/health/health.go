@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/logging"
+)
+
+// Estados posibles de un chequeo y del reporte completo
+const (
+	StatusOK          = "ok"
+	StatusFail        = "fail"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Mensajes fijos de un chequeo fallido. /readyz es público: el error real
+// (hosts, usuarios, nombres de migraciones) sólo va al log
+const (
+	ErrorUnavailable = "unavailable"
+	ErrorTimeout     = "timeout"
+)
+
+// Check es una dependencia que tiene que responder para que la API esté lista
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result es el resultado de un chequeo individual
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report agrupa los resultados de todos los chequeos
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Healthy indica si todos los chequeos pasaron
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// RunChecks ejecuta los chequeos en paralelo, cada uno con su propio plazo,
+// para que una dependencia colgada no demore al resto
+func RunChecks(ctx context.Context, timeout time.Duration, checks []Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check.Run(checkCtx)
+			result := Result{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				logging.FromContext(ctx).Warn("Chequeo de salud fallido", "check", check.Name, "error", err)
+				result.Status = StatusFail
+				result.Error = ErrorUnavailable
+				if errors.Is(err, context.DeadlineExceeded) {
+					result.Error = ErrorTimeout
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if err != nil {
+				report.Status = StatusUnavailable
+			}
+		}(check)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// Ping verifica que la base de datos responda
+func Ping(db *sql.DB) Check {
+	return Check{
+		Name: "database",
+		Run:  db.PingContext,
+	}
+}
+
+// Migrations verifica que no queden migraciones sin aplicar: una instancia
+// con el schema desactualizado no debería recibir tráfico
+func Migrations(pending func(ctx context.Context) ([]database.Migration, error)) Check {
+	return Check{
+		Name: "migrations",
+		Run: func(ctx context.Context) error {
+			migrations, err := pending(ctx)
+			if err != nil {
+				return err
+			}
+			if len(migrations) > 0 {
+				return fmt.Errorf("%d migraciones pendientes (primera: %04d_%s)",
+					len(migrations), migrations[0].Version, migrations[0].Name)
+			}
+			return nil
+		},
+	}
+}
+
+// Pool falla cuando la proporción de conexiones en uso alcanza threshold (0 a 1).
+// Sin límite de conexiones abiertas no hay saturación posible.
+func Pool(stats func() sql.DBStats, threshold float64) Check {
+	return Check{
+		Name: "pool",
+		Run: func(ctx context.Context) error {
+			current := stats()
+			if current.MaxOpenConnections <= 0 {
+				return nil
+			}
+
+			saturation := float64(current.InUse) / float64(current.MaxOpenConnections)
+			if saturation >= threshold {
+				return fmt.Errorf("pool saturado: %d de %d conexiones en uso",
+					current.InUse, current.MaxOpenConnections)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,80 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunChecks(t *testing.T) {
+	checks := []Check{
+		{Name: "ok", Run: func(ctx context.Context) error { return nil }},
+		{Name: "roto", Run: func(ctx context.Context) error { return errors.New("sin conexión") }},
+	}
+
+	report := RunChecks(context.Background(), time.Second, checks)
+
+	assert.False(t, report.Healthy())
+	assert.Equal(t, StatusUnavailable, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["ok"].Status)
+	// El detalle del error no sale en la respuesta
+	assert.Equal(t, Result{Status: StatusFail, Error: ErrorUnavailable}, report.Checks["roto"])
+}
+
+func TestRunChecks_PlazoPorChequeo(t *testing.T) {
+	// Un chequeo colgado no demora la respuesta más allá del plazo
+	hung := Check{Name: "colgado", Run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+
+	start := time.Now()
+	report := RunChecks(context.Background(), 20*time.Millisecond, []Check{hung})
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, StatusFail, report.Checks["colgado"].Status)
+	assert.Equal(t, ErrorTimeout, report.Checks["colgado"].Error)
+}
+
+func TestMigrations(t *testing.T) {
+	var pending []database.Migration
+	check := Migrations(func(ctx context.Context) ([]database.Migration, error) { return pending, nil })
+
+	assert.NoError(t, check.Run(context.Background()))
+
+	pending = []database.Migration{{Version: 8, Name: "nueva"}}
+	err := check.Run(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "0008_nueva")
+}
+
+func TestPool(t *testing.T) {
+	tests := []struct {
+		name    string
+		stats   sql.DBStats
+		wantErr bool
+	}{
+		{"sin límite", sql.DBStats{MaxOpenConnections: 0, InUse: 50}, false},
+		{"holgado", sql.DBStats{MaxOpenConnections: 10, InUse: 5}, false},
+		{"en el umbral", sql.DBStats{MaxOpenConnections: 10, InUse: 9}, true},
+		{"lleno", sql.DBStats{MaxOpenConnections: 10, InUse: 10}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := Pool(func() sql.DBStats { return tt.stats }, 0.9)
+			err := check.Run(context.Background())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
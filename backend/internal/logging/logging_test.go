@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, "warn")
+	require.NoError(t, err)
+
+	logger.Info("descartado")
+	logger.Warn("registrado", "user_id", 7)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "registrado", entry["msg"])
+	assert.Equal(t, float64(7), entry["user_id"])
+
+	_, err = New(&buf, "verbose")
+	assert.Error(t, err)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
+
+func TestRequestID(t *testing.T) {
+	id := NewRequestID()
+	assert.Len(t, id, 32)
+	assert.True(t, ValidRequestID(id))
+	assert.NotEqual(t, id, NewRequestID())
+
+	assert.True(t, ValidRequestID("abc-123_x.y"))
+	assert.False(t, ValidRequestID(""))
+	assert.False(t, ValidRequestID("con espacios"))
+	assert.False(t, ValidRequestID("salto\nde-linea"))
+	assert.False(t, ValidRequestID(string(make([]byte, 129))))
+}
@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"mime"
+	"mime/quotedprintable"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"ingsw3-tp08/internal/logging"
+)
+
+// Message es un mail de texto plano
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer envía mails. Las implementaciones deben respetar la cancelación de ctx.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer escribe los mails en el log en lugar de enviarlos (desarrollo local)
+type LogMailer struct{}
+
+// NewLogMailer crea una nueva instancia
+func NewLogMailer() *LogMailer {
+	return &LogMailer{}
+}
+
+// Send registra el mail completo: los links con tokens quedan en el log,
+// por eso no debe usarse en producción
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	logging.FromContext(ctx).Info("Mail no enviado (MAIL_BACKEND=log)",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("body", msg.Body),
+	)
+	return nil
+}
+
+// FileMailer guarda cada mail como un archivo .eml en un directorio, que se
+// puede abrir con cualquier cliente de correo
+type FileMailer struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+// NewFileMailer crea una nueva instancia y el directorio si no existe
+func NewFileMailer(dir string, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("no se pudo crear el directorio de mails: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// Send escribe el mail en <dir>/<fecha>-<n>.eml
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%d.eml", now.UTC().Format("20060102T150405.000000000"), m.seq.Add(1))
+	return os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg, now), 0o600)
+}
+
+// format arma el mail en formato RFC 5322 con el cuerpo en quoted-printable
+func format(from string, msg Message, date time.Time) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	_, _ = body.Write([]byte(strings.ReplaceAll(msg.Body, "\n", "\r\n")))
+	_ = body.Close()
+	return buf.Bytes()
+}
@@ -0,0 +1,62 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"mime/quotedprintable"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"ingsw3-tp08/internal/logging"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_GuardaUnEMLPorMail(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mails")
+	mailer, err := NewFileMailer(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	require.NoError(t, mailer.Send(context.Background(), Message{To: "ana@example.com", Subject: "Uno", Body: "Primera línea\nSegunda línea"}))
+	require.NoError(t, mailer.Send(context.Background(), Message{To: "beto@example.com", Subject: "Dos", Body: "Hola"}))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	raw, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	parsed, err := mail.ReadMessage(bytes.NewReader(raw))
+	require.NoError(t, err)
+	assert.Equal(t, "no-reply@example.com", parsed.Header.Get("From"))
+	assert.Equal(t, "ana@example.com", parsed.Header.Get("To"))
+
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "Primera línea\r\nSegunda línea", string(body))
+}
+
+func TestFileMailer_ContextoCancelado(t *testing.T) {
+	mailer, err := NewFileMailer(t.TempDir(), "no-reply@example.com")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert.ErrorIs(t, mailer.Send(ctx, Message{To: "ana@example.com"}), context.Canceled)
+}
+
+func TestLogMailer_RegistraElMail(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := logging.WithLogger(context.Background(), slog.New(slog.NewJSONHandler(&logs, nil)))
+
+	err := NewLogMailer().Send(ctx, Message{To: "ana@example.com", Subject: "Hola", Body: "https://app.example.com/reset?token=abc"})
+
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), `"to":"ana@example.com"`)
+	assert.Contains(t, logs.String(), "token=abc")
+}
@@ -0,0 +1,135 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	netmail "net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Modos de cifrado de la conexión SMTP
+const (
+	TLSStartTLS = "starttls" // conexión en texto plano que pasa a TLS (puerto 587)
+	TLSImplicit = "tls"      // TLS desde el primer byte (puerto 465)
+	TLSNone     = "none"     // sin cifrar: solo para servidores locales de prueba
+)
+
+// SMTPConfig es el servidor por el que salen los mails
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // vacío = sin autenticación
+	Password string
+	From     string // puede llevar nombre: "Blog <no-reply@example.com>"
+	TLS      string
+	Timeout  time.Duration // plazo de todo el envío si ctx no tiene uno
+}
+
+// SMTPMailer envía los mails por SMTP, con una conexión por mail
+type SMTPMailer struct {
+	config SMTPConfig
+}
+
+// NewSMTPMailer crea una nueva instancia
+func NewSMTPMailer(config SMTPConfig) *SMTPMailer {
+	return &SMTPMailer{config: config}
+}
+
+// Send abre la conexión, se autentica si corresponde y entrega el mail
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	if _, ok := ctx.Deadline(); !ok && m.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.config.Timeout)
+		defer cancel()
+	}
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp: conectando a %s: %w", m.addr(), err)
+	}
+	defer conn.Close()
+
+	// net/smtp no recibe contexto: el plazo se aplica a la conexión
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := m.deliver(conn, msg); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+func (m *SMTPMailer) addr() string {
+	return net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (net.Conn, error) {
+	if m.config.TLS == TLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: m.config.Host}}
+		return dialer.DialContext(ctx, "tcp", m.addr())
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", m.addr())
+}
+
+func (m *SMTPMailer) deliver(conn net.Conn, msg Message) error {
+	client, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if m.config.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("el servidor no soporta STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if m.config.Username != "" {
+		// PlainAuth se niega a mandar la contraseña sin TLS salvo a localhost
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	// El sobre SMTP lleva solo la dirección; el nombre va en el header From
+	sender := m.config.From
+	if addr, err := netmail.ParseAddress(sender); err == nil {
+		sender = addr.Address
+	}
+	if err := client.Mail(sender); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+
+	body, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := body.Write(format(m.config.From, msg, time.Now())); err != nil {
+		return err
+	}
+	if err := body.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
@@ -0,0 +1,209 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP es un servidor SMTP mínimo que acepta todo y guarda lo recibido
+type fakeSMTP struct {
+	listener   net.Listener
+	extensions []string
+
+	mu       sync.Mutex
+	commands []string
+	data     string
+}
+
+func startFakeSMTP(t *testing.T, extensions ...string) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &fakeSMTP{listener: listener, extensions: extensions}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+func (s *fakeSMTP) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		s.mu.Unlock()
+
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO":
+			for _, ext := range s.extensions {
+				reply("250-" + ext)
+			}
+			reply("250 fake")
+		case "AUTH":
+			reply("235 autenticado")
+		case "MAIL", "RCPT":
+			reply("250 ok")
+		case "DATA":
+			reply("354 enviar")
+			var data strings.Builder
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			reply("250 encolado")
+		case "QUIT":
+			reply("221 chau")
+			return
+		default:
+			reply("502 no implementado")
+		}
+	}
+}
+
+func (s *fakeSMTP) received() ([]string, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...), s.data
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	server := startFakeSMTP(t, "AUTH PLAIN")
+	mailer := NewSMTPMailer(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "api",
+		Password: "clave",
+		From:     "Blog <no-reply@example.com>",
+		TLS:      TLSNone,
+	})
+
+	err := mailer.Send(context.Background(), Message{
+		To:      "ana@example.com",
+		Subject: "Restablecé tu contraseña",
+		Body:    "Entrá a https://app.example.com/reset?token=abc",
+	})
+
+	require.NoError(t, err)
+	commands, data := server.received()
+	assert.Contains(t, commands, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00api\x00clave")))
+	assert.Contains(t, commands, "MAIL FROM:<no-reply@example.com>")
+	assert.Contains(t, commands, "RCPT TO:<ana@example.com>")
+	assert.Contains(t, data, "From: Blog <no-reply@example.com>\r\n")
+	assert.Contains(t, data, "To: ana@example.com\r\n")
+	assert.Contains(t, data, "Subject: =?utf-8?q?Restablec=C3=A9_tu_contrase=C3=B1a?=\r\n")
+	assert.Contains(t, data, "https://app.example.com/reset?token=3Dabc")
+}
+
+func TestSMTPMailer_SinUsuarioNoSeAutentica(t *testing.T) {
+	server := startFakeSMTP(t)
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: server.port(), From: "no-reply@example.com", TLS: TLSNone})
+
+	require.NoError(t, mailer.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hola", Body: "Hola"}))
+
+	commands, _ := server.received()
+	for _, command := range commands {
+		assert.NotContains(t, command, "AUTH")
+	}
+}
+
+func TestSMTPMailer_StartTLSRequerido(t *testing.T) {
+	// El servidor no ofrece STARTTLS: no se manda nada en texto plano
+	server := startFakeSMTP(t, "AUTH PLAIN")
+	mailer := NewSMTPMailer(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     server.port(),
+		Username: "api",
+		Password: "clave",
+		From:     "no-reply@example.com",
+		TLS:      TLSStartTLS,
+	})
+
+	err := mailer.Send(context.Background(), Message{To: "ana@example.com", Subject: "Hola", Body: "Hola"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STARTTLS")
+	commands, _ := server.received()
+	for _, command := range commands {
+		assert.NotContains(t, command, "AUTH")
+		assert.NotContains(t, command, "MAIL FROM")
+	}
+}
+
+func TestSMTPMailer_ServidorColgadoRespetaElPlazo(t *testing.T) {
+	// Acepta la conexión pero nunca saluda
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			_, _ = io.Copy(io.Discard, conn)
+		}
+	}()
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: listener.Addr().(*net.TCPAddr).Port, TLS: TLSNone, Timeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	err = mailer.Send(context.Background(), Message{To: "ana@example.com"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestSMTPMailer_ServidorCaido(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	mailer := NewSMTPMailer(SMTPConfig{Host: "127.0.0.1", Port: port, TLS: TLSNone})
+
+	err = mailer.Send(context.Background(), Message{To: "ana@example.com"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "127.0.0.1:"+strconv.Itoa(port))
+}
@@ -0,0 +1,29 @@
+// Package maintenance corre las limpiezas periódicas de la base: buckets del
+// rate limiter, links vencidos de user_tokens y registros de logins fallidos
+package maintenance
+
+import (
+	"context"
+	"time"
+)
+
+// Pruner es algo que se limpia periódicamente
+type Pruner interface {
+	Prune(ctx context.Context) error
+}
+
+// PruneEvery llama a p.Prune cada interval hasta que se cancele ctx
+func PruneEvery(ctx context.Context, p Pruner, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Prune(ctx); err != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		}
+	}
+}
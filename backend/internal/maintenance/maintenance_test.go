@@ -0,0 +1,41 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pruneFunc func(ctx context.Context) error
+
+func (f pruneFunc) Prune(ctx context.Context) error { return f(ctx) }
+
+func TestPruneEvery_RunsUntilCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		PruneEvery(ctx, pruneFunc(func(context.Context) error {
+			if calls.Add(1) == 3 {
+				cancel()
+			}
+			return errors.New("db caída")
+		}), time.Millisecond, func(err error) { errs <- err })
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("PruneEvery no terminó al cancelar el contexto")
+	}
+	assert.GreaterOrEqual(t, calls.Load(), int32(3))
+	// Los errores de después de cancelar no se reportan
+	assert.Len(t, errs, 2)
+}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ingsw3"
+
+// Metrics agrupa los colectores de la API en un registro propio,
+// así los tests pueden crear instancias independientes
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	postsCreated    prometheus.Counter
+	commentsCreated prometheus.Counter
+	registrations   prometheus.Counter
+	loginFailures   *prometheus.CounterVec
+	rateLimited     *prometheus.CounterVec
+}
+
+// New crea los colectores. Si db no es nil se exportan también las
+// estadísticas del pool de conexiones (sql.DB.Stats).
+func New(db *sql.DB) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Requests HTTP atendidos, por método, ruta y status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latencia de los requests HTTP, por método y ruta.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		postsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "posts_created_total",
+			Help:      "Posts creados.",
+		}),
+		commentsCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "comments_created_total",
+			Help:      "Comentarios creados.",
+		}),
+		registrations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "user_registrations_total",
+			Help:      "Usuarios registrados.",
+		}),
+		loginFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "login_failures_total",
+			Help:      "Logins fallidos, por motivo.",
+		}, []string{"reason"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rate_limited_total",
+			Help:      "Requests rechazados por el rate limiter, por política.",
+		}, []string{"policy"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.postsCreated,
+		m.commentsCreated,
+		m.registrations,
+		m.loginFailures,
+		m.rateLimited,
+	)
+	if db != nil {
+		m.registry.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+	}
+
+	return m
+}
+
+// Handler expone las métricas en el formato de Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest registra un request atendido. route es el template de la
+// ruta ("/api/posts/{id}") y no el path real, para acotar la cardinalidad.
+func (m *Metrics) ObserveRequest(method string, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// PostCreated implementa services.Events
+func (m *Metrics) PostCreated() {
+	m.postsCreated.Inc()
+}
+
+// CommentCreated implementa services.Events
+func (m *Metrics) CommentCreated() {
+	m.commentsCreated.Inc()
+}
+
+// UserRegistered implementa services.Events
+func (m *Metrics) UserRegistered() {
+	m.registrations.Inc()
+}
+
+// LoginFailed implementa services.Events
+func (m *Metrics) LoginFailed(reason string) {
+	m.loginFailures.WithLabelValues(reason).Inc()
+}
+
+// RateLimited registra un request rechazado con 429
+func (m *Metrics) RateLimited(policy string) {
+	m.rateLimited.WithLabelValues(policy).Inc()
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveRequest(t *testing.T) {
+	m := New(nil)
+
+	m.ObserveRequest("GET", "/api/posts/{id}", http.StatusOK, 10*time.Millisecond)
+	m.ObserveRequest("GET", "/api/posts/{id}", http.StatusOK, 20*time.Millisecond)
+	m.ObserveRequest("GET", "/api/posts/{id}", http.StatusNotFound, time.Millisecond)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/posts/{id}", "200")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/posts/{id}", "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.httpDuration))
+}
+
+func TestEventosDeNegocio(t *testing.T) {
+	m := New(nil)
+
+	m.PostCreated()
+	m.CommentCreated()
+	m.CommentCreated()
+	m.UserRegistered()
+	m.LoginFailed("bad_password")
+	m.LoginFailed("bad_password")
+	m.LoginFailed("banned")
+	m.RateLimited("auth")
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.postsCreated))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.commentsCreated))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.registrations))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.loginFailures.WithLabelValues("bad_password")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.loginFailures.WithLabelValues("banned")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.rateLimited.WithLabelValues("auth")))
+}
+
+func TestHandler(t *testing.T) {
+	m := New(nil)
+	m.PostCreated()
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "ingsw3_posts_created_total 1")
+	assert.Contains(t, w.Body.String(), "go_goroutines")
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Scopes de los tokens de API. Uno de escritura incluye el de lectura del mismo recurso.
+// Los de lectura están reservados: las lecturas son públicas y ninguna ruta los
+// pide todavía, pero se aceptan para que los tokens no cambien si eso cambia.
+const (
+	ScopePostsRead     = "posts:read"
+	ScopePostsWrite    = "posts:write"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+)
+
+// IsValidScope indica si el scope existe
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopePostsRead, ScopePostsWrite, ScopeCommentsRead, ScopeCommentsWrite:
+		return true
+	}
+	return false
+}
+
+// APIToken es un token de API personal. El token en sí se muestra una sola vez,
+// al crearlo; después solo se reconoce por su prefijo.
+type APIToken struct {
+	ID         int        `json:"id"`
+	UserID     int        `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"-"`
+
+	// OwnerBanned indica si el dueño está suspendido (solo lo completa FindByHash)
+	OwnerBanned bool `json:"-"`
+}
+
+// IsUsable indica si el token sigue sirviendo para autenticar
+func (t *APIToken) IsUsable(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// CreateAPITokenRequest se usa para crear un token de API.
+// ExpiresInDays = 0 usa la vigencia por defecto.
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreatedAPIToken es la respuesta al crear un token: la única vez que se ve completo
+type CreatedAPIToken struct {
+	*APIToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Alcances del seguimiento de logins fallidos
+const (
+	ThrottleScopeAccount = "account" // por email, exista o no la cuenta
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginThrottle son los logins fallidos recientes de una cuenta o una IP
+type LoginThrottle struct {
+	Scope         string
+	Subject       string
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   *time.Time
+}
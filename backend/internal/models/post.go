@@ -9,7 +9,10 @@ type Post struct {
 	Content   string    `json:"content"`
 	UserID    int       `json:"user_id"`
 	Username  string    `json:"username"` // Para mostrar quién publicó
+	Version   int       `json:"version"`  // Empieza en 1 y aumenta con cada edición
+	Locked    bool      `json:"locked"`   // Cerrado a nuevos comentarios por un moderador
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // CreatePostRequest se usa para crear un post
@@ -18,17 +21,58 @@ type CreatePostRequest struct {
 	Content string `json:"content"`
 }
 
-// Comment representa un comentario en un post
-type Comment struct {
+// UpdatePostRequest se usa para editar un post.
+// Los campos en nil no se modifican (PATCH); PUT exige ambos.
+type UpdatePostRequest struct {
+	Title   *string `json:"title"`
+	Content *string `json:"content"`
+}
+
+// PostRevision es una versión anterior de un post
+type PostRevision struct {
 	ID        int       `json:"id"`
 	PostID    int       `json:"post_id"`
-	UserID    int       `json:"user_id"`
-	Username  string    `json:"username"`
+	Version   int       `json:"version"`
+	Title     string    `json:"title"`
 	Content   string    `json:"content"`
-	CreatedAt time.Time `json:"created_at"`
+	CreatedAt time.Time `json:"created_at"` // Cuándo se escribió esta versión
+}
+
+// DiffLine es una línea del diff entre dos versiones
+type DiffLine struct {
+	Op   string `json:"op"` // "equal", "insert" o "delete"
+	Text string `json:"text"`
 }
 
-// CreateCommentRequest se usa para crear un comentario
+// PostDiff muestra qué cambió entre dos versiones de un post
+type PostDiff struct {
+	PostID  int        `json:"post_id"`
+	From    int        `json:"from"`
+	To      int        `json:"to"`
+	Title   []DiffLine `json:"title"`
+	Content []DiffLine `json:"content"`
+}
+
+// DeletedCommentContent reemplaza el texto de un comentario eliminado que tiene respuestas
+const DeletedCommentContent = "[deleted]"
+
+// Comment representa un comentario en un post
+type Comment struct {
+	ID        int        `json:"id"`
+	PostID    int        `json:"post_id"`
+	ParentID  *int       `json:"parent_id"` // nil = comentario de primer nivel
+	UserID    int        `json:"user_id"`
+	Username  string     `json:"username"`
+	Content   string     `json:"content"`
+	Depth     int        `json:"depth"`   // 0 = primer nivel
+	Path      string     `json:"path"`    // Ids de la raíz hasta este comentario, separados por "."
+	Deleted   bool       `json:"deleted"` // Eliminado pero conservado porque tiene respuestas
+	CreatedAt time.Time  `json:"created_at"`
+	Replies   []*Comment `json:"replies,omitempty"` // Solo en la vista de árbol
+}
+
+// CreateCommentRequest se usa para crear un comentario o responder a otro
 type CreateCommentRequest struct {
-	Content string `json:"content"`
+	Content  string `json:"content"`
+	ParentID *int   `json:"parent_id"`
 }
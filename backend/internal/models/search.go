@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Tipos de resultado de búsqueda
+const (
+	SearchTypePost    = "post"
+	SearchTypeComment = "comment"
+)
+
+// SearchQuery son los criterios de GET /api/search
+type SearchQuery struct {
+	Text   string     // Texto libre (admite "frases", OR y -exclusiones)
+	Type   string     // "post", "comment" o vacío para ambos
+	Author string     // Username del autor (opcional)
+	From   *time.Time // Creados desde (inclusive)
+	To     *time.Time // Creados hasta (exclusive)
+}
+
+// SearchResult es un post o comentario que coincide con la búsqueda
+type SearchResult struct {
+	Type      string    `json:"type"`
+	ID        int       `json:"id"` // ID del post o del comentario según Type
+	PostID    int       `json:"post_id"`
+	Title     string    `json:"title"`   // Título del post (o del post comentado)
+	Snippet   string    `json:"snippet"` // Fragmento con las coincidencias entre <mark></mark>
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	Rank      float32   `json:"rank"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Session representa un dispositivo con sesión iniciada.
+// Cada rotación del refresh token crea una fila nueva con el mismo ID de sesión.
+type Session struct {
+	TokenID    int        `json:"-"` // Fila del refresh token actual
+	ID         string     `json:"id"`
+	UserID     int        `json:"-"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	StartedAt  time.Time  `json:"started_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RotatedAt  *time.Time `json:"-"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current"` // Es la sesión del request actual
+}
+
+// ClientInfo identifica el dispositivo desde el que se hace un request
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// TokenPair son los tokens que recibe el cliente al iniciar o renovar sesión
+type TokenPair struct {
+	SessionID        string    `json:"session_id"`
+	AccessToken      string    `json:"access_token"`
+	TokenType        string    `json:"token_type"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// RefreshRequest se usa para renovar los tokens
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
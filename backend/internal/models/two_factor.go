@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// TOTPCredential es el segundo factor TOTP de un usuario
+type TOTPCredential struct {
+	UserID       int
+	Secret       string     // cifrado, nunca en claro
+	ConfirmedAt  *time.Time // nil = enrolamiento sin confirmar
+	LastUsedStep int64      // paso del último código aceptado
+	CreatedAt    time.Time
+}
+
+// IsConfirmed indica si el usuario ya validó la app con un primer código
+func (c *TOTPCredential) IsConfirmed() bool {
+	return c.ConfirmedAt != nil
+}
+
+// TOTPEnrollment es lo que el usuario carga en su app de autenticación
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth://, para mostrar como código QR
+}
+
+// RecoveryCodes son los códigos de un solo uso para entrar sin la app.
+// Se muestran una única vez.
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge es la respuesta del login cuando falta el segundo paso
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	Token       string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// MFALoginRequest completa el login con el token del desafío y un código
+// de la app o de recuperación
+type MFALoginRequest struct {
+	Token string `json:"mfa_token"`
+	Code  string `json:"code"`
+}
+
+// MFACodeRequest confirma una operación sobre el segundo factor con un código
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Usos de los tokens de un solo uso
+const (
+	TokenPurposePasswordReset = "password_reset"
+	TokenPurposeMFAChallenge  = "mfa_challenge"
+	TokenPurposeAccountUnlock = "account_unlock"
+)
+
+// UserToken es un token de un solo uso enviado por mail.
+// El token en sí solo lo conoce el destinatario; en la base queda su hash.
+type UserToken struct {
+	UserID    int
+	Purpose   string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// ForgotPasswordRequest se usa para pedir el mail de recuperación
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// UnlockRequest se usa para pedir el mail que desbloquea la cuenta
+type UnlockRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest se usa para elegir una contraseña nueva con el token del mail
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
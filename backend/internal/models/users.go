@@ -2,13 +2,40 @@ package models
 
 import "time"
 
+// Roles de usuario, de menor a mayor privilegio
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// IsValidRole indica si el rol existe
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
 // User representa un usuario del sistema
 type User struct {
-	ID        int       `json:"id"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"` // No se serializa en JSON (por seguridad)
-	Username  string    `json:"username"`
-	CreatedAt time.Time `json:"created_at"`
+	ID        int        `json:"id"`
+	Email     string     `json:"email"`
+	Password  string     `json:"-"` // No se serializa en JSON (por seguridad)
+	Username  string     `json:"username"`
+	Role      string     `json:"role"`
+	BannedAt  *time.Time `json:"banned_at,omitempty"` // nil = no suspendido
+	CreatedAt time.Time  `json:"created_at"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"` // nil = sin verificar
+	MFAEnabled      bool       `json:"mfa_enabled"`                 // tiene TOTP confirmado
+}
+
+// IsBanned indica si el usuario está suspendido
+func (u *User) IsBanned() bool {
+	return u.BannedAt != nil
+}
+
+// IsEmailVerified indica si el usuario confirmó su email con el link del mail
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
 
 // Credentials se usa para login
@@ -23,3 +50,15 @@ type RegisterRequest struct {
 	Password string `json:"password"`
 	Username string `json:"username"`
 }
+
+// LoginResponse incluye los datos del usuario y los tokens de la sesión.
+// Ambos van embebidos para que sus campos queden en el primer nivel del JSON.
+type LoginResponse struct {
+	*User
+	*TokenPair
+}
+
+// AssignRoleRequest se usa para cambiar el rol de un usuario
+type AssignRoleRequest struct {
+	Role string `json:"role"`
+}
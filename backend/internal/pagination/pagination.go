@@ -0,0 +1,125 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Límites de tamaño de página
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Errores al leer los parámetros de paginación
+var (
+	ErrInvalidCursor = errors.New("cursor inválido")
+	ErrInvalidLimit  = errors.New("el parámetro limit debe ser un número positivo")
+)
+
+// Cursor marca la última fila entregada en una página.
+// Las listas se ordenan por (created_at, id), así que con esos dos valores
+// alcanza para retomar la lectura aunque se inserten filas nuevas.
+type Cursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        int       `json:"id"`
+
+	// Claves extra para listados que no se ordenan solo por fecha,
+	// como la búsqueda (relevancia primero, y posts y comentarios mezclados)
+	Rank float32 `json:"r,omitempty"`
+	Kind string  `json:"k,omitempty"`
+}
+
+// Params son los parámetros de una página pedida por el cliente
+type Params struct {
+	Limit int
+	After *Cursor // nil = primera página
+}
+
+// Page es una página de resultados con el cursor de la siguiente
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"` // Vacío en la última página
+}
+
+// Normalize aplica el límite por defecto y el máximo permitido
+func (p Params) Normalize() Params {
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Limit > MaxLimit {
+		p.Limit = MaxLimit
+	}
+	return p
+}
+
+// ParseParams lee "limit" y "cursor" de la query string
+func ParseParams(query url.Values) (Params, error) {
+	params := Params{}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return Params{}, ErrInvalidLimit
+		}
+		params.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := Decode(value)
+		if err != nil {
+			return Params{}, err
+		}
+		params.After = cursor
+	}
+
+	return params.Normalize(), nil
+}
+
+// Encode convierte un cursor en un string opaco para el cliente
+func Encode(cursor Cursor) string {
+	cursor.CreatedAt = cursor.CreatedAt.UTC()
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode interpreta un cursor generado por Encode
+func Decode(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID <= 0 || cursor.CreatedAt.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// NewPage arma una página a partir de las filas leídas.
+// Los repositorios piden limit+1 filas: si llegó la fila extra hay otra página
+// y el cursor apunta a la última fila que sí se entrega.
+func NewPage[T any](items []T, limit int, cursorOf func(T) Cursor) *Page[T] {
+	page := &Page[T]{Items: items}
+
+	if limit > 0 && len(items) > limit {
+		page.Items = items[:limit]
+		page.NextCursor = Encode(cursorOf(page.Items[limit-1]))
+	}
+
+	// Nunca devolver null en JSON
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+
+	return page
+}
@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	original := Cursor{CreatedAt: time.Date(2025, 5, 1, 12, 30, 0, 123456000, time.UTC), ID: 42}
+
+	decoded, err := Decode(Encode(original))
+
+	assert.NoError(t, err)
+	assert.Equal(t, original.ID, decoded.ID)
+	assert.True(t, original.CreatedAt.Equal(decoded.CreatedAt))
+}
+
+func TestDecode_Invalid(t *testing.T) {
+	for _, value := range []string{"%%%", "bm8tanNvbg", Encode(Cursor{ID: 1})} {
+		_, err := Decode(value)
+		assert.ErrorIs(t, err, ErrInvalidCursor, value)
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	params, err := ParseParams(url.Values{})
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimit, params.Limit)
+	assert.Nil(t, params.After)
+
+	params, err = ParseParams(url.Values{"limit": {"1000"}})
+	assert.NoError(t, err)
+	assert.Equal(t, MaxLimit, params.Limit)
+
+	for _, limit := range []string{"0", "-1", "abc"} {
+		_, err := ParseParams(url.Values{"limit": {limit}})
+		assert.ErrorIs(t, err, ErrInvalidLimit, limit)
+	}
+}
+
+func TestNewPage(t *testing.T) {
+	cursorOf := func(n int) Cursor {
+		return Cursor{CreatedAt: time.Unix(int64(n), 0), ID: n}
+	}
+
+	// Llegó la fila extra: hay otra página
+	page := NewPage([]int{1, 2, 3}, 2, cursorOf)
+	assert.Equal(t, []int{1, 2}, page.Items)
+
+	next, err := Decode(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.ID, "el cursor apunta al último item entregado")
+
+	// Última página
+	page = NewPage([]int{1, 2}, 2, cursorOf)
+	assert.Len(t, page.Items, 2)
+	assert.Empty(t, page.NextCursor)
+
+	// Nunca devolver null en JSON
+	page = NewPage[int](nil, 2, cursorOf)
+	assert.NotNil(t, page.Items)
+}
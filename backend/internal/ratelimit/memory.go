@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore guarda los buckets en memoria. Cada instancia de la API
+// lleva su propia cuenta, así que con N instancias el límite efectivo es N veces mayor.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]memoryBucket
+	now     func() time.Time
+}
+
+type memoryBucket struct {
+	bucket
+	fullAt time.Time
+}
+
+// NewMemoryStore crea un store en memoria vacío
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Take implementa Store
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	current, ok := s.buckets[key]
+	if !ok {
+		current.bucket = bucket{tokens: float64(policy.Limit), updated: now}
+	}
+
+	next, result, fullAt := take(current.bucket, policy, now)
+	s.buckets[key] = memoryBucket{bucket: next, fullAt: fullAt}
+	return result, nil
+}
+
+// Prune implementa Store
+func (s *MemoryStore) Prune(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, b := range s.buckets {
+		if !b.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresStore guarda los buckets en la tabla rate_limit_buckets, compartida
+// por todas las instancias. El reloj es el de PostgreSQL, así el desfase entre
+// instancias no altera la recarga.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore crea un store sobre la base de la API (migración 0008)
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take implementa Store. El SELECT ... FOR UPDATE serializa los requests
+// concurrentes sobre el mismo bucket.
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	// Un bucket nuevo arranca lleno
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO rate_limit_buckets (key, tokens, updated_at, full_at)
+		VALUES ($1, $2, clock_timestamp(), clock_timestamp())
+		ON CONFLICT (key) DO NOTHING
+	`, key, policy.Limit)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var current bucket
+	var now time.Time
+	err = tx.QueryRowContext(ctx, `
+		SELECT tokens, updated_at, clock_timestamp()
+		FROM rate_limit_buckets
+		WHERE key = $1
+		FOR UPDATE
+	`, key).Scan(&current.tokens, &current.updated, &now)
+	if err != nil {
+		return Result{}, err
+	}
+
+	next, result, fullAt := take(current, policy, now)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE rate_limit_buckets SET tokens = $2, updated_at = $3, full_at = $4 WHERE key = $1
+	`, key, next.tokens, next.updated, fullAt)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return result, tx.Commit()
+}
+
+// Prune implementa Store
+func (s *PostgresStore) Prune(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE full_at <= clock_timestamp()`)
+	return err
+}
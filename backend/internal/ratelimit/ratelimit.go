@@ -0,0 +1,121 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Políticas que usa el router. Cada una tiene su propio bucket por usuario o IP.
+const (
+	PolicyAuth     = "auth"     // registro, login y refresh (por IP)
+	PolicyPosts    = "posts"    // crear y editar posts (por usuario)
+	PolicyComments = "comments" // crear comentarios (por usuario)
+	PolicySearch   = "search"   // búsqueda de texto completo (por IP)
+)
+
+// Policy es un token bucket: admite ráfagas de hasta Limit requests y
+// recupera Limit tokens por cada Period. Limit 0 desactiva la política.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy interpreta "10/1m" (10 requests por minuto). "0" desactiva la política.
+func ParsePolicy(name string, spec string) (Policy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "0" || spec == "" {
+		return Policy{Name: name}, nil
+	}
+
+	limitStr, periodStr, found := strings.Cut(spec, "/")
+	if !found {
+		return Policy{}, fmt.Errorf("%q: el formato es requests/período, ej. 10/1m", spec)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit < 0 {
+		return Policy{}, fmt.Errorf("%q: cantidad de requests inválida", spec)
+	}
+	period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("%q: período inválido", spec)
+	}
+
+	return Policy{Name: name, Limit: limit, Period: period}, nil
+}
+
+// Enabled indica si la política limita algo
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// String es la forma de RateLimit-Policy: "10;w=60"
+func (p Policy) String() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int(math.Ceil(p.Period.Seconds())))
+}
+
+// rate son los tokens que se recuperan por segundo
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result es el estado del bucket después de un request
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset es cuánto falta para que el bucket vuelva a estar lleno
+	Reset time.Duration
+	// RetryAfter es cuánto falta para el próximo token (0 si se permitió)
+	RetryAfter time.Duration
+}
+
+// Store guarda los buckets. MemoryStore sirve para una sola instancia;
+// con varias instancias detrás de un balanceador se usa PostgresStore.
+type Store interface {
+	// Take consume un token del bucket key según policy
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+	// Prune borra los buckets que ya se llenaron: equivalen a uno nuevo
+	Prune(ctx context.Context) error
+}
+
+// bucket es el estado persistido de un token bucket
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take recarga el bucket según el tiempo transcurrido y consume un token si hay.
+// Es la lógica común a todos los Store; devuelve el nuevo estado y el momento
+// en que el bucket vuelve a estar lleno.
+func take(b bucket, policy Policy, now time.Time) (bucket, Result, time.Time) {
+	capacity := float64(policy.Limit)
+	rate := policy.rate()
+
+	elapsed := now.Sub(b.updated).Seconds()
+	if elapsed < 0 {
+		// Reloj que retrocede (otra instancia desfasada): no se recarga
+		elapsed = 0
+	}
+	tokens := math.Min(capacity, b.tokens+elapsed*rate)
+
+	result := Result{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate)
+
+	return bucket{tokens: tokens, updated: now}, result, now.Add(result.Reset)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
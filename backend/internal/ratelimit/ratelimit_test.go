@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestStore devuelve un MemoryStore con un reloj que el test avanza a mano
+func newTestStore() (*MemoryStore, *time.Time) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	return store, &now
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := ParsePolicy(PolicyAuth, "10/1m")
+	require.NoError(t, err)
+	assert.Equal(t, Policy{Name: PolicyAuth, Limit: 10, Period: time.Minute}, policy)
+	assert.True(t, policy.Enabled())
+	assert.Equal(t, "10;w=60", policy.String())
+
+	disabled, err := ParsePolicy(PolicyAuth, "0")
+	require.NoError(t, err)
+	assert.False(t, disabled.Enabled())
+
+	for _, spec := range []string{"10", "diez/1m", "10/minuto", "10/0s", "-1/1m"} {
+		_, err := ParsePolicy(PolicyAuth, spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	store, now := newTestStore()
+	policy := Policy{Name: PolicyAuth, Limit: 3, Period: 30 * time.Second} // un token cada 10s
+	ctx := context.Background()
+
+	// La ráfaga inicial consume el bucket completo
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "ip:1.2.3.4", policy)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, _ := store.Take(ctx, "ip:1.2.3.4", policy)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 10*time.Second, result.RetryAfter)
+	assert.Equal(t, 30*time.Second, result.Reset)
+
+	// Otra clave tiene su propio bucket
+	other, _ := store.Take(ctx, "ip:5.6.7.8", policy)
+	assert.True(t, other.Allowed)
+
+	// A los 10s se recupera un token
+	*now = now.Add(10 * time.Second)
+	result, _ = store.Take(ctx, "ip:1.2.3.4", policy)
+	assert.True(t, result.Allowed)
+	result, _ = store.Take(ctx, "ip:1.2.3.4", policy)
+	assert.False(t, result.Allowed)
+}
+
+func TestMemoryStore_Prune(t *testing.T) {
+	store, now := newTestStore()
+	policy := Policy{Name: PolicyAuth, Limit: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	store.Take(ctx, "ip:1.2.3.4", policy)
+	store.Take(ctx, "ip:1.2.3.4", policy)
+	*now = now.Add(30 * time.Second)
+	require.NoError(t, store.Prune(ctx))
+	assert.Len(t, store.buckets, 1, "el bucket todavía no se llenó")
+
+	*now = now.Add(time.Minute)
+	require.NoError(t, store.Prune(ctx))
+	assert.Empty(t, store.buckets)
+}
+
+func TestClientIP(t *testing.T) {
+	trusted, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"sin proxy", "203.0.113.7:5000", "", "", "203.0.113.7"},
+		{"headers de un cliente que no es proxy se ignoran", "203.0.113.7:5000", "1.1.1.1", "2.2.2.2", "203.0.113.7"},
+		{"proxy de confianza", "10.0.0.5:80", "198.51.100.9", "", "198.51.100.9"},
+		{"se saltean los proxies propios", "10.0.0.5:80", "1.1.1.1, 198.51.100.9, 192.168.1.1", "", "198.51.100.9"},
+		{"X-Real-IP como alternativa", "192.168.1.1:80", "", "198.51.100.9", "198.51.100.9"},
+		{"IPv6", "[2001:db8::1]:443", "", "", "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			assert.Equal(t, tt.want, ClientIP(req, trusted))
+		})
+	}
+}
+
+func TestParseTrustedProxies_Invalido(t *testing.T) {
+	_, err := ParseTrustedProxies([]string{"10.0.0.0/8", "no-es-una-ip"})
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+
+	"github.com/lib/pq"
+)
+
+// APITokenRepository define las operaciones sobre los tokens de API personales
+type APITokenRepository interface {
+	Create(ctx context.Context, token *models.APIToken, tokenHash string) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	FindActiveByUserID(ctx context.Context, userID int, page pagination.Params) ([]*models.APIToken, error)
+	Revoke(ctx context.Context, userID int, id int) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID int) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
+
+// PostgreSQLAPITokenRepository implementa APITokenRepository usando PostgreSQL
+type PostgreSQLAPITokenRepository struct {
+	db *tracedDB
+}
+
+// NewPostgreSQLAPITokenRepository crea una nueva instancia
+func NewPostgreSQLAPITokenRepository(db *sql.DB) *PostgreSQLAPITokenRepository {
+	return &PostgreSQLAPITokenRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLAPITokenRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLAPITokenRepository {
+	r.db.retry = retry
+	return r
+}
+
+// Create guarda un token nuevo; del token en sí solo se guardan el hash y el prefijo
+func (r *PostgreSQLAPITokenRepository) Create(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		token.UserID,
+		token.Name,
+		token.Prefix,
+		tokenHash,
+		pq.Array(token.Scopes),
+		token.ExpiresAt,
+	).Scan(&token.ID, &token.CreatedAt)
+}
+
+// FindByHash busca un token por su hash, incluso si está revocado o vencido.
+// También informa si el dueño está suspendido.
+func (r *PostgreSQLAPITokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	query := `
+		SELECT t.id, t.user_id, t.name, t.token_prefix, t.scopes, t.created_at, t.expires_at,
+		       t.last_used_at, t.revoked_at, u.banned_at IS NOT NULL
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = $1
+	`
+
+	token := &models.APIToken{}
+	err := r.db.readQueryRow(ctx, query, tokenHash).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Prefix,
+		pq.Array(&token.Scopes),
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+		&token.OwnerBanned,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// FindActiveByUserID obtiene una página de tokens no revocados de un usuario,
+// incluidos los vencidos, del más nuevo al más viejo.
+// Devuelve hasta page.Limit+1 filas para que el service sepa si hay otra página.
+func (r *PostgreSQLAPITokenRepository) FindActiveByUserID(ctx context.Context, userID int, page pagination.Params) ([]*models.APIToken, error) {
+	query := `
+		SELECT id, user_id, name, token_prefix, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_tokens
+		WHERE user_id = $1 AND revoked_at IS NULL
+		  AND ($2::timestamp IS NULL OR (created_at, id) < ($2::timestamp, $3::integer))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
+	`
+
+	createdAt, id := keysetArgs(page)
+	rows, err := r.db.readQuery(ctx, query, userID, createdAt, id, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*models.APIToken{}
+	for rows.Next() {
+		token, err := scanAPIToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, rows.Err()
+}
+
+// Revoke revoca un token del usuario. Devuelve false si no existe, es de otro
+// usuario o ya estaba revocado.
+func (r *PostgreSQLAPITokenRepository) Revoke(ctx context.Context, userID int, id int) (bool, error) {
+	query := `UPDATE api_tokens SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// RevokeAllForUser revoca todos los tokens vigentes de un usuario
+func (r *PostgreSQLAPITokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	query := `UPDATE api_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// TouchLastUsed registra el último uso del token
+func (r *PostgreSQLAPITokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	query := `UPDATE api_tokens SET last_used_at = $2 WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id, at)
+	return err
+}
+
+// scanAPIToken lee una fila con las columnas de FindActiveByUserID
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	token := &models.APIToken{}
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.Prefix,
+		pq.Array(&token.Scopes),
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+		&token.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+)
+
+// LoginThrottleRepository registra los logins fallidos por cuenta y por IP
+type LoginThrottleRepository interface {
+	Find(ctx context.Context, scope string, subject string) (*models.LoginThrottle, error)
+	Reserve(ctx context.Context, scope string, subject string, at time.Time, resetBefore time.Time, check func(*models.LoginThrottle) error) (*models.LoginThrottle, error)
+	Release(ctx context.Context, scope string, subject string) error
+	Lock(ctx context.Context, scope string, subject string, until time.Time) error
+	Reset(ctx context.Context, scope string, subject string) error
+	DeleteStale(ctx context.Context, before time.Time) error
+}
+
+// PostgreSQLLoginThrottleRepository implementa LoginThrottleRepository usando PostgreSQL
+type PostgreSQLLoginThrottleRepository struct {
+	db *tracedDB
+}
+
+// NewPostgreSQLLoginThrottleRepository crea una nueva instancia
+func NewPostgreSQLLoginThrottleRepository(db *sql.DB) *PostgreSQLLoginThrottleRepository {
+	return &PostgreSQLLoginThrottleRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLLoginThrottleRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLLoginThrottleRepository {
+	r.db.retry = retry
+	return r
+}
+
+// Find devuelve el registro de scope/subject, o nil si no tiene fallos
+func (r *PostgreSQLLoginThrottleRepository) Find(ctx context.Context, scope string, subject string) (*models.LoginThrottle, error) {
+	query := `
+		SELECT scope, subject, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE scope = $1 AND subject = $2
+	`
+
+	throttle, err := scanLoginThrottle(r.db.readQueryRow(ctx, query, scope, subject))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return throttle, err
+}
+
+// Reserve cuenta un intento de login como fallo antes de comprobar la contraseña.
+// Bloquea el registro de scope/subject durante la transacción y le pasa a check
+// el estado actual: si check devuelve un error no se cuenta nada. Así varios
+// intentos simultáneos no pueden pasar el control antes de que se cuente el primero.
+// Si el último fallo es anterior a resetBefore la cuenta vuelve a empezar (y se
+// levanta un bloqueo vencido).
+func (r *PostgreSQLLoginThrottleRepository) Reserve(ctx context.Context, scope string, subject string, at time.Time, resetBefore time.Time, check func(*models.LoginThrottle) error) (*models.LoginThrottle, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// El registro tiene que existir para poder bloquearlo con FOR UPDATE
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO login_throttles (scope, subject, failures, last_failure_at)
+		VALUES ($1, $2, 0, $3)
+		ON CONFLICT (scope, subject) DO NOTHING
+	`, scope, subject, at)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := scanLoginThrottle(tx.QueryRowContext(ctx, `
+		SELECT scope, subject, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE scope = $1 AND subject = $2
+		FOR UPDATE
+	`, scope, subject))
+	if err != nil {
+		return nil, err
+	}
+	if err := check(current); err != nil {
+		return nil, err
+	}
+
+	reserved, err := scanLoginThrottle(tx.QueryRowContext(ctx, `
+		UPDATE login_throttles SET
+			failures = CASE WHEN last_failure_at < $4 THEN 1 ELSE failures + 1 END,
+			locked_until = CASE WHEN last_failure_at < $4 THEN NULL ELSE locked_until END,
+			last_failure_at = $3
+		WHERE scope = $1 AND subject = $2
+		RETURNING scope, subject, failures, last_failure_at, locked_until
+	`, scope, subject, at, resetBefore))
+	if err != nil {
+		return nil, err
+	}
+
+	return reserved, tx.Commit()
+}
+
+// Release descuenta un intento reservado que al final no fue un fallo
+func (r *PostgreSQLLoginThrottleRepository) Release(ctx context.Context, scope string, subject string) error {
+	query := `UPDATE login_throttles SET failures = GREATEST(failures - 1, 0) WHERE scope = $1 AND subject = $2`
+	_, err := r.db.ExecContext(ctx, query, scope, subject)
+	return err
+}
+
+// Lock bloquea scope/subject hasta until
+func (r *PostgreSQLLoginThrottleRepository) Lock(ctx context.Context, scope string, subject string, until time.Time) error {
+	query := `UPDATE login_throttles SET locked_until = $3 WHERE scope = $1 AND subject = $2`
+	_, err := r.db.ExecContext(ctx, query, scope, subject, until)
+	return err
+}
+
+// Reset olvida los fallos y el bloqueo de scope/subject
+func (r *PostgreSQLLoginThrottleRepository) Reset(ctx context.Context, scope string, subject string) error {
+	query := `DELETE FROM login_throttles WHERE scope = $1 AND subject = $2`
+	_, err := r.db.ExecContext(ctx, query, scope, subject)
+	return err
+}
+
+// DeleteStale borra los registros sin fallos desde before y sin bloqueo vigente
+func (r *PostgreSQLLoginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	query := `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until < $1)
+	`
+	_, err := r.db.ExecContext(ctx, query, before)
+	return err
+}
+
+func scanLoginThrottle(row rowScanner) (*models.LoginThrottle, error) {
+	throttle := &models.LoginThrottle{}
+	err := row.Scan(
+		&throttle.Scope,
+		&throttle.Subject,
+		&throttle.Failures,
+		&throttle.LastFailureAt,
+		&throttle.LockedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return throttle, nil
+}
@@ -1,55 +1,90 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 
+	"ingsw3-tp08/internal/database"
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+
+	"github.com/lib/pq"
 )
 
+// ErrNotFound indica que la fila a modificar ya no existe (por ejemplo, la borró otro request)
+var ErrNotFound = errors.New("registro no encontrado")
+
 // PostRepository define las operaciones sobre posts
 type PostRepository interface {
-	Create(post *models.Post) error
-	FindAll() ([]*models.Post, error)
-	FindByID(id int) (*models.Post, error)
-	Delete(id int) error
-	CreateComment(comment *models.Comment) error
-	FindCommentsByPostID(postID int) ([]*models.Comment, error)
-	DeleteComment(postID int, commentID int, userID int) error
+	Create(ctx context.Context, post *models.Post) error
+	FindAll(ctx context.Context, page pagination.Params) ([]*models.Post, error)
+	FindByID(ctx context.Context, id int) (*models.Post, error)
+	Update(ctx context.Context, post *models.Post) error
+	Delete(ctx context.Context, id int) error
+	SetLocked(ctx context.Context, id int, locked bool) error
+	FindRevisions(ctx context.Context, postID int, page pagination.Params) ([]*models.PostRevision, error)
+	FindRevision(ctx context.Context, postID int, version int) (*models.PostRevision, error)
+	CreateComment(ctx context.Context, comment *models.Comment) error
+	FindCommentByID(ctx context.Context, id int) (*models.Comment, error)
+	FindCommentsByPostID(ctx context.Context, postID int, page pagination.Params) ([]*models.Comment, error)
+	FindRootComments(ctx context.Context, postID int, page pagination.Params) ([]*models.Comment, error)
+	FindReplies(ctx context.Context, postID int, rootIDs []int) ([]*models.Comment, error)
+	DeleteComment(ctx context.Context, postID int, commentID int) error
 }
 
 // PostgreSQLPostRepository implementa PostRepository usando PostgreSQL
 type PostgreSQLPostRepository struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 // NewPostgreSQLPostRepository crea una nueva instancia
 func NewPostgreSQLPostRepository(db *sql.DB) *PostgreSQLPostRepository {
-	return &PostgreSQLPostRepository{db: db}
+	return &PostgreSQLPostRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLPostRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLPostRepository {
+	r.db.retry = retry
+	return r
 }
 
 // Create inserta un nuevo post
-func (r *PostgreSQLPostRepository) Create(post *models.Post) error {
+func (r *PostgreSQLPostRepository) Create(ctx context.Context, post *models.Post) error {
 	query := `
-		INSERT INTO posts (title, content, user_id, created_at)
-		VALUES ($1, $2, $3, NOW())
-		RETURNING id
+		INSERT INTO posts (title, content, user_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		RETURNING id, version, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(query, post.Title, post.Content, post.UserID).Scan(&post.ID)
+	err := r.db.QueryRowContext(ctx, query, post.Title, post.Content, post.UserID).
+		Scan(&post.ID, &post.Version, &post.CreatedAt, &post.UpdatedAt)
 	return err
 }
 
-// FindAll obtiene todos los posts con información del autor
-func (r *PostgreSQLPostRepository) FindAll() ([]*models.Post, error) {
+// keysetArgs devuelve los valores del cursor para las consultas paginadas.
+// Sin cursor ambos son NULL y la consulta arranca desde el principio.
+func keysetArgs(page pagination.Params) (interface{}, interface{}) {
+	if page.After == nil {
+		return nil, nil
+	}
+	return page.After.CreatedAt, page.After.ID
+}
+
+// FindAll obtiene una página de posts (del más nuevo al más viejo) con información del autor.
+// Devuelve hasta page.Limit+1 filas para que el service sepa si hay otra página.
+func (r *PostgreSQLPostRepository) FindAll(ctx context.Context, page pagination.Params) ([]*models.Post, error) {
 	query := `
-		SELECT p.id, p.title, p.content, p.user_id, u.username, p.created_at
+		SELECT p.id, p.title, p.content, p.user_id, u.username, p.version, p.locked_at IS NOT NULL, p.created_at, p.updated_at
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		ORDER BY p.created_at DESC
+		WHERE $1::timestamp IS NULL OR (p.created_at, p.id) < ($1::timestamp, $2::integer)
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT $3
 	`
 
-	rows, err := r.db.Query(query)
+	createdAt, id := keysetArgs(page)
+	rows, err := r.db.readQuery(ctx, query, createdAt, id, page.Limit+1)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +99,10 @@ func (r *PostgreSQLPostRepository) FindAll() ([]*models.Post, error) {
 			&post.Content,
 			&post.UserID,
 			&post.Username,
+			&post.Version,
+			&post.Locked,
 			&post.CreatedAt,
+			&post.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -72,26 +110,29 @@ func (r *PostgreSQLPostRepository) FindAll() ([]*models.Post, error) {
 		posts = append(posts, post)
 	}
 
-	return posts, nil
+	return posts, rows.Err()
 }
 
 // FindByID busca un post por ID
-func (r *PostgreSQLPostRepository) FindByID(id int) (*models.Post, error) {
+func (r *PostgreSQLPostRepository) FindByID(ctx context.Context, id int) (*models.Post, error) {
 	query := `
-		SELECT p.id, p.title, p.content, p.user_id, u.username, p.created_at
+		SELECT p.id, p.title, p.content, p.user_id, u.username, p.version, p.locked_at IS NOT NULL, p.created_at, p.updated_at
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 		WHERE p.id = $1
 	`
 
 	post := &models.Post{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.readQueryRow(ctx, query, id).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Content,
 		&post.UserID,
 		&post.Username,
+		&post.Version,
+		&post.Locked,
 		&post.CreatedAt,
+		&post.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -104,36 +145,157 @@ func (r *PostgreSQLPostRepository) FindByID(id int) (*models.Post, error) {
 	return post, nil
 }
 
-// Delete elimina un post por ID
-func (r *PostgreSQLPostRepository) Delete(id int) error {
-	query := `DELETE FROM posts WHERE id = $1`
-	_, err := r.db.Exec(query, id)
-	return err
+// Update guarda la versión actual del post en post_revisions y aplica los cambios.
+// Todo ocurre en una transacción para que no se pierdan versiones con ediciones concurrentes.
+func (r *PostgreSQLPostRepository) Update(ctx context.Context, post *models.Post) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO post_revisions (post_id, version, title, content, created_at)
+		SELECT id, version, title, content, updated_at
+		FROM posts
+		WHERE id = $1
+		FOR UPDATE
+	`, post.ID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE posts
+		SET title = $1, content = $2, version = version + 1, updated_at = NOW()
+		WHERE id = $3
+		RETURNING version, updated_at
+	`, post.Title, post.Content, post.ID).Scan(&post.Version, &post.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// CreateComment inserta un nuevo comentario
-func (r *PostgreSQLPostRepository) CreateComment(comment *models.Comment) error {
+// FindRevisions obtiene una página de versiones anteriores de un post, de la más nueva a la más vieja
+func (r *PostgreSQLPostRepository) FindRevisions(ctx context.Context, postID int, page pagination.Params) ([]*models.PostRevision, error) {
 	query := `
-		INSERT INTO comments (post_id, user_id, content, created_at)
-		VALUES ($1, $2, $3, NOW())
-		RETURNING id
+		SELECT id, post_id, version, title, content, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		  AND ($2::timestamp IS NULL OR (created_at, id) < ($2::timestamp, $3::integer))
+		ORDER BY created_at DESC, id DESC
+		LIMIT $4
 	`
 
-	err := r.db.QueryRow(query, comment.PostID, comment.UserID, comment.Content).Scan(&comment.ID)
-	return err
+	createdAt, id := keysetArgs(page)
+	rows, err := r.db.readQuery(ctx, query, postID, createdAt, id, page.Limit+1)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []*models.PostRevision
+	for rows.Next() {
+		revision := &models.PostRevision{}
+		err := rows.Scan(
+			&revision.ID,
+			&revision.PostID,
+			&revision.Version,
+			&revision.Title,
+			&revision.Content,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+
+	return revisions, rows.Err()
 }
 
-// FindCommentsByPostID obtiene todos los comentarios de un post
-func (r *PostgreSQLPostRepository) FindCommentsByPostID(postID int) ([]*models.Comment, error) {
+// FindRevision busca una versión anterior concreta de un post
+func (r *PostgreSQLPostRepository) FindRevision(ctx context.Context, postID int, version int) (*models.PostRevision, error) {
 	query := `
-		SELECT c.id, c.post_id, c.user_id, u.username, c.content, c.created_at
-		FROM comments c
-		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = $1
-		ORDER BY c.created_at ASC
+		SELECT id, post_id, version, title, content, created_at
+		FROM post_revisions
+		WHERE post_id = $1 AND version = $2
 	`
 
-	rows, err := r.db.Query(query, postID)
+	revision := &models.PostRevision{}
+	err := r.db.readQueryRow(ctx, query, postID, version).Scan(
+		&revision.ID,
+		&revision.PostID,
+		&revision.Version,
+		&revision.Title,
+		&revision.Content,
+		&revision.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// SetLocked cierra o reabre un post a nuevos comentarios
+func (r *PostgreSQLPostRepository) SetLocked(ctx context.Context, id int, locked bool) error {
+	query := `UPDATE posts SET locked_at = CASE WHEN $1 THEN COALESCE(locked_at, NOW()) END WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, locked, id)
+	return err
+}
+
+// Delete elimina un post por ID
+func (r *PostgreSQLPostRepository) Delete(ctx context.Context, id int) error {
+	query := `DELETE FROM posts WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// commentColumns son las columnas que se leen de cada comentario (ver scanComment)
+const commentColumns = `
+	c.id, c.post_id, c.parent_id, c.user_id, u.username, c.content,
+	c.depth, c.path, c.deleted_at IS NOT NULL, c.created_at
+`
+
+// rowScanner permite usar scanComment con *sql.Row y *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanComment lee un comentario en el orden de commentColumns
+func scanComment(row rowScanner) (*models.Comment, error) {
+	comment := &models.Comment{}
+	err := row.Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.ParentID,
+		&comment.UserID,
+		&comment.Username,
+		&comment.Content,
+		&comment.Depth,
+		&comment.Path,
+		&comment.Deleted,
+		&comment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// queryComments ejecuta una consulta que devuelve commentColumns
+func (r *PostgreSQLPostRepository) queryComments(ctx context.Context, query string, args ...interface{}) ([]*models.Comment, error) {
+	rows, err := r.db.readQuery(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -141,39 +303,190 @@ func (r *PostgreSQLPostRepository) FindCommentsByPostID(postID int) ([]*models.C
 
 	var comments []*models.Comment
 	for rows.Next() {
-		comment := &models.Comment{}
-		err := rows.Scan(
-			&comment.ID,
-			&comment.PostID,
-			&comment.UserID,
-			&comment.Username,
-			&comment.Content,
-			&comment.CreatedAt,
-		)
+		comment, err := scanComment(rows)
 		if err != nil {
 			return nil, err
 		}
 		comments = append(comments, comment)
 	}
 
-	return comments, nil
+	return comments, rows.Err()
 }
 
-func (r *PostgreSQLPostRepository) DeleteComment(postID int, commentID int, userID int) error {
+// CreateComment inserta un nuevo comentario o una respuesta.
+// La profundidad y el path se calculan a partir del padre en la misma sentencia.
+func (r *PostgreSQLPostRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
 	query := `
-		DELETE FROM comments
-		WHERE id = $1 AND post_id = $2 AND user_id = $3
+		WITH next AS (
+			SELECT nextval(pg_get_serial_sequence('comments', 'id')) AS id
+		), parent AS (
+			SELECT path, depth FROM comments WHERE id = $3
+		)
+		INSERT INTO comments (id, post_id, user_id, parent_id, depth, path, content, created_at)
+		SELECT next.id, $1, $2, $3,
+			COALESCE((SELECT depth + 1 FROM parent), 0),
+			COALESCE((SELECT path || '.' FROM parent), '') || lpad(next.id::text, 10, '0'),
+			$4, NOW()
+		FROM next
+		RETURNING id, depth, path, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, comment.PostID, comment.UserID, comment.ParentID, comment.Content).
+		Scan(&comment.ID, &comment.Depth, &comment.Path, &comment.CreatedAt)
+	return err
+}
+
+// FindCommentByID busca un comentario por ID
+func (r *PostgreSQLPostRepository) FindCommentByID(ctx context.Context, id int) (*models.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.id = $1
 	`
-	result, err := r.db.Exec(query, commentID, postID, userID)
+
+	comment, err := scanComment(r.db.readQueryRow(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
+}
+
+// FindCommentsByPostID obtiene una página de comentarios de un post (respuestas incluidas),
+// del más viejo al más nuevo
+func (r *PostgreSQLPostRepository) FindCommentsByPostID(ctx context.Context, postID int, page pagination.Params) ([]*models.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = $1
+		  AND ($2::timestamp IS NULL OR (c.created_at, c.id) > ($2::timestamp, $3::integer))
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $4
+	`
+
+	createdAt, id := keysetArgs(page)
+	return r.queryComments(ctx, query, postID, createdAt, id, page.Limit+1)
+}
+
+// FindRootComments obtiene una página de comentarios de primer nivel de un post
+func (r *PostgreSQLPostRepository) FindRootComments(ctx context.Context, postID int, page pagination.Params) ([]*models.Comment, error) {
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = $1 AND c.parent_id IS NULL
+		  AND ($2::timestamp IS NULL OR (c.created_at, c.id) > ($2::timestamp, $3::integer))
+		ORDER BY c.created_at ASC, c.id ASC
+		LIMIT $4
+	`
+
+	createdAt, id := keysetArgs(page)
+	return r.queryComments(ctx, query, postID, createdAt, id, page.Limit+1)
+}
+
+// FindReplies obtiene todas las respuestas de los hilos indicados, en orden de hilo
+func (r *PostgreSQLPostRepository) FindReplies(ctx context.Context, postID int, rootIDs []int) ([]*models.Comment, error) {
+	if len(rootIDs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, len(rootIDs))
+	for i, id := range rootIDs {
+		ids[i] = int64(id)
+	}
+
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = $1 AND c.parent_id IS NOT NULL
+		  AND split_part(c.path, '.', 1)::integer = ANY($2)
+		ORDER BY c.path
+	`
+
+	return r.queryComments(ctx, query, postID, pq.Array(ids))
+}
+
+// DeleteComment elimina un comentario.
+// Si tiene respuestas se conserva como "[deleted]" para no perder el hilo;
+// si no, se borra junto con los ancestros "[deleted]" que quedan sin respuestas.
+// Quién puede borrarlo lo decide el service (ver Policy).
+func (r *PostgreSQLPostRepository) DeleteComment(ctx context.Context, postID int, commentID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rowsAffected, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	// FOR UPDATE bloquea nuevas respuestas mientras se decide cómo borrar
+	var parentID sql.NullInt64
+	err = tx.QueryRowContext(ctx, `
+		SELECT parent_id FROM comments
+		WHERE id = $1 AND post_id = $2 AND deleted_at IS NULL
+		FOR UPDATE
+	`, commentID, postID).Scan(&parentID)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if rowsAffected == 0 {
-		return errors.New("no tienes permiso para eliminar este comentario o no existe")
+
+	hasReplies, err := commentHasReplies(ctx, tx, commentID)
+	if err != nil {
+		return err
 	}
-	return nil
+	if hasReplies {
+		if _, err = tx.ExecContext(ctx, `UPDATE comments SET content = '', deleted_at = NOW() WHERE id = $1`, commentID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, commentID); err != nil {
+		return err
+	}
+
+	// Un "[deleted]" sólo existe para sostener sus respuestas: al irse la
+	// última se elimina, y se repite hacia arriba hasta un ancestro vivo
+	for parentID.Valid {
+		var deleted bool
+		var grandparentID sql.NullInt64
+		err = tx.QueryRowContext(ctx, `
+			SELECT parent_id, deleted_at IS NOT NULL FROM comments WHERE id = $1 FOR UPDATE
+		`, parentID.Int64).Scan(&grandparentID, &deleted)
+		if err == sql.ErrNoRows || (err == nil && !deleted) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		hasReplies, err = commentHasReplies(ctx, tx, int(parentID.Int64))
+		if err != nil {
+			return err
+		}
+		if hasReplies {
+			break
+		}
+		if _, err = tx.ExecContext(ctx, `DELETE FROM comments WHERE id = $1`, parentID.Int64); err != nil {
+			return err
+		}
+		parentID = grandparentID
+	}
+
+	return tx.Commit()
+}
+
+// commentHasReplies indica si un comentario tiene respuestas directas.
+// Se consulta después de bloquear la fila para no competir con una respuesta nueva.
+func commentHasReplies(ctx context.Context, tx *tracedTx, commentID int) (bool, error) {
+	var hasReplies bool
+	err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM comments WHERE parent_id = $1)`, commentID).Scan(&hasReplies)
+	return hasReplies, err
 }
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+)
+
+// SearchRepository define la búsqueda de texto completo
+type SearchRepository interface {
+	Search(ctx context.Context, query models.SearchQuery, page pagination.Params) ([]*models.SearchResult, error)
+}
+
+// PostgreSQLSearchRepository implementa SearchRepository con tsvector de PostgreSQL
+type PostgreSQLSearchRepository struct {
+	db *tracedDB
+}
+
+// NewPostgreSQLSearchRepository crea una nueva instancia
+func NewPostgreSQLSearchRepository(db *sql.DB) *PostgreSQLSearchRepository {
+	return &PostgreSQLSearchRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLSearchRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLSearchRepository {
+	r.db.retry = retry
+	return r
+}
+
+// headlineOptions configura los fragmentos de ts_headline
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// Search busca en posts y comentarios, de más a menos relevante.
+// La consulta se interpreta en español y en inglés a la vez (igual que search_vector).
+// Devuelve hasta page.Limit+1 filas para que el service sepa si hay otra página.
+func (r *PostgreSQLSearchRepository) Search(ctx context.Context, query models.SearchQuery, page pagination.Params) ([]*models.SearchResult, error) {
+	// ts_headline es caro (vuelve a analizar el texto completo): se calcula solo
+	// para las filas de la página, después de ordenar y limitar
+	sqlQuery := `
+		WITH q AS (
+			SELECT websearch_to_tsquery('spanish', $1) || websearch_to_tsquery('english', $1) AS tsq
+		), results AS (
+			SELECT 'post' AS kind, p.id, p.id AS post_id, p.title, p.content,
+				p.user_id, u.username, ts_rank(p.search_vector, q.tsq) AS rank, p.created_at
+			FROM posts p
+			JOIN users u ON p.user_id = u.id
+			CROSS JOIN q
+			WHERE p.search_vector @@ q.tsq
+			UNION ALL
+			SELECT 'comment' AS kind, c.id, c.post_id, p.title, c.content,
+				c.user_id, u.username, ts_rank(c.search_vector, q.tsq) AS rank, c.created_at
+			FROM comments c
+			JOIN posts p ON c.post_id = p.id
+			JOIN users u ON c.user_id = u.id
+			CROSS JOIN q
+			WHERE c.search_vector @@ q.tsq
+		), page AS (
+			SELECT *
+			FROM results
+			WHERE ($3 = '' OR kind = $3)
+			  AND ($4 = '' OR lower(username) = lower($4))
+			  AND ($5::timestamp IS NULL OR created_at >= $5::timestamp)
+			  AND ($6::timestamp IS NULL OR created_at < $6::timestamp)
+			  AND ($7::real IS NULL OR (rank, created_at, kind, id) < ($7::real, $8::timestamp, $9::text, $10::integer))
+			ORDER BY rank DESC, created_at DESC, kind DESC, id DESC
+			LIMIT $11
+		)
+		SELECT page.kind, page.id, page.post_id, page.title,
+			ts_headline('spanish', page.content, q.tsq, $2) AS snippet,
+			page.user_id, page.username, page.rank, page.created_at
+		FROM page
+		CROSS JOIN q
+		ORDER BY page.rank DESC, page.created_at DESC, page.kind DESC, page.id DESC
+	`
+
+	var from, to interface{}
+	if query.From != nil {
+		from = *query.From
+	}
+	if query.To != nil {
+		to = *query.To
+	}
+
+	var afterRank, afterCreatedAt, afterKind, afterID interface{}
+	if page.After != nil {
+		afterRank = page.After.Rank
+		afterCreatedAt = page.After.CreatedAt
+		afterKind = page.After.Kind
+		afterID = page.After.ID
+	}
+
+	rows, err := r.db.readQuery(ctx, sqlQuery,
+		query.Text, headlineOptions, query.Type, query.Author, from, to,
+		afterRank, afterCreatedAt, afterKind, afterID, page.Limit+1,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.SearchResult
+	for rows.Next() {
+		result := &models.SearchResult{}
+		err := rows.Scan(
+			&result.Type,
+			&result.ID,
+			&result.PostID,
+			&result.Title,
+			&result.Snippet,
+			&result.UserID,
+			&result.Username,
+			&result.Rank,
+			&result.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, rows.Err()
+}
@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+)
+
+// ErrSessionAlreadyRotated indica que otro request ya usó el mismo refresh token
+var ErrSessionAlreadyRotated = errors.New("el refresh token ya fue utilizado")
+
+// SessionRepository define las operaciones sobre sesiones y refresh tokens
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session, tokenHash string) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error)
+	Rotate(ctx context.Context, current *models.Session, next *models.Session, nextTokenHash string) error
+	Revoke(ctx context.Context, userID int, sessionID string) (bool, error)
+	RevokeAllForUser(ctx context.Context, userID int) error
+	FindActiveByUserID(ctx context.Context, userID int) ([]*models.Session, error)
+	IsActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// PostgreSQLSessionRepository implementa SessionRepository usando PostgreSQL
+type PostgreSQLSessionRepository struct {
+	db *tracedDB
+}
+
+// NewPostgreSQLSessionRepository crea una nueva instancia
+func NewPostgreSQLSessionRepository(db *sql.DB) *PostgreSQLSessionRepository {
+	return &PostgreSQLSessionRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLSessionRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLSessionRepository {
+	r.db.retry = retry
+	return r
+}
+
+// Create inserta el primer refresh token de una sesión nueva
+func (r *PostgreSQLSessionRepository) Create(ctx context.Context, session *models.Session, tokenHash string) error {
+	query := `
+		INSERT INTO sessions (session_id, user_id, token_hash, user_agent, ip_address, started_at, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW(), NOW(), $6)
+		RETURNING id, started_at, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		session.ID,
+		session.UserID,
+		tokenHash,
+		session.UserAgent,
+		session.IPAddress,
+		session.ExpiresAt,
+	).Scan(&session.TokenID, &session.StartedAt, &session.LastUsedAt)
+}
+
+// FindByTokenHash busca el refresh token, incluso si ya fue rotado o revocado
+func (r *PostgreSQLSessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	query := `
+		SELECT id, session_id, user_id, user_agent, ip_address, started_at, created_at, expires_at, rotated_at, revoked_at
+		FROM sessions
+		WHERE token_hash = $1
+	`
+
+	session := &models.Session{}
+	err := r.db.readQueryRow(ctx, query, tokenHash).Scan(
+		&session.TokenID,
+		&session.ID,
+		&session.UserID,
+		&session.UserAgent,
+		&session.IPAddress,
+		&session.StartedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RotatedAt,
+		&session.RevokedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Rotate marca el refresh token actual como usado e inserta el siguiente
+// en una sola transacción. Si el token ya había sido rotado por un request
+// concurrente devuelve ErrSessionAlreadyRotated.
+func (r *PostgreSQLSessionRepository) Rotate(ctx context.Context, current *models.Session, next *models.Session, nextTokenHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE sessions SET rotated_at = NOW()
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL
+	`, current.TokenID)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrSessionAlreadyRotated
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO sessions (session_id, user_id, token_hash, user_agent, ip_address, started_at, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW(), $7)
+		RETURNING id, created_at
+	`,
+		next.ID,
+		next.UserID,
+		nextTokenHash,
+		next.UserAgent,
+		next.IPAddress,
+		next.StartedAt,
+		next.ExpiresAt,
+	).Scan(&next.TokenID, &next.LastUsedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Revoke revoca todos los tokens de una sesión del usuario.
+// Devuelve false si la sesión no existe o ya estaba revocada.
+func (r *PostgreSQLSessionRepository) Revoke(ctx context.Context, userID int, sessionID string) (bool, error) {
+	query := `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+	result, err := r.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// RevokeAllForUser revoca todas las sesiones de un usuario
+func (r *PostgreSQLSessionRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// FindActiveByUserID obtiene las sesiones vigentes de un usuario (una por dispositivo)
+func (r *PostgreSQLSessionRepository) FindActiveByUserID(ctx context.Context, userID int) ([]*models.Session, error) {
+	query := `
+		SELECT id, session_id, user_id, user_agent, ip_address, started_at, created_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.readQuery(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session := &models.Session{}
+		err := rows.Scan(
+			&session.TokenID,
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.StartedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// IsActive indica si la sesión sigue vigente (no revocada ni expirada)
+func (r *PostgreSQLSessionRepository) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE session_id = $1 AND rotated_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+		)
+	`
+
+	var active bool
+	err := r.db.readQueryRow(ctx, query, sessionID).Scan(&active)
+	return active, err
+}
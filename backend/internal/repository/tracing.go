@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/tracing"
+
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "ingsw3-tp08/internal/repository"
+
+// tracedDB envuelve *sql.DB con un span por cada query. Solo se registra el
+// texto SQL: los parámetros pueden tener datos personales y no se exportan.
+type tracedDB struct {
+	db    *sql.DB
+	retry database.ReadRetry
+}
+
+// tracedTx es el equivalente de tracedDB para las queries dentro de una transacción
+type tracedTx struct {
+	tx *sql.Tx
+}
+
+func newTracedDB(db *sql.DB) *tracedDB {
+	return &tracedDB{db: db, retry: database.DefaultReadRetry}
+}
+
+// tracedRows mantiene abierto el span de la query hasta que se cierran las filas:
+// leerlas también es parte de la query, y los errores de lectura solo aparecen ahí
+type tracedRows struct {
+	*sql.Rows
+	span trace.Span
+}
+
+// Close cierra las filas y el span, con el error de la lectura si lo hubo
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+	spanErr := r.Rows.Err()
+	if spanErr == nil {
+		spanErr = err
+	}
+	endQuerySpan(r.span, spanErr)
+	return err
+}
+
+// readQuery es QueryContext para lecturas idempotentes: reintenta ante errores
+// transitorios de conexión. Cada intento tiene su propio span.
+func (t *tracedDB) readQuery(ctx context.Context, query string, args ...interface{}) (*tracedRows, error) {
+	var rows *tracedRows
+	err := t.retry.Do(ctx, func() error {
+		var err error
+		rows, err = t.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// readQueryRow es QueryRowContext para lecturas idempotentes, con los mismos reintentos
+func (t *tracedDB) readQueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	var row *sql.Row
+	t.retry.Do(ctx, func() error {
+		row = t.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
+
+// QueryContext devuelve las filas con el span todavía abierto: termina en rows.Close()
+func (t *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*tracedRows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	rows, err := t.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		endQuerySpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (t *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := t.db.QueryRowContext(ctx, query, args...)
+	endQuerySpan(span, row.Err())
+	return row
+}
+
+func (t *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := t.db.ExecContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (t *tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*tracedTx, error) {
+	tx, err := t.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{tx: tx}, nil
+}
+
+func (t *tracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	row := t.tx.QueryRowContext(ctx, query, args...)
+	endQuerySpan(span, row.Err())
+	return row
+}
+
+func (t *tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	result, err := t.tx.ExecContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+func (t *tracedTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *tracedTx) Rollback() error {
+	return t.tx.Rollback()
+}
+
+// startQuerySpan abre un span con el nombre de la operación SQL (SELECT, INSERT...)
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := sqlOperation(query)
+	return tracing.Tracer(tracerName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(strings.Join(strings.Fields(query), " ")),
+		),
+	)
+}
+
+// endQuerySpan cierra el span. sql.ErrNoRows no es una falla: es "no encontrado".
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation devuelve la primera palabra de la query ("WITH" incluido)
+func sqlOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// ErrTOTPNotPending indica que no hay un enrolamiento TOTP sin confirmar
+var ErrTOTPNotPending = errors.New("no hay un enrolamiento TOTP pendiente")
+
+// TwoFactorRepository guarda el secreto TOTP y los códigos de recuperación
+type TwoFactorRepository interface {
+	SavePendingTOTP(ctx context.Context, userID int, secret string) error
+	FindTOTP(ctx context.Context, userID int) (*models.TOTPCredential, error)
+	ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	DeleteTOTP(ctx context.Context, userID int) error
+}
+
+// PostgreSQLTwoFactorRepository implementa TwoFactorRepository usando PostgreSQL
+type PostgreSQLTwoFactorRepository struct {
+	db *tracedDB
+}
+
+// NewPostgreSQLTwoFactorRepository crea una nueva instancia
+func NewPostgreSQLTwoFactorRepository(db *sql.DB) *PostgreSQLTwoFactorRepository {
+	return &PostgreSQLTwoFactorRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLTwoFactorRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLTwoFactorRepository {
+	r.db.retry = retry
+	return r
+}
+
+// SavePendingTOTP guarda un secreto nuevo sin confirmar, reemplazando otro
+// pendiente. Si el usuario ya tiene TOTP confirmado no cambia nada.
+func (r *PostgreSQLTwoFactorRepository) SavePendingTOTP(ctx context.Context, userID int, secret string) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret = EXCLUDED.secret, last_used_step = 0, created_at = NOW()
+			WHERE user_totp.confirmed_at IS NULL
+	`
+	_, err := r.db.ExecContext(ctx, query, userID, secret)
+	return err
+}
+
+// FindTOTP busca el segundo factor del usuario; nil si no tiene
+func (r *PostgreSQLTwoFactorRepository) FindTOTP(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	query := `SELECT user_id, secret, confirmed_at, last_used_step, created_at FROM user_totp WHERE user_id = $1`
+
+	credential := &models.TOTPCredential{}
+	err := r.db.readQueryRow(ctx, query, userID).Scan(
+		&credential.UserID,
+		&credential.Secret,
+		&credential.ConfirmedAt,
+		&credential.LastUsedStep,
+		&credential.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+// ConfirmTOTP activa el enrolamiento pendiente y guarda los primeros códigos
+// de recuperación, en una sola transacción. Devuelve ErrTOTPNotPending si no
+// había nada que confirmar (por ejemplo, otro request ya lo confirmó).
+func (r *PostgreSQLTwoFactorRepository) ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_totp SET confirmed_at = NOW(), last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL
+	`, userID, step)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrTOTPNotPending
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep registra el paso del código aceptado. Devuelve false si ese paso
+// o uno posterior ya se usó: así un código interceptado no sirve dos veces.
+func (r *PostgreSQLTwoFactorRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+	result, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// ReplaceRecoveryCodes reemplaza todos los códigos de recuperación del usuario
+func (r *PostgreSQLTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, userID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *tracedTx, userID int, recoveryCodeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO user_recovery_codes (code_hash, user_id)
+		SELECT unnest($2::text[]), $1
+	`, userID, pq.Array(recoveryCodeHashes))
+	return err
+}
+
+// UseRecoveryCode marca el código como usado. Devuelve false si no existe,
+// es de otro usuario o ya se usó.
+func (r *PostgreSQLTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `UPDATE user_recovery_codes SET used_at = NOW() WHERE code_hash = $1 AND user_id = $2 AND used_at IS NULL`
+	result, err := r.db.ExecContext(ctx, query, codeHash, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// DeleteTOTP desactiva el segundo factor: borra el secreto y los códigos de recuperación
+func (r *PostgreSQLTwoFactorRepository) DeleteTOTP(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
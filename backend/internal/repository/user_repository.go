@@ -1,52 +1,74 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
+	"ingsw3-tp08/internal/database"
 	"ingsw3-tp08/internal/models"
 )
 
 // UserRepository define las operaciones sobre usuarios
 // INTERFACE: permite crear mocks fácilmente para testing
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByEmail(email string) (*models.User, error)
-	FindByID(id int) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id int) (*models.User, error)
+	UpdatePassword(ctx context.Context, id int, passwordHash string) error
+	UpdateRole(ctx context.Context, id int, role string) error
+	SetBanned(ctx context.Context, id int, banned bool) error
+	MarkEmailVerified(ctx context.Context, id int) error
+	ClaimVerificationEmail(ctx context.Context, id int, at time.Time, notAfter time.Time) (bool, *time.Time, error)
 }
 
 // PostgreSQLUserRepository implementa UserRepository usando PostgreSQL
 type PostgreSQLUserRepository struct {
-	db *sql.DB
+	db *tracedDB
 }
 
 // NewPostgreSQLUserRepository crea una nueva instancia
 func NewPostgreSQLUserRepository(db *sql.DB) *PostgreSQLUserRepository {
-	return &PostgreSQLUserRepository{db: db}
+	return &PostgreSQLUserRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLUserRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLUserRepository {
+	r.db.retry = retry
+	return r
 }
 
 // Create inserta un nuevo usuario en la base de datos
-func (r *PostgreSQLUserRepository) Create(user *models.User) error {
+func (r *PostgreSQLUserRepository) Create(ctx context.Context, user *models.User) error {
 	query := `
-		INSERT INTO users (email, password, username, created_at)
-		VALUES ($1, $2, $3, NOW())
-		RETURNING id
+		INSERT INTO users (email, password, username, role, created_at)
+		VALUES ($1, $2, $3, COALESCE(NULLIF($4, ''), 'user'), NOW())
+		RETURNING id, role
 	`
 
-	err := r.db.QueryRow(query, user.Email, user.Password, user.Username).Scan(&user.ID)
+	err := r.db.QueryRowContext(ctx, query, user.Email, user.Password, user.Username, user.Role).Scan(&user.ID, &user.Role)
 	return err
 }
 
 // FindByEmail busca un usuario por email
-func (r *PostgreSQLUserRepository) FindByEmail(email string) (*models.User, error) {
-	query := `SELECT id, email, password, username, created_at FROM users WHERE email = $1`
+func (r *PostgreSQLUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `
+		SELECT id, email, password, username, role, banned_at, created_at, email_verified_at,
+			EXISTS (SELECT 1 FROM user_totp t WHERE t.user_id = users.id AND t.confirmed_at IS NOT NULL) AS mfa_enabled
+		FROM users WHERE email = $1
+	`
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, email).Scan(
+	err := r.db.readQueryRow(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
 		&user.Username,
+		&user.Role,
+		&user.BannedAt,
 		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.MFAEnabled,
 	)
 
 	if err == sql.ErrNoRows {
@@ -60,16 +82,24 @@ func (r *PostgreSQLUserRepository) FindByEmail(email string) (*models.User, erro
 }
 
 // FindByID busca un usuario por ID
-func (r *PostgreSQLUserRepository) FindByID(id int) (*models.User, error) {
-	query := `SELECT id, email, password, username, created_at FROM users WHERE id = $1`
+func (r *PostgreSQLUserRepository) FindByID(ctx context.Context, id int) (*models.User, error) {
+	query := `
+		SELECT id, email, password, username, role, banned_at, created_at, email_verified_at,
+			EXISTS (SELECT 1 FROM user_totp t WHERE t.user_id = users.id AND t.confirmed_at IS NOT NULL) AS mfa_enabled
+		FROM users WHERE id = $1
+	`
 
 	user := &models.User{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := r.db.readQueryRow(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
 		&user.Username,
+		&user.Role,
+		&user.BannedAt,
 		&user.CreatedAt,
+		&user.EmailVerifiedAt,
+		&user.MFAEnabled,
 	)
 
 	if err == sql.ErrNoRows {
@@ -81,3 +111,61 @@ func (r *PostgreSQLUserRepository) FindByID(id int) (*models.User, error) {
 
 	return user, nil
 }
+
+// UpdatePassword reemplaza el hash de la contraseña de un usuario
+func (r *PostgreSQLUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	query := `UPDATE users SET password = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, passwordHash, id)
+	return err
+}
+
+// UpdateRole cambia el rol de un usuario
+func (r *PostgreSQLUserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	query := `UPDATE users SET role = $1 WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, role, id)
+	return err
+}
+
+// SetBanned suspende o rehabilita a un usuario
+func (r *PostgreSQLUserRepository) SetBanned(ctx context.Context, id int, banned bool) error {
+	query := `UPDATE users SET banned_at = CASE WHEN $1 THEN COALESCE(banned_at, NOW()) END WHERE id = $2`
+	_, err := r.db.ExecContext(ctx, query, banned, id)
+	return err
+}
+
+// MarkEmailVerified registra que el usuario confirmó su email.
+// Si ya estaba verificado conserva la fecha original.
+func (r *PostgreSQLUserRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	query := `UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
+
+// ClaimVerificationEmail registra un envío del mail de verificación en at, solo si
+// el anterior fue antes de notAfter. Es un único UPDATE condicional, así que de
+// varios pedidos simultáneos gana uno solo. Si no gana devuelve false y la fecha
+// del último envío.
+func (r *PostgreSQLUserRepository) ClaimVerificationEmail(ctx context.Context, id int, at time.Time, notAfter time.Time) (bool, *time.Time, error) {
+	query := `
+		UPDATE users SET verification_sent_at = $2
+		WHERE id = $1 AND (verification_sent_at IS NULL OR verification_sent_at <= $3)
+	`
+	result, err := r.db.ExecContext(ctx, query, id, at, notAfter)
+	if err != nil {
+		return false, nil, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, nil, err
+	}
+	if rowsAffected > 0 {
+		return true, nil, nil
+	}
+
+	var sentAt *time.Time
+	err = r.db.readQueryRow(ctx, `SELECT verification_sent_at FROM users WHERE id = $1`, id).Scan(&sentAt)
+	if err != nil && err != sql.ErrNoRows {
+		return false, nil, err
+	}
+	return false, sentAt, nil
+}
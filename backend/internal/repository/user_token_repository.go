@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"ingsw3-tp08/internal/database"
+	"ingsw3-tp08/internal/models"
+)
+
+// UserTokenRepository guarda los tokens de un solo uso enviados por mail
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *models.UserToken, tokenHash string) error
+	Find(ctx context.Context, purpose string, tokenHash string, now time.Time) (*models.UserToken, error)
+	Consume(ctx context.Context, purpose string, tokenHash string, now time.Time) (*models.UserToken, error)
+	DeleteForUser(ctx context.Context, userID int, purpose string) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// PostgreSQLUserTokenRepository implementa UserTokenRepository usando PostgreSQL
+type PostgreSQLUserTokenRepository struct {
+	db *tracedDB
+}
+
+// NewPostgreSQLUserTokenRepository crea una nueva instancia
+func NewPostgreSQLUserTokenRepository(db *sql.DB) *PostgreSQLUserTokenRepository {
+	return &PostgreSQLUserTokenRepository{db: newTracedDB(db)}
+}
+
+// WithReadRetry configura los reintentos de las lecturas ante errores transitorios
+func (r *PostgreSQLUserTokenRepository) WithReadRetry(retry database.ReadRetry) *PostgreSQLUserTokenRepository {
+	r.db.retry = retry
+	return r
+}
+
+// Create guarda un token nuevo
+func (r *PostgreSQLUserTokenRepository) Create(ctx context.Context, token *models.UserToken, tokenHash string) error {
+	query := `
+		INSERT INTO user_tokens (token_hash, user_id, purpose, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query, tokenHash, token.UserID, token.Purpose, token.ExpiresAt).Scan(&token.CreatedAt)
+}
+
+// Find devuelve el token sin usarlo, o nil si no existe, es de otro uso, ya se usó o venció
+func (r *PostgreSQLUserTokenRepository) Find(ctx context.Context, purpose string, tokenHash string, now time.Time) (*models.UserToken, error) {
+	query := `
+		SELECT user_id, purpose, created_at, expires_at, used_at FROM user_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+	`
+
+	token := &models.UserToken{}
+	err := r.db.readQueryRow(ctx, query, tokenHash, purpose, now).Scan(
+		&token.UserID,
+		&token.Purpose,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.UsedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Consume marca el token como usado y lo devuelve, en una sola sentencia para
+// que dos requests con el mismo token no puedan usarlo los dos.
+// Devuelve nil si no existe, es de otro uso, ya se usó o venció.
+func (r *PostgreSQLUserTokenRepository) Consume(ctx context.Context, purpose string, tokenHash string, now time.Time) (*models.UserToken, error) {
+	query := `
+		UPDATE user_tokens SET used_at = $3
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING user_id, purpose, created_at, expires_at, used_at
+	`
+
+	token := &models.UserToken{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash, purpose, now).Scan(
+		&token.UserID,
+		&token.Purpose,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.UsedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// DeleteForUser borra los tokens de un uso del usuario, usados o no
+func (r *PostgreSQLUserTokenRepository) DeleteForUser(ctx context.Context, userID int, purpose string) error {
+	query := `DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2`
+	_, err := r.db.ExecContext(ctx, query, userID, purpose)
+	return err
+}
+
+// DeleteExpired borra los tokens vencidos antes de before
+func (r *PostgreSQLUserTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	query := `DELETE FROM user_tokens WHERE expires_at < $1`
+	_, err := r.db.ExecContext(ctx, query, before)
+	return err
+}
@@ -0,0 +1,195 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+// Headers que el frontend puede enviar y leer en requests cross-origin
+const (
+	corsAllowedHeaders = "Content-Type, Authorization, X-Request-ID, traceparent, tracestate"
+	corsExposedHeaders = "X-Request-ID, Link, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After"
+)
+
+// ErrOriginNotAllowed es el mensaje para orígenes fuera de la lista
+const ErrOriginNotAllowed = "Origen no permitido"
+
+// CORSConfig define qué orígenes pueden llamar a la API desde un navegador
+type CORSConfig struct {
+	// AllowedOrigins son orígenes exactos ("https://app.example.com").
+	// "*" acepta cualquiera, pero no se puede combinar con credenciales.
+	AllowedOrigins []string
+
+	// AllowCredentials habilita cookies y Authorization en requests cross-origin
+	AllowCredentials bool
+
+	// MaxAge es cuánto puede cachear el navegador un preflight. 0 = no se envía.
+	MaxAge time.Duration
+}
+
+// Validate verifica que la configuración sea aceptable para un navegador
+func (c CORSConfig) Validate() error {
+	for _, origin := range c.AllowedOrigins {
+		if origin == "*" {
+			if c.AllowCredentials {
+				return errors.New("CORS: el origen \"*\" no se puede combinar con credenciales")
+			}
+			continue
+		}
+		if !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return errors.New("CORS: origen inválido " + strconv.Quote(origin) + " (debe incluir http:// o https://)")
+		}
+	}
+	if c.MaxAge < 0 {
+		return errors.New("CORS: MaxAge no puede ser negativo")
+	}
+	return nil
+}
+
+// ParseOrigins separa una lista de orígenes por comas, sin espacios ni "/" final
+func ParseOrigins(list string) []string {
+	var origins []string
+	for _, origin := range strings.Split(list, ",") {
+		origin = strings.TrimSuffix(strings.TrimSpace(origin), "/")
+		if origin != "" {
+			origins = append(origins, strings.ToLower(origin))
+		}
+	}
+	return origins
+}
+
+// allows indica si el origen está en la lista
+func (c CORSConfig) allows(origin string) (allowed bool, wildcard bool) {
+	origin = strings.ToLower(origin)
+	for _, allowedOrigin := range c.AllowedOrigins {
+		if allowedOrigin == "*" {
+			wildcard = true
+			allowed = true
+		} else if allowedOrigin == origin {
+			return true, false
+		}
+	}
+	return allowed, wildcard
+}
+
+// corsMiddleware aplica la política CORS. Los métodos permitidos se obtienen
+// de las rutas registradas en router para el path del request.
+func corsMiddleware(router *mux.Router, config CORSConfig) mux.MiddlewareFunc {
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// La respuesta depende del Origin: los caches no deben mezclarlas
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			if origin == "" {
+				// Mismo origen o cliente que no es un navegador: CORS no aplica
+				if r.Method == http.MethodOptions {
+					w.Header().Set("Allow", strings.Join(routeMethods(router, r), ", "))
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, wildcard := config.allows(origin)
+			if !allowed {
+				respondJSONError(w, http.StatusForbidden, handlers.ErrorResponse{
+					Error: ErrOriginNotAllowed,
+					Code:  handlers.CodeForbidden,
+				})
+				return
+			}
+
+			if wildcard {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+				if r.Method == http.MethodOptions {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Preflight: se responde acá, sin llegar al handler
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+			methods := routeMethods(router, r)
+			requested := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+			if !containsMethod(methods, requested) {
+				respondJSONError(w, http.StatusMethodNotAllowed, handlers.ErrorResponse{
+					Error: "Método no permitido en esta ruta",
+					Code:  handlers.CodeInvalidRequest,
+				})
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+			if config.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// routeMethods devuelve los métodos (sin OPTIONS) de todas las rutas cuyo
+// path coincide con el del request, sin importar el método
+func routeMethods(router *mux.Router, r *http.Request) []string {
+	seen := make(map[string]bool)
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		var match mux.RouteMatch
+		if !route.Match(r, &match) && match.MatchErr != mux.ErrMethodMismatch {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				seen[method] = true
+			}
+		}
+		return nil
+	})
+
+	methods := make([]string, 0, len(seen))
+	for method := range seen {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+func containsMethod(methods []string, method string) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
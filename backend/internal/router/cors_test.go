@@ -0,0 +1,141 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+const testOrigin = "http://localhost:3000"
+
+// newCORSRouter arma un router con dos rutas sobre el mismo path
+func newCORSRouter(config CORSConfig) (*mux.Router, *bool) {
+	handled := false
+	router := mux.NewRouter()
+	router.Use(corsMiddleware(router, config))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		handled = true
+		w.WriteHeader(http.StatusOK)
+	}
+	router.HandleFunc("/api/posts/{id}", ok).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/posts/{id}", ok).Methods("DELETE", "OPTIONS")
+	router.HandleFunc("/api/posts", ok).Methods("POST", "OPTIONS")
+
+	return router, &handled
+}
+
+func preflight(path string, origin string, method string) *http.Request {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	return req
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	router, handled := newCORSRouter(CORSConfig{AllowedOrigins: []string{testOrigin}, MaxAge: 10 * time.Minute})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, preflight("/api/posts/7", testOrigin, "DELETE"))
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.False(t, *handled, "el preflight no llega al handler")
+	assert.Equal(t, testOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+	// Solo los métodos de las rutas de ese path
+	assert.Equal(t, "DELETE, GET", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_PreflightMetodoNoPermitido(t *testing.T) {
+	router, _ := newCORSRouter(CORSConfig{AllowedOrigins: []string{testOrigin}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, preflight("/api/posts/7", testOrigin, "PUT"))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+}
+
+func TestCORS_OrigenNoPermitido(t *testing.T) {
+	router, handled := newCORSRouter(CORSConfig{AllowedOrigins: []string{testOrigin}})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, preflight("/api/posts", "https://evil.example", "POST"))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	// Un request simple de otro origen tampoco llega al handler
+	req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.False(t, *handled)
+}
+
+func TestCORS_RequestPermitidoConCredenciales(t *testing.T) {
+	router, handled := newCORSRouter(CORSConfig{AllowedOrigins: []string{testOrigin}, AllowCredentials: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+	req.Header.Set("Origin", testOrigin)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, *handled)
+	assert.Equal(t, testOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, corsExposedHeaders, w.Header().Get("Access-Control-Expose-Headers"))
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "X-Request-ID")
+	assert.Contains(t, w.Header().Get("Access-Control-Expose-Headers"), "Link")
+	assert.Contains(t, w.Header().Values("Vary"), "Origin")
+}
+
+func TestCORS_SinOrigin(t *testing.T) {
+	router, handled := newCORSRouter(CORSConfig{})
+
+	// curl o el mismo origen: CORS no aplica
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/posts/7", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, *handled)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/api/posts/7", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "DELETE, GET", w.Header().Get("Allow"))
+}
+
+func TestCORS_Comodin(t *testing.T) {
+	router, _ := newCORSRouter(CORSConfig{AllowedOrigins: []string{"*"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+	req.Header.Set("Origin", "https://cualquiera.example")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSConfig_Validate(t *testing.T) {
+	assert.NoError(t, CORSConfig{AllowedOrigins: []string{testOrigin}, AllowCredentials: true}.Validate())
+	assert.NoError(t, CORSConfig{AllowedOrigins: []string{"*"}}.Validate())
+	assert.Error(t, CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}.Validate())
+	assert.Error(t, CORSConfig{AllowedOrigins: []string{"localhost:3000"}}.Validate())
+	assert.Error(t, CORSConfig{MaxAge: -time.Second}.Validate())
+}
+
+func TestParseOrigins(t *testing.T) {
+	assert.Equal(t,
+		[]string{"http://localhost:3000", "https://app.example.com"},
+		ParseOrigins(" http://localhost:3000/, HTTPS://App.Example.com ,,"))
+	assert.Empty(t, ParseOrigins(""))
+}
@@ -0,0 +1,89 @@
+package router
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/handlers"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/metrics"
+	"ingsw3-tp08/internal/ratelimit"
+)
+
+// RateLimitConfig define los límites por ruta
+type RateLimitConfig struct {
+	// Store guarda los buckets. nil = sin rate limiting.
+	Store ratelimit.Store
+
+	// Policies por nombre (ratelimit.PolicyAuth, ...). Una política que falta
+	// o con Limit 0 no limita.
+	Policies map[string]ratelimit.Policy
+}
+
+// rateLimiter arma el middleware de cada política
+type rateLimiter struct {
+	config  RateLimitConfig
+	metrics *metrics.Metrics
+}
+
+// policy devuelve el middleware que aplica la política name. El bucket es por
+// usuario si la ruta ya pasó por authMiddleware, si no por IP.
+func (l rateLimiter) policy(name string) func(http.Handler) http.Handler {
+	policy, ok := l.config.Policies[name]
+	if l.config.Store == nil || !ok || !policy.Enabled() {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	policyHeader := policy.String()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Los preflight no cuentan: los responde corsMiddleware antes de llegar acá
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// realIPMiddleware ya reemplazó RemoteAddr por la IP del cliente
+			subject := "ip:" + ratelimit.ClientIP(r, nil)
+			if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+				subject = "user:" + strconv.Itoa(principal.UserID)
+			}
+
+			result, err := l.config.Store.Take(r.Context(), name+":"+subject, policy)
+			if err != nil {
+				// Si falla el store se deja pasar: es preferible a cortar toda la API
+				logging.FromContext(r.Context()).Warn("Error en el rate limiter", "policy", name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("RateLimit-Policy", policyHeader)
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("RateLimit-Reset", ceilSeconds(result.Reset))
+
+			if !result.Allowed {
+				if l.metrics != nil {
+					l.metrics.RateLimited(name)
+				}
+				logging.FromContext(r.Context()).Info("Request rechazado por rate limit", "policy", name, "subject", subject)
+				w.Header().Set("Retry-After", ceilSeconds(result.RetryAfter))
+				respondJSONError(w, http.StatusTooManyRequests, handlers.ErrorResponse{
+					Error: handlers.ErrRateLimited,
+					Code:  handlers.CodeRateLimited,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds redondea hacia arriba: "Retry-After: 0" invitaría a reintentar ya
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
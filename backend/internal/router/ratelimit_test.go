@@ -0,0 +1,108 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/handlers"
+	"ingsw3-tp08/internal/metrics"
+	"ingsw3-tp08/internal/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLimiter(limit int, m *metrics.Metrics) rateLimiter {
+	return rateLimiter{
+		config: RateLimitConfig{
+			Store: ratelimit.NewMemoryStore(),
+			Policies: map[string]ratelimit.Policy{
+				ratelimit.PolicyAuth: {Name: ratelimit.PolicyAuth, Limit: limit, Period: time.Minute},
+			},
+		},
+		metrics: m,
+	}
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func loginFrom(remoteAddr string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+	req.RemoteAddr = remoteAddr
+	return req
+}
+
+func TestRateLimit_RechazaConRetryAfter(t *testing.T) {
+	m := metrics.New(nil)
+	limited := newTestLimiter(2, m).policy(ratelimit.PolicyAuth)(okHandler)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, loginFrom("203.0.113.7:1234"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+	}
+
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, loginFrom("203.0.113.7:1234"))
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	var body handlers.ErrorResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&body))
+	assert.Equal(t, handlers.CodeRateLimited, body.Code)
+	scrape := httptest.NewRecorder()
+	m.Handler().ServeHTTP(scrape, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, scrape.Body.String(), `ingsw3_rate_limited_total{policy="auth"} 1`)
+
+	// Otra IP no comparte el bucket
+	w = httptest.NewRecorder()
+	limited.ServeHTTP(w, loginFrom("198.51.100.9:1234"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRateLimit_PorUsuarioAutenticado(t *testing.T) {
+	limited := newTestLimiter(1, nil).policy(ratelimit.PolicyAuth)(okHandler)
+	asUser := func(userID int) *http.Request {
+		req := loginFrom("203.0.113.7:1234")
+		return req.WithContext(auth.WithPrincipal(req.Context(), &auth.Principal{UserID: userID}))
+	}
+
+	w := httptest.NewRecorder()
+	limited.ServeHTTP(w, asUser(1))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Misma IP, otro usuario: bucket propio
+	w = httptest.NewRecorder()
+	limited.ServeHTTP(w, asUser(2))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = httptest.NewRecorder()
+	limited.ServeHTTP(w, asUser(1))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestRateLimit_PoliticaDesactivada(t *testing.T) {
+	limiter := newTestLimiter(0, nil)
+	limited := limiter.policy(ratelimit.PolicyAuth)(okHandler)
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		limited.ServeHTTP(w, loginFrom("203.0.113.7:1234"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+
+	// Una política que no está configurada tampoco limita
+	w := httptest.NewRecorder()
+	limiter.policy(ratelimit.PolicySearch)(okHandler).ServeHTTP(w, loginFrom("203.0.113.7:1234"))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -1,52 +1,381 @@
 package router
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+	"net/netip"
+	"strings"
+	"time"
 
+	"ingsw3-tp08/internal/auth"
 	"ingsw3-tp08/internal/handlers"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/metrics"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/ratelimit"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/internal/tracing"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Options agrupa la configuración del router
+type Options struct {
+	// RequestTimeout es el plazo máximo de cada request; al vencer se cancelan
+	// las consultas a la base en curso. 0 = sin límite.
+	RequestTimeout time.Duration
+
+	// Logger es el logger base de los requests. nil = slog.Default().
+	Logger *slog.Logger
+
+	// CORS define los orígenes que pueden llamar a la API desde un navegador.
+	// Sin orígenes configurados se rechaza todo request cross-origin.
+	CORS CORSConfig
+
+	// Metrics recibe las métricas HTTP y se expone en /metrics. nil = sin métricas.
+	Metrics *metrics.Metrics
+
+	// RateLimit limita los requests por usuario o IP en las rutas sensibles
+	RateLimit RateLimitConfig
+
+	// TrustedProxies son los proxies cuyos X-Forwarded-For y X-Real-IP se respetan
+	// para obtener la IP del cliente (rate limiting, sesiones, bloqueo de logins)
+	TrustedProxies []netip.Prefix
+}
+
 // Setup configura todas las rutas de la aplicación
-func Setup(authHandler *handlers.AuthHandler, postHandler *handlers.PostHandler) *mux.Router {
+func Setup(authHandler *handlers.AuthHandler, postHandler *handlers.PostHandler, searchHandler *handlers.SearchHandler, adminHandler *handlers.AdminHandler, apiTokenHandler *handlers.APITokenHandler, healthHandler *handlers.HealthHandler, authenticator auth.Authenticator, opts Options) *mux.Router {
 	router := mux.NewRouter()
 
-	// Middleware CORS
-	router.Use(corsMiddleware)
+	// Primero la traza (continúa el traceparent entrante) y el log, así cada
+	// request, incluso los preflight, tiene su span y su X-Request-ID
+	router.Use(tracingMiddleware)
+
+	// De acá en adelante RemoteAddr es la IP del cliente, aun detrás de un proxy
+	router.Use(realIPMiddleware(opts.TrustedProxies))
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	router.Use(requestLogMiddleware(logger))
+
+	if opts.Metrics != nil {
+		router.Use(metricsMiddleware(opts.Metrics))
+	}
+
+	// Política CORS: orígenes permitidos y métodos según las rutas registradas
+	router.Use(corsMiddleware(router, opts.CORS))
+
+	// Plazo por request: el contexto se cancela al vencer o si el cliente corta la conexión
+	router.Use(timeoutMiddleware(opts.RequestTimeout))
+
+	// Las rutas privadas exigen un bearer token válido. requireAuth solo acepta
+	// sesiones; requireScope acepta también tokens de API con ese scope.
+	requireAuth := authMiddleware(authenticator, "")
+	requireScope := func(scope string) func(http.Handler) http.Handler {
+		return authMiddleware(authenticator, scope)
+	}
+	postsWrite := requireScope(models.ScopePostsWrite)
+	commentsWrite := requireScope(models.ScopeCommentsWrite)
+
+	// Límites por ruta: las públicas cuentan por IP, las privadas por usuario
+	// (por eso van dentro de requireAuth)
+	limiter := rateLimiter{config: opts.RateLimit, metrics: opts.Metrics}
+	limitAuth := limiter.policy(ratelimit.PolicyAuth)
+	limitPosts := limiter.policy(ratelimit.PolicyPosts)
+	limitComments := limiter.policy(ratelimit.PolicyComments)
+	limitSearch := limiter.policy(ratelimit.PolicySearch)
 
 	// Rutas de autenticación
-	router.HandleFunc("/api/auth/register", authHandler.Register).Methods("POST", "OPTIONS")
-	router.HandleFunc("/api/auth/login", authHandler.Login).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/register", limitAuth(http.HandlerFunc(authHandler.Register))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/login", limitAuth(http.HandlerFunc(authHandler.Login))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/refresh", limitAuth(http.HandlerFunc(authHandler.Refresh))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/password/forgot", limitAuth(http.HandlerFunc(authHandler.ForgotPassword))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/password/reset", limitAuth(http.HandlerFunc(authHandler.ResetPassword))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/verify", limitAuth(http.HandlerFunc(authHandler.VerifyEmail))).Methods("GET", "OPTIONS")
+	router.Handle("/api/auth/unlock", limitAuth(http.HandlerFunc(authHandler.RequestUnlock))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/unlock", limitAuth(http.HandlerFunc(authHandler.UnlockAccount))).Methods("GET")
+	router.Handle("/api/auth/verify/resend", requireAuth(limitAuth(http.HandlerFunc(authHandler.ResendVerification)))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/login/mfa", limitAuth(http.HandlerFunc(authHandler.LoginMFA))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/mfa/totp", requireAuth(limitAuth(http.HandlerFunc(authHandler.EnrollTOTP)))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/mfa/totp", requireAuth(limitAuth(http.HandlerFunc(authHandler.DisableTOTP)))).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/auth/mfa/totp/confirm", requireAuth(limitAuth(http.HandlerFunc(authHandler.ConfirmTOTP)))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/mfa/recovery-codes", requireAuth(limitAuth(http.HandlerFunc(authHandler.RegenerateRecoveryCodes)))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/logout", requireAuth(http.HandlerFunc(authHandler.Logout))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/logout-all", requireAuth(http.HandlerFunc(authHandler.LogoutAll))).Methods("POST", "OPTIONS")
+	router.Handle("/api/auth/sessions", requireAuth(http.HandlerFunc(authHandler.ListSessions))).Methods("GET", "OPTIONS")
+	router.Handle("/api/auth/sessions/{id}", requireAuth(http.HandlerFunc(authHandler.RevokeSession))).Methods("DELETE", "OPTIONS")
 
 	// Rutas de posts
 	router.HandleFunc("/api/posts", postHandler.GetAllPosts).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/posts", postHandler.CreatePost).Methods("POST", "OPTIONS")
+	router.Handle("/api/posts", postsWrite(limitPosts(http.HandlerFunc(postHandler.CreatePost)))).Methods("POST", "OPTIONS")
 	router.HandleFunc("/api/posts/{id}", postHandler.GetPostByID).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/posts/{id}", postHandler.DeletePost).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/posts/{id}", postsWrite(limitPosts(http.HandlerFunc(postHandler.UpdatePost)))).Methods("PUT", "PATCH", "OPTIONS")
+	router.Handle("/api/posts/{id}", postsWrite(http.HandlerFunc(postHandler.DeletePost))).Methods("DELETE", "OPTIONS")
+
+	// Moderación: cerrar un post a nuevos comentarios
+	router.Handle("/api/posts/{id}/lock", requireAuth(http.HandlerFunc(postHandler.LockPost))).Methods("POST", "OPTIONS")
+	router.Handle("/api/posts/{id}/lock", requireAuth(http.HandlerFunc(postHandler.UnlockPost))).Methods("DELETE", "OPTIONS")
+
+	// Historial de versiones de un post
+	router.HandleFunc("/api/posts/{id}/revisions", postHandler.GetRevisions).Methods("GET", "OPTIONS")
+	router.HandleFunc("/api/posts/{id}/revisions/diff", postHandler.DiffRevisions).Methods("GET", "OPTIONS")
 
 	// Rutas de comentarios
 	router.HandleFunc("/api/posts/{id}/comments", postHandler.GetComments).Methods("GET", "OPTIONS")
-	router.HandleFunc("/api/posts/{id}/comments", postHandler.CreateComment).Methods("POST", "OPTIONS")
-	router.HandleFunc("/api/posts/{postId}/comments/{commentId}", postHandler.DeleteComment).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/posts/{id}/comments", commentsWrite(limitComments(http.HandlerFunc(postHandler.CreateComment)))).Methods("POST", "OPTIONS")
+	router.Handle("/api/posts/{postId}/comments/{commentId}", commentsWrite(http.HandlerFunc(postHandler.DeleteComment))).Methods("DELETE", "OPTIONS")
+
+	// Tokens de API personales: se administran solo desde una sesión
+	router.Handle("/api/me/tokens", requireAuth(http.HandlerFunc(apiTokenHandler.ListTokens))).Methods("GET", "OPTIONS")
+	router.Handle("/api/me/tokens", requireAuth(http.HandlerFunc(apiTokenHandler.CreateToken))).Methods("POST", "OPTIONS")
+	router.Handle("/api/me/tokens/{id}", requireAuth(http.HandlerFunc(apiTokenHandler.RevokeToken))).Methods("DELETE", "OPTIONS")
+
+	// Administración de usuarios (los permisos según rol los decide el service)
+	router.Handle("/api/admin/users/{id}/role", requireAuth(http.HandlerFunc(adminHandler.AssignRole))).Methods("PUT", "OPTIONS")
+	router.Handle("/api/admin/users/{id}/ban", requireAuth(http.HandlerFunc(adminHandler.BanUser))).Methods("POST", "OPTIONS")
+	router.Handle("/api/admin/users/{id}/ban", requireAuth(http.HandlerFunc(adminHandler.UnbanUser))).Methods("DELETE", "OPTIONS")
+	router.Handle("/api/admin/users/{id}/lockout", requireAuth(http.HandlerFunc(adminHandler.UnlockUser))).Methods("DELETE", "OPTIONS")
+
+	// Búsqueda de texto completo
+	router.Handle("/api/search", limitSearch(http.HandlerFunc(searchHandler.Search))).Methods("GET", "OPTIONS")
+
+	// Sondas de liveness y readiness para el orquestador
+	router.HandleFunc("/healthz", healthHandler.Live).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Ready).Methods("GET")
+
+	// Métricas para Prometheus
+	if opts.Metrics != nil {
+		router.Handle("/metrics", opts.Metrics.Handler()).Methods("GET")
+	}
 
 	return router
 }
 
-// corsMiddleware permite peticiones desde el frontend
-func corsMiddleware(next http.Handler) http.Handler {
+// requestLogKey guarda en el contexto los datos del request que se conocen
+// recién dentro de la cadena (el usuario lo resuelve authMiddleware)
+type requestLogKey struct{}
+
+type requestLog struct {
+	userID int
+}
+
+// statusRecorder captura el status que escribe el handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// requestLogMiddleware asigna o propaga el X-Request-ID, deja en el contexto
+// un logger con ese ID y registra una línea por request al terminar
+func requestLogMiddleware(base *slog.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			requestID := r.Header.Get("X-Request-ID")
+			if !logging.ValidRequestID(requestID) {
+				requestID = logging.NewRequestID()
+			}
+			w.Header().Set("X-Request-ID", requestID)
+
+			logger := base.With("request_id", requestID)
+			if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+				logger = logger.With("trace_id", spanContext.TraceID().String())
+			}
+			entry := &requestLog{}
+			ctx := logging.WithLogger(r.Context(), logger)
+			ctx = context.WithValue(ctx, requestLogKey{}, entry)
+
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			attrs := []any{
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"duration_ms", time.Since(start).Milliseconds(),
+			}
+			if entry.userID != 0 {
+				attrs = append(attrs, "user_id", entry.userID)
+			}
+
+			level := slog.LevelInfo
+			if recorder.status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.Log(r.Context(), level, "request", attrs...)
+		})
+	}
+}
+
+// realIPMiddleware reemplaza RemoteAddr por la IP del cliente según los
+// headers de los proxies de confianza. Sin proxies configurados no cambia nada.
+func realIPMiddleware(trusted []netip.Prefix) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if len(trusted) == 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.RemoteAddr = ratelimit.ClientIP(r, trusted)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tracingMiddleware abre un span de servidor por request, hijo del traceparent
+// W3C entrante si lo hay. El nombre usa el template de la ruta, no el path real.
+func tracingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Configurar headers CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-User-ID")
-
-		// Si es una petición OPTIONS (preflight), responder inmediatamente
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+		route := routeTemplate(r)
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer("ingsw3-tp08/internal/router").Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
 		}
+	})
+}
+
+// routeTemplate devuelve el template de la ruta resuelta por mux ("/api/posts/{id}")
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "desconocida"
+}
+
+// metricsMiddleware cuenta los requests y su latencia por template de ruta.
+// Los middlewares de mux corren con la ruta ya resuelta, así que CurrentRoute no es nil.
+func metricsMiddleware(m *metrics.Metrics) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 
-		next.ServeHTTP(w, r)
+			next.ServeHTTP(recorder, r)
+
+			m.ObserveRequest(r.Method, routeTemplate(r), recorder.status, time.Since(start))
+		})
+	}
+}
+
+// timeoutMiddleware agrega un deadline al contexto del request
+func timeoutMiddleware(timeout time.Duration) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if timeout <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// authMiddleware valida el header "Authorization: Bearer <token>"
+// y guarda la identidad del usuario en el contexto del request.
+// Un token de API pasa solo si tiene scope; con scope vacío la ruta es solo para sesiones.
+func authMiddleware(authenticator auth.Authenticator, scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				respondUnauthorized(w, "Token de acceso requerido")
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), token)
+			if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, services.ErrUnauthorized) {
+				respondUnauthorized(w, err.Error())
+				return
+			}
+			if err != nil {
+				// Falla de infraestructura (p. ej. la base de sesiones): no es culpa del token
+				logging.FromContext(r.Context()).Error("Error al autenticar", "error", err)
+				respondJSONError(w, http.StatusInternalServerError, handlers.ErrorResponse{
+					Error: handlers.ErrInternal,
+					Code:  handlers.CodeInternal,
+				})
+				return
+			}
+
+			if !principal.Allows(scope) {
+				message := "Esta ruta requiere iniciar sesión: no acepta tokens de API"
+				if scope != "" {
+					message = "El token de API no tiene el scope " + scope
+				}
+				respondJSONError(w, http.StatusForbidden, handlers.ErrorResponse{
+					Error: message,
+					Code:  handlers.CodeForbidden,
+				})
+				return
+			}
+
+			// El usuario queda en la línea del request y en los logs de services y repositorios
+			if entry, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+				entry.userID = principal.UserID
+			}
+			ctx := logging.WithLogger(r.Context(), logging.FromContext(r.Context()).With("user_id", principal.UserID))
+
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(ctx, principal)))
+		})
+	}
+}
+
+// bearerToken extrae el token del header Authorization
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// respondUnauthorized responde 401 con el mismo formato JSON que los handlers
+func respondUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	respondJSONError(w, http.StatusUnauthorized, handlers.ErrorResponse{
+		Error: message,
+		Code:  handlers.CodeUnauthorized,
 	})
 }
+
+// respondJSONError escribe un error con el formato de handlers.ErrorResponse
+func respondJSONError(w http.ResponseWriter, status int, response handlers.ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -1,9 +1,34 @@
 package router
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
 	"testing"
+	"time"
 
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/handlers"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/metrics"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/ratelimit"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestSetup_ReturnsRouter(t *testing.T) {
@@ -19,7 +44,357 @@ func TestSetup_ReturnsRouter(t *testing.T) {
 		assert.NotPanics(t, func() {
 			// This will panic because nil, but tests that function is callable
 			// In practice, router would be tested in integration with proper handlers
-			_ = Setup(nil, nil)
+			_ = Setup(nil, nil, nil, nil, nil, nil, nil, Options{})
 		})
 	})
 }
+
+func newTestAuthenticator(t *testing.T) *auth.TokenManager {
+	t.Helper()
+	tokens, err := auth.NewHS256TokenManager([]byte("test-secret-de-al-menos-32-bytes!!"), "test", time.Minute)
+	assert.NoError(t, err)
+	return tokens
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	tokens := newTestAuthenticator(t)
+
+	// Handler protegido que devuelve el usuario del contexto
+	var gotUserID int
+	protected := authMiddleware(tokens, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID, _ = auth.UserIDFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("sin header Authorization devuelve 401", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/posts", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Header().Get("WWW-Authenticate"), "Bearer")
+	})
+
+	t.Run("el header X-User-ID ya no autentica", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+		req.Header.Set("X-User-ID", "1")
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("token inválido devuelve 401", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+		req.Header.Set("Authorization", "Bearer no-es-un-jwt")
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		var response handlers.ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, handlers.CodeUnauthorized, response.Code)
+	})
+
+	t.Run("token válido pone el usuario en el contexto", func(t *testing.T) {
+		token, _, err := tokens.Issue(42, "sesion-1")
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		protected.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 42, gotUserID)
+	})
+}
+
+// failingAuthenticator simula una caída de la base de sesiones
+type failingAuthenticator struct{}
+
+func (failingAuthenticator) Authenticate(context.Context, string) (*auth.Principal, error) {
+	return nil, errors.New("pq: connection refused")
+}
+
+// apiTokenAuthenticator simula un token de API con scope posts:write
+type apiTokenAuthenticator struct{}
+
+func (apiTokenAuthenticator) Authenticate(context.Context, string) (*auth.Principal, error) {
+	return &auth.Principal{UserID: 7, APITokenID: 3, Scopes: []string{"posts:write"}}, nil
+}
+
+func TestAuthMiddleware_ScopesDeTokensDeAPI(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		scope      string
+		wantStatus int
+	}{
+		{"con el scope pedido", "posts:write", http.StatusOK},
+		{"sin el scope pedido", "comments:write", http.StatusForbidden},
+		{"ruta solo para sesiones", "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+			req.Header.Set("Authorization", "Bearer pat_cualquiera")
+			w := httptest.NewRecorder()
+
+			authMiddleware(apiTokenAuthenticator{}, tt.scope)(ok).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusForbidden {
+				var response handlers.ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, handlers.CodeForbidden, response.Code)
+				assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+// readOnlyTokenAuthenticator simula un token de API con solo los scopes de lectura
+type readOnlyTokenAuthenticator struct{}
+
+func (readOnlyTokenAuthenticator) Authenticate(context.Context, string) (*auth.Principal, error) {
+	return &auth.Principal{UserID: 7, APITokenID: 4, Scopes: []string{models.ScopePostsRead, models.ScopeCommentsRead}}, nil
+}
+
+// TestSetup_ScopesDeLecturaReservados: posts:read y comments:read se pueden otorgar
+// pero ninguna ruta los pide; las lecturas son públicas y el token no habilita nada más
+func TestSetup_ScopesDeLecturaReservados(t *testing.T) {
+	mockPostService := new(mocks.MockPostService)
+	mockAPITokenService := new(mocks.MockAPITokenService)
+	router := Setup(nil, handlers.NewPostHandler(mockPostService), nil, nil, handlers.NewAPITokenHandler(mockAPITokenService), nil, readOnlyTokenAuthenticator{}, Options{})
+
+	mockPostService.On("GetAllPosts", mock.Anything, mock.Anything).Return(&pagination.Page[*models.Post]{Items: []*models.Post{}}, nil)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"lectura pública", http.MethodGet, "/api/posts", http.StatusOK},
+		{"crear post", http.MethodPost, "/api/posts", http.StatusForbidden},
+		{"comentar", http.MethodPost, "/api/posts/1/comments", http.StatusForbidden},
+		{"ruta solo para sesiones", http.MethodGet, "/api/me/tokens", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Authorization", "Bearer pat_solo-lectura")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+
+	mockPostService.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything, mock.Anything)
+	mockPostService.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	mockAPITokenService.AssertNotCalled(t, "List", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAuthMiddleware_ErrorDeInfraestructura(t *testing.T) {
+	protected := authMiddleware(failingAuthenticator{}, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+	req.Header.Set("Authorization", "Bearer cualquiera")
+	w := httptest.NewRecorder()
+	protected.ServeHTTP(w, req)
+
+	// No es un token inválido: no debe pedir credenciales ni filtrar el error
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+	assert.NotContains(t, w.Body.String(), "pq:")
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Run("agrega un deadline al contexto", func(t *testing.T) {
+		var hasDeadline bool
+		handler := timeoutMiddleware(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+
+		assert.True(t, hasDeadline)
+	})
+
+	t.Run("con 0 no limita el request", func(t *testing.T) {
+		var hasDeadline bool
+		handler := timeoutMiddleware(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, hasDeadline = r.Context().Deadline()
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+
+		assert.False(t, hasDeadline)
+	})
+}
+
+func TestRequestLogMiddleware(t *testing.T) {
+	tokens := newTestAuthenticator(t)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	// Handler que loguea con el logger del contexto, como lo haría un service
+	var handlerCalled bool
+	handler := requestLogMiddleware(logger)(authMiddleware(tokens, "")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerCalled = true
+		logging.FromContext(r.Context()).Error("falla del repositorio")
+		w.WriteHeader(http.StatusCreated)
+	})))
+
+	lines := func() []map[string]interface{} {
+		var entries []map[string]interface{}
+		for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+			var entry map[string]interface{}
+			assert.NoError(t, json.Unmarshal(line, &entry))
+			entries = append(entries, entry)
+		}
+		logs.Reset()
+		return entries
+	}
+
+	t.Run("propaga el X-Request-ID y lo comparte con los logs internos", func(t *testing.T) {
+		token, _, err := tokens.Issue(42, "sesion-1")
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("X-Request-ID", "abc-123")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		assert.True(t, handlerCalled)
+		assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+
+		entries := lines()
+		assert.Len(t, entries, 2)
+
+		// Primero el log del handler, después la línea del request
+		assert.Equal(t, "falla del repositorio", entries[0]["msg"])
+		assert.Equal(t, "abc-123", entries[0]["request_id"])
+		assert.Equal(t, float64(42), entries[0]["user_id"])
+
+		assert.Equal(t, "request", entries[1]["msg"])
+		assert.Equal(t, "abc-123", entries[1]["request_id"])
+		assert.Equal(t, "POST", entries[1]["method"])
+		assert.Equal(t, "/api/posts", entries[1]["path"])
+		assert.Equal(t, float64(http.StatusCreated), entries[1]["status"])
+		assert.Equal(t, float64(42), entries[1]["user_id"])
+		assert.Contains(t, entries[1], "duration_ms")
+	})
+
+	t.Run("genera un ID si falta o no es válido", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/posts", nil)
+		req.Header.Set("X-Request-ID", "inyección\nde logs")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		requestID := w.Header().Get("X-Request-ID")
+		assert.True(t, logging.ValidRequestID(requestID))
+		assert.NotContains(t, requestID, "inyección")
+
+		// Sin token: 401 sin usuario en la línea del request
+		entries := lines()
+		assert.Len(t, entries, 1)
+		assert.Equal(t, requestID, entries[0]["request_id"])
+		assert.Equal(t, float64(http.StatusUnauthorized), entries[0]["status"])
+		assert.NotContains(t, entries[0], "user_id")
+	})
+}
+
+func TestMetricsMiddleware_UsaElTemplateDeLaRuta(t *testing.T) {
+	m := metrics.New(nil)
+	router := mux.NewRouter()
+	router.Use(metricsMiddleware(m))
+	router.HandleFunc("/api/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+	router.Handle("/metrics", m.Handler()).Methods("GET")
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/posts/7", nil))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/posts/8", nil))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Un solo label por ruta, no uno por ID
+	assert.Contains(t, w.Body.String(), `ingsw3_http_requests_total{method="GET",route="/api/posts/{id}",status="404"} 2`)
+	assert.NotContains(t, w.Body.String(), "/api/posts/7")
+}
+
+func TestTracingMiddleware_ContinuaElTraceparent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	router := mux.NewRouter()
+	router.Use(tracingMiddleware)
+	router.HandleFunc("/api/posts/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}).Methods("GET")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/posts/7", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal(t, "GET /api/posts/{id}", span.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+}
+
+func TestRealIPMiddleware(t *testing.T) {
+	trusted, err := ratelimit.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	assert.NoError(t, err)
+
+	var gotRemoteAddr string
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotRemoteAddr = r.RemoteAddr
+	})
+
+	tests := []struct {
+		name       string
+		trusted    []netip.Prefix
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{"desde el proxy de confianza", trusted, "10.0.0.2:4000", "203.0.113.7", "203.0.113.7"},
+		{"conexión directa ignora el header", trusted, "198.51.100.9:4000", "203.0.113.7", "198.51.100.9"},
+		{"sin proxies configurados no cambia nada", nil, "10.0.0.2:4000", "203.0.113.7", "10.0.0.2:4000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+
+			realIPMiddleware(tt.trusted)(echo).ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, gotRemoteAddr)
+		})
+	}
+}
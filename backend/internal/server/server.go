@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Config son los límites del servidor HTTP y los plazos del apagado
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration // protege contra clientes que mandan los headers de a poco
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration // debe superar el plazo por request del router
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+
+	// DrainDelay es cuánto se espera con la readiness en falla antes de cerrar,
+	// para que el orquestador deje de mandar tráfico nuevo a esta instancia
+	DrainDelay time.Duration
+	// ShutdownTimeout es el máximo que se espera a que terminen los requests en curso
+	ShutdownTimeout time.Duration
+}
+
+// DefaultConfig devuelve valores razonables para producción
+func DefaultConfig(addr string) Config {
+	return Config{
+		Addr:              addr,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       15 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		DrainDelay:        5 * time.Second,
+		ShutdownTimeout:   20 * time.Second,
+	}
+}
+
+// Server envuelve http.Server con apagado ordenado
+type Server struct {
+	config Config
+	ready  atomic.Bool
+}
+
+// New crea un servidor; todavía no escucha ni está listo
+func New(config Config) *Server {
+	return &Server{config: config}
+}
+
+// Ready indica si la instancia acepta tráfico nuevo (falso antes de arrancar y durante el apagado)
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// Run escucha en config.Addr y atiende con handler hasta que se cancele ctx
+func (s *Server) Run(ctx context.Context, handler http.Handler) error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener, handler)
+}
+
+// Serve atiende en listener hasta que se cancele ctx. Entonces:
+//  1. marca la instancia como no lista (readiness en falla)
+//  2. espera DrainDelay para que el balanceador la saque de rotación
+//  3. deja de aceptar conexiones y espera hasta ShutdownTimeout a los requests en curso
+func (s *Server) Serve(ctx context.Context, listener net.Listener, handler http.Handler) error {
+	httpServer := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: s.config.ReadHeaderTimeout,
+		ReadTimeout:       s.config.ReadTimeout,
+		WriteTimeout:      s.config.WriteTimeout,
+		IdleTimeout:       s.config.IdleTimeout,
+		MaxHeaderBytes:    s.config.MaxHeaderBytes,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(listener)
+	}()
+	s.ready.Store(true)
+
+	select {
+	case err := <-serveErr:
+		s.ready.Store(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	s.ready.Store(false)
+	slog.Info("Apagando el servidor: readiness en falla", "drain_delay", s.config.DrainDelay.String())
+	time.Sleep(s.config.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		httpServer.Close()
+		return fmt.Errorf("no terminaron todos los requests en %s: %w", s.config.ShutdownTimeout, err)
+	}
+
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("Servidor detenido")
+	return nil
+}
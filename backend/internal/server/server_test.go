@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig usa plazos cortos para que los tests no esperen
+func testConfig() Config {
+	config := DefaultConfig("127.0.0.1:0")
+	config.DrainDelay = 10 * time.Millisecond
+	config.ShutdownTimeout = time.Second
+	return config
+}
+
+func startServer(t *testing.T, srv *Server, handler http.Handler) (string, context.CancelFunc, <-chan error) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(ctx, listener, handler)
+	}()
+
+	return "http://" + listener.Addr().String(), cancel, done
+}
+
+func TestServe_ApagadoOrdenado(t *testing.T) {
+	srv := New(testConfig())
+	assert.False(t, srv.Ready(), "no está listo antes de arrancar")
+
+	url, cancel, done := startServer(t, srv, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.True(t, srv.Ready())
+
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("el servidor no se detuvo")
+	}
+	assert.False(t, srv.Ready())
+}
+
+func TestServe_EsperaLosRequestsEnCurso(t *testing.T) {
+	srv := New(testConfig())
+
+	started := make(chan struct{})
+	url, cancel, done := startServer(t, srv, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "terminado")
+	}))
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+
+	// El apagado empieza con el request todavía en curso
+	<-started
+	cancel()
+
+	got := <-response
+	require.NoError(t, got.err)
+	assert.Equal(t, "terminado", got.body)
+	assert.NoError(t, <-done)
+}
+
+func TestServe_ReadinessEnFallaDuranteElDrenado(t *testing.T) {
+	config := testConfig()
+	config.DrainDelay = 300 * time.Millisecond
+	srv := New(config)
+
+	_, cancel, done := startServer(t, srv, http.NotFoundHandler())
+	require.Eventually(t, srv.Ready, time.Second, 5*time.Millisecond)
+
+	cancel()
+
+	// Mientras drena la readiness ya falla, aunque todavía no se cerró
+	assert.Eventually(t, func() bool { return !srv.Ready() }, 100*time.Millisecond, 5*time.Millisecond)
+	assert.NoError(t, <-done)
+}
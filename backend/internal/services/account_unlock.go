@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/mail"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// ErrInvalidUnlockToken se muestra tanto si el token no existe como si venció o ya se usó
+const ErrInvalidUnlockToken = "el enlace para desbloquear la cuenta es inválido o expiró"
+
+// errAccountUnlockDisabled indica que el AuthService se armó sin WithAccountUnlock o sin bloqueo de logins
+var errAccountUnlockDisabled = errors.New("desbloqueo de cuenta por mail no configurado")
+
+// AccountUnlockConfig son la vigencia de los links y la URL que los recibe
+type AccountUnlockConfig struct {
+	TTL time.Duration
+	URL string // se le agrega ?token=...
+}
+
+// AccountUnlock emite y canjea los links de un solo uso que levantan el
+// bloqueo por logins fallidos. Llegan al mail de la cuenta: quien lo lee
+// demuestra ser el dueño sin necesidad de una sesión abierta.
+type AccountUnlock struct {
+	tokens repository.UserTokenRepository
+	mailer mail.Mailer
+	config AccountUnlockConfig
+	now    func() time.Time
+}
+
+// NewAccountUnlock crea una nueva instancia
+func NewAccountUnlock(tokens repository.UserTokenRepository, mailer mail.Mailer, config AccountUnlockConfig) *AccountUnlock {
+	return &AccountUnlock{
+		tokens: tokens,
+		mailer: mailer,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// WithClock reemplaza el reloj (para tests)
+func (u *AccountUnlock) WithClock(now func() time.Time) *AccountUnlock {
+	u.now = now
+	return u
+}
+
+// issue invalida los links anteriores del usuario y emite uno nuevo
+func (u *AccountUnlock) issue(ctx context.Context, user *models.User) (string, error) {
+	return issueUserToken(ctx, u.tokens, user.ID, models.TokenPurposeAccountUnlock, u.now().Add(u.config.TTL))
+}
+
+// link arma la URL con el token
+func (u *AccountUnlock) link(token string) string {
+	return tokenLink(u.config.URL, token)
+}
+
+// RequestUnlock manda por mail un link para levantar el bloqueo de la cuenta.
+// Como ForgotPassword, responde igual y enseguida exista o no la cuenta, y
+// esté o no bloqueada: el resto sigue en segundo plano.
+func (s *AuthService) RequestUnlock(ctx context.Context, req *models.UnlockRequest) error {
+	ctx, span := startSpan(ctx, "AuthService.RequestUnlock")
+	defer span.End()
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" {
+		return invalid("email", "el email es requerido")
+	}
+	if s.unlock == nil || s.throttle == nil {
+		return errAccountUnlockDisabled
+	}
+
+	go s.sendUnlockLink(context.WithoutCancel(ctx), email)
+	return nil
+}
+
+// sendUnlockLink emite el token y manda el mail solo si la cuenta está
+// bloqueada; los errores solo quedan en el log
+func (s *AuthService) sendUnlockLink(ctx context.Context, email string) {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+	ctx, span := startSpan(ctx, "AuthService.sendUnlockLink")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	locked, err := s.throttle.isLocked(ctx, email)
+	if err != nil {
+		logger.Error("No se pudo consultar el bloqueo de la cuenta", "error", err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		logger.Error("No se pudo buscar al usuario para desbloquear la cuenta", "error", err)
+		return
+	}
+	// Un usuario suspendido no recupera el acceso por esta vía
+	if user == nil || user.IsBanned() {
+		return
+	}
+
+	token, err := s.unlock.issue(ctx, user)
+	if err != nil {
+		logger.Error("No se pudo emitir el token para desbloquear la cuenta", "user_id", user.ID, "error", err)
+		return
+	}
+
+	err = s.unlock.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Desbloqueá tu cuenta",
+		Body: fmt.Sprintf("Hola %s,\n\n"+
+			"Tu cuenta quedó bloqueada por varios intentos fallidos de inicio de sesión. Para desbloquearla, entrá a:\n\n"+
+			"%s\n\n"+
+			"El enlace vence en %s y sirve una sola vez. Si los intentos no fueron tuyos, restablecé tu contraseña.\n",
+			user.Username, s.unlock.link(token), humanWait(s.unlock.config.TTL)),
+	})
+	if err != nil {
+		logger.Error("No se pudo enviar el mail para desbloquear la cuenta", "user_id", user.ID, "error", err)
+	}
+}
+
+// UnlockAccount canjea el token del mail y levanta el bloqueo por logins fallidos
+func (s *AuthService) UnlockAccount(ctx context.Context, token string) error {
+	ctx, span := startSpan(ctx, "AuthService.UnlockAccount")
+	defer span.End()
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return invalid("token", "el token es requerido")
+	}
+	if s.unlock == nil || s.throttle == nil {
+		return errAccountUnlockDisabled
+	}
+
+	// Token vigente y sin usar (queda usado aunque algo falle después)
+	record, err := s.unlock.tokens.Consume(ctx, models.TokenPurposeAccountUnlock, auth.HashToken(token), s.unlock.now())
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return invalid("token", ErrInvalidUnlockToken)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, record.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.IsBanned() {
+		return invalid("token", ErrInvalidUnlockToken)
+	}
+
+	return s.throttle.Unlock(ctx, user.Email)
+}
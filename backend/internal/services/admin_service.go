@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"slices"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// AdminServiceInterface define las operaciones de administración de usuarios
+type AdminServiceInterface interface {
+	AssignRole(ctx context.Context, actorID int, targetID int, role string) (*models.User, error)
+	BanUser(ctx context.Context, actorID int, targetID int) (*models.User, error)
+	UnbanUser(ctx context.Context, actorID int, targetID int) (*models.User, error)
+	UnlockUser(ctx context.Context, actorID int, targetID int) (*models.User, error)
+}
+
+// AdminService maneja roles y suspensiones de usuarios
+type AdminService struct {
+	userRepo    repository.UserRepository
+	sessionRepo repository.SessionRepository
+	policy      Policy
+	throttle    *LoginThrottle
+	apiTokens   repository.APITokenRepository
+
+	// mfaRequiredRoles son los roles que solo administran con 2FA activado
+	mfaRequiredRoles []string
+}
+
+// NewAdminService crea una nueva instancia
+func NewAdminService(userRepo repository.UserRepository, sessionRepo repository.SessionRepository) *AdminService {
+	return &AdminService{
+		userRepo:    userRepo,
+		sessionRepo: sessionRepo,
+		policy:      NewRolePolicy(),
+	}
+}
+
+// WithLoginThrottle permite levantar los bloqueos por logins fallidos
+func (s *AdminService) WithLoginThrottle(throttle *LoginThrottle) *AdminService {
+	s.throttle = throttle
+	return s
+}
+
+// WithAPITokenRepository permite revocar los tokens de API de un usuario suspendido
+func (s *AdminService) WithAPITokenRepository(repo repository.APITokenRepository) *AdminService {
+	s.apiTokens = repo
+	return s
+}
+
+// WithMFARequiredRoles exige la verificación en dos pasos a quienes administren con esos roles
+func (s *AdminService) WithMFARequiredRoles(roles ...string) *AdminService {
+	s.mfaRequiredRoles = roles
+	return s
+}
+
+// AssignRole cambia el rol de un usuario (solo admins)
+func (s *AdminService) AssignRole(ctx context.Context, actorID int, targetID int, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, invalid("role", "rol inválido: usar user, moderator o admin")
+	}
+
+	actor, target, err := s.actorAndTarget(ctx, actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.policy.Can(actor, ActionAssignRole, Target{OwnerID: target.ID, OwnerRole: target.Role}) {
+		return nil, forbidden("no tienes permiso para cambiar roles")
+	}
+
+	if err := s.userRepo.UpdateRole(ctx, target.ID, role); err != nil {
+		return nil, err
+	}
+
+	target.Role = role
+	return target, nil
+}
+
+// BanUser suspende a un usuario, cierra todas sus sesiones y revoca sus tokens de API
+func (s *AdminService) BanUser(ctx context.Context, actorID int, targetID int) (*models.User, error) {
+	actor, target, err := s.actorAndTarget(ctx, actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.policy.Can(actor, ActionBanUser, Target{OwnerID: target.ID, OwnerRole: target.Role}) {
+		return nil, forbidden("no tienes permiso para suspender a este usuario")
+	}
+
+	if err := s.userRepo.SetBanned(ctx, target.ID, true); err != nil {
+		return nil, err
+	}
+
+	// Sin sesiones, sus tokens de acceso dejan de valer de inmediato
+	if err := s.sessionRepo.RevokeAllForUser(ctx, target.ID); err != nil {
+		return nil, err
+	}
+	if s.apiTokens != nil {
+		if err := s.apiTokens.RevokeAllForUser(ctx, target.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.userRepo.FindByID(ctx, target.ID)
+}
+
+// UnbanUser levanta la suspensión de un usuario
+func (s *AdminService) UnbanUser(ctx context.Context, actorID int, targetID int) (*models.User, error) {
+	actor, target, err := s.actorAndTarget(ctx, actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.policy.Can(actor, ActionBanUser, Target{OwnerID: target.ID, OwnerRole: target.Role}) {
+		return nil, forbidden("no tienes permiso para rehabilitar a este usuario")
+	}
+
+	if err := s.userRepo.SetBanned(ctx, target.ID, false); err != nil {
+		return nil, err
+	}
+
+	target.BannedAt = nil
+	return target, nil
+}
+
+// UnlockUser levanta el bloqueo por logins fallidos de un usuario
+func (s *AdminService) UnlockUser(ctx context.Context, actorID int, targetID int) (*models.User, error) {
+	actor, target, err := s.actorAndTarget(ctx, actorID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.policy.Can(actor, ActionBanUser, Target{OwnerID: target.ID, OwnerRole: target.Role}) {
+		return nil, forbidden("no tienes permiso para desbloquear a este usuario")
+	}
+
+	if s.throttle != nil {
+		if err := s.throttle.Unlock(ctx, target.Email); err != nil {
+			return nil, err
+		}
+	}
+
+	return target, nil
+}
+
+// actorAndTarget busca a quien realiza la acción y al usuario afectado
+func (s *AdminService) actorAndTarget(ctx context.Context, actorID int, targetID int) (*models.User, *models.User, error) {
+	actor, err := s.userRepo.FindByID(ctx, actorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actor == nil {
+		return nil, nil, notFound(ErrUserNotFound)
+	}
+	if !actor.MFAEnabled && slices.Contains(s.mfaRequiredRoles, actor.Role) {
+		return nil, nil, mfaSetupRequired("activá la verificación en dos pasos para administrar usuarios")
+	}
+
+	target, err := s.userRepo.FindByID(ctx, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if target == nil {
+		return nil, nil, notFound(ErrUserNotFound)
+	}
+
+	return actor, target, nil
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/repository"
+)
+
+// APITokenServiceInterface define las operaciones sobre los tokens de API personales
+type APITokenServiceInterface interface {
+	Create(ctx context.Context, userID int, req *models.CreateAPITokenRequest) (*models.CreatedAPIToken, error)
+	List(ctx context.Context, userID int, page pagination.Params) (*pagination.Page[*models.APIToken], error)
+	Revoke(ctx context.Context, userID int, id int) error
+}
+
+// Constantes para mensajes de error de tokens de API
+const (
+	ErrInvalidAPIToken  = "token de API inválido, vencido o revocado"
+	ErrAPITokenNotFound = "token de API no encontrado"
+)
+
+// maxAPITokenNameLength es el largo máximo del nombre (columna VARCHAR(100))
+const maxAPITokenNameLength = 100
+
+// apiTokenTouchInterval evita escribir last_used_at en cada request de un script
+const apiTokenTouchInterval = time.Minute
+
+// APITokenConfig es la vigencia de los tokens de API
+type APITokenConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// APITokenService maneja los tokens de API personales: los crea, los lista,
+// los revoca y autentica los requests que los usan
+type APITokenService struct {
+	repo   repository.APITokenRepository
+	config APITokenConfig
+	now    func() time.Time
+}
+
+// NewAPITokenService crea una nueva instancia
+func NewAPITokenService(repo repository.APITokenRepository, config APITokenConfig) *APITokenService {
+	return &APITokenService{
+		repo:   repo,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// WithClock reemplaza el reloj (para tests)
+func (s *APITokenService) WithClock(now func() time.Time) *APITokenService {
+	s.now = now
+	return s
+}
+
+// Create crea un token con nombre, scopes y vencimiento.
+// El token completo solo aparece en esta respuesta.
+func (s *APITokenService) Create(ctx context.Context, userID int, req *models.CreateAPITokenRequest) (*models.CreatedAPIToken, error) {
+	// Validación 1: Nombre
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, invalid("name", "el nombre es requerido")
+	}
+	if len([]rune(name)) > maxAPITokenNameLength {
+		return nil, invalid("name", "el nombre no puede superar los 100 caracteres")
+	}
+
+	// Validación 2: Al menos un scope y todos conocidos
+	if len(req.Scopes) == 0 {
+		return nil, invalid("scopes", "indicá al menos un scope")
+	}
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.IsValidScope(scope) {
+			return nil, invalid("scopes", "scope desconocido: "+scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	// Validación 3: Vencimiento dentro del máximo
+	ttl := s.config.DefaultTTL
+	if req.ExpiresInDays != 0 {
+		ttl = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	if req.ExpiresInDays < 0 || ttl > s.config.MaxTTL {
+		maxDays := int(s.config.MaxTTL / (24 * time.Hour))
+		return nil, invalid("expires_in_days", fmt.Sprintf("el vencimiento debe estar entre 1 y %d días", maxDays))
+	}
+
+	token, prefix, err := auth.NewAPIToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: s.now().Add(ttl),
+	}
+	if err := s.repo.Create(ctx, record, auth.HashToken(token)); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAPIToken{APIToken: record, Token: token}, nil
+}
+
+// List devuelve una página de tokens no revocados del usuario, sin el token en sí
+func (s *APITokenService) List(ctx context.Context, userID int, page pagination.Params) (*pagination.Page[*models.APIToken], error) {
+	page = page.Normalize()
+
+	tokens, err := s.repo.FindActiveByUserID(ctx, userID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	return pagination.NewPage(tokens, page.Limit, apiTokenCursor), nil
+}
+
+func apiTokenCursor(token *models.APIToken) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: token.CreatedAt, ID: token.ID}
+}
+
+// Revoke revoca un token del usuario; deja de autenticar en el request siguiente
+func (s *APITokenService) Revoke(ctx context.Context, userID int, id int) error {
+	revoked, err := s.repo.Revoke(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return notFound(ErrAPITokenNotFound)
+	}
+	return nil
+}
+
+// Authenticate valida un token de API y devuelve la identidad con sus scopes
+func (s *APITokenService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	record, err := s.repo.FindByHash(ctx, auth.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if record == nil || !record.IsUsable(now) {
+		return nil, unauthorized(ErrInvalidAPIToken)
+	}
+
+	// Un usuario suspendido no puede seguir operando con sus tokens
+	if record.OwnerBanned {
+		return nil, unauthorized(ErrInvalidAPIToken)
+	}
+
+	if record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) >= apiTokenTouchInterval {
+		if err := s.repo.TouchLastUsed(ctx, record.ID, now); err != nil {
+			logging.FromContext(ctx).Error("No se pudo registrar el uso del token de API", "api_token_id", record.ID, "error", err)
+		}
+	}
+
+	return &auth.Principal{
+		UserID:     record.UserID,
+		APITokenID: record.ID,
+		Scopes:     record.Scopes,
+	}, nil
+}
@@ -1,106 +1,357 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
 
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
 	"ingsw3-tp08/internal/models"
 	"ingsw3-tp08/internal/repository"
 )
 
 // AuthServiceInterface define las operaciones del servicio de autenticación
 type AuthServiceInterface interface {
-	Register(req *models.RegisterRequest) (*models.User, error)
-	Login(creds *models.Credentials) (*models.User, error)
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error)
+	Login(ctx context.Context, creds *models.Credentials, client models.ClientInfo) (*models.User, error)
+	RequestUnlock(ctx context.Context, req *models.UnlockRequest) error
+	UnlockAccount(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error
+	VerifyEmail(ctx context.Context, token string) error
+	ResendVerification(ctx context.Context, userID int) error
+	VerifyMFA(ctx context.Context, req *models.MFALoginRequest, client models.ClientInfo) (*models.User, error)
+	BeginTOTPEnrollment(ctx context.Context, userID int) (*models.TOTPEnrollment, error)
+	ConfirmTOTPEnrollment(ctx context.Context, userID int, req *models.MFACodeRequest) (*models.RecoveryCodes, error)
+	DisableTOTP(ctx context.Context, userID int, req *models.MFACodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, userID int, req *models.MFACodeRequest) (*models.RecoveryCodes, error)
 }
 
 // AuthService maneja la lógica de autenticación
 type AuthService struct {
 	userRepo repository.UserRepository
+	hasher   auth.PasswordHasher
+	events   Events
+	throttle *LoginThrottle
+	reset    *PasswordReset
+	unlock   *AccountUnlock
+	verify   *EmailVerification
+	mfa      *TwoFactor
+
+	// dummyHash se compara cuando el email no existe, para que la respuesta
+	// tarde lo mismo que con una contraseña incorrecta
+	dummyHashOnce sync.Once
+	dummyHash     string
 }
 
 // NewAuthService crea una nueva instancia
-func NewAuthService(userRepo repository.UserRepository) *AuthService {
+func NewAuthService(userRepo repository.UserRepository, hasher auth.PasswordHasher) *AuthService {
 	return &AuthService{
 		userRepo: userRepo,
+		hasher:   hasher,
+		events:   noEvents{},
 	}
 }
 
+// WithEvents registra el receptor de eventos de negocio (registros y logins fallidos)
+func (s *AuthService) WithEvents(events Events) *AuthService {
+	if events != nil {
+		s.events = events
+	}
+	return s
+}
+
+// WithLoginThrottle activa las demoras y el bloqueo ante logins fallidos
+func (s *AuthService) WithLoginThrottle(throttle *LoginThrottle) *AuthService {
+	s.throttle = throttle
+	return s
+}
+
+// WithPasswordReset activa la recuperación de contraseña por mail
+func (s *AuthService) WithPasswordReset(reset *PasswordReset) *AuthService {
+	s.reset = reset
+	return s
+}
+
+// WithAccountUnlock activa el desbloqueo de la cuenta con un link por mail
+func (s *AuthService) WithAccountUnlock(unlock *AccountUnlock) *AuthService {
+	s.unlock = unlock
+	return s
+}
+
+// WithEmailVerification manda el link de verificación al registrarse
+func (s *AuthService) WithEmailVerification(verify *EmailVerification) *AuthService {
+	s.verify = verify
+	return s
+}
+
+// WithTwoFactor activa la verificación en dos pasos con TOTP
+func (s *AuthService) WithTwoFactor(mfa *TwoFactor) *AuthService {
+	s.mfa = mfa
+	return s
+}
+
 // Register registra un nuevo usuario
 // Aquí validamos las reglas de negocio
-func (s *AuthService) Register(req *models.RegisterRequest) (*models.User, error) {
+func (s *AuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	ctx, span := startSpan(ctx, "AuthService.Register")
+	defer span.End()
+
 	// Validación 1: Email no puede estar vacío
 	if strings.TrimSpace(req.Email) == "" {
-		return nil, errors.New("el email es requerido")
+		return nil, invalid("email", "el email es requerido")
 	}
 
 	// Validación 2: Email debe contener @
 	if !strings.Contains(req.Email, "@") {
-		return nil, errors.New("el email debe ser válido")
+		return nil, invalid("email", "el email debe ser válido")
 	}
 
-	// Validación 3: Password debe tener al menos 6 caracteres
-	if len(req.Password) < 6 {
-		return nil, errors.New("la contraseña debe tener al menos 6 caracteres")
+	// Validación 3: Password entre 6 caracteres y el máximo de bcrypt
+	if err := validatePassword(req.Password); err != nil {
+		return nil, err
 	}
 
 	// Validación 4: Username no puede estar vacío
 	if strings.TrimSpace(req.Username) == "" {
-		return nil, errors.New("el nombre de usuario es requerido")
+		return nil, invalid("username", "el nombre de usuario es requerido")
 	}
 
 	// Validación 5: Verificar que el email no esté registrado
-	existingUser, err := s.userRepo.FindByEmail(req.Email)
+	existingUser, err := s.userRepo.FindByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("el email ya está registrado")
+		return nil, conflict("el email ya está registrado")
+	}
+
+	// Nunca se guarda la contraseña en texto plano
+	hash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return nil, err
 	}
 
 	// Crear el usuario
 	user := &models.User{
 		Email:    strings.ToLower(strings.TrimSpace(req.Email)),
-		Password: req.Password, // En producción: hashear con bcrypt
+		Password: hash,
 		Username: strings.TrimSpace(req.Username),
+		Role:     models.RoleUser,
 	}
 
-	err = s.userRepo.Create(user)
+	err = s.userRepo.Create(ctx, user)
 	if err != nil {
 		return nil, err
 	}
 
+	s.events.UserRegistered()
+
+	// El mail sale en segundo plano: un SMTP lento no demora el registro
+	if s.verify != nil {
+		s.startVerification(ctx, user)
+	}
 	return user, nil
 }
 
-// Login autentica un usuario
-func (s *AuthService) Login(creds *models.Credentials) (*models.User, error) {
+// Login autentica un usuario. client identifica la IP para frenar la fuerza bruta.
+// Si la cuenta tiene verificación en dos pasos devuelve *MFARequired con el
+// desafío para VerifyMFA en lugar del usuario.
+func (s *AuthService) Login(ctx context.Context, creds *models.Credentials, client models.ClientInfo) (*models.User, error) {
+	ctx, span := startSpan(ctx, "AuthService.Login")
+	defer span.End()
+
 	// Validación 1: Email no puede estar vacío
 	if strings.TrimSpace(creds.Email) == "" {
-		return nil, errors.New("el email es requerido")
+		return nil, invalid("email", "el email es requerido")
 	}
 
 	// Validación 2: Password no puede estar vacío
 	if creds.Password == "" {
-		return nil, errors.New("la contraseña es requerida")
+		return nil, invalid("password", "la contraseña es requerida")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(creds.Email))
+
+	// Validación 3: Ni la cuenta ni la IP bloqueadas por intentos fallidos.
+	// El intento se cuenta como fallo antes de comprobar la contraseña, así no se
+	// pueden mandar muchos a la vez. El bloqueo es por email aunque no exista,
+	// así no revela qué cuentas hay.
+	attempt, err := s.reserveLoginAttempt(ctx, email, client)
+	if err != nil {
+		return nil, err
 	}
 
 	// Buscar usuario por email
-	user, err := s.userRepo.FindByEmail(strings.ToLower(strings.TrimSpace(creds.Email)))
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
+		s.releaseLoginAttempt(ctx, attempt)
 		return nil, err
 	}
 
-	// Validación 3: Usuario debe existir
+	// Validación 4: Usuario debe existir
 	if user == nil {
-		return nil, errors.New("credenciales inválidas")
+		// Se compara igual contra un hash para no revelar por el tiempo de respuesta
+		// que el email no está registrado
+		s.hasher.Compare(s.getDummyHash(), creds.Password)
+		s.loginFailed(ctx, attempt, LoginFailureUnknownUser)
+		return nil, unauthorized("credenciales inválidas")
+	}
+
+	// Validación 5: Password debe coincidir
+	ok, err := s.checkPassword(ctx, user, creds.Password)
+	if err != nil {
+		s.releaseLoginAttempt(ctx, attempt)
+		return nil, err
+	}
+	if !ok {
+		s.loginFailed(ctx, attempt, LoginFailureBadPassword)
+		return nil, unauthorized("credenciales inválidas")
+	}
+
+	// Con 2FA la contraseña sola no alcanza: los intentos fallidos se reinician
+	// recién con el código, para que no se pueda probar códigos sin límite.
+	// Hasta entonces solo se descuenta este intento.
+	if attempt != nil {
+		var err error
+		if user.MFAEnabled {
+			err = s.throttle.release(ctx, attempt)
+		} else {
+			err = s.throttle.succeeded(ctx, attempt)
+		}
+		if err != nil {
+			logging.FromContext(ctx).Error("No se pudieron reiniciar los intentos fallidos", "user_id", user.ID, "error", err)
+		}
+	}
+
+	// Validación 6: Usuario no suspendido
+	if user.IsBanned() {
+		s.events.LoginFailed(LoginFailureBanned)
+		return nil, forbidden("tu cuenta está suspendida")
 	}
 
-	// Validación 4: Password debe coincidir
-	// En producción: usar bcrypt.CompareHashAndPassword
-	if user.Password != creds.Password {
-		return nil, errors.New("credenciales inválidas")
+	// Validación 7: Segundo factor
+	if user.MFAEnabled {
+		if s.mfa == nil {
+			return nil, errTwoFactorDisabled
+		}
+		challenge, err := s.mfa.challenge(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &MFARequired{Challenge: challenge}
 	}
 
 	return user, nil
 }
+
+// reserveLoginAttempt cuenta el intento contra la cuenta y la IP, o lo rechaza
+// si están bloqueadas. Sin bloqueo de logins configurado devuelve nil.
+func (s *AuthService) reserveLoginAttempt(ctx context.Context, email string, client models.ClientInfo) (*loginAttempt, error) {
+	if s.throttle == nil {
+		return nil, nil
+	}
+	attempt, err := s.throttle.reserve(ctx, email, client.IPAddress)
+	if errors.Is(err, ErrTooManyTries) {
+		s.events.LoginFailed(LoginFailureThrottled)
+	}
+	return attempt, err
+}
+
+// loginFailed registra el evento y aplica el bloqueo si el intento llegó al umbral.
+// Si no se puede bloquear, el login igual responde "credenciales inválidas".
+func (s *AuthService) loginFailed(ctx context.Context, attempt *loginAttempt, reason string) {
+	s.events.LoginFailed(reason)
+	if attempt == nil {
+		return
+	}
+	if err := s.throttle.failed(ctx, attempt); err != nil {
+		logging.FromContext(ctx).Error("No se pudo registrar el login fallido", "error", err)
+	}
+}
+
+// releaseLoginAttempt devuelve el intento reservado cuando el login no llegó a
+// decidir nada (error de la base o del hasher): no es culpa del usuario
+func (s *AuthService) releaseLoginAttempt(ctx context.Context, attempt *loginAttempt) {
+	if attempt == nil {
+		return
+	}
+	if err := s.throttle.release(ctx, attempt); err != nil {
+		logging.FromContext(ctx).Error("No se pudo liberar el intento de login", "error", err)
+	}
+}
+
+// validatePassword aplica el largo mínimo y el máximo que admite bcrypt
+// (72 bytes, no caracteres: las letras con tilde ocupan dos)
+func validatePassword(password string) error {
+	if len(password) < 6 {
+		return invalid("password", "la contraseña debe tener al menos 6 caracteres")
+	}
+	if len(password) > auth.MaxPasswordLength {
+		return invalid("password", fmt.Sprintf("la contraseña no puede superar los %d bytes", auth.MaxPasswordLength))
+	}
+	return nil
+}
+
+// getDummyHash devuelve un hash con el costo actual del hasher
+func (s *AuthService) getDummyHash() string {
+	s.dummyHashOnce.Do(func() {
+		hash, err := s.hasher.Hash("contraseña-de-relleno-para-emails-inexistentes")
+		if err != nil {
+			logging.FromContext(context.Background()).Error("No se pudo generar el hash de relleno", "error", err)
+			return
+		}
+		s.dummyHash = hash
+	})
+	return s.dummyHash
+}
+
+// checkPassword verifica la contraseña del usuario.
+// Si la fila es legacy (texto plano) o el hash quedó con un costo viejo,
+// aprovecha el login exitoso para reescribirla con un hash actual.
+func (s *AuthService) checkPassword(ctx context.Context, user *models.User, password string) (bool, error) {
+	if !auth.IsHashed(user.Password) {
+		if !auth.ComparePlaintext(user.Password, password) {
+			return false, nil
+		}
+		s.upgradePassword(ctx, user, password)
+		return true, nil
+	}
+
+	ok, err := s.hasher.Compare(user.Password, password)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if s.hasher.NeedsRehash(user.Password) {
+		s.upgradePassword(ctx, user, password)
+	}
+
+	return true, nil
+}
+
+// upgradePassword guarda un hash nuevo de la contraseña.
+// Un fallo acá no debe impedir el login: se reintenta en el próximo.
+func (s *AuthService) upgradePassword(ctx context.Context, user *models.User, password string) {
+	// Una contraseña legacy de más de 72 bytes no entra en bcrypt: la fila
+	// queda como está hasta que el usuario la cambie
+	if len(password) > auth.MaxPasswordLength {
+		return
+	}
+
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		logging.FromContext(ctx).Error("No se pudo hashear la contraseña", "user_id", user.ID, "error", err)
+		return
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		logging.FromContext(ctx).Error("No se pudo actualizar la contraseña", "user_id", user.ID, "error", err)
+		return
+	}
+
+	user.Password = hash
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/mail"
+	"ingsw3-tp08/internal/models"
+)
+
+// ErrInvalidVerificationToken se muestra tanto si el token no existe como si venció o ya se usó
+const ErrInvalidVerificationToken = "el enlace de verificación es inválido o expiró"
+
+// errEmailVerificationDisabled indica que el AuthService se armó sin WithEmailVerification
+var errEmailVerificationDisabled = errors.New("verificación de email no configurada")
+
+// EmailVerificationConfig son la vigencia de los links, la URL que los recibe
+// y la espera mínima entre dos reenvíos
+type EmailVerificationConfig struct {
+	TTL            time.Duration
+	URL            string // se le agrega ?token=...
+	ResendCooldown time.Duration
+}
+
+// EmailVerification firma y comprueba los links que confirman el email de una cuenta.
+// Los links no se guardan: la firma cubre el usuario, el email y el vencimiento.
+type EmailVerification struct {
+	signer *auth.EmailLinkSigner
+	mailer mail.Mailer
+	config EmailVerificationConfig
+	now    func() time.Time
+}
+
+// NewEmailVerification crea una nueva instancia
+func NewEmailVerification(signer *auth.EmailLinkSigner, mailer mail.Mailer, config EmailVerificationConfig) *EmailVerification {
+	return &EmailVerification{
+		signer: signer,
+		mailer: mailer,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// WithClock reemplaza el reloj (para tests)
+func (v *EmailVerification) WithClock(now func() time.Time) *EmailVerification {
+	v.now = now
+	return v
+}
+
+// VerifyEmail comprueba la firma del link y marca el email como verificado.
+// Usar el mismo link dos veces no es un error: la fecha de verificación no cambia.
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) error {
+	ctx, span := startSpan(ctx, "AuthService.VerifyEmail")
+	defer span.End()
+
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return invalid("token", "el token es requerido")
+	}
+	if s.verify == nil {
+		return errEmailVerificationDisabled
+	}
+
+	link, err := auth.ParseEmailLink(token)
+	if err != nil || !s.verify.now().Before(link.ExpiresAt) {
+		return invalid("token", ErrInvalidVerificationToken)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, link.UserID)
+	if err != nil {
+		return err
+	}
+	// Si el usuario cambió de email, la firma ya no coincide
+	if user == nil || !s.verify.signer.Verify(token, user.Email) {
+		return invalid("token", ErrInvalidVerificationToken)
+	}
+
+	return s.userRepo.MarkEmailVerified(ctx, user.ID)
+}
+
+// ResendVerification manda un link nuevo al usuario.
+// Entre dos envíos tiene que pasar ResendCooldown; la espera se reserva con un
+// único UPDATE, así que de varios pedidos simultáneos solo uno manda el mail.
+func (s *AuthService) ResendVerification(ctx context.Context, userID int) error {
+	ctx, span := startSpan(ctx, "AuthService.ResendVerification")
+	defer span.End()
+
+	if s.verify == nil {
+		return errEmailVerificationDisabled
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return notFound(ErrUserNotFound)
+	}
+	if user.IsEmailVerified() {
+		return conflict("tu email ya está verificado")
+	}
+
+	now := s.verify.now()
+	cooldown := s.verify.config.ResendCooldown
+	claimed, lastSent, err := s.userRepo.ClaimVerificationEmail(ctx, user.ID, now, now.Add(-cooldown))
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		wait := cooldown
+		if lastSent != nil {
+			wait = lastSent.Add(cooldown).Sub(now)
+		}
+		return tooManyTries(fmt.Sprintf("ya te enviamos un enlace, podés pedir otro en %s", humanWait(wait)), wait)
+	}
+
+	go s.sendVerificationLink(context.WithoutCancel(ctx), user, now)
+	return nil
+}
+
+// startVerification registra el primer envío al registrarse y manda el link en segundo plano
+func (s *AuthService) startVerification(ctx context.Context, user *models.User) {
+	now := s.verify.now()
+	if _, _, err := s.userRepo.ClaimVerificationEmail(ctx, user.ID, now, now); err != nil {
+		logging.FromContext(ctx).Error("No se pudo registrar el envío del mail de verificación", "user_id", user.ID, "error", err)
+		return
+	}
+	go s.sendVerificationLink(context.WithoutCancel(ctx), user, now)
+}
+
+// sendVerificationLink firma el link y manda el mail; los errores solo quedan en el log
+func (s *AuthService) sendVerificationLink(ctx context.Context, user *models.User, issuedAt time.Time) {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+	ctx, span := startSpan(ctx, "AuthService.sendVerificationLink")
+	defer span.End()
+
+	token := s.verify.signer.Sign(user.ID, user.Email, issuedAt.Add(s.verify.config.TTL))
+	err := s.verify.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Verificá tu email",
+		Body: fmt.Sprintf("Hola %s,\n\n"+
+			"Para confirmar que este email es tuyo, entrá a:\n\n"+
+			"%s\n\n"+
+			"El enlace vence en %s. Si no creaste una cuenta, ignorá este mail.\n",
+			user.Username, tokenLink(s.verify.config.URL, token), humanWait(s.verify.config.TTL)),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("No se pudo enviar el mail de verificación", "user_id", user.ID, "error", err)
+	}
+}
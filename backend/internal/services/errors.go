@@ -0,0 +1,77 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+// Categorías de errores de dominio.
+// Los handlers las distinguen con errors.Is para elegir el código HTTP.
+var (
+	ErrNotFound     = errors.New("recurso no encontrado")
+	ErrForbidden    = errors.New("operación no permitida")
+	ErrValidation   = errors.New("datos inválidos")
+	ErrConflict     = errors.New("conflicto con el estado actual")
+	ErrUnauthorized = errors.New("no autenticado")
+	ErrTooManyTries = errors.New("demasiados intentos")
+
+	// ErrEmailNotVerified rechaza la acción hasta que el usuario verifique su email
+	ErrEmailNotVerified = errors.New("email sin verificar")
+
+	// ErrMFASetupRequired rechaza la acción hasta que el usuario active la verificación en dos pasos
+	ErrMFASetupRequired = errors.New("verificación en dos pasos requerida")
+)
+
+// Error es un error de dominio: Kind indica la categoría y Message
+// el texto que se le puede mostrar al cliente
+type Error struct {
+	Kind    error
+	Message string
+	Field   string // campo inválido, solo en errores de validación
+
+	// RetryAfter es cuánto debe esperar el cliente, solo en ErrTooManyTries
+	RetryAfter time.Duration
+}
+
+// Error implementa error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap permite usar errors.Is(err, ErrNotFound), etc.
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+func notFound(message string) error {
+	return &Error{Kind: ErrNotFound, Message: message}
+}
+
+func forbidden(message string) error {
+	return &Error{Kind: ErrForbidden, Message: message}
+}
+
+func unverified(message string) error {
+	return &Error{Kind: ErrEmailNotVerified, Message: message}
+}
+
+func mfaSetupRequired(message string) error {
+	return &Error{Kind: ErrMFASetupRequired, Message: message}
+}
+
+func conflict(message string) error {
+	return &Error{Kind: ErrConflict, Message: message}
+}
+
+func unauthorized(message string) error {
+	return &Error{Kind: ErrUnauthorized, Message: message}
+}
+
+func tooManyTries(message string, retryAfter time.Duration) error {
+	return &Error{Kind: ErrTooManyTries, Message: message, RetryAfter: retryAfter}
+}
+
+// invalid indica qué campo de la entrada no pasó la validación
+func invalid(field string, message string) error {
+	return &Error{Kind: ErrValidation, Message: message, Field: field}
+}
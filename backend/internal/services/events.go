@@ -0,0 +1,27 @@
+package services
+
+// Motivos de un login fallido
+const (
+	LoginFailureUnknownUser = "unknown_user"
+	LoginFailureBadPassword = "bad_password"
+	LoginFailureBanned      = "banned"
+	LoginFailureThrottled   = "throttled"
+	LoginFailureBadMFACode  = "bad_mfa_code"
+)
+
+// Events recibe los eventos de negocio que interesan fuera de los services
+// (contadores de métricas). Las implementaciones no deben bloquear.
+type Events interface {
+	PostCreated()
+	CommentCreated()
+	UserRegistered()
+	LoginFailed(reason string)
+}
+
+// noEvents descarta los eventos: es el default de los services
+type noEvents struct{}
+
+func (noEvents) PostCreated()       {}
+func (noEvents) CommentCreated()    {}
+func (noEvents) UserRegistered()    {}
+func (noEvents) LoginFailed(string) {}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// LoginThrottlePolicy define cuánto se frena la fuerza bruta sobre el login
+type LoginThrottlePolicy struct {
+	// FreeAttempts son los fallos por cuenta que no generan demora
+	FreeAttempts int
+	// BaseDelay es la espera después del primer fallo extra; se duplica con
+	// cada fallo siguiente hasta MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// AccountLockAfter fallos bloquean la cuenta durante LockDuration
+	AccountLockAfter int
+	// IPLockAfter fallos desde una IP, a cualquier cuenta, la bloquean durante LockDuration
+	IPLockAfter  int
+	LockDuration time.Duration
+	// Window: los fallos más viejos se olvidan
+	Window time.Duration
+}
+
+// DefaultLoginThrottlePolicy son los valores si no se configuran otros
+var DefaultLoginThrottlePolicy = LoginThrottlePolicy{
+	FreeAttempts:     3,
+	BaseDelay:        time.Second,
+	MaxDelay:         30 * time.Second,
+	AccountLockAfter: 10,
+	IPLockAfter:      50,
+	LockDuration:     15 * time.Minute,
+	Window:           time.Hour,
+}
+
+// LoginThrottle lleva la cuenta de los logins fallidos por cuenta y por IP:
+// primero demoras crecientes, después un bloqueo temporal
+type LoginThrottle struct {
+	repo   repository.LoginThrottleRepository
+	policy LoginThrottlePolicy
+	now    func() time.Time
+}
+
+// NewLoginThrottle crea una nueva instancia
+func NewLoginThrottle(repo repository.LoginThrottleRepository, policy LoginThrottlePolicy) *LoginThrottle {
+	return &LoginThrottle{
+		repo:   repo,
+		policy: policy,
+		now:    time.Now,
+	}
+}
+
+// WithClock reemplaza el reloj (para tests)
+func (t *LoginThrottle) WithClock(now func() time.Time) *LoginThrottle {
+	t.now = now
+	return t
+}
+
+// loginAttempt es un intento de login ya contado como fallo en la cuenta y en la IP
+type loginAttempt struct {
+	email   string
+	ip      string
+	account *models.LoginThrottle
+	fromIP  *models.LoginThrottle
+}
+
+// reserve rechaza el intento si la cuenta o la IP están bloqueadas o todavía no
+// pasó la demora desde el último fallo, y si no lo cuenta como fallo de antemano.
+// El control y el registro son una sola operación sobre cada registro: varios
+// intentos simultáneos no pueden pasar todos antes de que se cuente el primero.
+func (t *LoginThrottle) reserve(ctx context.Context, email string, ip string) (*loginAttempt, error) {
+	now := t.now()
+	resetBefore := now.Add(-t.policy.Window)
+	attempt := &loginAttempt{email: email, ip: ip}
+
+	account, err := t.repo.Reserve(ctx, models.ThrottleScopeAccount, email, now, resetBefore, func(current *models.LoginThrottle) error {
+		if wait := t.wait(current, now, true); wait > 0 {
+			return tooManyTries(fmt.Sprintf("demasiados intentos fallidos, probá de nuevo en %s", humanWait(wait)), wait)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	attempt.account = account
+
+	if ip == "" {
+		return attempt, nil
+	}
+	fromIP, err := t.repo.Reserve(ctx, models.ThrottleScopeIP, ip, now, resetBefore, func(current *models.LoginThrottle) error {
+		if wait := t.wait(current, now, false); wait > 0 {
+			return tooManyTries(fmt.Sprintf("demasiados intentos fallidos desde tu red, probá de nuevo en %s", humanWait(wait)), wait)
+		}
+		return nil
+	})
+	if err != nil {
+		// El intento no llegó a hacerse: no cuenta contra la cuenta
+		if releaseErr := t.repo.Release(ctx, models.ThrottleScopeAccount, email); releaseErr != nil {
+			logging.FromContext(ctx).Error("No se pudo descontar el intento de login", "error", releaseErr)
+		}
+		return nil, err
+	}
+	attempt.fromIP = fromIP
+
+	return attempt, nil
+}
+
+// wait devuelve cuánto falta para poder intentar de nuevo (0 = puede intentar)
+func (t *LoginThrottle) wait(throttle *models.LoginThrottle, now time.Time, withDelays bool) time.Duration {
+	if throttle == nil || throttle.LastFailureAt.Before(now.Add(-t.policy.Window)) {
+		return 0
+	}
+	if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+		return throttle.LockedUntil.Sub(now)
+	}
+	if withDelays {
+		if next := throttle.LastFailureAt.Add(t.delay(throttle.Failures)); next.After(now) {
+			return next.Sub(now)
+		}
+	}
+	return 0
+}
+
+// delay es la espera obligatoria después de failures fallos seguidos
+func (t *LoginThrottle) delay(failures int) time.Duration {
+	extra := failures - t.policy.FreeAttempts
+	if extra <= 0 {
+		return 0
+	}
+	delay := t.policy.BaseDelay
+	for i := 1; i < extra && delay < t.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, t.policy.MaxDelay)
+}
+
+// failed bloquea la cuenta o la IP si el intento, ya contado, llegó al umbral.
+// Pasado el umbral, cada fallo nuevo vuelve a bloquear.
+func (t *LoginThrottle) failed(ctx context.Context, attempt *loginAttempt) error {
+	if err := t.lockAt(ctx, models.ThrottleScopeAccount, attempt.email, attempt.account, t.policy.AccountLockAfter); err != nil {
+		return err
+	}
+	if attempt.fromIP == nil {
+		return nil
+	}
+	return t.lockAt(ctx, models.ThrottleScopeIP, attempt.ip, attempt.fromIP, t.policy.IPLockAfter)
+}
+
+func (t *LoginThrottle) lockAt(ctx context.Context, scope string, subject string, throttle *models.LoginThrottle, lockAfter int) error {
+	if throttle == nil || lockAfter <= 0 || throttle.Failures < lockAfter {
+		return nil
+	}
+
+	logging.FromContext(ctx).Warn("Login bloqueado por intentos fallidos", "scope", scope, "failures", throttle.Failures)
+	return t.repo.Lock(ctx, scope, subject, t.now().Add(t.policy.LockDuration))
+}
+
+// succeeded olvida los fallos de la cuenta y descuenta el intento de la IP. Los
+// fallos anteriores de la IP se mantienen: si no, un atacante podría limpiarlos
+// entrando a una cuenta propia.
+func (t *LoginThrottle) succeeded(ctx context.Context, attempt *loginAttempt) error {
+	if err := t.repo.Reset(ctx, models.ThrottleScopeAccount, attempt.email); err != nil {
+		return err
+	}
+	if attempt.fromIP == nil {
+		return nil
+	}
+	return t.repo.Release(ctx, models.ThrottleScopeIP, attempt.ip)
+}
+
+// release descuenta el intento de la cuenta y de la IP sin olvidar los fallos
+// anteriores: la contraseña era correcta pero falta el segundo factor
+func (t *LoginThrottle) release(ctx context.Context, attempt *loginAttempt) error {
+	if err := t.repo.Release(ctx, models.ThrottleScopeAccount, attempt.email); err != nil {
+		return err
+	}
+	if attempt.fromIP == nil {
+		return nil
+	}
+	return t.repo.Release(ctx, models.ThrottleScopeIP, attempt.ip)
+}
+
+// isLocked indica si la cuenta está bloqueada por logins fallidos (no cuenta las demoras)
+func (t *LoginThrottle) isLocked(ctx context.Context, email string) (bool, error) {
+	throttle, err := t.repo.Find(ctx, models.ThrottleScopeAccount, email)
+	if err != nil {
+		return false, err
+	}
+	return t.wait(throttle, t.now(), false) > 0, nil
+}
+
+// Unlock levanta el bloqueo y los fallos de una cuenta
+func (t *LoginThrottle) Unlock(ctx context.Context, email string) error {
+	return t.repo.Reset(ctx, models.ThrottleScopeAccount, email)
+}
+
+// Prune borra los registros que ya no frenan a nadie
+func (t *LoginThrottle) Prune(ctx context.Context) error {
+	return t.repo.DeleteStale(ctx, t.now().Add(-t.policy.Window))
+}
+
+// humanWait redondea la espera para el mensaje: "10 segundos", "15 minutos", "24 horas".
+// Las horas solo se usan si son justas, para no redondear 90 minutos a 2 horas.
+func humanWait(d time.Duration) string {
+	if d < time.Minute {
+		seconds := int(math.Ceil(d.Seconds()))
+		if seconds == 1 {
+			return "1 segundo"
+		}
+		return fmt.Sprintf("%d segundos", seconds)
+	}
+	if d >= time.Hour && d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			return "1 hora"
+		}
+		return fmt.Sprintf("%d horas", hours)
+	}
+	minutes := int(math.Ceil(d.Minutes()))
+	if minutes == 1 {
+		return "1 minuto"
+	}
+	return fmt.Sprintf("%d minutos", minutes)
+}
@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/mail"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// ErrInvalidResetToken se muestra tanto si el token no existe como si venció o ya se usó
+const ErrInvalidResetToken = "el enlace para restablecer la contraseña es inválido o expiró"
+
+// errPasswordResetDisabled indica que el AuthService se armó sin WithPasswordReset
+var errPasswordResetDisabled = errors.New("recuperación de contraseña no configurada")
+
+// PasswordResetConfig son la vigencia de los links y la página del frontend que los recibe
+type PasswordResetConfig struct {
+	TTL time.Duration
+	URL string // se le agrega ?token=...
+}
+
+// PasswordReset emite y canjea los tokens de un solo uso que llegan por mail
+type PasswordReset struct {
+	tokens    repository.UserTokenRepository
+	sessions  repository.SessionRepository
+	apiTokens repository.APITokenRepository
+	mailer    mail.Mailer
+	config    PasswordResetConfig
+	now       func() time.Time
+}
+
+// NewPasswordReset crea una nueva instancia
+func NewPasswordReset(tokens repository.UserTokenRepository, sessions repository.SessionRepository, mailer mail.Mailer, config PasswordResetConfig) *PasswordReset {
+	return &PasswordReset{
+		tokens:   tokens,
+		sessions: sessions,
+		mailer:   mailer,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+// WithClock reemplaza el reloj (para tests)
+func (p *PasswordReset) WithClock(now func() time.Time) *PasswordReset {
+	p.now = now
+	return p
+}
+
+// WithAPITokenRepository permite revocar los tokens de API al restablecer la contraseña
+func (p *PasswordReset) WithAPITokenRepository(repo repository.APITokenRepository) *PasswordReset {
+	p.apiTokens = repo
+	return p
+}
+
+// issue invalida los links anteriores del usuario y emite uno nuevo
+func (p *PasswordReset) issue(ctx context.Context, user *models.User) (string, error) {
+	return issueUserToken(ctx, p.tokens, user.ID, models.TokenPurposePasswordReset, p.now().Add(p.config.TTL))
+}
+
+// Prune borra los links vencidos que nadie usó, también los desafíos de 2FA
+func (p *PasswordReset) Prune(ctx context.Context) error {
+	return p.tokens.DeleteExpired(ctx, p.now())
+}
+
+// link arma la URL del frontend con el token
+func (p *PasswordReset) link(token string) string {
+	return tokenLink(p.config.URL, token)
+}
+
+// ForgotPassword manda por mail un link para elegir una contraseña nueva.
+// Responde igual, y enseguida, exista o no la cuenta: la búsqueda y el envío
+// siguen en segundo plano para que ni la respuesta ni su demora revelen qué
+// emails están registrados.
+func (s *AuthService) ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) error {
+	ctx, span := startSpan(ctx, "AuthService.ForgotPassword")
+	defer span.End()
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if email == "" {
+		return invalid("email", "el email es requerido")
+	}
+	if s.reset == nil {
+		return errPasswordResetDisabled
+	}
+
+	go s.sendResetLink(context.WithoutCancel(ctx), email)
+	return nil
+}
+
+// sendResetLink emite el token y manda el mail; los errores solo quedan en el log
+func (s *AuthService) sendResetLink(ctx context.Context, email string) {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+	ctx, span := startSpan(ctx, "AuthService.sendResetLink")
+	defer span.End()
+	logger := logging.FromContext(ctx)
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		logger.Error("No se pudo buscar al usuario para restablecer la contraseña", "error", err)
+		return
+	}
+	// Un usuario suspendido no puede recuperar el acceso por esta vía
+	if user == nil || user.IsBanned() {
+		return
+	}
+
+	token, err := s.reset.issue(ctx, user)
+	if err != nil {
+		logger.Error("No se pudo emitir el token para restablecer la contraseña", "user_id", user.ID, "error", err)
+		return
+	}
+
+	err = s.reset.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Restablecé tu contraseña",
+		Body: fmt.Sprintf("Hola %s,\n\n"+
+			"Recibimos un pedido para restablecer la contraseña de tu cuenta. Para elegir una nueva, entrá a:\n\n"+
+			"%s\n\n"+
+			"El enlace vence en %s y sirve una sola vez. Si no lo pediste, ignorá este mail: tu contraseña no cambia.\n",
+			user.Username, s.reset.link(token), humanWait(s.reset.config.TTL)),
+	})
+	if err != nil {
+		logger.Error("No se pudo enviar el mail para restablecer la contraseña", "user_id", user.ID, "error", err)
+	}
+}
+
+// ResetPassword canjea el token del mail por una contraseña nueva, cierra
+// todas las sesiones abiertas y revoca los tokens de API, por si alguien más
+// tenía acceso a la cuenta
+func (s *AuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	ctx, span := startSpan(ctx, "AuthService.ResetPassword")
+	defer span.End()
+
+	// Validación 1: Token no puede estar vacío
+	if strings.TrimSpace(req.Token) == "" {
+		return invalid("token", "el token es requerido")
+	}
+
+	// Validación 2: las mismas reglas de contraseña que el registro. Va antes
+	// de canjear el token: una contraseña rechazada no debe gastar el link
+	if err := validatePassword(req.Password); err != nil {
+		return err
+	}
+
+	if s.reset == nil {
+		return errPasswordResetDisabled
+	}
+
+	// Validación 3: Token vigente y sin usar (queda usado aunque algo falle después)
+	token, err := s.reset.tokens.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken(strings.TrimSpace(req.Token)), s.reset.now())
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return invalid("token", ErrInvalidResetToken)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return invalid("token", ErrInvalidResetToken)
+	}
+
+	hash, err := s.hasher.Hash(req.Password)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hash); err != nil {
+		return err
+	}
+
+	// Sin sesiones, los tokens de acceso emitidos con la contraseña vieja dejan de valer
+	if err := s.reset.sessions.RevokeAllForUser(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Un token de API robado tampoco debe sobrevivir a la recuperación de la cuenta
+	if s.reset.apiTokens != nil {
+		if err := s.reset.apiTokens.RevokeAllForUser(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	// Los otros links pendientes ya no hacen falta
+	if err := s.reset.tokens.DeleteForUser(ctx, user.ID, models.TokenPurposePasswordReset); err != nil {
+		logging.FromContext(ctx).Error("No se pudieron borrar los tokens pendientes", "user_id", user.ID, "error", err)
+	}
+
+	// Quien recupera la cuenta por mail demuestra que es el dueño
+	if s.throttle != nil {
+		if err := s.throttle.Unlock(ctx, user.Email); err != nil {
+			logging.FromContext(ctx).Error("No se pudo levantar el bloqueo por logins fallidos", "user_id", user.ID, "error", err)
+		}
+	}
+
+	go s.notifyPasswordChanged(context.WithoutCancel(ctx), user)
+	return nil
+}
+
+// notifyPasswordChanged avisa al dueño de la cuenta que su contraseña cambió
+func (s *AuthService) notifyPasswordChanged(ctx context.Context, user *models.User) {
+	ctx, cancel := context.WithTimeout(ctx, mailTimeout)
+	defer cancel()
+
+	err := s.reset.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Tu contraseña fue cambiada",
+		Body: fmt.Sprintf("Hola %s,\n\n"+
+			"La contraseña de tu cuenta se cambió, se cerraron todas tus sesiones y se revocaron tus tokens de API.\n"+
+			"Si no fuiste vos, pedí un nuevo enlace para restablecerla y avisanos.\n",
+			user.Username),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Error("No se pudo enviar el aviso de contraseña cambiada", "user_id", user.ID, "error", err)
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import "ingsw3-tp08/internal/models"
+
+// Action es una operación sujeta a autorización
+type Action string
+
+// Acciones que decide la política
+const (
+	ActionDeletePost    Action = "delete_post"
+	ActionDeleteComment Action = "delete_comment"
+	ActionLockThread    Action = "lock_thread"
+	ActionBanUser       Action = "ban_user"
+	ActionAssignRole    Action = "assign_role"
+)
+
+// Target describe sobre quién recae la acción: el autor del contenido
+// o el usuario que se quiere suspender o cambiar de rol
+type Target struct {
+	OwnerID   int
+	OwnerRole string
+}
+
+// Policy decide si un usuario puede realizar una acción
+// INTERFACE: centraliza las reglas de autorización en un solo lugar
+type Policy interface {
+	Can(actor *models.User, action Action, target Target) bool
+}
+
+// RolePolicy autoriza según el rol del usuario:
+//   - user: solo borra su propio contenido
+//   - moderator: además borra contenido ajeno, cierra posts y suspende usuarios
+//   - admin: además suspende moderadores y asigna roles
+type RolePolicy struct{}
+
+// NewRolePolicy crea una nueva instancia
+func NewRolePolicy() *RolePolicy {
+	return &RolePolicy{}
+}
+
+// roleRank ordena los roles por privilegio
+func roleRank(role string) int {
+	switch role {
+	case models.RoleAdmin:
+		return 2
+	case models.RoleModerator:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Can implementa Policy
+func (p *RolePolicy) Can(actor *models.User, action Action, target Target) bool {
+	if actor == nil || actor.IsBanned() {
+		return false
+	}
+
+	isOwner := actor.ID == target.OwnerID
+	rank := roleRank(actor.Role)
+
+	switch action {
+	case ActionDeletePost, ActionDeleteComment:
+		return isOwner || rank >= roleRank(models.RoleModerator)
+	case ActionLockThread:
+		return rank >= roleRank(models.RoleModerator)
+	case ActionBanUser:
+		// Nadie se suspende a sí mismo ni a alguien de su mismo rango
+		return !isOwner && rank >= roleRank(models.RoleModerator) && rank > roleRank(target.OwnerRole)
+	case ActionAssignRole:
+		// Un admin no puede quitarse el rol: siempre queda al menos uno
+		return !isOwner && rank == roleRank(models.RoleAdmin)
+	default:
+		return false
+	}
+}
@@ -1,64 +1,135 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
 	"ingsw3-tp08/internal/repository"
 )
 
 // PostServiceInterface define las operaciones del servicio de posts
 type PostServiceInterface interface {
-	CreatePost(req *models.CreatePostRequest, userID int) (*models.Post, error)
-	GetAllPosts() ([]*models.Post, error)
-	GetPostByID(id int) (*models.Post, error)
-	DeletePost(postID int, userID int) error
-	CreateComment(postID int, req *models.CreateCommentRequest, userID int) (*models.Comment, error)
-	GetCommentsByPostID(postID int) ([]*models.Comment, error)
-	DeleteComment(postID int, commentID int, userID int) error
+	CreatePost(ctx context.Context, req *models.CreatePostRequest, userID int) (*models.Post, error)
+	GetAllPosts(ctx context.Context, page pagination.Params) (*pagination.Page[*models.Post], error)
+	GetPostByID(ctx context.Context, id int) (*models.Post, error)
+	UpdatePost(ctx context.Context, postID int, req *models.UpdatePostRequest, userID int) (*models.Post, error)
+	DeletePost(ctx context.Context, postID int, userID int) error
+	SetPostLocked(ctx context.Context, postID int, locked bool, userID int) (*models.Post, error)
+	GetPostRevisions(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.PostRevision], error)
+	DiffPostVersions(ctx context.Context, postID int, from int, to int) (*models.PostDiff, error)
+	CreateComment(ctx context.Context, postID int, req *models.CreateCommentRequest, userID int) (*models.Comment, error)
+	GetCommentsByPostID(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.Comment], error)
+	GetCommentTree(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.Comment], error)
+	DeleteComment(ctx context.Context, postID int, commentID int, userID int) error
 }
 
 // Constantes para mensajes de error
 const (
-	ErrUserNotFound = "usuario no encontrado"
-	ErrPostNotFound = "post no encontrado"
+	ErrUserNotFound     = "usuario no encontrado"
+	ErrPostNotFound     = "post no encontrado"
+	ErrRevisionNotFound = "versión no encontrada"
+	ErrParentNotFound   = "comentario padre no encontrado"
+	ErrCommentNotFound  = "comentario no encontrado"
 )
 
+// DefaultMaxCommentDepth es la profundidad máxima de respuestas si no se configura otra
+const DefaultMaxCommentDepth = 5
+
 // PostService maneja la lógica de posts y comentarios
 type PostService struct {
-	postRepo repository.PostRepository
-	userRepo repository.UserRepository
+	postRepo        repository.PostRepository
+	userRepo        repository.UserRepository
+	policy          Policy
+	maxCommentDepth int
+	events          Events
+
+	// requireVerifiedEmail impide publicar y comentar sin el email verificado
+	requireVerifiedEmail bool
+
+	// mfaRequiredRoles son los roles que solo moderan con 2FA activado
+	mfaRequiredRoles []string
 }
 
 // NewPostService crea una nueva instancia
 func NewPostService(postRepo repository.PostRepository, userRepo repository.UserRepository) *PostService {
 	return &PostService{
-		postRepo: postRepo,
-		userRepo: userRepo,
+		postRepo:        postRepo,
+		userRepo:        userRepo,
+		policy:          NewRolePolicy(),
+		maxCommentDepth: DefaultMaxCommentDepth,
+		events:          noEvents{},
 	}
 }
 
-// CreatePost crea un nuevo post
-func (s *PostService) CreatePost(req *models.CreatePostRequest, userID int) (*models.Post, error) {
-	if strings.TrimSpace(req.Title) == "" {
-		return nil, errors.New("el título es requerido")
+// WithMaxCommentDepth cambia la profundidad máxima de respuestas (0 = sin respuestas)
+func (s *PostService) WithMaxCommentDepth(depth int) *PostService {
+	if depth >= 0 {
+		s.maxCommentDepth = depth
+	}
+	return s
+}
+
+// WithRequireVerifiedEmail exige el email verificado para crear posts y comentarios
+func (s *PostService) WithRequireVerifiedEmail(require bool) *PostService {
+	s.requireVerifiedEmail = require
+	return s
+}
+
+// WithMFARequiredRoles exige la verificación en dos pasos a quienes moderen con esos roles:
+// borrar contenido ajeno y cerrar posts. Borrar lo propio no la exige.
+func (s *PostService) WithMFARequiredRoles(roles ...string) *PostService {
+	s.mfaRequiredRoles = roles
+	return s
+}
+
+// WithEvents registra el receptor de eventos de negocio (posts y comentarios creados)
+func (s *PostService) WithEvents(events Events) *PostService {
+	if events != nil {
+		s.events = events
 	}
+	return s
+}
 
-	if len(strings.TrimSpace(req.Title)) < 3 {
-		return nil, errors.New("el título debe tener al menos 3 caracteres")
+// validatePostFields aplica las reglas de título y contenido de un post
+func validatePostFields(title string, content string) error {
+	if strings.TrimSpace(title) == "" {
+		return invalid("title", "el título es requerido")
 	}
 
-	if strings.TrimSpace(req.Content) == "" {
-		return nil, errors.New("el contenido es requerido")
+	if len(strings.TrimSpace(title)) < 3 {
+		return invalid("title", "el título debe tener al menos 3 caracteres")
 	}
 
-	user, err := s.userRepo.FindByID(userID)
+	if strings.TrimSpace(content) == "" {
+		return invalid("content", "el contenido es requerido")
+	}
+
+	return nil
+}
+
+// CreatePost crea un nuevo post
+func (s *PostService) CreatePost(ctx context.Context, req *models.CreatePostRequest, userID int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.CreatePost")
+	defer span.End()
+
+	if err := validatePostFields(req.Title, req.Content); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New(ErrUserNotFound)
+		return nil, notFound(ErrUserNotFound)
+	}
+	if err := s.checkEmailVerified(user); err != nil {
+		return nil, err
 	}
 
 	post := &models.Post{
@@ -67,144 +138,509 @@ func (s *PostService) CreatePost(req *models.CreatePostRequest, userID int) (*mo
 		UserID:  userID,
 	}
 
-	err = s.postRepo.Create(post)
+	err = s.postRepo.Create(ctx, post)
 	if err != nil {
 		return nil, err
 	}
 
 	post.Username = user.Username
+	s.events.PostCreated()
 
 	return post, nil
 }
 
-// GetAllPosts obtiene todos los posts del sistema.
+// GetAllPosts obtiene una página del feed de posts, del más nuevo al más viejo.
 // Retorna una lista vacía si no hay posts, nunca retorna nil.
-func (s *PostService) GetAllPosts() ([]*models.Post, error) {
-	posts, err := s.postRepo.FindAll()
+func (s *PostService) GetAllPosts(ctx context.Context, page pagination.Params) (*pagination.Page[*models.Post], error) {
+	ctx, span := startSpan(ctx, "PostService.GetAllPosts")
+	defer span.End()
+
+	page = page.Normalize()
+
+	posts, err := s.postRepo.FindAll(ctx, page)
 	if err != nil {
 		return nil, err
 	}
 
-	// Si no hay posts, devolver lista vacía (no es error)
-	// No retorna nil
-	if posts == nil {
-		return []*models.Post{}, nil
-	}
-
-	return posts, nil
+	return pagination.NewPage(posts, page.Limit, postCursor), nil
 }
 
 // GetPostByID obtiene un post específico
-func (s *PostService) GetPostByID(id int) (*models.Post, error) {
+func (s *PostService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostByID")
+	defer span.End()
+
 	if id <= 0 {
-		return nil, errors.New("id inválido")
+		return nil, invalid("id", "id inválido")
 	}
 
-	post, err := s.postRepo.FindByID(id)
+	post, err := s.postRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
 	if post == nil {
-		return nil, errors.New(ErrPostNotFound)
+		return nil, notFound(ErrPostNotFound)
 	}
 
 	return post, nil
 }
 
-// DeletePost elimina un post (solo el autor puede hacerlo)
-func (s *PostService) DeletePost(postID int, userID int) error {
-	post, err := s.postRepo.FindByID(postID)
+// UpdatePost edita el título y/o contenido de un post (solo el autor puede hacerlo).
+// La versión anterior queda guardada en el historial de revisiones.
+func (s *PostService) UpdatePost(ctx context.Context, postID int, req *models.UpdatePostRequest, userID int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.UpdatePost")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if post == nil {
-		return errors.New(ErrPostNotFound)
+		return nil, notFound(ErrPostNotFound)
 	}
 
 	if post.UserID != userID {
-		return errors.New("no tienes permiso para eliminar este post")
+		return nil, forbidden("no tienes permiso para editar este post")
+	}
+
+	title := post.Title
+	if req.Title != nil {
+		title = *req.Title
+	}
+	content := post.Content
+	if req.Content != nil {
+		content = *req.Content
+	}
+
+	if err := validatePostFields(title, content); err != nil {
+		return nil, err
+	}
+
+	title = strings.TrimSpace(title)
+	content = strings.TrimSpace(content)
+
+	// Sin cambios reales no se genera una revisión nueva
+	if title == post.Title && content == post.Content {
+		return post, nil
+	}
+
+	post.Title = title
+	post.Content = content
+
+	err = s.postRepo.Update(ctx, post)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, notFound(ErrPostNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return post, nil
+}
+
+// GetPostRevisions obtiene una página de versiones anteriores de un post.
+// Retorna una lista vacía si el post nunca fue editado.
+func (s *PostService) GetPostRevisions(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.PostRevision], error) {
+	ctx, span := startSpan(ctx, "PostService.GetPostRevisions")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, notFound(ErrPostNotFound)
+	}
+
+	page = page.Normalize()
+
+	revisions, err := s.postRepo.FindRevisions(ctx, postID, page)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.postRepo.Delete(postID)
+	return pagination.NewPage(revisions, page.Limit, revisionCursor), nil
+}
+
+// DiffPostVersions compara dos versiones de un post.
+// La versión actual del post también se puede usar como extremo del diff.
+// Con to = 0 se usa la versión actual y con from = 0 la inmediatamente anterior a to.
+func (s *PostService) DiffPostVersions(ctx context.Context, postID int, from int, to int) (*models.PostDiff, error) {
+	ctx, span := startSpan(ctx, "PostService.DiffPostVersions")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, notFound(ErrPostNotFound)
+	}
+
+	if to == 0 {
+		to = post.Version
+	}
+	if from == 0 {
+		from = to - 1
+	}
+
+	fromTitle, fromContent, err := s.postVersion(ctx, post, from)
+	if err != nil {
+		return nil, err
+	}
+	toTitle, toContent, err := s.postVersion(ctx, post, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PostDiff{
+		PostID:  postID,
+		From:    from,
+		To:      to,
+		Title:   diffLines(fromTitle, toTitle),
+		Content: diffLines(fromContent, toContent),
+	}, nil
+}
+
+// postVersion devuelve el título y contenido de una versión del post
+func (s *PostService) postVersion(ctx context.Context, post *models.Post, version int) (string, string, error) {
+	if version == post.Version {
+		return post.Title, post.Content, nil
+	}
+	if version <= 0 || version > post.Version {
+		return "", "", notFound(ErrRevisionNotFound)
+	}
+
+	revision, err := s.postRepo.FindRevision(ctx, post.ID, version)
+	if err != nil {
+		return "", "", err
+	}
+	if revision == nil {
+		return "", "", notFound(ErrRevisionNotFound)
+	}
+
+	return revision.Title, revision.Content, nil
+}
+
+// actor busca al usuario que realiza la acción (su rol define qué puede hacer)
+func (s *PostService) actor(ctx context.Context, userID int) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, notFound(ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// checkModeratorMFA rechaza la moderación de quien tiene un rol que exige 2FA sin haberlo activado
+func (s *PostService) checkModeratorMFA(actor *models.User) error {
+	if !actor.MFAEnabled && slices.Contains(s.mfaRequiredRoles, actor.Role) {
+		return mfaSetupRequired("activá la verificación en dos pasos para moderar")
+	}
+	return nil
+}
+
+// DeletePost elimina un post (el autor o un moderador)
+func (s *PostService) DeletePost(ctx context.Context, postID int, userID int) error {
+	ctx, span := startSpan(ctx, "PostService.DeletePost")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return err
+	}
+	if post == nil {
+		return notFound(ErrPostNotFound)
+	}
+
+	actor, err := s.actor(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !s.policy.Can(actor, ActionDeletePost, Target{OwnerID: post.UserID}) {
+		return forbidden("no tienes permiso para eliminar este post")
+	}
+	if actor.ID != post.UserID {
+		if err := s.checkModeratorMFA(actor); err != nil {
+			return err
+		}
+	}
+
+	return s.postRepo.Delete(ctx, postID)
+}
+
+// SetPostLocked cierra o reabre un post a nuevos comentarios (solo moderadores)
+func (s *PostService) SetPostLocked(ctx context.Context, postID int, locked bool, userID int) (*models.Post, error) {
+	ctx, span := startSpan(ctx, "PostService.SetPostLocked")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, notFound(ErrPostNotFound)
+	}
+
+	actor, err := s.actor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.policy.Can(actor, ActionLockThread, Target{OwnerID: post.UserID}) {
+		return nil, forbidden("no tienes permiso para cerrar este post")
+	}
+	if err := s.checkModeratorMFA(actor); err != nil {
+		return nil, err
+	}
+
+	if err := s.postRepo.SetLocked(ctx, postID, locked); err != nil {
+		return nil, err
+	}
+
+	post.Locked = locked
+	return post, nil
 }
 
 // CreateComment agrega un comentario a un post
-func (s *PostService) CreateComment(postID int, req *models.CreateCommentRequest, userID int) (*models.Comment, error) {
+func (s *PostService) CreateComment(ctx context.Context, postID int, req *models.CreateCommentRequest, userID int) (*models.Comment, error) {
+	ctx, span := startSpan(ctx, "PostService.CreateComment")
+	defer span.End()
+
 	if strings.TrimSpace(req.Content) == "" {
-		return nil, errors.New("el contenido del comentario es requerido")
+		return nil, invalid("content", "el contenido del comentario es requerido")
 	}
 
-	post, err := s.postRepo.FindByID(postID)
+	post, err := s.postRepo.FindByID(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 	if post == nil {
-		return nil, errors.New(ErrPostNotFound)
+		return nil, notFound(ErrPostNotFound)
 	}
 
-	user, err := s.userRepo.FindByID(userID)
+	if post.Locked {
+		return nil, conflict("el post está cerrado a nuevos comentarios")
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 	if user == nil {
-		return nil, errors.New(ErrUserNotFound)
+		return nil, notFound(ErrUserNotFound)
+	}
+	if err := s.checkEmailVerified(user); err != nil {
+		return nil, err
+	}
+
+	if req.ParentID != nil {
+		if err := s.validateParent(ctx, postID, *req.ParentID); err != nil {
+			return nil, err
+		}
 	}
 
 	comment := &models.Comment{
-		PostID:  postID,
-		UserID:  userID,
-		Content: strings.TrimSpace(req.Content),
+		PostID:   postID,
+		ParentID: req.ParentID,
+		UserID:   userID,
+		Content:  strings.TrimSpace(req.Content),
 	}
 
-	err = s.postRepo.CreateComment(comment)
+	err = s.postRepo.CreateComment(ctx, comment)
 	if err != nil {
 		return nil, err
 	}
 
 	comment.Username = user.Username
+	s.events.CommentCreated()
 
 	return comment, nil
 }
 
-// GetCommentsByPostID obtiene todos los comentarios de un post
-func (s *PostService) GetCommentsByPostID(postID int) ([]*models.Comment, error) {
-	post, err := s.postRepo.FindByID(postID)
+// checkEmailVerified rechaza al autor si la configuración exige el email verificado
+func (s *PostService) checkEmailVerified(user *models.User) error {
+	if s.requireVerifiedEmail && !user.IsEmailVerified() {
+		return unverified("verificá tu email para poder publicar y comentar")
+	}
+	return nil
+}
+
+// validateParent verifica que se pueda responder al comentario indicado
+func (s *PostService) validateParent(ctx context.Context, postID int, parentID int) error {
+	parent, err := s.postRepo.FindCommentByID(ctx, parentID)
+	if err != nil {
+		return err
+	}
+	if parent == nil || parent.PostID != postID {
+		return invalid("parent_id", ErrParentNotFound)
+	}
+
+	if parent.Deleted {
+		return invalid("parent_id", "no se puede responder a un comentario eliminado")
+	}
+
+	if parent.Depth+1 > s.maxCommentDepth {
+		return invalid("parent_id", fmt.Sprintf("se alcanzó la profundidad máxima de respuestas (%d)", s.maxCommentDepth))
+	}
+
+	return nil
+}
+
+// GetCommentsByPostID obtiene una página de comentarios de un post como lista plana,
+// del más viejo al más nuevo. Cada comentario indica su profundidad y path en el hilo.
+func (s *PostService) GetCommentsByPostID(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.Comment], error) {
+	ctx, span := startSpan(ctx, "PostService.GetCommentsByPostID")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
+	if err != nil {
+		return nil, err
+	}
+	if post == nil {
+		return nil, notFound(ErrPostNotFound)
+	}
+
+	page = page.Normalize()
+
+	comments, err := s.postRepo.FindCommentsByPostID(ctx, postID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, comment := range comments {
+		redactDeleted(comment)
+	}
+
+	return pagination.NewPage(comments, page.Limit, commentCursor), nil
+}
+
+// GetCommentTree obtiene una página de hilos de un post: los comentarios de primer nivel
+// se paginan y cada uno trae todas sus respuestas anidadas en Replies
+func (s *PostService) GetCommentTree(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.Comment], error) {
+	ctx, span := startSpan(ctx, "PostService.GetCommentTree")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
 	if err != nil {
 		return nil, err
 	}
 	if post == nil {
-		return nil, errors.New(ErrPostNotFound)
+		return nil, notFound(ErrPostNotFound)
+	}
+
+	page = page.Normalize()
+
+	roots, err := s.postRepo.FindRootComments(ctx, postID, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := pagination.NewPage(roots, page.Limit, commentCursor)
+
+	rootIDs := make([]int, len(result.Items))
+	for i, root := range result.Items {
+		rootIDs[i] = root.ID
 	}
 
-	comments, err := s.postRepo.FindCommentsByPostID(postID)
+	replies, err := s.postRepo.FindReplies(ctx, postID, rootIDs)
 	if err != nil {
 		return nil, err
 	}
 
-	if comments == nil {
-		return []*models.Comment{}, nil
+	buildCommentTree(result.Items, replies)
+
+	return result, nil
+}
+
+// buildCommentTree cuelga cada respuesta de su padre.
+// Las respuestas llegan ordenadas por path, así que el padre siempre aparece antes.
+func buildCommentTree(roots []*models.Comment, replies []*models.Comment) {
+	byID := make(map[int]*models.Comment, len(roots)+len(replies))
+	for _, root := range roots {
+		redactDeleted(root)
+		byID[root.ID] = root
 	}
 
-	return comments, nil
+	for _, reply := range replies {
+		redactDeleted(reply)
+		if reply.ParentID == nil {
+			continue
+		}
+		parent, ok := byID[*reply.ParentID]
+		if !ok {
+			continue
+		}
+		parent.Replies = append(parent.Replies, reply)
+		byID[reply.ID] = reply
+	}
 }
 
-func (s *PostService) DeleteComment(postID int, commentID int, userID int) error {
-	post, err := s.postRepo.FindByID(postID)
+// redactDeleted oculta el contenido y el autor de un comentario eliminado
+func redactDeleted(comment *models.Comment) {
+	if !comment.Deleted {
+		return
+	}
+	comment.Content = models.DeletedCommentContent
+	comment.UserID = 0
+	comment.Username = ""
+}
+
+func (s *PostService) DeleteComment(ctx context.Context, postID int, commentID int, userID int) error {
+	ctx, span := startSpan(ctx, "PostService.DeleteComment")
+	defer span.End()
+
+	post, err := s.postRepo.FindByID(ctx, postID)
 	if err != nil {
 		return err
 	}
 	if post == nil {
-		return errors.New(ErrPostNotFound)
+		return notFound(ErrPostNotFound)
 	}
 
-	user, err := s.userRepo.FindByID(userID)
+	actor, err := s.actor(ctx, userID)
 	if err != nil {
 		return err
 	}
-	if user == nil {
-		return errors.New(ErrUserNotFound)
+
+	comment, err := s.postRepo.FindCommentByID(ctx, commentID)
+	if err != nil {
+		return err
+	}
+	if comment == nil || comment.PostID != postID || comment.Deleted {
+		return notFound(ErrCommentNotFound)
+	}
+
+	if !s.policy.Can(actor, ActionDeleteComment, Target{OwnerID: comment.UserID}) {
+		return forbidden("no tienes permiso para eliminar este comentario")
+	}
+	if actor.ID != comment.UserID {
+		if err := s.checkModeratorMFA(actor); err != nil {
+			return err
+		}
+	}
+
+	err = s.postRepo.DeleteComment(ctx, postID, commentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return notFound(ErrCommentNotFound)
 	}
+	return err
+}
+
+// Cursores de cada listado: todos se ordenan por (created_at, id)
+
+func postCursor(post *models.Post) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: post.CreatedAt, ID: post.ID}
+}
+
+func revisionCursor(revision *models.PostRevision) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: revision.CreatedAt, ID: revision.ID}
+}
 
-	return s.postRepo.DeleteComment(postID, commentID, userID)
+func commentCursor(comment *models.Comment) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: comment.CreatedAt, ID: comment.ID}
 }
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/repository"
+)
+
+// SearchServiceInterface define la búsqueda de posts y comentarios
+type SearchServiceInterface interface {
+	Search(ctx context.Context, query models.SearchQuery, page pagination.Params) (*pagination.Page[*models.SearchResult], error)
+}
+
+// MaxSearchLength limita el largo del texto a buscar
+const MaxSearchLength = 200
+
+// SearchService valida las búsquedas y prepara los resultados para el cliente
+type SearchService struct {
+	searchRepo repository.SearchRepository
+}
+
+// NewSearchService crea una nueva instancia
+func NewSearchService(searchRepo repository.SearchRepository) *SearchService {
+	return &SearchService{searchRepo: searchRepo}
+}
+
+// Search busca posts y comentarios ordenados por relevancia
+func (s *SearchService) Search(ctx context.Context, query models.SearchQuery, page pagination.Params) (*pagination.Page[*models.SearchResult], error) {
+	query.Text = strings.TrimSpace(query.Text)
+	query.Author = strings.TrimSpace(query.Author)
+
+	if query.Text == "" {
+		return nil, invalid("q", "el texto a buscar es requerido")
+	}
+	if len(query.Text) > MaxSearchLength {
+		return nil, invalid("q", "el texto a buscar es demasiado largo")
+	}
+	if query.Type != "" && query.Type != models.SearchTypePost && query.Type != models.SearchTypeComment {
+		return nil, invalid("type", "tipo de búsqueda inválido: usar post o comment")
+	}
+	if query.From != nil && query.To != nil && !query.From.Before(*query.To) {
+		return nil, invalid("to", "rango de fechas inválido")
+	}
+
+	page = page.Normalize()
+
+	results, err := s.searchRepo.Search(ctx, query, page)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		result.Snippet = escapeSnippet(result.Snippet)
+	}
+
+	return pagination.NewPage(results, page.Limit, searchCursor), nil
+}
+
+// escapeSnippet escapa el HTML del contenido del usuario y conserva
+// solo las marcas <mark> que agrega ts_headline
+func escapeSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, "&lt;mark&gt;", "<mark>")
+	return strings.ReplaceAll(escaped, "&lt;/mark&gt;", "</mark>")
+}
+
+func searchCursor(result *models.SearchResult) pagination.Cursor {
+	return pagination.Cursor{
+		CreatedAt: result.CreatedAt,
+		ID:        result.ID,
+		Rank:      result.Rank,
+		Kind:      result.Type,
+	}
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// SessionServiceInterface define las operaciones sobre sesiones
+type SessionServiceInterface interface {
+	Start(ctx context.Context, userID int, client models.ClientInfo) (*models.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string, client models.ClientInfo) (*models.TokenPair, error)
+	Logout(ctx context.Context, userID int, sessionID string) error
+	LogoutAll(ctx context.Context, userID int) error
+	ListSessions(ctx context.Context, userID int, currentSessionID string) ([]*models.Session, error)
+	Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error)
+}
+
+// Constantes para mensajes de error de sesiones
+const (
+	ErrInvalidRefreshToken = "refresh token inválido o expirado"
+	ErrRefreshTokenReused  = "refresh token reutilizado: la sesión fue revocada"
+	ErrSessionNotFound     = "sesión no encontrada"
+	ErrSessionRevoked      = "la sesión fue cerrada"
+)
+
+// SessionService maneja el ciclo de vida de las sesiones:
+// emisión de tokens, rotación de refresh tokens y revocación
+type SessionService struct {
+	sessionRepo repository.SessionRepository
+	tokens      *auth.TokenManager
+	refreshTTL  time.Duration
+	apiTokens   *APITokenService
+}
+
+// NewSessionService crea una nueva instancia
+func NewSessionService(sessionRepo repository.SessionRepository, tokens *auth.TokenManager, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		sessionRepo: sessionRepo,
+		tokens:      tokens,
+		refreshTTL:  refreshTTL,
+	}
+}
+
+// WithAPITokens acepta también tokens de API personales en Authenticate
+func (s *SessionService) WithAPITokens(apiTokens *APITokenService) *SessionService {
+	s.apiTokens = apiTokens
+	return s
+}
+
+// Start abre una sesión nueva para el usuario (un dispositivo nuevo)
+func (s *SessionService) Start(ctx context.Context, userID int, client models.ClientInfo) (*models.TokenPair, error) {
+	sessionID, err := auth.NewRandomID()
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    userID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+
+	if err := s.sessionRepo.Create(ctx, session, auth.HashToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(session, refreshToken)
+}
+
+// Refresh canjea un refresh token por un par de tokens nuevo.
+// Cada refresh token sirve una sola vez: si se presenta uno ya rotado
+// se asume que fue robado y se revoca la sesión completa.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string, client models.ClientInfo) (*models.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, unauthorized(ErrInvalidRefreshToken)
+	}
+
+	current, err := s.sessionRepo.FindByTokenHash(ctx, auth.HashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || current.RevokedAt != nil {
+		return nil, unauthorized(ErrInvalidRefreshToken)
+	}
+
+	if current.RotatedAt != nil {
+		s.revokeReusedSession(ctx, current)
+		return nil, unauthorized(ErrRefreshTokenReused)
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		return nil, unauthorized(ErrInvalidRefreshToken)
+	}
+
+	nextToken, err := auth.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	// El vencimiento de la sesión se desliza con cada uso
+	next := &models.Session{
+		ID:        current.ID,
+		UserID:    current.UserID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		StartedAt: current.StartedAt,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}
+
+	err = s.sessionRepo.Rotate(ctx, current, next, auth.HashToken(nextToken))
+	if errors.Is(err, repository.ErrSessionAlreadyRotated) {
+		s.revokeReusedSession(ctx, current)
+		return nil, unauthorized(ErrRefreshTokenReused)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(next, nextToken)
+}
+
+// Logout cierra una sesión del usuario
+func (s *SessionService) Logout(ctx context.Context, userID int, sessionID string) error {
+	if sessionID == "" {
+		return notFound(ErrSessionNotFound)
+	}
+
+	revoked, err := s.sessionRepo.Revoke(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return notFound(ErrSessionNotFound)
+	}
+
+	return nil
+}
+
+// LogoutAll cierra todas las sesiones del usuario en todos sus dispositivos
+func (s *SessionService) LogoutAll(ctx context.Context, userID int) error {
+	return s.sessionRepo.RevokeAllForUser(ctx, userID)
+}
+
+// ListSessions obtiene los dispositivos con sesión activa del usuario
+func (s *SessionService) ListSessions(ctx context.Context, userID int, currentSessionID string) ([]*models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if sessions == nil {
+		return []*models.Session{}, nil
+	}
+
+	for _, session := range sessions {
+		session.Current = session.ID == currentSessionID
+	}
+
+	return sessions, nil
+}
+
+// Authenticate valida el token de acceso y que su sesión siga abierta.
+// Así un logout corta el acceso aunque el JWT todavía no haya expirado.
+// Con WithAPITokens, los tokens "pat_..." se validan como tokens de API.
+func (s *SessionService) Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error) {
+	if s.apiTokens != nil && auth.IsAPIToken(accessToken) {
+		return s.apiTokens.Authenticate(ctx, accessToken)
+	}
+
+	principal, err := s.tokens.Authenticate(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.SessionID == "" {
+		return nil, auth.ErrInvalidToken
+	}
+
+	active, err := s.sessionRepo.IsActive(ctx, principal.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, unauthorized(ErrSessionRevoked)
+	}
+
+	return principal, nil
+}
+
+// issueTokens firma el token de acceso de la sesión y arma la respuesta
+func (s *SessionService) issueTokens(session *models.Session, refreshToken string) (*models.TokenPair, error) {
+	accessToken, expiresAt, err := s.tokens.Issue(session.UserID, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		SessionID:        session.ID,
+		AccessToken:      accessToken,
+		TokenType:        "Bearer",
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// revokeReusedSession revoca la sesión cuyo refresh token se reutilizó.
+// La revocación no se cancela aunque el cliente (posiblemente el atacante) corte el request.
+func (s *SessionService) revokeReusedSession(ctx context.Context, session *models.Session) {
+	logger := logging.FromContext(ctx).With("session_id", session.ID, "user_id", session.UserID)
+	logger.Warn("Reutilización de refresh token detectada")
+	if _, err := s.sessionRepo.Revoke(context.WithoutCancel(ctx), session.UserID, session.ID); err != nil {
+		logger.Error("No se pudo revocar la sesión", "error", err)
+	}
+}
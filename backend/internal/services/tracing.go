@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"ingsw3-tp08/internal/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "ingsw3-tp08/internal/services"
+
+// startSpan abre el span de un método de service; los spans de las queries
+// que ejecute el repositorio quedan como hijos
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracing.Tracer(tracerName).Start(ctx, name)
+}
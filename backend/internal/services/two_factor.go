@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/logging"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// Mensajes de error del segundo factor
+const (
+	ErrInvalidMFAChallenge = "el desafío expiró o es inválido, iniciá sesión de nuevo"
+	ErrInvalidMFACode      = "código inválido"
+)
+
+// recoveryCodeCount es cuántos códigos de recuperación se entregan cada vez
+const recoveryCodeCount = 10
+
+// errTwoFactorDisabled indica que el AuthService se armó sin WithTwoFactor
+var errTwoFactorDisabled = errors.New("verificación en dos pasos no configurada")
+
+// MFARequired lo devuelve Login cuando la contraseña es correcta pero la cuenta
+// tiene verificación en dos pasos: el login sigue en VerifyMFA con Challenge.Token
+type MFARequired struct {
+	Challenge *models.MFAChallenge
+}
+
+// Error implementa error
+func (e *MFARequired) Error() string {
+	return "falta el segundo paso del login"
+}
+
+// TwoFactorConfig es el nombre que muestran las apps y la vigencia del desafío del login
+type TwoFactorConfig struct {
+	Issuer       string
+	ChallengeTTL time.Duration
+}
+
+// TwoFactor guarda el secreto TOTP cifrado, valida los códigos y emite los
+// desafíos del segundo paso del login
+type TwoFactor struct {
+	repo   repository.TwoFactorRepository
+	tokens repository.UserTokenRepository
+	box    *auth.SecretBox
+	config TwoFactorConfig
+	now    func() time.Time
+}
+
+// NewTwoFactor crea una nueva instancia
+func NewTwoFactor(repo repository.TwoFactorRepository, tokens repository.UserTokenRepository, box *auth.SecretBox, config TwoFactorConfig) *TwoFactor {
+	return &TwoFactor{
+		repo:   repo,
+		tokens: tokens,
+		box:    box,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// WithClock reemplaza el reloj (para tests)
+func (t *TwoFactor) WithClock(now func() time.Time) *TwoFactor {
+	t.now = now
+	return t
+}
+
+// challenge emite el token del segundo paso del login; invalida los anteriores
+func (t *TwoFactor) challenge(ctx context.Context, user *models.User) (*models.MFAChallenge, error) {
+	expiresAt := t.now().Add(t.config.ChallengeTTL)
+	token, err := issueUserToken(ctx, t.tokens, user.ID, models.TokenPurposeMFAChallenge, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &models.MFAChallenge{MFARequired: true, Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// confirmed devuelve el TOTP activo del usuario, o un conflicto si no tiene
+func (t *TwoFactor) confirmed(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	credential, err := t.repo.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || !credential.IsConfirmed() {
+		return nil, conflict("la verificación en dos pasos no está activada")
+	}
+	return credential, nil
+}
+
+// checkCode acepta un código de la app o uno de recuperación; ninguno sirve dos veces
+func (t *TwoFactor) checkCode(ctx context.Context, credential *models.TOTPCredential, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+	if !isTOTPCode(code) {
+		return t.repo.UseRecoveryCode(ctx, credential.UserID, auth.HashToken(auth.NormalizeRecoveryCode(code)))
+	}
+
+	secret, err := t.box.Open(credential.Secret)
+	if err != nil {
+		return false, err
+	}
+	step, ok := auth.VerifyTOTP(secret, code, t.now())
+	if !ok || step <= credential.LastUsedStep {
+		return false, nil
+	}
+	return t.repo.UseTOTPStep(ctx, credential.UserID, step)
+}
+
+// isTOTPCode distingue un código de la app (6 dígitos) de uno de recuperación
+func isTOTPCode(code string) bool {
+	if len(code) != auth.TOTPDigits {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRecoveryCodes genera los códigos para mostrar y los hashes para guardar
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := auth.NewRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes[i] = code
+		hashes[i] = auth.HashToken(auth.NormalizeRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// BeginTOTPEnrollment genera un secreto nuevo para cargar en la app.
+// Queda pendiente hasta que ConfirmTOTPEnrollment reciba un primer código.
+func (s *AuthService) BeginTOTPEnrollment(ctx context.Context, userID int) (*models.TOTPEnrollment, error) {
+	ctx, span := startSpan(ctx, "AuthService.BeginTOTPEnrollment")
+	defer span.End()
+
+	if s.mfa == nil {
+		return nil, errTwoFactorDisabled
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, notFound(ErrUserNotFound)
+	}
+	if user.MFAEnabled {
+		return nil, conflict("la verificación en dos pasos ya está activada")
+	}
+
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.mfa.box.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.repo.SavePendingTOTP(ctx, user.ID, sealed); err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPEnrollment{
+		Secret: secret,
+		URI:    auth.TOTPURI(s.mfa.config.Issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTPEnrollment activa el segundo factor con el primer código de la app
+// y devuelve los códigos de recuperación, que no se vuelven a mostrar
+func (s *AuthService) ConfirmTOTPEnrollment(ctx context.Context, userID int, req *models.MFACodeRequest) (*models.RecoveryCodes, error) {
+	ctx, span := startSpan(ctx, "AuthService.ConfirmTOTPEnrollment")
+	defer span.End()
+
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, invalid("code", "el código es requerido")
+	}
+	if s.mfa == nil {
+		return nil, errTwoFactorDisabled
+	}
+
+	credential, err := s.mfa.repo.FindTOTP(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, conflict("primero generá el secreto para tu app de autenticación")
+	}
+	if credential.IsConfirmed() {
+		return nil, conflict("la verificación en dos pasos ya está activada")
+	}
+
+	secret, err := s.mfa.box.Open(credential.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := auth.VerifyTOTP(secret, strings.TrimSpace(req.Code), s.mfa.now())
+	if !ok {
+		return nil, invalid("code", ErrInvalidMFACode)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	err = s.mfa.repo.ConfirmTOTP(ctx, userID, step, hashes)
+	if errors.Is(err, repository.ErrTOTPNotPending) {
+		return nil, conflict("la verificación en dos pasos ya está activada")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// DisableTOTP desactiva el segundo factor; pide un código para que una sesión robada no alcance
+func (s *AuthService) DisableTOTP(ctx context.Context, userID int, req *models.MFACodeRequest) error {
+	ctx, span := startSpan(ctx, "AuthService.DisableTOTP")
+	defer span.End()
+
+	if strings.TrimSpace(req.Code) == "" {
+		return invalid("code", "el código es requerido")
+	}
+	if s.mfa == nil {
+		return errTwoFactorDisabled
+	}
+
+	credential, err := s.mfa.confirmed(ctx, userID)
+	if err != nil {
+		return err
+	}
+	ok, err := s.mfa.checkCode(ctx, credential, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return invalid("code", ErrInvalidMFACode)
+	}
+
+	return s.mfa.repo.DeleteTOTP(ctx, userID)
+}
+
+// RegenerateRecoveryCodes reemplaza los códigos de recuperación por otros nuevos
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID int, req *models.MFACodeRequest) (*models.RecoveryCodes, error) {
+	ctx, span := startSpan(ctx, "AuthService.RegenerateRecoveryCodes")
+	defer span.End()
+
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, invalid("code", "el código es requerido")
+	}
+	if s.mfa == nil {
+		return nil, errTwoFactorDisabled
+	}
+
+	credential, err := s.mfa.confirmed(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.mfa.checkCode(ctx, credential, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, invalid("code", ErrInvalidMFACode)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfa.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &models.RecoveryCodes{Codes: codes}, nil
+}
+
+// VerifyMFA completa el login con el token del desafío y un código de la app
+// o de recuperación. Los códigos incorrectos cuentan como logins fallidos.
+func (s *AuthService) VerifyMFA(ctx context.Context, req *models.MFALoginRequest, client models.ClientInfo) (*models.User, error) {
+	ctx, span := startSpan(ctx, "AuthService.VerifyMFA")
+	defer span.End()
+
+	// Validación 1: Token del desafío y código no pueden estar vacíos
+	if strings.TrimSpace(req.Token) == "" {
+		return nil, invalid("mfa_token", "el token del desafío es requerido")
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		return nil, invalid("code", "el código es requerido")
+	}
+	if s.mfa == nil {
+		return nil, errTwoFactorDisabled
+	}
+
+	// Validación 2: Desafío vigente. Se usa recién con un código correcto,
+	// así un error de tipeo no obliga a poner la contraseña de nuevo.
+	challengeHash := auth.HashToken(strings.TrimSpace(req.Token))
+	challenge, err := s.mfa.tokens.Find(ctx, models.TokenPurposeMFAChallenge, challengeHash, s.mfa.now())
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, unauthorized(ErrInvalidMFAChallenge)
+	}
+
+	user, err := s.userRepo.FindByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, unauthorized(ErrInvalidMFAChallenge)
+	}
+
+	// Validación 3: Ni la cuenta ni la IP bloqueadas por intentos fallidos
+	attempt, err := s.reserveLoginAttempt(ctx, user.Email, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validación 4: Código correcto
+	credential, err := s.mfa.repo.FindTOTP(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil || !credential.IsConfirmed() {
+		return nil, unauthorized(ErrInvalidMFAChallenge)
+	}
+	ok, err := s.mfa.checkCode(ctx, credential, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		s.loginFailed(ctx, attempt, LoginFailureBadMFACode)
+		return nil, unauthorized(ErrInvalidMFACode)
+	}
+
+	// El desafío sirve para un solo login, aunque lleguen dos códigos a la vez
+	consumed, err := s.mfa.tokens.Consume(ctx, models.TokenPurposeMFAChallenge, challengeHash, s.mfa.now())
+	if err != nil {
+		return nil, err
+	}
+	if consumed == nil {
+		return nil, unauthorized(ErrInvalidMFAChallenge)
+	}
+
+	if attempt != nil {
+		if err := s.throttle.succeeded(ctx, attempt); err != nil {
+			logging.FromContext(ctx).Error("No se pudieron reiniciar los intentos fallidos", "user_id", user.ID, "error", err)
+		}
+	}
+
+	// Validación 5: Usuario no suspendido desde el primer paso
+	if user.IsBanned() {
+		s.events.LoginFailed(LoginFailureBanned)
+		return nil, forbidden("tu cuenta está suspendida")
+	}
+
+	return user, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+)
+
+// mailTimeout es el plazo de lo que se hace en segundo plano para mandar un mail
+const mailTimeout = 30 * time.Second
+
+// issueUserToken invalida los tokens anteriores del usuario para ese uso y emite
+// uno nuevo. Devuelve el token en claro, que solo viaja en el mail.
+func issueUserToken(ctx context.Context, tokens repository.UserTokenRepository, userID int, purpose string, expiresAt time.Time) (string, error) {
+	if err := tokens.DeleteForUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, err := auth.NewOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+
+	record := &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: expiresAt,
+	}
+	if err := tokens.Create(ctx, record, auth.HashToken(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// tokenLink agrega el token a la URL base como ?token=...
+func tokenLink(base string, token string) string {
+	link, err := url.Parse(base)
+	if err != nil {
+		return base + "?token=" + url.QueryEscape(token)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String()
+}
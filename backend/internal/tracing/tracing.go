@@ -0,0 +1,88 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exportadores aceptados (OTEL_TRACES_EXPORTER)
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Config define a dónde se exportan las trazas. El endpoint del exportador
+// OTLP (OTEL_EXPORTER_OTLP_ENDPOINT, OTEL_EXPORTER_OTLP_HEADERS, etc.) y el
+// muestreo (OTEL_TRACES_SAMPLER) los lee directamente el SDK.
+type Config struct {
+	// Exporter es "none", "otlp" o "stdout"
+	Exporter    string
+	ServiceName string
+	// Stdout es el destino del exportador "stdout" (default: os.Stdout)
+	Stdout io.Writer
+}
+
+// Setup instala el TracerProvider y el propagador W3C (traceparent y baggage)
+// globales. Devuelve la función que vacía y cierra el exportador al apagar.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	// El propagador se instala siempre: aunque no se exporte nada,
+	// el traceparent entrante se respeta y se reenvía
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterStdout:
+		out := config.Stdout
+		if out == nil {
+			out = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(out), stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER inválido: %q (none, otlp o stdout)", config.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo crear el exportador de trazas: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.ServiceName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer devuelve un tracer del provider global. Se resuelve en cada llamada
+// para que los tests puedan instalar su propio provider.
+func Tracer(name string) trace.Tracer {
+	return otel.Tracer(name)
+}
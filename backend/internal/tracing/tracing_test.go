@@ -0,0 +1,56 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+// restoreGlobals deja el provider y el propagador como estaban antes del test
+func restoreGlobals(t *testing.T) {
+	provider := otel.GetTracerProvider()
+	propagator := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestSetup_Stdout(t *testing.T) {
+	restoreGlobals(t)
+	var out bytes.Buffer
+
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterStdout, ServiceName: "api-test", Stdout: &out})
+	require.NoError(t, err)
+
+	_, span := Tracer("test").Start(context.Background(), "PostService.CreatePost")
+	span.End()
+
+	// Shutdown vacía el batcher: recién ahí se escribe el span
+	require.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), "PostService.CreatePost")
+	assert.Contains(t, out.String(), "api-test")
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+func TestSetup_None(t *testing.T) {
+	restoreGlobals(t)
+
+	shutdown, err := Setup(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	// Sin exportador el traceparent se sigue propagando
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+func TestSetup_ExportadorInvalido(t *testing.T) {
+	restoreGlobals(t)
+
+	_, err := Setup(context.Background(), Config{Exporter: "jaeger"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,196 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/repository"
+
+	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// firstTokenPage is large enough to hold every token a test creates
+var firstTokenPage = pagination.Params{Limit: pagination.DefaultLimit}
+
+type APITokenIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	repo      *repository.PostgreSQLAPITokenRepository
+	user      *models.User
+	cleanupDB func()
+}
+
+func (suite *APITokenIntegrationTestSuite) SetupTest() {
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+	suite.repo = repository.NewPostgreSQLAPITokenRepository(db)
+	suite.Require().NoError(CleanupTestDB(db))
+
+	suite.user = &models.User{Email: "bot@example.com", Password: "hashedpassword", Username: "bot"}
+	suite.Require().NoError(repository.NewPostgreSQLUserRepository(db).Create(context.Background(), suite.user))
+}
+
+func (suite *APITokenIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+func (suite *APITokenIntegrationTestSuite) createToken(name string, token string) *models.APIToken {
+	record := &models.APIToken{
+		UserID:    suite.user.ID,
+		Name:      name,
+		Prefix:    token[:12],
+		Scopes:    []string{models.ScopePostsWrite, models.ScopeCommentsRead},
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	suite.Require().NoError(suite.repo.Create(context.Background(), record, auth.HashToken(token)))
+	suite.NotZero(record.ID)
+	suite.False(record.CreatedAt.IsZero())
+	return record
+}
+
+func (suite *APITokenIntegrationTestSuite) TestCreateAndFindByHash() {
+	ctx := context.Background()
+	created := suite.createToken("bot de CI", "pat_abcdefgh-resto-del-token")
+
+	found, err := suite.repo.FindByHash(ctx, auth.HashToken("pat_abcdefgh-resto-del-token"))
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found)
+	suite.Equal(created.ID, found.ID)
+	suite.Equal(suite.user.ID, found.UserID)
+	suite.Equal("pat_abcdefgh", found.Prefix)
+	suite.Equal([]string{models.ScopePostsWrite, models.ScopeCommentsRead}, found.Scopes)
+	suite.Nil(found.LastUsedAt)
+	suite.False(found.OwnerBanned)
+
+	// The raw token is never stored
+	var count int
+	suite.Require().NoError(suite.db.QueryRow(`SELECT COUNT(*) FROM api_tokens WHERE token_hash = $1`, "pat_abcdefgh-resto-del-token").Scan(&count))
+	suite.Equal(0, count)
+
+	missing, err := suite.repo.FindByHash(ctx, auth.HashToken("pat_otro"))
+	suite.Require().NoError(err)
+	suite.Nil(missing)
+}
+
+func (suite *APITokenIntegrationTestSuite) TestRevokeAndList() {
+	ctx := context.Background()
+	first := suite.createToken("primero", "pat_11111111-resto")
+	second := suite.createToken("segundo", "pat_22222222-resto")
+
+	tokens, err := suite.repo.FindActiveByUserID(ctx, suite.user.ID, firstTokenPage)
+	suite.Require().NoError(err)
+	suite.Require().Len(tokens, 2)
+	suite.Equal(second.ID, tokens[0].ID)
+
+	// Another user's token cannot be revoked
+	revoked, err := suite.repo.Revoke(ctx, suite.user.ID+1, first.ID)
+	suite.Require().NoError(err)
+	suite.False(revoked)
+
+	revoked, err = suite.repo.Revoke(ctx, suite.user.ID, first.ID)
+	suite.Require().NoError(err)
+	suite.True(revoked)
+
+	revoked, err = suite.repo.Revoke(ctx, suite.user.ID, first.ID)
+	suite.Require().NoError(err)
+	suite.False(revoked)
+
+	tokens, err = suite.repo.FindActiveByUserID(ctx, suite.user.ID, firstTokenPage)
+	suite.Require().NoError(err)
+	suite.Require().Len(tokens, 1)
+	suite.Equal(second.ID, tokens[0].ID)
+
+	found, err := suite.repo.FindByHash(ctx, auth.HashToken("pat_11111111-resto"))
+	suite.Require().NoError(err)
+	suite.NotNil(found.RevokedAt)
+}
+
+func (suite *APITokenIntegrationTestSuite) TestList_KeysetPagination() {
+	ctx := context.Background()
+	first := suite.createToken("primero", "pat_11111111-resto")
+	second := suite.createToken("segundo", "pat_22222222-resto")
+	third := suite.createToken("tercero", "pat_33333333-resto")
+
+	// The repository returns limit+1 rows so the service knows there is a next page
+	tokens, err := suite.repo.FindActiveByUserID(ctx, suite.user.ID, pagination.Params{Limit: 1})
+	suite.Require().NoError(err)
+	suite.Require().Len(tokens, 2)
+	suite.Equal(third.ID, tokens[0].ID)
+	suite.Equal(second.ID, tokens[1].ID)
+
+	after := &pagination.Cursor{CreatedAt: tokens[0].CreatedAt, ID: tokens[0].ID}
+	tokens, err = suite.repo.FindActiveByUserID(ctx, suite.user.ID, pagination.Params{Limit: 2, After: after})
+	suite.Require().NoError(err)
+	suite.Require().Len(tokens, 2)
+	suite.Equal(second.ID, tokens[0].ID)
+	suite.Equal(first.ID, tokens[1].ID)
+}
+
+func (suite *APITokenIntegrationTestSuite) TestList_SpanEndsWhenRowsClose() {
+	suite.createToken("primero", "pat_11111111-resto")
+	suite.createToken("segundo", "pat_22222222-resto")
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	tokens, err := suite.repo.FindActiveByUserID(context.Background(), suite.user.ID, firstTokenPage)
+	suite.Require().NoError(err)
+	suite.Len(tokens, 2)
+
+	// The span is ended once, by rows.Close(), and covers reading every row
+	spans := recorder.Ended()
+	suite.Require().Len(spans, 1)
+	suite.Equal("SELECT", spans[0].Name())
+	suite.Equal(codes.Unset, spans[0].Status().Code)
+}
+
+func (suite *APITokenIntegrationTestSuite) TestRevokeAllForUserAndBannedOwner() {
+	ctx := context.Background()
+	suite.createToken("primero", "pat_44444444-resto")
+	suite.createToken("segundo", "pat_55555555-resto")
+
+	// A banned owner is reported by FindByHash
+	suite.Require().NoError(repository.NewPostgreSQLUserRepository(suite.db).SetBanned(ctx, suite.user.ID, true))
+	found, err := suite.repo.FindByHash(ctx, auth.HashToken("pat_44444444-resto"))
+	suite.Require().NoError(err)
+	suite.True(found.OwnerBanned)
+
+	suite.Require().NoError(suite.repo.RevokeAllForUser(ctx, suite.user.ID))
+
+	tokens, err := suite.repo.FindActiveByUserID(ctx, suite.user.ID, firstTokenPage)
+	suite.Require().NoError(err)
+	suite.Empty(tokens)
+}
+
+func (suite *APITokenIntegrationTestSuite) TestTouchLastUsed() {
+	ctx := context.Background()
+	created := suite.createToken("bot", "pat_33333333-resto")
+
+	at := time.Now().UTC().Truncate(time.Second)
+	suite.Require().NoError(suite.repo.TouchLastUsed(ctx, created.ID, at))
+
+	found, err := suite.repo.FindByHash(ctx, auth.HashToken("pat_33333333-resto"))
+	suite.Require().NoError(err)
+	suite.Require().NotNil(found.LastUsedAt)
+	suite.True(found.LastUsedAt.Equal(at))
+}
+
+func TestAPITokenIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(APITokenIntegrationTestSuite))
+}
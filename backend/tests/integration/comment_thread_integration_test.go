@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CommentThreadIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	repo      *repository.PostgreSQLPostRepository
+	user      *models.User
+	post      *models.Post
+	cleanupDB func()
+}
+
+func (suite *CommentThreadIntegrationTestSuite) SetupTest() {
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+	suite.repo = repository.NewPostgreSQLPostRepository(db)
+	suite.Require().NoError(CleanupTestDB(db))
+
+	ctx := context.Background()
+	suite.user = &models.User{Email: "hilos@example.com", Password: "hashedpassword", Username: "hilos"}
+	suite.Require().NoError(repository.NewPostgreSQLUserRepository(db).Create(ctx, suite.user))
+
+	suite.post = &models.Post{Title: "Hilo", Content: "Contenido", UserID: suite.user.ID}
+	suite.Require().NoError(suite.repo.Create(ctx, suite.post))
+}
+
+func (suite *CommentThreadIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+func (suite *CommentThreadIntegrationTestSuite) createComment(parent *models.Comment) *models.Comment {
+	comment := &models.Comment{PostID: suite.post.ID, UserID: suite.user.ID, Content: "comentario"}
+	if parent != nil {
+		comment.ParentID = &parent.ID
+	}
+	suite.Require().NoError(suite.repo.CreateComment(context.Background(), comment))
+	return comment
+}
+
+func (suite *CommentThreadIntegrationTestSuite) findComment(id int) *models.Comment {
+	comment, err := suite.repo.FindCommentByID(context.Background(), id)
+	suite.Require().NoError(err)
+	return comment
+}
+
+func (suite *CommentThreadIntegrationTestSuite) TestDeleteComment_WithRepliesKeepsPlaceholder() {
+	root := suite.createComment(nil)
+	reply := suite.createComment(root)
+
+	suite.Require().NoError(suite.repo.DeleteComment(context.Background(), suite.post.ID, root.ID))
+
+	placeholder := suite.findComment(root.ID)
+	suite.Require().NotNil(placeholder)
+	suite.True(placeholder.Deleted)
+	suite.Empty(placeholder.Content)
+	suite.NotNil(suite.findComment(reply.ID))
+}
+
+func (suite *CommentThreadIntegrationTestSuite) TestDeleteComment_LastReplyRemovesDeletedAncestors() {
+	ctx := context.Background()
+	root := suite.createComment(nil)
+	middle := suite.createComment(root)
+	leaf := suite.createComment(middle)
+
+	// Both ancestors become placeholders because they still have replies
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, suite.post.ID, root.ID))
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, suite.post.ID, middle.ID))
+
+	// Removing the only leaf leaves nothing for the placeholders to hold up
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, suite.post.ID, leaf.ID))
+
+	suite.Nil(suite.findComment(leaf.ID))
+	suite.Nil(suite.findComment(middle.ID))
+	suite.Nil(suite.findComment(root.ID))
+}
+
+func (suite *CommentThreadIntegrationTestSuite) TestDeleteComment_StopsAtAncestorWithOtherReplies() {
+	ctx := context.Background()
+	root := suite.createComment(nil)
+	first := suite.createComment(root)
+	second := suite.createComment(root)
+
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, suite.post.ID, root.ID))
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, suite.post.ID, first.ID))
+
+	// The placeholder still holds the second reply
+	placeholder := suite.findComment(root.ID)
+	suite.Require().NotNil(placeholder)
+	suite.True(placeholder.Deleted)
+	suite.NotNil(suite.findComment(second.ID))
+}
+
+func (suite *CommentThreadIntegrationTestSuite) TestDeleteComment_KeepsLiveAncestor() {
+	ctx := context.Background()
+	root := suite.createComment(nil)
+	reply := suite.createComment(root)
+
+	suite.Require().NoError(suite.repo.DeleteComment(ctx, suite.post.ID, reply.ID))
+
+	live := suite.findComment(root.ID)
+	suite.Require().NotNil(live)
+	suite.False(live.Deleted)
+	suite.Equal("comentario", live.Content)
+}
+
+func (suite *CommentThreadIntegrationTestSuite) TestParentForeignKey_DoesNotCascade() {
+	root := suite.createComment(nil)
+	reply := suite.createComment(root)
+
+	// A raw delete of the parent must not take its replies with it
+	_, err := suite.db.Exec(`DELETE FROM comments WHERE id = $1`, root.ID)
+	suite.Require().NoError(err)
+
+	orphan := suite.findComment(reply.ID)
+	suite.Require().NotNil(orphan)
+	suite.Nil(orphan.ParentID)
+}
+
+func TestCommentThreadIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(CommentThreadIntegrationTestSuite))
+}
@@ -0,0 +1,198 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type LoginThrottleIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	repo      *repository.PostgreSQLLoginThrottleRepository
+	cleanupDB func()
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) SetupTest() {
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+	suite.repo = repository.NewPostgreSQLLoginThrottleRepository(db)
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+// acceptAll lets every reservation through
+func acceptAll(*models.LoginThrottle) error { return nil }
+
+func (suite *LoginThrottleIntegrationTestSuite) TestReserve_CountsAndLocks() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	resetBefore := now.Add(-time.Hour)
+
+	missing, err := suite.repo.Find(ctx, models.ThrottleScopeAccount, "victim@example.com")
+	suite.Require().NoError(err)
+	suite.Nil(missing)
+
+	for i := 1; i <= 3; i++ {
+		throttle, err := suite.repo.Reserve(ctx, models.ThrottleScopeAccount, "victim@example.com", now, resetBefore, acceptAll)
+		suite.Require().NoError(err)
+		suite.Equal(i, throttle.Failures)
+	}
+
+	suite.Require().NoError(suite.repo.Lock(ctx, models.ThrottleScopeAccount, "victim@example.com", now.Add(15*time.Minute)))
+
+	throttle, err := suite.repo.Find(ctx, models.ThrottleScopeAccount, "victim@example.com")
+	suite.Require().NoError(err)
+	suite.Equal(3, throttle.Failures)
+	suite.Require().NotNil(throttle.LockedUntil)
+	suite.WithinDuration(now.Add(15*time.Minute), *throttle.LockedUntil, time.Millisecond)
+
+	// The same subject under another scope is tracked separately
+	other, err := suite.repo.Find(ctx, models.ThrottleScopeIP, "victim@example.com")
+	suite.Require().NoError(err)
+	suite.Nil(other)
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) TestReserve_OldFailuresStartOver() {
+	ctx := context.Background()
+	old := time.Now().Add(-2 * time.Hour)
+
+	_, err := suite.repo.Reserve(ctx, models.ThrottleScopeIP, "203.0.113.7", old, old.Add(-time.Hour), acceptAll)
+	suite.Require().NoError(err)
+	suite.Require().NoError(suite.repo.Lock(ctx, models.ThrottleScopeIP, "203.0.113.7", old.Add(time.Minute)))
+
+	now := time.Now()
+	throttle, err := suite.repo.Reserve(ctx, models.ThrottleScopeIP, "203.0.113.7", now, now.Add(-time.Hour), acceptAll)
+	suite.Require().NoError(err)
+	suite.Equal(1, throttle.Failures)
+	suite.Nil(throttle.LockedUntil)
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) TestReserve_ConcurrentAttemptsAreAllCounted() {
+	ctx := context.Background()
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.repo.Reserve(ctx, models.ThrottleScopeAccount, "victim@example.com", now, now.Add(-time.Hour), acceptAll)
+			suite.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	throttle, err := suite.repo.Find(ctx, models.ThrottleScopeAccount, "victim@example.com")
+	suite.Require().NoError(err)
+	suite.Equal(20, throttle.Failures)
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) TestReserve_ConcurrentAttemptsSeeEachOther() {
+	ctx := context.Background()
+	now := time.Now()
+	errBlocked := errors.New("blocked")
+	// Only the first three attempts are let through; the rest must see them
+	allowThree := func(current *models.LoginThrottle) error {
+		if current != nil && current.Failures >= 3 {
+			return errBlocked
+		}
+		return nil
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		allowed int
+		blocked int
+	)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := suite.repo.Reserve(ctx, models.ThrottleScopeAccount, "victim@example.com", now, now.Add(-time.Hour), allowThree)
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, errBlocked) {
+				blocked++
+				return
+			}
+			suite.NoError(err)
+			allowed++
+		}()
+	}
+	wg.Wait()
+
+	suite.Equal(3, allowed)
+	suite.Equal(17, blocked)
+	throttle, err := suite.repo.Find(ctx, models.ThrottleScopeAccount, "victim@example.com")
+	suite.Require().NoError(err)
+	suite.Equal(3, throttle.Failures)
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) TestRelease_GivesBackTheAttempt() {
+	ctx := context.Background()
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.repo.Reserve(ctx, models.ThrottleScopeIP, "203.0.113.7", now, now.Add(-time.Hour), acceptAll)
+		suite.Require().NoError(err)
+	}
+	suite.Require().NoError(suite.repo.Release(ctx, models.ThrottleScopeIP, "203.0.113.7"))
+
+	throttle, err := suite.repo.Find(ctx, models.ThrottleScopeIP, "203.0.113.7")
+	suite.Require().NoError(err)
+	suite.Equal(1, throttle.Failures)
+
+	// Releasing more than was reserved never goes below zero
+	suite.Require().NoError(suite.repo.Release(ctx, models.ThrottleScopeIP, "203.0.113.7"))
+	suite.Require().NoError(suite.repo.Release(ctx, models.ThrottleScopeIP, "203.0.113.7"))
+	throttle, err = suite.repo.Find(ctx, models.ThrottleScopeIP, "203.0.113.7")
+	suite.Require().NoError(err)
+	suite.Equal(0, throttle.Failures)
+}
+
+func (suite *LoginThrottleIntegrationTestSuite) TestResetAndDeleteStale() {
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := suite.repo.Reserve(ctx, models.ThrottleScopeAccount, "fresh@example.com", now, now.Add(-time.Hour), acceptAll)
+	suite.Require().NoError(err)
+	_, err = suite.repo.Reserve(ctx, models.ThrottleScopeAccount, "stale@example.com", now.Add(-2*time.Hour), now.Add(-3*time.Hour), acceptAll)
+	suite.Require().NoError(err)
+	_, err = suite.repo.Reserve(ctx, models.ThrottleScopeAccount, "unlocked@example.com", now, now.Add(-time.Hour), acceptAll)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(suite.repo.Reset(ctx, models.ThrottleScopeAccount, "unlocked@example.com"))
+	suite.Require().NoError(suite.repo.DeleteStale(ctx, now.Add(-time.Hour)))
+
+	var subjects []string
+	rows, err := suite.db.Query(`SELECT subject FROM login_throttles ORDER BY subject`)
+	suite.Require().NoError(err)
+	defer rows.Close()
+	for rows.Next() {
+		var subject string
+		suite.Require().NoError(rows.Scan(&subject))
+		subjects = append(subjects, subject)
+	}
+	suite.Equal([]string{"fresh@example.com"}, subjects)
+}
+
+func TestLoginThrottleIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginThrottleIntegrationTestSuite))
+}
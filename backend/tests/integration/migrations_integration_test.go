@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"ingsw3-tp08/internal/database"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type MigrationsIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	migrator  *database.Migrator
+	cleanupDB func()
+}
+
+func (suite *MigrationsIntegrationTestSuite) SetupTest() {
+	// SetupTestDB already applies every migration
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+
+	suite.migrator, err = database.NewMigrator(db)
+	suite.Require().NoError(err)
+}
+
+func (suite *MigrationsIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+func (suite *MigrationsIntegrationTestSuite) TestUp_Idempotent() {
+	applied, err := suite.migrator.Up()
+
+	suite.NoError(err)
+	suite.Empty(applied)
+}
+
+func (suite *MigrationsIntegrationTestSuite) TestStatus_AllApplied() {
+	statuses, err := suite.migrator.Status()
+
+	suite.NoError(err)
+	suite.Len(statuses, len(suite.migrator.Migrations()))
+	for _, status := range statuses {
+		suite.NotNil(status.AppliedAt, status.Name)
+	}
+}
+
+func (suite *MigrationsIntegrationTestSuite) TestPending() {
+	pending, err := suite.migrator.Pending(context.Background())
+	suite.NoError(err)
+	suite.Empty(pending)
+
+	// After reverting the last migration it shows up as pending
+	_, err = suite.migrator.Down(1)
+	suite.Require().NoError(err)
+
+	pending, err = suite.migrator.Pending(context.Background())
+	suite.NoError(err)
+	suite.Require().Len(pending, 1)
+	suite.Equal(len(suite.migrator.Migrations()), pending[0].Version)
+}
+
+func (suite *MigrationsIntegrationTestSuite) TestDownAndUp_RoundTrip() {
+	// Revert everything, then apply it all again
+	total := len(suite.migrator.Migrations())
+
+	reverted, err := suite.migrator.Down(total)
+	suite.NoError(err)
+	suite.Len(reverted, total)
+
+	var exists bool
+	err = suite.db.QueryRow(`SELECT to_regclass('public.posts') IS NOT NULL`).Scan(&exists)
+	suite.NoError(err)
+	suite.False(exists)
+
+	applied, err := suite.migrator.Up()
+	suite.NoError(err)
+	suite.Len(applied, total)
+}
+
+func TestMigrationsIntegrationSuite(t *testing.T) {
+	suite.Run(t, new(MigrationsIntegrationTestSuite))
+}
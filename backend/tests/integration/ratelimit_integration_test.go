@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/ratelimit"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RateLimitIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	store     *ratelimit.PostgresStore
+	cleanupDB func()
+}
+
+func (suite *RateLimitIntegrationTestSuite) SetupTest() {
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+	suite.store = ratelimit.NewPostgresStore(db)
+}
+
+func (suite *RateLimitIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+func (suite *RateLimitIntegrationTestSuite) TestTake_ExhaustsBucket() {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: ratelimit.PolicyAuth, Limit: 2, Period: time.Hour}
+
+	first, err := suite.store.Take(ctx, "auth:ip:203.0.113.7", policy)
+	suite.Require().NoError(err)
+	suite.True(first.Allowed)
+	suite.Equal(1, first.Remaining)
+
+	second, err := suite.store.Take(ctx, "auth:ip:203.0.113.7", policy)
+	suite.Require().NoError(err)
+	suite.True(second.Allowed)
+
+	third, err := suite.store.Take(ctx, "auth:ip:203.0.113.7", policy)
+	suite.Require().NoError(err)
+	suite.False(third.Allowed)
+	suite.Greater(third.RetryAfter, time.Duration(0))
+
+	// Other keys have their own bucket
+	other, err := suite.store.Take(ctx, "auth:ip:198.51.100.9", policy)
+	suite.Require().NoError(err)
+	suite.True(other.Allowed)
+}
+
+func (suite *RateLimitIntegrationTestSuite) TestTake_ConcurrentInstancesShareTheBucket() {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: ratelimit.PolicyComments, Limit: 5, Period: time.Hour}
+
+	// Two stores over the same database behave like two API instances
+	stores := []*ratelimit.PostgresStore{suite.store, ratelimit.NewPostgresStore(suite.db)}
+
+	var mu sync.Mutex
+	allowed := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(store *ratelimit.PostgresStore) {
+			defer wg.Done()
+			result, err := store.Take(ctx, "comments:user:1", policy)
+			suite.NoError(err)
+			if result.Allowed {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			}
+		}(stores[i%2])
+	}
+	wg.Wait()
+
+	suite.Equal(5, allowed)
+}
+
+func (suite *RateLimitIntegrationTestSuite) TestPrune_DeletesFullBuckets() {
+	ctx := context.Background()
+	policy := ratelimit.Policy{Name: ratelimit.PolicySearch, Limit: 10, Period: 50 * time.Millisecond}
+
+	_, err := suite.store.Take(ctx, "search:ip:203.0.113.7", policy)
+	suite.Require().NoError(err)
+
+	time.Sleep(100 * time.Millisecond)
+	suite.Require().NoError(suite.store.Prune(ctx))
+
+	var count int
+	suite.Require().NoError(suite.db.QueryRow(`SELECT COUNT(*) FROM rate_limit_buckets`).Scan(&count))
+	suite.Equal(0, count)
+}
+
+func TestRateLimitIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(RateLimitIntegrationTestSuite))
+}
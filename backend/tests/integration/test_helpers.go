@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"log"
 
+	"ingsw3-tp08/internal/database"
+
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
@@ -41,10 +43,10 @@ func SetupTestDB() (*sql.DB, func(), error) {
 		return nil, nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Create tables
-	if err := createTables(db); err != nil {
+	// Apply the same embedded migrations used in production
+	if err := database.Migrate(db); err != nil {
 		db.Close()
-		return nil, nil, fmt.Errorf("failed to create tables: %w", err)
+		return nil, nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
 	cleanup := func() {
@@ -57,56 +59,9 @@ func SetupTestDB() (*sql.DB, func(), error) {
 	return db, cleanup, nil
 }
 
-// createTables creates the necessary tables for testing
-func createTables(db *sql.DB) error {
-	// Create users table
-	usersTable := `
-	CREATE TABLE IF NOT EXISTS users (
-		id SERIAL PRIMARY KEY,
-		email VARCHAR(255) UNIQUE NOT NULL,
-		password VARCHAR(255) NOT NULL,
-		username VARCHAR(255) NOT NULL,
-		created_at TIMESTAMP DEFAULT NOW()
-	);`
-
-	if _, err := db.Exec(usersTable); err != nil {
-		return fmt.Errorf("failed to create users table: %w", err)
-	}
-
-	// Create posts table
-	postsTable := `
-	CREATE TABLE IF NOT EXISTS posts (
-		id SERIAL PRIMARY KEY,
-		title VARCHAR(255) NOT NULL,
-		content TEXT NOT NULL,
-		user_id INTEGER NOT NULL REFERENCES users(id),
-		created_at TIMESTAMP DEFAULT NOW()
-	);`
-
-	if _, err := db.Exec(postsTable); err != nil {
-		return fmt.Errorf("failed to create posts table: %w", err)
-	}
-
-	// Create comments table
-	commentsTable := `
-	CREATE TABLE IF NOT EXISTS comments (
-		id SERIAL PRIMARY KEY,
-		post_id INTEGER NOT NULL REFERENCES posts(id),
-		user_id INTEGER NOT NULL REFERENCES users(id),
-		content TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT NOW()
-	);`
-
-	if _, err := db.Exec(commentsTable); err != nil {
-		return fmt.Errorf("failed to create comments table: %w", err)
-	}
-
-	return nil
-}
-
 // CleanupTestDB truncates tables to clean state
 func CleanupTestDB(db *sql.DB) error {
-	tables := []string{"comments", "posts", "users"}
+	tables := []string{"api_tokens", "user_recovery_codes", "user_totp", "user_tokens", "login_throttles", "rate_limit_buckets", "sessions", "comments", "post_revisions", "posts", "users"}
 	for _, table := range tables {
 		query := "TRUNCATE TABLE " + table + " CASCADE"
 		if _, err := db.Exec(query); err != nil {
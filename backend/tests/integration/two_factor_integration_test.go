@@ -0,0 +1,131 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type TwoFactorIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	repo      *repository.PostgreSQLTwoFactorRepository
+	userRepo  *repository.PostgreSQLUserRepository
+	user      *models.User
+	cleanupDB func()
+}
+
+func (suite *TwoFactorIntegrationTestSuite) SetupTest() {
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+	suite.repo = repository.NewPostgreSQLTwoFactorRepository(db)
+	suite.userRepo = repository.NewPostgreSQLUserRepository(db)
+	suite.Require().NoError(CleanupTestDB(db))
+
+	suite.user = &models.User{Email: "mfa@example.com", Password: "hashedpassword", Username: "mfa"}
+	suite.Require().NoError(suite.userRepo.Create(context.Background(), suite.user))
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TestEnrollmentLifecycle() {
+	ctx := context.Background()
+
+	credential, err := suite.repo.FindTOTP(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Nil(credential)
+
+	// A pending secret can be replaced until it is confirmed
+	suite.Require().NoError(suite.repo.SavePendingTOTP(ctx, suite.user.ID, "primero"))
+	suite.Require().NoError(suite.repo.SavePendingTOTP(ctx, suite.user.ID, "segundo"))
+	credential, err = suite.repo.FindTOTP(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal("segundo", credential.Secret)
+	suite.False(credential.IsConfirmed())
+
+	user, err := suite.userRepo.FindByID(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.False(user.MFAEnabled)
+
+	suite.Require().NoError(suite.repo.ConfirmTOTP(ctx, suite.user.ID, 100, []string{"hash-1", "hash-2"}))
+	suite.ErrorIs(suite.repo.ConfirmTOTP(ctx, suite.user.ID, 101, []string{"hash-3"}), repository.ErrTOTPNotPending)
+
+	// Once confirmed, a new enrollment does not overwrite the secret
+	suite.Require().NoError(suite.repo.SavePendingTOTP(ctx, suite.user.ID, "tercero"))
+	credential, err = suite.repo.FindTOTP(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Equal("segundo", credential.Secret)
+	suite.True(credential.IsConfirmed())
+	suite.Equal(int64(100), credential.LastUsedStep)
+
+	user, err = suite.userRepo.FindByEmail(ctx, suite.user.Email)
+	suite.Require().NoError(err)
+	suite.True(user.MFAEnabled)
+
+	suite.Require().NoError(suite.repo.DeleteTOTP(ctx, suite.user.ID))
+	credential, err = suite.repo.FindTOTP(ctx, suite.user.ID)
+	suite.Require().NoError(err)
+	suite.Nil(credential)
+
+	var count int
+	suite.Require().NoError(suite.db.QueryRow(`SELECT COUNT(*) FROM user_recovery_codes`).Scan(&count))
+	suite.Equal(0, count)
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TestUseTOTPStepRejectsReplays() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.repo.SavePendingTOTP(ctx, suite.user.ID, "secreto"))
+	suite.Require().NoError(suite.repo.ConfirmTOTP(ctx, suite.user.ID, 100, nil))
+
+	ok, err := suite.repo.UseTOTPStep(ctx, suite.user.ID, 100)
+	suite.Require().NoError(err)
+	suite.False(ok)
+
+	ok, err = suite.repo.UseTOTPStep(ctx, suite.user.ID, 101)
+	suite.Require().NoError(err)
+	suite.True(ok)
+
+	ok, err = suite.repo.UseTOTPStep(ctx, suite.user.ID, 101)
+	suite.Require().NoError(err)
+	suite.False(ok)
+}
+
+func (suite *TwoFactorIntegrationTestSuite) TestRecoveryCodesAreSingleUse() {
+	ctx := context.Background()
+	suite.Require().NoError(suite.repo.SavePendingTOTP(ctx, suite.user.ID, "secreto"))
+	suite.Require().NoError(suite.repo.ConfirmTOTP(ctx, suite.user.ID, 100, []string{"hash-1", "hash-2"}))
+
+	ok, err := suite.repo.UseRecoveryCode(ctx, suite.user.ID, "hash-1")
+	suite.Require().NoError(err)
+	suite.True(ok)
+
+	ok, err = suite.repo.UseRecoveryCode(ctx, suite.user.ID, "hash-1")
+	suite.Require().NoError(err)
+	suite.False(ok)
+
+	// Regenerating drops the old codes, used or not
+	suite.Require().NoError(suite.repo.ReplaceRecoveryCodes(ctx, suite.user.ID, []string{"hash-3"}))
+	ok, err = suite.repo.UseRecoveryCode(ctx, suite.user.ID, "hash-2")
+	suite.Require().NoError(err)
+	suite.False(ok)
+
+	ok, err = suite.repo.UseRecoveryCode(ctx, suite.user.ID, "hash-3")
+	suite.Require().NoError(err)
+	suite.True(ok)
+}
+
+func TestTwoFactorIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(TwoFactorIntegrationTestSuite))
+}
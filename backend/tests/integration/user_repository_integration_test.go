@@ -1,13 +1,20 @@
 package integration
 
 import (
+	"context"
 	"database/sql"
+	"sync"
 	"testing"
+	"time"
 
 	"ingsw3-tp08/internal/models"
 	"ingsw3-tp08/internal/repository"
 
 	"github.com/stretchr/testify/suite"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type UserRepositoryIntegrationTestSuite struct {
@@ -47,14 +54,14 @@ func (suite *UserRepositoryIntegrationTestSuite) TestCreate_Success() {
 	}
 
 	// Execute
-	err := suite.repo.Create(user)
+	err := suite.repo.Create(context.Background(), user)
 
 	// Assert
 	suite.NoError(err)
 	suite.NotZero(user.ID) // Should have auto-generated ID
 
 	// Verify can find the user
-	found, err := suite.repo.FindByEmail("test@example.com")
+	found, err := suite.repo.FindByEmail(context.Background(), "test@example.com")
 	suite.NoError(err)
 	suite.NotNil(found)
 	suite.Equal(user.ID, found.ID)
@@ -69,7 +76,7 @@ func (suite *UserRepositoryIntegrationTestSuite) TestCreate_DuplicateEmail() {
 		Password: "pass1",
 		Username: "user1",
 	}
-	err := suite.repo.Create(user1)
+	err := suite.repo.Create(context.Background(), user1)
 	suite.NoError(err)
 
 	// Try to create second user with same email (should fail due to unique constraint)
@@ -78,7 +85,7 @@ func (suite *UserRepositoryIntegrationTestSuite) TestCreate_DuplicateEmail() {
 		Password: "pass2",
 		Username: "user2",
 	}
-	err = suite.repo.Create(user2)
+	err = suite.repo.Create(context.Background(), user2)
 	suite.Error(err) // Should fail due to duplicate email
 }
 
@@ -89,11 +96,11 @@ func (suite *UserRepositoryIntegrationTestSuite) TestFindByEmail_Exists() {
 		Password: "password",
 		Username: "findme",
 	}
-	err := suite.repo.Create(user)
+	err := suite.repo.Create(context.Background(), user)
 	suite.NoError(err)
 
 	// Find by email
-	found, err := suite.repo.FindByEmail("findme@example.com")
+	found, err := suite.repo.FindByEmail(context.Background(), "findme@example.com")
 
 	// Assert
 	suite.NoError(err)
@@ -105,7 +112,7 @@ func (suite *UserRepositoryIntegrationTestSuite) TestFindByEmail_Exists() {
 
 func (suite *UserRepositoryIntegrationTestSuite) TestFindByEmail_NotExists() {
 	// Try to find non-existent user
-	found, err := suite.repo.FindByEmail("nonexistent@example.com")
+	found, err := suite.repo.FindByEmail(context.Background(), "nonexistent@example.com")
 
 	// Assert
 	suite.NoError(err)
@@ -119,11 +126,11 @@ func (suite *UserRepositoryIntegrationTestSuite) TestFindByID_Exists() {
 		Password: "password",
 		Username: "findbyid",
 	}
-	err := suite.repo.Create(user)
+	err := suite.repo.Create(context.Background(), user)
 	suite.NoError(err)
 
 	// Find by ID
-	found, err := suite.repo.FindByID(user.ID)
+	found, err := suite.repo.FindByID(context.Background(), user.ID)
 
 	// Assert
 	suite.NoError(err)
@@ -135,13 +142,124 @@ func (suite *UserRepositoryIntegrationTestSuite) TestFindByID_Exists() {
 
 func (suite *UserRepositoryIntegrationTestSuite) TestFindByID_NotExists() {
 	// Try to find non-existent user by ID
-	found, err := suite.repo.FindByID(99999)
+	found, err := suite.repo.FindByID(context.Background(), 99999)
 
 	// Assert
 	suite.NoError(err)
 	suite.Nil(found)
 }
 
+func (suite *UserRepositoryIntegrationTestSuite) TestUpdatePassword_Success() {
+	// Create user with a legacy plaintext password
+	user := &models.User{
+		Email:    "legacy@example.com",
+		Password: "plaintext",
+		Username: "legacy",
+	}
+	err := suite.repo.Create(context.Background(), user)
+	suite.NoError(err)
+
+	// Replace it with a hash
+	err = suite.repo.UpdatePassword(context.Background(), user.ID, "$2a$04$newhashvalue")
+	suite.NoError(err)
+
+	// Assert
+	found, err := suite.repo.FindByID(context.Background(), user.ID)
+	suite.NoError(err)
+	suite.NotNil(found)
+	suite.Equal("$2a$04$newhashvalue", found.Password)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestMarkEmailVerified_KeepsFirstDate() {
+	// New users start unverified
+	user := &models.User{Email: "verify@example.com", Password: "hashedpassword", Username: "verify"}
+	suite.Require().NoError(suite.repo.Create(context.Background(), user))
+
+	found, err := suite.repo.FindByEmail(context.Background(), user.Email)
+	suite.Require().NoError(err)
+	suite.False(found.IsEmailVerified())
+
+	// Verify twice: the second call must not move the date
+	suite.Require().NoError(suite.repo.MarkEmailVerified(context.Background(), user.ID))
+	first, err := suite.repo.FindByID(context.Background(), user.ID)
+	suite.Require().NoError(err)
+	suite.Require().True(first.IsEmailVerified())
+
+	suite.Require().NoError(suite.repo.MarkEmailVerified(context.Background(), user.ID))
+	second, err := suite.repo.FindByID(context.Background(), user.ID)
+	suite.Require().NoError(err)
+	suite.True(first.EmailVerifiedAt.Equal(*second.EmailVerifiedAt))
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestClaimVerificationEmail_OneWinner() {
+	ctx := context.Background()
+	user := &models.User{Email: "claim@example.com", Password: "hashedpassword", Username: "claim"}
+	suite.Require().NoError(suite.repo.Create(ctx, user))
+
+	// Concurrent claims inside the cooldown: exactly one wins
+	now := time.Now().UTC().Truncate(time.Second)
+	const requests = 10
+	results := make(chan bool, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, _, err := suite.repo.ClaimVerificationEmail(ctx, user.ID, now, now.Add(-time.Minute))
+			suite.NoError(err)
+			results <- claimed
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	winners := 0
+	for claimed := range results {
+		if claimed {
+			winners++
+		}
+	}
+	suite.Equal(1, winners)
+
+	// The losers learn when the last mail went out
+	claimed, lastSent, err := suite.repo.ClaimVerificationEmail(ctx, user.ID, now.Add(30*time.Second), now.Add(-30*time.Second))
+	suite.Require().NoError(err)
+	suite.False(claimed)
+	suite.Require().NotNil(lastSent)
+	suite.True(lastSent.Equal(now))
+
+	// Once the cooldown is over the next claim wins
+	claimed, _, err = suite.repo.ClaimVerificationEmail(ctx, user.ID, now.Add(time.Minute), now)
+	suite.Require().NoError(err)
+	suite.True(claimed)
+}
+
+func (suite *UserRepositoryIntegrationTestSuite) TestQueries_EmitSpans() {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	// A missing user is not an error in the span
+	found, err := suite.repo.FindByEmail(context.Background(), "missing@example.com")
+	suite.NoError(err)
+	suite.Nil(found)
+
+	spans := recorder.Ended()
+	suite.Require().Len(spans, 1)
+	suite.Equal("SELECT", spans[0].Name())
+	suite.Equal(codes.Unset, spans[0].Status().Code)
+
+	attrs := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	suite.Equal("postgresql", attrs["db.system.name"])
+	suite.Contains(attrs["db.query.text"], "FROM users")
+	// Query parameters are never exported
+	suite.NotContains(attrs["db.query.text"], "missing@example.com")
+}
+
 func TestUserRepositoryIntegrationTestSuite(t *testing.T) {
 	suite.Run(t, new(UserRepositoryIntegrationTestSuite))
 }
@@ -0,0 +1,130 @@
+package integration
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type UserTokenIntegrationTestSuite struct {
+	suite.Suite
+	db        *sql.DB
+	repo      *repository.PostgreSQLUserTokenRepository
+	user      *models.User
+	cleanupDB func()
+}
+
+func (suite *UserTokenIntegrationTestSuite) SetupTest() {
+	db, cleanup, err := SetupTestDB()
+	suite.Require().NoError(err)
+
+	suite.db = db
+	suite.cleanupDB = cleanup
+	suite.repo = repository.NewPostgreSQLUserTokenRepository(db)
+	suite.Require().NoError(CleanupTestDB(db))
+
+	suite.user = &models.User{Email: "reset@example.com", Password: "hashedpassword", Username: "reset"}
+	suite.Require().NoError(repository.NewPostgreSQLUserRepository(db).Create(context.Background(), suite.user))
+}
+
+func (suite *UserTokenIntegrationTestSuite) TearDownTest() {
+	if suite.cleanupDB != nil {
+		suite.cleanupDB()
+	}
+}
+
+func (suite *UserTokenIntegrationTestSuite) createToken(token string, expiresAt time.Time) {
+	record := &models.UserToken{UserID: suite.user.ID, Purpose: models.TokenPurposePasswordReset, ExpiresAt: expiresAt}
+	suite.Require().NoError(suite.repo.Create(context.Background(), record, auth.HashToken(token)))
+	suite.False(record.CreatedAt.IsZero())
+}
+
+func (suite *UserTokenIntegrationTestSuite) TestConsume_OnlyOnce() {
+	ctx := context.Background()
+	suite.createToken("token-del-mail", time.Now().Add(time.Hour))
+
+	first, err := suite.repo.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken("token-del-mail"), time.Now())
+	suite.Require().NoError(err)
+	suite.Require().NotNil(first)
+	suite.Equal(suite.user.ID, first.UserID)
+	suite.NotNil(first.UsedAt)
+
+	second, err := suite.repo.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken("token-del-mail"), time.Now())
+	suite.Require().NoError(err)
+	suite.Nil(second)
+}
+
+func (suite *UserTokenIntegrationTestSuite) TestConsume_RejectsExpiredAndUnknown() {
+	ctx := context.Background()
+	suite.createToken("vencido", time.Now().Add(-time.Minute))
+
+	expired, err := suite.repo.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken("vencido"), time.Now())
+	suite.Require().NoError(err)
+	suite.Nil(expired)
+
+	unknown, err := suite.repo.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken("inventado"), time.Now())
+	suite.Require().NoError(err)
+	suite.Nil(unknown)
+}
+
+func (suite *UserTokenIntegrationTestSuite) TestConsume_ConcurrentRequestsOnlyOneWins() {
+	ctx := context.Background()
+	suite.createToken("token-del-mail", time.Now().Add(time.Hour))
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			token, err := suite.repo.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken("token-del-mail"), time.Now())
+			suite.NoError(err)
+			if token != nil {
+				wins.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	suite.Equal(int32(1), wins.Load())
+}
+
+func (suite *UserTokenIntegrationTestSuite) TestDeleteForUserAndExpired() {
+	ctx := context.Background()
+	suite.createToken("viejo", time.Now().Add(-2*time.Hour))
+	suite.createToken("vigente", time.Now().Add(time.Hour))
+
+	suite.Require().NoError(suite.repo.DeleteExpired(ctx, time.Now()))
+
+	var count int
+	suite.Require().NoError(suite.db.QueryRow(`SELECT COUNT(*) FROM user_tokens`).Scan(&count))
+	suite.Equal(1, count)
+
+	suite.Require().NoError(suite.repo.DeleteForUser(ctx, suite.user.ID, models.TokenPurposePasswordReset))
+	suite.Require().NoError(suite.db.QueryRow(`SELECT COUNT(*) FROM user_tokens`).Scan(&count))
+	suite.Equal(0, count)
+}
+
+func (suite *UserTokenIntegrationTestSuite) TestConsume_OtherPurpose() {
+	ctx := context.Background()
+	record := &models.UserToken{UserID: suite.user.ID, Purpose: models.TokenPurposeMFAChallenge, ExpiresAt: time.Now().Add(time.Hour)}
+	suite.Require().NoError(suite.repo.Create(ctx, record, auth.HashToken("desafio")))
+
+	// An MFA challenge cannot be used to reset the password
+	token, err := suite.repo.Consume(ctx, models.TokenPurposePasswordReset, auth.HashToken("desafio"), time.Now())
+	suite.Require().NoError(err)
+	suite.Nil(token)
+}
+
+func TestUserTokenIntegrationTestSuite(t *testing.T) {
+	suite.Run(t, new(UserTokenIntegrationTestSuite))
+}
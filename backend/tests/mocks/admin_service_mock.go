@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAdminService es un mock del AdminService para testing
+type MockAdminService struct {
+	mock.Mock
+}
+
+// AssignRole simula cambiar el rol de un usuario
+func (m *MockAdminService) AssignRole(ctx context.Context, actorID int, targetID int, role string) (*models.User, error) {
+	args := m.Called(ctx, actorID, targetID, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// BanUser simula suspender a un usuario
+func (m *MockAdminService) BanUser(ctx context.Context, actorID int, targetID int) (*models.User, error) {
+	args := m.Called(ctx, actorID, targetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// UnbanUser simula rehabilitar a un usuario
+func (m *MockAdminService) UnbanUser(ctx context.Context, actorID int, targetID int) (*models.User, error) {
+	args := m.Called(ctx, actorID, targetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// UnlockUser simula levantar el bloqueo por logins fallidos
+func (m *MockAdminService) UnlockUser(ctx context.Context, actorID int, targetID int) (*models.User, error) {
+	args := m.Called(ctx, actorID, targetID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
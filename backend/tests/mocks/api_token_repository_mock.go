@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPITokenRepository es un mock del APITokenRepository
+type MockAPITokenRepository struct {
+	mock.Mock
+}
+
+// Create simula guardar un token de API
+func (m *MockAPITokenRepository) Create(ctx context.Context, token *models.APIToken, tokenHash string) error {
+	args := m.Called(ctx, token, tokenHash)
+	return args.Error(0)
+}
+
+// FindByHash simula buscar un token por su hash
+func (m *MockAPITokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	args := m.Called(ctx, tokenHash)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIToken), args.Error(1)
+}
+
+// FindActiveByUserID simula listar una página de tokens no revocados de un usuario
+func (m *MockAPITokenRepository) FindActiveByUserID(ctx context.Context, userID int, page pagination.Params) ([]*models.APIToken, error) {
+	args := m.Called(ctx, userID, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.APIToken), args.Error(1)
+}
+
+// Revoke simula revocar un token
+func (m *MockAPITokenRepository) Revoke(ctx context.Context, userID int, id int) (bool, error) {
+	args := m.Called(ctx, userID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// RevokeAllForUser simula revocar todos los tokens de un usuario
+func (m *MockAPITokenRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// TouchLastUsed simula registrar el último uso
+func (m *MockAPITokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockAPITokenService es un mock del APITokenService para testing
+type MockAPITokenService struct {
+	mock.Mock
+}
+
+// Create simula crear un token de API
+func (m *MockAPITokenService) Create(ctx context.Context, userID int, req *models.CreateAPITokenRequest) (*models.CreatedAPIToken, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CreatedAPIToken), args.Error(1)
+}
+
+// List simula listar una página de tokens del usuario
+func (m *MockAPITokenService) List(ctx context.Context, userID int, page pagination.Params) (*pagination.Page[*models.APIToken], error) {
+	args := m.Called(ctx, userID, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[*models.APIToken]), args.Error(1)
+}
+
+// Revoke simula revocar un token
+func (m *MockAPITokenService) Revoke(ctx context.Context, userID int, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"ingsw3-tp08/internal/models"
 
 	"github.com/stretchr/testify/mock"
@@ -12,8 +13,8 @@ type MockAuthService struct {
 }
 
 // Register simula el registro de usuario
-func (m *MockAuthService) Register(req *models.RegisterRequest) (*models.User, error) {
-	args := m.Called(req)
+func (m *MockAuthService) Register(ctx context.Context, req *models.RegisterRequest) (*models.User, error) {
+	args := m.Called(ctx, req)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -21,10 +22,88 @@ func (m *MockAuthService) Register(req *models.RegisterRequest) (*models.User, e
 }
 
 // Login simula el login de usuario
-func (m *MockAuthService) Login(creds *models.Credentials) (*models.User, error) {
-	args := m.Called(creds)
+func (m *MockAuthService) Login(ctx context.Context, creds *models.Credentials, client models.ClientInfo) (*models.User, error) {
+	args := m.Called(ctx, creds, client)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
+
+// RequestUnlock simula pedir el mail de desbloqueo
+func (m *MockAuthService) RequestUnlock(ctx context.Context, req *models.UnlockRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+// UnlockAccount simula canjear el link de desbloqueo
+func (m *MockAuthService) UnlockAccount(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+// ForgotPassword simula pedir el mail de recuperación
+func (m *MockAuthService) ForgotPassword(ctx context.Context, req *models.ForgotPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+// ResetPassword simula elegir una contraseña nueva con el token del mail
+func (m *MockAuthService) ResetPassword(ctx context.Context, req *models.ResetPasswordRequest) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+// VerifyEmail simula canjear el link de verificación
+func (m *MockAuthService) VerifyEmail(ctx context.Context, token string) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+// ResendVerification simula reenviar el mail de verificación
+func (m *MockAuthService) ResendVerification(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// VerifyMFA simula el segundo paso del login
+func (m *MockAuthService) VerifyMFA(ctx context.Context, req *models.MFALoginRequest, client models.ClientInfo) (*models.User, error) {
+	args := m.Called(ctx, req, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+// BeginTOTPEnrollment simula generar el secreto TOTP
+func (m *MockAuthService) BeginTOTPEnrollment(ctx context.Context, userID int) (*models.TOTPEnrollment, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TOTPEnrollment), args.Error(1)
+}
+
+// ConfirmTOTPEnrollment simula activar el segundo factor
+func (m *MockAuthService) ConfirmTOTPEnrollment(ctx context.Context, userID int, req *models.MFACodeRequest) (*models.RecoveryCodes, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecoveryCodes), args.Error(1)
+}
+
+// DisableTOTP simula desactivar el segundo factor
+func (m *MockAuthService) DisableTOTP(ctx context.Context, userID int, req *models.MFACodeRequest) error {
+	args := m.Called(ctx, userID, req)
+	return args.Error(0)
+}
+
+// RegenerateRecoveryCodes simula reemplazar los códigos de recuperación
+func (m *MockAuthService) RegenerateRecoveryCodes(ctx context.Context, userID int, req *models.MFACodeRequest) (*models.RecoveryCodes, error) {
+	args := m.Called(ctx, userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecoveryCodes), args.Error(1)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/stretchr/testify/mock"
+)
+
+// MockEvents es un mock de services.Events para testing
+type MockEvents struct {
+	mock.Mock
+}
+
+// PostCreated simula el evento de post creado
+func (m *MockEvents) PostCreated() {
+	m.Called()
+}
+
+// CommentCreated simula el evento de comentario creado
+func (m *MockEvents) CommentCreated() {
+	m.Called()
+}
+
+// UserRegistered simula el evento de usuario registrado
+func (m *MockEvents) UserRegistered() {
+	m.Called()
+}
+
+// LoginFailed simula el evento de login fallido
+func (m *MockEvents) LoginFailed(reason string) {
+	m.Called(reason)
+}
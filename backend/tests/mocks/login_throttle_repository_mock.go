@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLoginThrottleRepository es un mock del LoginThrottleRepository
+type MockLoginThrottleRepository struct {
+	mock.Mock
+}
+
+// Find simula buscar los intentos fallidos de una cuenta o IP
+func (m *MockLoginThrottleRepository) Find(ctx context.Context, scope string, subject string) (*models.LoginThrottle, error) {
+	args := m.Called(ctx, scope, subject)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.LoginThrottle), args.Error(1)
+}
+
+// Reserve simula reservar un intento: le pasa a check el estado configurado
+// (primer valor) y, si lo acepta, devuelve el estado con el intento contado
+// (segundo valor)
+func (m *MockLoginThrottleRepository) Reserve(ctx context.Context, scope string, subject string, at time.Time, resetBefore time.Time, check func(*models.LoginThrottle) error) (*models.LoginThrottle, error) {
+	args := m.Called(ctx, scope, subject, at, resetBefore)
+	if err := args.Error(2); err != nil {
+		return nil, err
+	}
+	current, _ := args.Get(0).(*models.LoginThrottle)
+	if err := check(current); err != nil {
+		return nil, err
+	}
+	reserved, _ := args.Get(1).(*models.LoginThrottle)
+	return reserved, nil
+}
+
+// Release simula descontar un intento reservado
+func (m *MockLoginThrottleRepository) Release(ctx context.Context, scope string, subject string) error {
+	args := m.Called(ctx, scope, subject)
+	return args.Error(0)
+}
+
+// Lock simula bloquear una cuenta o IP
+func (m *MockLoginThrottleRepository) Lock(ctx context.Context, scope string, subject string, until time.Time) error {
+	args := m.Called(ctx, scope, subject, until)
+	return args.Error(0)
+}
+
+// Reset simula olvidar los intentos fallidos
+func (m *MockLoginThrottleRepository) Reset(ctx context.Context, scope string, subject string) error {
+	args := m.Called(ctx, scope, subject)
+	return args.Error(0)
+}
+
+// DeleteStale simula borrar los registros viejos
+func (m *MockLoginThrottleRepository) DeleteStale(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
+	return args.Error(0)
+}
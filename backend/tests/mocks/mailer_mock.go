@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/mail"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockMailer es un mock del Mailer. Sent recibe cada mail enviado, para
+// esperar los que se mandan en segundo plano.
+type MockMailer struct {
+	mock.Mock
+	Sent chan mail.Message
+}
+
+// NewMockMailer crea un mock con lugar para algunos mails pendientes
+func NewMockMailer() *MockMailer {
+	return &MockMailer{Sent: make(chan mail.Message, 10)}
+}
+
+// Send simula enviar un mail
+func (m *MockMailer) Send(ctx context.Context, msg mail.Message) error {
+	args := m.Called(ctx, msg)
+	m.Sent <- msg
+	return args.Error(0)
+}
@@ -1,7 +1,9 @@
 package mocks
 
 import (
+	"context"
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -12,14 +14,14 @@ type MockPostRepository struct {
 }
 
 // Create simula la creación de un post
-func (m *MockPostRepository) Create(post *models.Post) error {
-	args := m.Called(post)
+func (m *MockPostRepository) Create(ctx context.Context, post *models.Post) error {
+	args := m.Called(ctx, post)
 	return args.Error(0)
 }
 
-// FindAll simula obtener todos los posts
-func (m *MockPostRepository) FindAll() ([]*models.Post, error) {
-	args := m.Called()
+// FindAll simula obtener una página de posts
+func (m *MockPostRepository) FindAll(ctx context.Context, page pagination.Params) ([]*models.Post, error) {
+	args := m.Called(ctx, page)
 
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -29,8 +31,8 @@ func (m *MockPostRepository) FindAll() ([]*models.Post, error) {
 }
 
 // FindByID simula buscar un post por ID
-func (m *MockPostRepository) FindByID(id int) (*models.Post, error) {
-	args := m.Called(id)
+func (m *MockPostRepository) FindByID(ctx context.Context, id int) (*models.Post, error) {
+	args := m.Called(ctx, id)
 
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -39,21 +41,88 @@ func (m *MockPostRepository) FindByID(id int) (*models.Post, error) {
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
+// Update simula editar un post
+func (m *MockPostRepository) Update(ctx context.Context, post *models.Post) error {
+	args := m.Called(ctx, post)
+	return args.Error(0)
+}
+
+// SetLocked simula cerrar o reabrir un post
+func (m *MockPostRepository) SetLocked(ctx context.Context, id int, locked bool) error {
+	args := m.Called(ctx, id, locked)
+	return args.Error(0)
+}
+
+// FindRevisions simula obtener una página de versiones anteriores de un post
+func (m *MockPostRepository) FindRevisions(ctx context.Context, postID int, page pagination.Params) ([]*models.PostRevision, error) {
+	args := m.Called(ctx, postID, page)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*models.PostRevision), args.Error(1)
+}
+
+// FindRevision simula buscar una versión anterior de un post
+func (m *MockPostRepository) FindRevision(ctx context.Context, postID int, version int) (*models.PostRevision, error) {
+	args := m.Called(ctx, postID, version)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.PostRevision), args.Error(1)
+}
+
 // Delete simula eliminar un post
-func (m *MockPostRepository) Delete(id int) error {
-	args := m.Called(id)
+func (m *MockPostRepository) Delete(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
 // CreateComment simula crear un comentario
-func (m *MockPostRepository) CreateComment(comment *models.Comment) error {
-	args := m.Called(comment)
+func (m *MockPostRepository) CreateComment(ctx context.Context, comment *models.Comment) error {
+	args := m.Called(ctx, comment)
 	return args.Error(0)
 }
 
-// FindCommentsByPostID simula obtener comentarios de un post
-func (m *MockPostRepository) FindCommentsByPostID(postID int) ([]*models.Comment, error) {
-	args := m.Called(postID)
+// FindCommentByID simula buscar un comentario por ID
+func (m *MockPostRepository) FindCommentByID(ctx context.Context, id int) (*models.Comment, error) {
+	args := m.Called(ctx, id)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.Comment), args.Error(1)
+}
+
+// FindRootComments simula obtener una página de comentarios de primer nivel
+func (m *MockPostRepository) FindRootComments(ctx context.Context, postID int, page pagination.Params) ([]*models.Comment, error) {
+	args := m.Called(ctx, postID, page)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*models.Comment), args.Error(1)
+}
+
+// FindReplies simula obtener las respuestas de varios hilos
+func (m *MockPostRepository) FindReplies(ctx context.Context, postID int, rootIDs []int) ([]*models.Comment, error) {
+	args := m.Called(ctx, postID, rootIDs)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*models.Comment), args.Error(1)
+}
+
+// FindCommentsByPostID simula obtener una página de comentarios de un post
+func (m *MockPostRepository) FindCommentsByPostID(ctx context.Context, postID int, page pagination.Params) ([]*models.Comment, error) {
+	args := m.Called(ctx, postID, page)
 
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -63,7 +132,7 @@ func (m *MockPostRepository) FindCommentsByPostID(postID int) ([]*models.Comment
 }
 
 // DeleteComment simula eliminar un comentario
-func (m *MockPostRepository) DeleteComment(postID int, commentID int, userID int) error {
-	args := m.Called(postID, commentID, userID)
+func (m *MockPostRepository) DeleteComment(ctx context.Context, postID int, commentID int) error {
+	args := m.Called(ctx, postID, commentID)
 	return args.Error(0)
 }
@@ -1,7 +1,9 @@
 package mocks
 
 import (
+	"context"
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -12,58 +14,103 @@ type MockPostService struct {
 }
 
 // CreatePost simula la creación de un post
-func (m *MockPostService) CreatePost(req *models.CreatePostRequest, userID int) (*models.Post, error) {
-	args := m.Called(req, userID)
+func (m *MockPostService) CreatePost(ctx context.Context, req *models.CreatePostRequest, userID int) (*models.Post, error) {
+	args := m.Called(ctx, req, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
-// GetAllPosts simula obtener todos los posts
-func (m *MockPostService) GetAllPosts() ([]*models.Post, error) {
-	args := m.Called()
+// GetAllPosts simula obtener una página de posts
+func (m *MockPostService) GetAllPosts(ctx context.Context, page pagination.Params) (*pagination.Page[*models.Post], error) {
+	args := m.Called(ctx, page)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*models.Post), args.Error(1)
+	return args.Get(0).(*pagination.Page[*models.Post]), args.Error(1)
 }
 
 // GetPostByID simula obtener un post por ID
-func (m *MockPostService) GetPostByID(id int) (*models.Post, error) {
-	args := m.Called(id)
+func (m *MockPostService) GetPostByID(ctx context.Context, id int) (*models.Post, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Post), args.Error(1)
 }
 
+// UpdatePost simula editar un post
+func (m *MockPostService) UpdatePost(ctx context.Context, postID int, req *models.UpdatePostRequest, userID int) (*models.Post, error) {
+	args := m.Called(ctx, postID, req, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Post), args.Error(1)
+}
+
+// SetPostLocked simula cerrar o reabrir un post
+func (m *MockPostService) SetPostLocked(ctx context.Context, postID int, locked bool, userID int) (*models.Post, error) {
+	args := m.Called(ctx, postID, locked, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Post), args.Error(1)
+}
+
+// GetPostRevisions simula obtener una página del historial de un post
+func (m *MockPostService) GetPostRevisions(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.PostRevision], error) {
+	args := m.Called(ctx, postID, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[*models.PostRevision]), args.Error(1)
+}
+
+// DiffPostVersions simula comparar dos versiones de un post
+func (m *MockPostService) DiffPostVersions(ctx context.Context, postID int, from int, to int) (*models.PostDiff, error) {
+	args := m.Called(ctx, postID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.PostDiff), args.Error(1)
+}
+
 // DeletePost simula eliminar un post
-func (m *MockPostService) DeletePost(postID int, userID int) error {
-	args := m.Called(postID, userID)
+func (m *MockPostService) DeletePost(ctx context.Context, postID int, userID int) error {
+	args := m.Called(ctx, postID, userID)
 	return args.Error(0)
 }
 
 // CreateComment simula crear un comentario
-func (m *MockPostService) CreateComment(postID int, req *models.CreateCommentRequest, userID int) (*models.Comment, error) {
-	args := m.Called(postID, req, userID)
+func (m *MockPostService) CreateComment(ctx context.Context, postID int, req *models.CreateCommentRequest, userID int) (*models.Comment, error) {
+	args := m.Called(ctx, postID, req, userID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.Comment), args.Error(1)
 }
 
-// GetCommentsByPostID simula obtener comentarios por post ID
-func (m *MockPostService) GetCommentsByPostID(postID int) ([]*models.Comment, error) {
-	args := m.Called(postID)
+// GetCommentsByPostID simula obtener una página de comentarios por post ID
+func (m *MockPostService) GetCommentsByPostID(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.Comment], error) {
+	args := m.Called(ctx, postID, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[*models.Comment]), args.Error(1)
+}
+
+// GetCommentTree simula obtener una página de hilos de comentarios
+func (m *MockPostService) GetCommentTree(ctx context.Context, postID int, page pagination.Params) (*pagination.Page[*models.Comment], error) {
+	args := m.Called(ctx, postID, page)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]*models.Comment), args.Error(1)
+	return args.Get(0).(*pagination.Page[*models.Comment]), args.Error(1)
 }
 
 // DeleteComment simula eliminar un comentario
-func (m *MockPostService) DeleteComment(postID int, commentID int, userID int) error {
-	args := m.Called(postID, commentID, userID)
+func (m *MockPostService) DeleteComment(ctx context.Context, postID int, commentID int, userID int) error {
+	args := m.Called(ctx, postID, commentID, userID)
 	return args.Error(0)
 }
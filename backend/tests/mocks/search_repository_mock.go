@@ -0,0 +1,25 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchRepository es un mock del SearchRepository para testing
+type MockSearchRepository struct {
+	mock.Mock
+}
+
+// Search simula la búsqueda de texto completo
+func (m *MockSearchRepository) Search(ctx context.Context, query models.SearchQuery, page pagination.Params) ([]*models.SearchResult, error) {
+	args := m.Called(ctx, query, page)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*models.SearchResult), args.Error(1)
+}
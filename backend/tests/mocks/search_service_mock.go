@@ -0,0 +1,23 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSearchService es un mock del SearchService para testing
+type MockSearchService struct {
+	mock.Mock
+}
+
+// Search simula una búsqueda
+func (m *MockSearchService) Search(ctx context.Context, query models.SearchQuery, page pagination.Params) (*pagination.Page[*models.SearchResult], error) {
+	args := m.Called(ctx, query, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*pagination.Page[*models.SearchResult]), args.Error(1)
+}
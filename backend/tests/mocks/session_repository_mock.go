@@ -0,0 +1,65 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionRepository es un mock del SessionRepository
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+// Create simula crear una sesión
+func (m *MockSessionRepository) Create(ctx context.Context, session *models.Session, tokenHash string) error {
+	args := m.Called(ctx, session, tokenHash)
+	return args.Error(0)
+}
+
+// FindByTokenHash simula buscar un refresh token por su hash
+func (m *MockSessionRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*models.Session, error) {
+	args := m.Called(ctx, tokenHash)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).(*models.Session), args.Error(1)
+}
+
+// Rotate simula rotar un refresh token
+func (m *MockSessionRepository) Rotate(ctx context.Context, current *models.Session, next *models.Session, nextTokenHash string) error {
+	args := m.Called(ctx, current, next, nextTokenHash)
+	return args.Error(0)
+}
+
+// Revoke simula revocar una sesión
+func (m *MockSessionRepository) Revoke(ctx context.Context, userID int, sessionID string) (bool, error) {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Bool(0), args.Error(1)
+}
+
+// RevokeAllForUser simula revocar todas las sesiones de un usuario
+func (m *MockSessionRepository) RevokeAllForUser(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// FindActiveByUserID simula obtener las sesiones activas de un usuario
+func (m *MockSessionRepository) FindActiveByUserID(ctx context.Context, userID int) ([]*models.Session, error) {
+	args := m.Called(ctx, userID)
+
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+// IsActive simula verificar si una sesión sigue vigente
+func (m *MockSessionRepository) IsActive(ctx context.Context, sessionID string) (bool, error) {
+	args := m.Called(ctx, sessionID)
+	return args.Bool(0), args.Error(1)
+}
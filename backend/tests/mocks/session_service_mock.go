@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockSessionService es un mock del SessionService para testing
+type MockSessionService struct {
+	mock.Mock
+}
+
+// Start simula iniciar una sesión
+func (m *MockSessionService) Start(ctx context.Context, userID int, client models.ClientInfo) (*models.TokenPair, error) {
+	args := m.Called(ctx, userID, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenPair), args.Error(1)
+}
+
+// Refresh simula renovar los tokens
+func (m *MockSessionService) Refresh(ctx context.Context, refreshToken string, client models.ClientInfo) (*models.TokenPair, error) {
+	args := m.Called(ctx, refreshToken, client)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TokenPair), args.Error(1)
+}
+
+// Logout simula cerrar una sesión
+func (m *MockSessionService) Logout(ctx context.Context, userID int, sessionID string) error {
+	args := m.Called(ctx, userID, sessionID)
+	return args.Error(0)
+}
+
+// LogoutAll simula cerrar todas las sesiones
+func (m *MockSessionService) LogoutAll(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+// ListSessions simula listar las sesiones activas
+func (m *MockSessionService) ListSessions(ctx context.Context, userID int, currentSessionID string) ([]*models.Session, error) {
+	args := m.Called(ctx, userID, currentSessionID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]*models.Session), args.Error(1)
+}
+
+// Authenticate simula validar un token de acceso
+func (m *MockSessionService) Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error) {
+	args := m.Called(ctx, accessToken)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*auth.Principal), args.Error(1)
+}
@@ -0,0 +1,58 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTwoFactorRepository es un mock del TwoFactorRepository
+type MockTwoFactorRepository struct {
+	mock.Mock
+}
+
+// SavePendingTOTP simula guardar un secreto sin confirmar
+func (m *MockTwoFactorRepository) SavePendingTOTP(ctx context.Context, userID int, secret string) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+// FindTOTP simula buscar el segundo factor del usuario
+func (m *MockTwoFactorRepository) FindTOTP(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.TOTPCredential), args.Error(1)
+}
+
+// ConfirmTOTP simula activar el enrolamiento pendiente
+func (m *MockTwoFactorRepository) ConfirmTOTP(ctx context.Context, userID int, step int64, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, step, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+// UseTOTPStep simula registrar el paso del código aceptado
+func (m *MockTwoFactorRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+// ReplaceRecoveryCodes simula reemplazar los códigos de recuperación
+func (m *MockTwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	args := m.Called(ctx, userID, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+// UseRecoveryCode simula canjear un código de recuperación
+func (m *MockTwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+// DeleteTOTP simula desactivar el segundo factor
+func (m *MockTwoFactorRepository) DeleteTOTP(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
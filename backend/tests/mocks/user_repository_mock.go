@@ -1,7 +1,9 @@
 package mocks
 
 import (
+	"context"
 	"ingsw3-tp08/internal/models"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -12,14 +14,14 @@ type MockUserRepository struct {
 }
 
 // Create simula la creación de un usuario
-func (m *MockUserRepository) Create(user *models.User) error {
-	args := m.Called(user)
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
 // FindByEmail simula la búsqueda por email
-func (m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
-	args := m.Called(email)
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
 
 	// Si se configuró para devolver nil (usuario no encontrado)
 	if args.Get(0) == nil {
@@ -30,8 +32,8 @@ func (m *MockUserRepository) FindByEmail(email string) (*models.User, error) {
 }
 
 // FindByID simula la búsqueda por ID
-func (m *MockUserRepository) FindByID(id int) (*models.User, error) {
-	args := m.Called(id)
+func (m *MockUserRepository) FindByID(ctx context.Context, id int) (*models.User, error) {
+	args := m.Called(ctx, id)
 
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -39,3 +41,36 @@ func (m *MockUserRepository) FindByID(id int) (*models.User, error) {
 
 	return args.Get(0).(*models.User), args.Error(1)
 }
+
+// UpdatePassword simula actualizar el hash de la contraseña
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, id int, passwordHash string) error {
+	args := m.Called(ctx, id, passwordHash)
+	return args.Error(0)
+}
+
+// UpdateRole simula cambiar el rol de un usuario
+func (m *MockUserRepository) UpdateRole(ctx context.Context, id int, role string) error {
+	args := m.Called(ctx, id, role)
+	return args.Error(0)
+}
+
+// SetBanned simula suspender o rehabilitar a un usuario
+func (m *MockUserRepository) SetBanned(ctx context.Context, id int, banned bool) error {
+	args := m.Called(ctx, id, banned)
+	return args.Error(0)
+}
+
+// MarkEmailVerified simula marcar el email como verificado
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+// ClaimVerificationEmail simula registrar un envío del mail de verificación
+func (m *MockUserRepository) ClaimVerificationEmail(ctx context.Context, id int, at time.Time, notAfter time.Time) (bool, *time.Time, error) {
+	args := m.Called(ctx, id, at, notAfter)
+	if args.Get(1) == nil {
+		return args.Bool(0), nil, args.Error(2)
+	}
+	return args.Bool(0), args.Get(1).(*time.Time), args.Error(2)
+}
@@ -0,0 +1,50 @@
+package mocks
+
+import (
+	"context"
+	"ingsw3-tp08/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockUserTokenRepository es un mock del UserTokenRepository
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+// Create simula guardar un token de un solo uso
+func (m *MockUserTokenRepository) Create(ctx context.Context, token *models.UserToken, tokenHash string) error {
+	args := m.Called(ctx, token, tokenHash)
+	return args.Error(0)
+}
+
+// Find simula buscar un token vigente sin usarlo
+func (m *MockUserTokenRepository) Find(ctx context.Context, purpose string, tokenHash string, now time.Time) (*models.UserToken, error) {
+	args := m.Called(ctx, purpose, tokenHash, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserToken), args.Error(1)
+}
+
+// Consume simula canjear un token
+func (m *MockUserTokenRepository) Consume(ctx context.Context, purpose string, tokenHash string, now time.Time) (*models.UserToken, error) {
+	args := m.Called(ctx, purpose, tokenHash, now)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserToken), args.Error(1)
+}
+
+// DeleteForUser simula borrar los tokens de un usuario
+func (m *MockUserTokenRepository) DeleteForUser(ctx context.Context, userID int, purpose string) error {
+	args := m.Called(ctx, userID, purpose)
+	return args.Error(0)
+}
+
+// DeleteExpired simula borrar los tokens vencidos
+func (m *MockUserTokenRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	args := m.Called(ctx, before)
+	return args.Error(0)
+}
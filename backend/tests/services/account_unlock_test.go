@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newUnlockingAuthService arma un AuthService con bloqueo de logins y desbloqueo por mail, con el reloj en throttleNow
+func newUnlockingAuthService(userRepo *mocks.MockUserRepository, tokenRepo *mocks.MockUserTokenRepository, throttleRepo *mocks.MockLoginThrottleRepository, mailer *mocks.MockMailer) *services.AuthService {
+	clock := func() time.Time { return throttleNow }
+	unlock := services.NewAccountUnlock(tokenRepo, mailer, services.AccountUnlockConfig{
+		TTL: time.Hour,
+		URL: "https://api.example.com/api/auth/unlock",
+	}).WithClock(clock)
+	throttle := services.NewLoginThrottle(throttleRepo, services.DefaultLoginThrottlePolicy).WithClock(clock)
+	return services.NewAuthService(userRepo, testHasher).WithLoginThrottle(throttle).WithAccountUnlock(unlock)
+}
+
+// lockedThrottle es una cuenta bloqueada por diez minutos más
+func lockedThrottle() *models.LoginThrottle {
+	lockedUntil := throttleNow.Add(10 * time.Minute)
+	return &models.LoginThrottle{Failures: 10, LastFailureAt: throttleNow.Add(-5 * time.Minute), LockedUntil: &lockedUntil}
+}
+
+// TestRequestUnlock_EnviaElLink prueba que una cuenta bloqueada reciba un link cuyo hash es el guardado
+func TestRequestUnlock_EnviaElLink(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	mockMailer := mocks.NewMockMailer()
+	authService := newUnlockingAuthService(mockUserRepo, mockTokenRepo, mockThrottleRepo, mockMailer)
+
+	var storedHash string
+	mockThrottleRepo.On("Find", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(lockedThrottle(), nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 7, Email: testEmail, Username: testUsername}, nil)
+	mockTokenRepo.On("DeleteForUser", mock.Anything, 7, models.TokenPurposeAccountUnlock).Return(nil)
+	mockTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.UserToken) bool {
+		return token.UserID == 7 && token.Purpose == models.TokenPurposeAccountUnlock && token.ExpiresAt.Equal(throttleNow.Add(time.Hour))
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		storedHash = args.String(2)
+	}).Return(nil)
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	err := authService.RequestUnlock(context.Background(), &models.UnlockRequest{Email: " Test@Example.com"})
+
+	require.NoError(t, err)
+	msg := waitForMail(t, mockMailer)
+	assert.Equal(t, testEmail, msg.To)
+	assert.Contains(t, msg.Body, "1 hora")
+
+	parsed, err := url.Parse(regexp.MustCompile(`https://\S+`).FindString(msg.Body))
+	require.NoError(t, err)
+	assert.Equal(t, "/api/auth/unlock", parsed.Path)
+	token := parsed.Query().Get("token")
+	assert.NotEmpty(t, token)
+	assert.Equal(t, auth.HashToken(token), storedHash)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+// TestRequestUnlock_SinBloqueoNoManda: responde igual pero no manda nada ni revela si la cuenta existe
+func TestRequestUnlock_SinBloqueoNoManda(t *testing.T) {
+	tests := []struct {
+		name     string
+		throttle *models.LoginThrottle
+		user     *models.User
+	}{
+		{"cuenta sin fallos", nil, &models.User{ID: 7, Email: testEmail}},
+		{"solo demoras, sin bloqueo", &models.LoginThrottle{Failures: 5, LastFailureAt: throttleNow}, &models.User{ID: 7, Email: testEmail}},
+		{"email inexistente", lockedThrottle(), nil},
+		{"usuario suspendido", lockedThrottle(), &models.User{ID: 7, Email: testEmail, BannedAt: &throttleNow}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(mocks.MockUserRepository)
+			mockTokenRepo := new(mocks.MockUserTokenRepository)
+			mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+			mockMailer := mocks.NewMockMailer()
+			authService := newUnlockingAuthService(mockUserRepo, mockTokenRepo, mockThrottleRepo, mockMailer)
+
+			looked := make(chan struct{})
+			mockThrottleRepo.On("Find", mock.Anything, models.ThrottleScopeAccount, testEmail).
+				Run(func(mock.Arguments) { close(looked) }).Return(tt.throttle, nil)
+			mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(tt.user, nil).Maybe()
+
+			err := authService.RequestUnlock(context.Background(), &models.UnlockRequest{Email: testEmail})
+
+			require.NoError(t, err)
+			<-looked
+			select {
+			case <-mockMailer.Sent:
+				t.Fatal("no debería mandarse un mail")
+			case <-time.After(50 * time.Millisecond):
+			}
+			mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestRequestUnlock_EmailVacio prueba que se valide el email
+func TestRequestUnlock_EmailVacio(t *testing.T) {
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newUnlockingAuthService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), mockThrottleRepo, mocks.NewMockMailer())
+
+	err := authService.RequestUnlock(context.Background(), &models.UnlockRequest{Email: " "})
+
+	assert.ErrorIs(t, err, services.ErrValidation)
+	mockThrottleRepo.AssertNotCalled(t, "Find", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestUnlockAccount_Success prueba que el link levante el bloqueo de la cuenta
+func TestUnlockAccount_Success(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newUnlockingAuthService(mockUserRepo, mockTokenRepo, mockThrottleRepo, mocks.NewMockMailer())
+
+	mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposeAccountUnlock, auth.HashToken("token-del-mail"), throttleNow).
+		Return(&models.UserToken{UserID: 7}, nil).Once()
+	mockUserRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail}, nil)
+	mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+
+	err := authService.UnlockAccount(context.Background(), " token-del-mail ")
+
+	require.NoError(t, err)
+	mockTokenRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertExpectations(t)
+}
+
+// TestUnlockAccount_TokenInvalido prueba que un link vencido, usado o de un usuario suspendido no desbloquee
+func TestUnlockAccount_TokenInvalido(t *testing.T) {
+	tests := []struct {
+		name   string
+		record *models.UserToken
+		user   *models.User
+	}{
+		{"vencido o usado", nil, nil},
+		{"usuario borrado", &models.UserToken{UserID: 7}, nil},
+		{"usuario suspendido", &models.UserToken{UserID: 7}, &models.User{ID: 7, Email: testEmail, BannedAt: &throttleNow}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(mocks.MockUserRepository)
+			mockTokenRepo := new(mocks.MockUserTokenRepository)
+			mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+			authService := newUnlockingAuthService(mockUserRepo, mockTokenRepo, mockThrottleRepo, mocks.NewMockMailer())
+
+			mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposeAccountUnlock, mock.Anything, throttleNow).Return(tt.record, nil)
+			mockUserRepo.On("FindByID", mock.Anything, 7).Return(tt.user, nil).Maybe()
+
+			err := authService.UnlockAccount(context.Background(), "token-del-mail")
+
+			assert.ErrorIs(t, err, services.ErrValidation)
+			assert.Equal(t, services.ErrInvalidUnlockToken, err.Error())
+			mockThrottleRepo.AssertNotCalled(t, "Reset", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestUnlockAccount_TokenVacio prueba que se valide el token
+func TestUnlockAccount_TokenVacio(t *testing.T) {
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	authService := newUnlockingAuthService(new(mocks.MockUserRepository), mockTokenRepo, new(mocks.MockLoginThrottleRepository), mocks.NewMockMailer())
+
+	err := authService.UnlockAccount(context.Background(), "")
+
+	assert.ErrorIs(t, err, services.ErrValidation)
+	mockTokenRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestAssignRole_Success prueba que un admin cambie el rol de otro usuario
+func TestAssignRole_Success(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	adminService := services.NewAdminService(mockUserRepo, mockSessionRepo)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil)
+	mockUserRepo.On("UpdateRole", mock.Anything, 2, models.RoleModerator).Return(nil)
+
+	// ACT
+	user, err := adminService.AssignRole(context.Background(), 1, 2, models.RoleModerator)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleModerator, user.Role)
+	mockUserRepo.AssertExpectations(t)
+}
+
+// TestAssignRole_RolInvalido prueba que se rechace un rol desconocido
+func TestAssignRole_RolInvalido(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository))
+
+	// ACT
+	user, err := adminService.AssignRole(context.Background(), 1, 2, "superuser")
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "rol inválido: usar user, moderator o admin", err.Error())
+	mockUserRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+// TestAssignRole_SinPermiso prueba que un moderador no pueda asignar roles
+func TestAssignRole_SinPermiso(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository))
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleModerator}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil)
+
+	// ACT
+	user, err := adminService.AssignRole(context.Background(), 1, 2, models.RoleAdmin)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "no tienes permiso para cambiar roles", err.Error())
+	mockUserRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestAssignRole_UsuarioNoExiste prueba cambiar el rol de un usuario inexistente
+func TestAssignRole_UsuarioNoExiste(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository))
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
+
+	// ACT
+	user, err := adminService.AssignRole(context.Background(), 1, 999, models.RoleModerator)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "usuario no encontrado", err.Error())
+}
+
+// TestBanUser_Success prueba que suspender revoque todas las sesiones del usuario
+func TestBanUser_Success(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	adminService := services.NewAdminService(mockUserRepo, mockSessionRepo)
+
+	bannedAt := time.Now()
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleModerator}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil).Once()
+	mockUserRepo.On("SetBanned", mock.Anything, 2, true).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 2).Return(nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser, BannedAt: &bannedAt}, nil).Once()
+
+	// ACT
+	user, err := adminService.BanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.True(t, user.IsBanned())
+	mockUserRepo.AssertExpectations(t)
+	mockSessionRepo.AssertExpectations(t)
+}
+
+// TestBanUser_RevocaTokensDeAPI prueba que suspender también revoque los tokens de API
+func TestBanUser_RevocaTokensDeAPI(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockAPITokenRepo := new(mocks.MockAPITokenRepository)
+	adminService := services.NewAdminService(mockUserRepo, mockSessionRepo).
+		WithAPITokenRepository(mockAPITokenRepo)
+
+	bannedAt := time.Now()
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil).Once()
+	mockUserRepo.On("SetBanned", mock.Anything, 2, true).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 2).Return(nil)
+	mockAPITokenRepo.On("RevokeAllForUser", mock.Anything, 2).Return(nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser, BannedAt: &bannedAt}, nil).Once()
+
+	// ACT
+	user, err := adminService.BanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.True(t, user.IsBanned())
+	mockAPITokenRepo.AssertExpectations(t)
+}
+
+// TestBanUser_ErrorAlRevocarTokensDeAPI prueba que se informe si no se pudieron revocar los tokens
+func TestBanUser_ErrorAlRevocarTokensDeAPI(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockAPITokenRepo := new(mocks.MockAPITokenRepository)
+	adminService := services.NewAdminService(mockUserRepo, mockSessionRepo).
+		WithAPITokenRepository(mockAPITokenRepo)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil)
+	mockUserRepo.On("SetBanned", mock.Anything, 2, true).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 2).Return(nil)
+	mockAPITokenRepo.On("RevokeAllForUser", mock.Anything, 2).Return(errors.New("db error"))
+
+	// ACT
+	user, err := adminService.BanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+}
+
+// TestBanUser_ModeradorNoSuspendeModerador prueba que solo se suspenda a rangos menores
+func TestBanUser_ModeradorNoSuspendeModerador(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	adminService := services.NewAdminService(mockUserRepo, mockSessionRepo)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleModerator}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleModerator}, nil)
+
+	// ACT
+	user, err := adminService.BanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "no tienes permiso para suspender a este usuario", err.Error())
+	mockUserRepo.AssertNotCalled(t, "SetBanned", mock.Anything, mock.Anything, mock.Anything)
+	mockSessionRepo.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+}
+
+// TestBanUser_ErrorAlRevocar prueba que se informe si no se pudieron cerrar las sesiones
+func TestBanUser_ErrorAlRevocar(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	adminService := services.NewAdminService(mockUserRepo, mockSessionRepo)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil)
+	mockUserRepo.On("SetBanned", mock.Anything, 2, true).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 2).Return(errors.New("db error"))
+
+	// ACT
+	user, err := adminService.BanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+}
+
+// TestUnbanUser_Success prueba levantar una suspensión
+func TestUnbanUser_Success(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository))
+
+	bannedAt := time.Now()
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser, BannedAt: &bannedAt}, nil)
+	mockUserRepo.On("SetBanned", mock.Anything, 2, false).Return(nil)
+
+	// ACT
+	user, err := adminService.UnbanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.False(t, user.IsBanned())
+	mockUserRepo.AssertExpectations(t)
+}
+
+// TestUnbanUser_SinPermiso prueba que un usuario común no rehabilite a nadie
+func TestUnbanUser_SinPermiso(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository))
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleUser}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil)
+
+	// ACT
+	user, err := adminService.UnbanUser(context.Background(), 1, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "no tienes permiso para rehabilitar a este usuario", err.Error())
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var apiTokenNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func newAPITokenService(repo *mocks.MockAPITokenRepository) *services.APITokenService {
+	return services.NewAPITokenService(repo, services.APITokenConfig{
+		DefaultTTL: 30 * 24 * time.Hour,
+		MaxTTL:     365 * 24 * time.Hour,
+	}).WithClock(func() time.Time { return apiTokenNow })
+}
+
+// TestCreateAPIToken_Success prueba que se guarden el hash y el prefijo, nunca el token
+func TestCreateAPIToken_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+
+	var storedHash string
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.APIToken) bool {
+		return token.UserID == 1 && token.Name == "bot de CI" &&
+			assert.ObjectsAreEqual([]string{models.ScopePostsWrite, models.ScopeCommentsRead}, token.Scopes) &&
+			token.ExpiresAt.Equal(apiTokenNow.Add(90*24*time.Hour))
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.APIToken).ID = 5
+		storedHash = args.String(2)
+	}).Return(nil)
+
+	// ACT
+	created, err := apiTokenService.Create(context.Background(), 1, &models.CreateAPITokenRequest{
+		Name:          "  bot de CI ",
+		Scopes:        []string{models.ScopePostsWrite, models.ScopeCommentsRead, models.ScopePostsWrite},
+		ExpiresInDays: 90,
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, 5, created.ID)
+	assert.True(t, auth.IsAPIToken(created.Token))
+	assert.True(t, strings.HasPrefix(created.Token, created.Prefix))
+	assert.Equal(t, auth.HashToken(created.Token), storedHash)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateAPIToken_VigenciaPorDefecto prueba que sin expires_in_days se use la vigencia configurada
+func TestCreateAPIToken_VigenciaPorDefecto(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+	mockRepo.On("Create", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	created, err := apiTokenService.Create(context.Background(), 1, &models.CreateAPITokenRequest{
+		Name:   "script",
+		Scopes: []string{models.ScopePostsRead},
+	})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.True(t, created.ExpiresAt.Equal(apiTokenNow.Add(30*24*time.Hour)))
+}
+
+// TestCreateAPIToken_Validaciones prueba los rechazos antes de tocar la base
+func TestCreateAPIToken_Validaciones(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   models.CreateAPITokenRequest
+		field string
+	}{
+		{"sin nombre", models.CreateAPITokenRequest{Name: " ", Scopes: []string{models.ScopePostsWrite}}, "name"},
+		{"nombre largo", models.CreateAPITokenRequest{Name: strings.Repeat("a", 101), Scopes: []string{models.ScopePostsWrite}}, "name"},
+		{"sin scopes", models.CreateAPITokenRequest{Name: "bot"}, "scopes"},
+		{"scope desconocido", models.CreateAPITokenRequest{Name: "bot", Scopes: []string{"admin:write"}}, "scopes"},
+		{"vencimiento negativo", models.CreateAPITokenRequest{Name: "bot", Scopes: []string{models.ScopePostsWrite}, ExpiresInDays: -1}, "expires_in_days"},
+		{"vencimiento mayor al máximo", models.CreateAPITokenRequest{Name: "bot", Scopes: []string{models.ScopePostsWrite}, ExpiresInDays: 366}, "expires_in_days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockAPITokenRepository)
+			apiTokenService := newAPITokenService(mockRepo)
+
+			created, err := apiTokenService.Create(context.Background(), 1, &tt.req)
+
+			assert.Nil(t, created)
+			assert.ErrorIs(t, err, services.ErrValidation)
+			var svcErr *services.Error
+			require.ErrorAs(t, err, &svcErr)
+			assert.Equal(t, tt.field, svcErr.Field)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestRevokeAPIToken_NoExiste prueba que revocar un token ajeno o inexistente sea un 404
+func TestRevokeAPIToken_NoExiste(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+	mockRepo.On("Revoke", mock.Anything, 1, 99).Return(false, nil)
+
+	// ACT
+	err := apiTokenService.Revoke(context.Background(), 1, 99)
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrNotFound)
+	assert.Equal(t, services.ErrAPITokenNotFound, err.Error())
+}
+
+// TestListAPITokens_SinTokens prueba que se devuelva una lista vacía y no null
+func TestListAPITokens_SinTokens(t *testing.T) {
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+	mockRepo.On("FindActiveByUserID", mock.Anything, 1, pagination.Params{Limit: pagination.DefaultLimit}).Return(nil, nil)
+
+	page, err := apiTokenService.List(context.Background(), 1, pagination.Params{})
+
+	require.NoError(t, err)
+	assert.NotNil(t, page.Items)
+	assert.Empty(t, page.Items)
+	assert.Empty(t, page.NextCursor)
+}
+
+// TestListAPITokens_Paginado prueba que la fila extra del repositorio se convierta
+// en el cursor de la página siguiente
+func TestListAPITokens_Paginado(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+	tokens := []*models.APIToken{
+		{ID: 3, Name: "tercero", CreatedAt: apiTokenNow},
+		{ID: 2, Name: "segundo", CreatedAt: apiTokenNow.Add(-time.Hour)},
+		{ID: 1, Name: "primero", CreatedAt: apiTokenNow.Add(-2 * time.Hour)},
+	}
+	mockRepo.On("FindActiveByUserID", mock.Anything, 1, pagination.Params{Limit: 2}).Return(tokens, nil)
+
+	// ACT
+	page, err := apiTokenService.List(context.Background(), 1, pagination.Params{Limit: 2})
+
+	// ASSERT
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, 2, page.Items[1].ID)
+
+	cursor, err := pagination.Decode(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cursor.ID)
+	assert.True(t, cursor.CreatedAt.Equal(tokens[1].CreatedAt))
+}
+
+// TestAuthenticateAPIToken_Success prueba la identidad con scopes y el registro del uso
+func TestAuthenticateAPIToken_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+	mockRepo.On("FindByHash", mock.Anything, auth.HashToken("pat_secreto")).Return(&models.APIToken{
+		ID:        5,
+		UserID:    1,
+		Scopes:    []string{models.ScopePostsWrite},
+		ExpiresAt: apiTokenNow.Add(time.Hour),
+	}, nil)
+	mockRepo.On("TouchLastUsed", mock.Anything, 5, apiTokenNow).Return(nil).Once()
+
+	// ACT
+	principal, err := apiTokenService.Authenticate(context.Background(), "pat_secreto")
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, 1, principal.UserID)
+	assert.Equal(t, 5, principal.APITokenID)
+	assert.Empty(t, principal.SessionID)
+	assert.True(t, principal.Allows(models.ScopePostsWrite))
+	assert.False(t, principal.Allows(models.ScopeCommentsWrite))
+	mockRepo.AssertExpectations(t)
+}
+
+// TestAuthenticateAPIToken_UsoReciente prueba que no se escriba last_used_at en cada request
+func TestAuthenticateAPIToken_UsoReciente(t *testing.T) {
+	mockRepo := new(mocks.MockAPITokenRepository)
+	apiTokenService := newAPITokenService(mockRepo)
+	lastUsed := apiTokenNow.Add(-10 * time.Second)
+	mockRepo.On("FindByHash", mock.Anything, mock.Anything).Return(&models.APIToken{
+		ID:         5,
+		UserID:     1,
+		ExpiresAt:  apiTokenNow.Add(time.Hour),
+		LastUsedAt: &lastUsed,
+	}, nil)
+
+	_, err := apiTokenService.Authenticate(context.Background(), "pat_secreto")
+
+	require.NoError(t, err)
+	mockRepo.AssertNotCalled(t, "TouchLastUsed", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestAuthenticateAPIToken_Rechazos prueba que inexistente, vencido, revocado o de un
+// usuario suspendido den el mismo error
+func TestAuthenticateAPIToken_Rechazos(t *testing.T) {
+	revokedAt := apiTokenNow.Add(-time.Minute)
+	tests := []struct {
+		name  string
+		token *models.APIToken
+	}{
+		{"inexistente", nil},
+		{"vencido", &models.APIToken{ID: 5, UserID: 1, ExpiresAt: apiTokenNow}},
+		{"revocado", &models.APIToken{ID: 5, UserID: 1, ExpiresAt: apiTokenNow.Add(time.Hour), RevokedAt: &revokedAt}},
+		{"dueño suspendido", &models.APIToken{ID: 5, UserID: 1, ExpiresAt: apiTokenNow.Add(time.Hour), OwnerBanned: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockAPITokenRepository)
+			apiTokenService := newAPITokenService(mockRepo)
+			if tt.token == nil {
+				mockRepo.On("FindByHash", mock.Anything, mock.Anything).Return(nil, nil)
+			} else {
+				mockRepo.On("FindByHash", mock.Anything, mock.Anything).Return(tt.token, nil)
+			}
+
+			principal, err := apiTokenService.Authenticate(context.Background(), "pat_secreto")
+
+			assert.Nil(t, principal)
+			assert.ErrorIs(t, err, services.ErrUnauthorized)
+			assert.Equal(t, services.ErrInvalidAPIToken, err.Error())
+		})
+	}
+}
+
+// TestAuthenticate_DerivaTokensDeAPI prueba que la sesión derive los "pat_..." sin buscarlos como JWT
+func TestAuthenticate_DerivaTokensDeAPI(t *testing.T) {
+	// ARRANGE
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockRepo := new(mocks.MockAPITokenRepository)
+	sessionService := services.NewSessionService(mockSessionRepo, newTestTokens(t), testRefreshTTL).
+		WithAPITokens(newAPITokenService(mockRepo))
+	mockRepo.On("FindByHash", mock.Anything, auth.HashToken("pat_secreto")).Return(&models.APIToken{
+		ID:        5,
+		UserID:    1,
+		ExpiresAt: apiTokenNow.Add(time.Hour),
+	}, nil)
+	mockRepo.On("TouchLastUsed", mock.Anything, 5, apiTokenNow).Return(nil)
+
+	// ACT
+	principal, err := sessionService.Authenticate(context.Background(), "pat_secreto")
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, 5, principal.APITokenID)
+	mockSessionRepo.AssertNotCalled(t, "IsActive", mock.Anything, mock.Anything)
+}
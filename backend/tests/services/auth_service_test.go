@@ -1,14 +1,20 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"ingsw3-tp08/internal/auth"
 	"ingsw3-tp08/internal/models"
 	"ingsw3-tp08/internal/services"
 	"ingsw3-tp08/tests/mocks"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // Constantes para tests comunes
@@ -16,19 +22,32 @@ const (
 	testEmail    = "test@example.com"
 	testPassword = "123456"
 	testUsername = "testuser"
+	testHashCost = 4 // Costo mínimo de bcrypt para que los tests sean rápidos
 )
 
+var testHasher = auth.NewBcryptHasher(testHashCost)
+
+// hashForTest genera un hash bcrypt válido para usar en los mocks
+func hashForTest(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := testHasher.Hash(password)
+	if err != nil {
+		t.Fatalf("no se pudo hashear la contraseña: %v", err)
+	}
+	return hash
+}
+
 // TestRegister_Success prueba el registro exitoso de un usuario
 func TestRegister_Success(t *testing.T) {
 	// ARRANGE: Preparar el mock y datos de prueba
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	// Configurar el mock: el email NO existe (devuelve nil)
-	mockRepo.On("FindByEmail", testEmail).Return(nil, nil)
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, nil)
 
 	// Configurar el mock: Create debe ejecutarse correctamente
-	mockRepo.On("Create", mock.AnythingOfType("*models.User")).Return(nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
 
 	req := &models.RegisterRequest{
 		Email:    testEmail,
@@ -37,7 +56,7 @@ func TestRegister_Success(t *testing.T) {
 	}
 
 	// ACT: Ejecutar la función que estamos probando
-	user, err := authService.Register(req)
+	user, err := authService.Register(context.Background(), req)
 
 	// ASSERT: Verificar los resultados
 	assert.NoError(t, err)
@@ -45,6 +64,13 @@ func TestRegister_Success(t *testing.T) {
 	assert.Equal(t, testEmail, user.Email)
 	assert.Equal(t, testUsername, user.Username)
 
+	// La contraseña se guarda hasheada, nunca en texto plano
+	assert.NotEqual(t, testPassword, user.Password)
+	assert.True(t, auth.IsHashed(user.Password))
+	ok, err := testHasher.Compare(user.Password, testPassword)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
 	// Verificar que se llamaron los métodos del mock
 	mockRepo.AssertExpectations(t)
 }
@@ -53,7 +79,7 @@ func TestRegister_Success(t *testing.T) {
 func TestRegister_EmailVacio(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	req := &models.RegisterRequest{
 		Email:    "", // Email vacío
@@ -62,7 +88,7 @@ func TestRegister_EmailVacio(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Register(req)
+	user, err := authService.Register(context.Background(), req)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -78,7 +104,7 @@ func TestRegister_EmailVacio(t *testing.T) {
 func TestRegister_EmailInvalido(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	req := &models.RegisterRequest{
 		Email:    "invalidemail", // Sin @
@@ -87,7 +113,7 @@ func TestRegister_EmailInvalido(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Register(req)
+	user, err := authService.Register(context.Background(), req)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -99,7 +125,7 @@ func TestRegister_EmailInvalido(t *testing.T) {
 func TestRegister_PasswordCorto(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	req := &models.RegisterRequest{
 		Email:    testEmail,
@@ -108,7 +134,7 @@ func TestRegister_PasswordCorto(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Register(req)
+	user, err := authService.Register(context.Background(), req)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -116,11 +142,36 @@ func TestRegister_PasswordCorto(t *testing.T) {
 	assert.Equal(t, "la contraseña debe tener al menos 6 caracteres", err.Error())
 }
 
+// TestRegister_PasswordDemasiadoLarga prueba que más de 72 bytes sea un error de validación y no un 500 de bcrypt
+func TestRegister_PasswordDemasiadoLarga(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, testHasher)
+
+	req := &models.RegisterRequest{
+		Email:    testEmail,
+		Password: strings.Repeat("a", 73),
+		Username: testUsername,
+	}
+
+	// ACT
+	user, err := authService.Register(context.Background(), req)
+
+	// ASSERT
+	assert.Nil(t, user)
+	var svcErr *services.Error
+	require.ErrorAs(t, err, &svcErr)
+	assert.ErrorIs(t, err, services.ErrValidation)
+	assert.Equal(t, "password", svcErr.Field)
+	assert.Equal(t, "la contraseña no puede superar los 72 bytes", err.Error())
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
 // TestRegister_UsernameVacio prueba que falle con username vacío
 func TestRegister_UsernameVacio(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	req := &models.RegisterRequest{
 		Email:    testEmail,
@@ -129,7 +180,7 @@ func TestRegister_UsernameVacio(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Register(req)
+	user, err := authService.Register(context.Background(), req)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -141,7 +192,7 @@ func TestRegister_UsernameVacio(t *testing.T) {
 func TestRegister_EmailDuplicado(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	existingUser := &models.User{
 		ID:       1,
@@ -150,7 +201,7 @@ func TestRegister_EmailDuplicado(t *testing.T) {
 	}
 
 	// Configurar el mock: el email YA existe
-	mockRepo.On("FindByEmail", testEmail).Return(existingUser, nil)
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(existingUser, nil)
 
 	req := &models.RegisterRequest{
 		Email:    testEmail,
@@ -159,12 +210,13 @@ func TestRegister_EmailDuplicado(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Register(req)
+	user, err := authService.Register(context.Background(), req)
 
 	// ASSERT
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Equal(t, "el email ya está registrado", err.Error())
+	assert.ErrorIs(t, err, services.ErrConflict)
 
 	// NO debe llamar a Create porque el email ya existe
 	mockRepo.AssertNotCalled(t, "Create")
@@ -174,17 +226,17 @@ func TestRegister_EmailDuplicado(t *testing.T) {
 func TestLogin_Success(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	existingUser := &models.User{
 		ID:       1,
 		Email:    testEmail,
-		Password: testPassword,
+		Password: hashForTest(t, testPassword),
 		Username: testUsername,
 	}
 
 	// Configurar el mock: el usuario existe
-	mockRepo.On("FindByEmail", testEmail).Return(existingUser, nil)
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(existingUser, nil)
 
 	creds := &models.Credentials{
 		Email:    testEmail,
@@ -192,7 +244,7 @@ func TestLogin_Success(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Login(creds)
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
 
 	// ASSERT
 	assert.NoError(t, err)
@@ -201,13 +253,15 @@ func TestLogin_Success(t *testing.T) {
 	assert.Equal(t, testUsername, user.Username)
 
 	mockRepo.AssertExpectations(t)
+	// El hash ya está al día: no hay que reescribirlo
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
 }
 
 // TestLogin_EmailVacio prueba que falle con email vacío
 func TestLogin_EmailVacio(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	creds := &models.Credentials{
 		Email:    "",
@@ -215,7 +269,7 @@ func TestLogin_EmailVacio(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Login(creds)
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
 
 	// ASSERT
 	assert.Error(t, err)
@@ -229,7 +283,7 @@ func TestLogin_EmailVacio(t *testing.T) {
 func TestLogin_PasswordVacio(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	creds := &models.Credentials{
 		Email:    testEmail,
@@ -237,7 +291,7 @@ func TestLogin_PasswordVacio(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Login(creds)
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
 
 	// ASSERT
 	assert.Error(t, err)
@@ -249,10 +303,10 @@ func TestLogin_PasswordVacio(t *testing.T) {
 func TestLogin_UsuarioNoExiste(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	// Configurar el mock: el usuario NO existe
-	mockRepo.On("FindByEmail", "noexiste@example.com").Return(nil, nil)
+	mockRepo.On("FindByEmail", mock.Anything, "noexiste@example.com").Return(nil, nil)
 
 	creds := &models.Credentials{
 		Email:    "noexiste@example.com",
@@ -260,7 +314,7 @@ func TestLogin_UsuarioNoExiste(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Login(creds)
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
 
 	// ASSERT
 	assert.Error(t, err)
@@ -274,16 +328,16 @@ func TestLogin_UsuarioNoExiste(t *testing.T) {
 func TestLogin_PasswordIncorrecta(t *testing.T) {
 	// ARRANGE
 	mockRepo := new(mocks.MockUserRepository)
-	authService := services.NewAuthService(mockRepo)
+	authService := services.NewAuthService(mockRepo, testHasher)
 
 	existingUser := &models.User{
 		ID:       1,
 		Email:    testEmail,
-		Password: testPassword,
+		Password: hashForTest(t, testPassword),
 		Username: testUsername,
 	}
 
-	mockRepo.On("FindByEmail", testEmail).Return(existingUser, nil)
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(existingUser, nil)
 
 	creds := &models.Credentials{
 		Email:    testEmail,
@@ -291,12 +345,240 @@ func TestLogin_PasswordIncorrecta(t *testing.T) {
 	}
 
 	// ACT
-	user, err := authService.Login(creds)
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "credenciales inválidas", err.Error())
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestLogin_PasswordLegacyIncorrecta prueba que una fila en texto plano no acepte otra contraseña
+func TestLogin_PasswordLegacyIncorrecta(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, testHasher)
+
+	legacyUser := &models.User{
+		ID:       1,
+		Email:    testEmail,
+		Password: testPassword, // Fila vieja en texto plano
+		Username: testUsername,
+	}
+
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(legacyUser, nil)
+
+	creds := &models.Credentials{
+		Email:    testEmail,
+		Password: "wrongpassword",
+	}
+
+	// ACT
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
 
 	// ASSERT
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.Equal(t, "credenciales inválidas", err.Error())
 
+	// Un login fallido nunca migra la contraseña
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestLogin_MigraPasswordLegacy prueba que un login exitoso hashee una fila en texto plano
+func TestLogin_MigraPasswordLegacy(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, testHasher)
+
+	legacyUser := &models.User{
+		ID:       1,
+		Email:    testEmail,
+		Password: testPassword, // Fila vieja en texto plano
+		Username: testUsername,
+	}
+
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(legacyUser, nil)
+	mockRepo.On("UpdatePassword", mock.Anything, 1, mock.MatchedBy(func(hash string) bool {
+		ok, err := testHasher.Compare(hash, testPassword)
+		return err == nil && ok
+	})).Return(nil)
+
+	creds := &models.Credentials{
+		Email:    testEmail,
+		Password: testPassword,
+	}
+
+	// ACT
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+	assert.True(t, auth.IsHashed(user.Password))
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestLogin_PasswordLegacyLargaNoSeMigra prueba que una fila legacy de más de 72 bytes
+// siga entrando sin intentar hashearla en cada login
+func TestLogin_PasswordLegacyLargaNoSeMigra(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, testHasher)
+
+	longPassword := strings.Repeat("a", 73)
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).
+		Return(&models.User{ID: 1, Email: testEmail, Password: longPassword, Username: testUsername}, nil)
+
+	// ACT
+	user, err := authService.Login(context.Background(), &models.Credentials{Email: testEmail, Password: longPassword}, models.ClientInfo{})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestLogin_RehashPorCambioDeCosto prueba que se reescriba un hash con costo viejo
+func TestLogin_RehashPorCambioDeCosto(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, auth.NewBcryptHasher(testHashCost+1))
+
+	existingUser := &models.User{
+		ID:       1,
+		Email:    testEmail,
+		Password: hashForTest(t, testPassword), // Generado con testHashCost
+		Username: testUsername,
+	}
+
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(existingUser, nil)
+	mockRepo.On("UpdatePassword", mock.Anything, 1, mock.AnythingOfType("string")).Return(nil)
+
+	creds := &models.Credentials{
+		Email:    testEmail,
+		Password: testPassword,
+	}
+
+	// ACT
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestLogin_FalloAlMigrarNoBloqueaLogin prueba que un error al reescribir el hash no impida entrar
+func TestLogin_FalloAlMigrarNoBloqueaLogin(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, testHasher)
+
+	legacyUser := &models.User{
+		ID:       1,
+		Email:    testEmail,
+		Password: testPassword,
+		Username: testUsername,
+	}
+
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(legacyUser, nil)
+	mockRepo.On("UpdatePassword", mock.Anything, 1, mock.AnythingOfType("string")).Return(errors.New("db error"))
+
+	creds := &models.Credentials{
+		Email:    testEmail,
+		Password: testPassword,
+	}
+
+	// ACT
+	user, err := authService.Login(context.Background(), creds, models.ClientInfo{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+
 	mockRepo.AssertExpectations(t)
 }
+
+// TestLogin_UsuarioSuspendido prueba que un usuario suspendido no pueda iniciar sesión
+func TestLogin_UsuarioSuspendido(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	authService := services.NewAuthService(mockRepo, testHasher)
+
+	bannedAt := time.Now()
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{
+		ID:       1,
+		Email:    testEmail,
+		Password: hashForTest(t, testPassword),
+		Username: testUsername,
+		BannedAt: &bannedAt,
+	}, nil)
+
+	// ACT
+	user, err := authService.Login(context.Background(), &models.Credentials{Email: testEmail, Password: testPassword}, models.ClientInfo{})
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, user)
+	assert.Equal(t, "tu cuenta está suspendida", err.Error())
+	assert.ErrorIs(t, err, services.ErrForbidden)
+}
+
+func TestRegister_EmiteEvento(t *testing.T) {
+	mockRepo := new(mocks.MockUserRepository)
+	mockEvents := new(mocks.MockEvents)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEvents(mockEvents)
+
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil)
+	mockEvents.On("UserRegistered").Return().Once()
+
+	_, err := authService.Register(context.Background(), &models.RegisterRequest{
+		Email:    testEmail,
+		Password: testPassword,
+		Username: testUsername,
+	})
+
+	assert.NoError(t, err)
+	mockEvents.AssertExpectations(t)
+}
+
+// TestLogin_FallosEmitenEvento: cada login fallido se cuenta con su motivo
+func TestLogin_FallosEmitenEvento(t *testing.T) {
+	bannedAt := time.Now()
+	tests := []struct {
+		name     string
+		user     *models.User
+		password string
+		reason   string
+	}{
+		{"usuario inexistente", nil, testPassword, services.LoginFailureUnknownUser},
+		{"password incorrecta", &models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword)}, "wrongpassword", services.LoginFailureBadPassword},
+		{"usuario suspendido", &models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword), BannedAt: &bannedAt}, testPassword, services.LoginFailureBanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockUserRepository)
+			mockEvents := new(mocks.MockEvents)
+			authService := services.NewAuthService(mockRepo, testHasher).WithEvents(mockEvents)
+
+			if tt.user == nil {
+				mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, nil)
+			} else {
+				mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(tt.user, nil)
+			}
+			mockEvents.On("LoginFailed", tt.reason).Return().Once()
+
+			_, err := authService.Login(context.Background(), &models.Credentials{Email: testEmail, Password: tt.password}, models.ClientInfo{})
+
+			assert.Error(t, err)
+			mockEvents.AssertExpectations(t)
+		})
+	}
+}
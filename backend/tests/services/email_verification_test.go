@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// testVerificationConfig es la configuración de los links de verificación en los tests
+var testVerificationConfig = services.EmailVerificationConfig{
+	TTL:            24 * time.Hour,
+	URL:            "https://api.example.com/api/auth/verify",
+	ResendCooldown: time.Minute,
+}
+
+// newTestLinkSigner crea el firmador de links con una clave fija
+func newTestLinkSigner(t *testing.T) *auth.EmailLinkSigner {
+	t.Helper()
+	signer, err := auth.NewEmailLinkSigner(bytes.Repeat([]byte{7}, auth.MinSecretLength))
+	require.NoError(t, err)
+	return signer
+}
+
+// TestRegister_MandaElLinkDeVerificacion prueba que el mail lleve un link firmado para ese usuario y email
+func TestRegister_MandaElLinkDeVerificacion(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	mockMailer := mocks.NewMockMailer()
+	signer := newTestLinkSigner(t)
+	verify := services.NewEmailVerification(signer, mockMailer, testVerificationConfig)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	mockRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.User).ID = 7
+	}).Return(nil)
+	mockRepo.On("ClaimVerificationEmail", mock.Anything, 7, mock.Anything, mock.Anything).Return(true, nil, nil).Once()
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	user, err := authService.Register(context.Background(), &models.RegisterRequest{Email: testEmail, Password: testPassword, Username: testUsername})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.False(t, user.IsEmailVerified())
+	mockRepo.AssertExpectations(t)
+
+	msg := waitForMail(t, mockMailer)
+	assert.Equal(t, testEmail, msg.To)
+	assert.Contains(t, msg.Body, "24 horas")
+
+	link := regexp.MustCompile(`https://\S+`).FindString(msg.Body)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/auth/verify", parsed.Path)
+	token := parsed.Query().Get("token")
+	assert.True(t, signer.Verify(token, testEmail))
+	assert.False(t, signer.Verify(token, "otra@example.com"))
+}
+
+// TestVerifyEmail_Success prueba que un link firmado y vigente marque el email
+func TestVerifyEmail_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	signer := newTestLinkSigner(t)
+	verify := services.NewEmailVerification(signer, mocks.NewMockMailer(), testVerificationConfig)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	token := signer.Sign(7, testEmail, time.Now().Add(time.Hour))
+	mockRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail}, nil)
+	mockRepo.On("MarkEmailVerified", mock.Anything, 7).Return(nil).Once()
+
+	// ACT
+	err := authService.VerifyEmail(context.Background(), " "+token+" ")
+
+	// ASSERT
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestVerifyEmail_TokenInvalido prueba que vencido, mal firmado, de otro email o
+// de un usuario inexistente den el mismo error
+func TestVerifyEmail_TokenInvalido(t *testing.T) {
+	signer := newTestLinkSigner(t)
+	tests := []struct {
+		name  string
+		token string
+		user  *models.User
+	}{
+		{"vencido", signer.Sign(7, testEmail, time.Now().Add(-time.Minute)), &models.User{ID: 7, Email: testEmail}},
+		{"email cambiado", signer.Sign(7, testEmail, time.Now().Add(time.Hour)), &models.User{ID: 7, Email: "nuevo@example.com"}},
+		{"firma alterada", signer.Sign(7, testEmail, time.Now().Add(time.Hour)) + "x", &models.User{ID: 7, Email: testEmail}},
+		{"usuario inexistente", signer.Sign(7, testEmail, time.Now().Add(time.Hour)), nil},
+		{"mal formado", "no-es-un-link", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			mockRepo := new(mocks.MockUserRepository)
+			verify := services.NewEmailVerification(signer, mocks.NewMockMailer(), testVerificationConfig)
+			authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+			if tt.user == nil {
+				mockRepo.On("FindByID", mock.Anything, 7).Return(nil, nil)
+			} else {
+				mockRepo.On("FindByID", mock.Anything, 7).Return(tt.user, nil)
+			}
+
+			// ACT
+			err := authService.VerifyEmail(context.Background(), tt.token)
+
+			// ASSERT
+			assert.ErrorIs(t, err, services.ErrValidation)
+			assert.Equal(t, services.ErrInvalidVerificationToken, err.Error())
+			mockRepo.AssertNotCalled(t, "MarkEmailVerified", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestVerifyEmail_SinToken no consulta la base
+func TestVerifyEmail_SinToken(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	verify := services.NewEmailVerification(newTestLinkSigner(t), mocks.NewMockMailer(), testVerificationConfig)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	// ACT
+	err := authService.VerifyEmail(context.Background(), "")
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrValidation)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+// TestResendVerification_Success reserva el envío antes de responder y manda un link nuevo
+func TestResendVerification_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	mockMailer := mocks.NewMockMailer()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	verify := services.NewEmailVerification(newTestLinkSigner(t), mockMailer, testVerificationConfig).
+		WithClock(func() time.Time { return now })
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	mockRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail, Username: testUsername}, nil)
+	mockRepo.On("ClaimVerificationEmail", mock.Anything, 7, now, now.Add(-time.Minute)).Return(true, nil, nil).Once()
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	err := authService.ResendVerification(context.Background(), 7)
+
+	// ASSERT
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	msg := waitForMail(t, mockMailer)
+	assert.Equal(t, testEmail, msg.To)
+	assert.Equal(t, "Verificá tu email", msg.Subject)
+}
+
+// TestResendVerification_RespetaLaEspera no manda otro mail antes de ResendCooldown
+func TestResendVerification_RespetaLaEspera(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	mockMailer := mocks.NewMockMailer()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	verify := services.NewEmailVerification(newTestLinkSigner(t), mockMailer, testVerificationConfig).
+		WithClock(func() time.Time { return now })
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	lastSent := now.Add(-20 * time.Second)
+	mockRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail}, nil)
+	mockRepo.On("ClaimVerificationEmail", mock.Anything, 7, now, now.Add(-time.Minute)).Return(false, &lastSent, nil)
+
+	// ACT
+	err := authService.ResendVerification(context.Background(), 7)
+
+	// ASSERT
+	var svcErr *services.Error
+	require.ErrorAs(t, err, &svcErr)
+	assert.ErrorIs(t, err, services.ErrTooManyTries)
+	assert.Equal(t, 40*time.Second, svcErr.RetryAfter)
+	assert.Contains(t, err.Error(), "40 segundos")
+	mockMailer.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+// TestResendVerification_PedidosSimultaneos prueba que de varios pedidos a la vez
+// salga un solo mail: la espera se reserva antes de responder, no al enviar
+func TestResendVerification_PedidosSimultaneos(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	mockMailer := mocks.NewMockMailer()
+	verify := services.NewEmailVerification(newTestLinkSigner(t), mockMailer, testVerificationConfig)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	mockRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail}, nil)
+	// La base deja ganar a un solo UPDATE condicional
+	lastSent := time.Now()
+	mockRepo.On("ClaimVerificationEmail", mock.Anything, 7, mock.Anything, mock.Anything).Return(true, nil, nil).Once()
+	mockRepo.On("ClaimVerificationEmail", mock.Anything, 7, mock.Anything, mock.Anything).Return(false, &lastSent, nil)
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	const requests = 10
+	errs := make(chan error, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- authService.ResendVerification(context.Background(), 7)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	// ASSERT
+	accepted := 0
+	for err := range errs {
+		if err == nil {
+			accepted++
+		} else {
+			assert.ErrorIs(t, err, services.ErrTooManyTries)
+		}
+	}
+	assert.Equal(t, 1, accepted)
+	waitForMail(t, mockMailer)
+	select {
+	case <-mockMailer.Sent:
+		t.Fatal("se envió más de un mail")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestResendVerification_YaVerificado no manda nada
+func TestResendVerification_YaVerificado(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	verify := services.NewEmailVerification(newTestLinkSigner(t), mocks.NewMockMailer(), testVerificationConfig)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	verifiedAt := time.Now().Add(-time.Hour)
+	mockRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail, EmailVerifiedAt: &verifiedAt}, nil)
+
+	// ACT
+	err := authService.ResendVerification(context.Background(), 7)
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrConflict)
+	mockRepo.AssertNotCalled(t, "ClaimVerificationEmail", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestResendVerification_UsuarioNoExiste responde 404
+func TestResendVerification_UsuarioNoExiste(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockUserRepository)
+	verify := services.NewEmailVerification(newTestLinkSigner(t), mocks.NewMockMailer(), testVerificationConfig)
+	authService := services.NewAuthService(mockRepo, testHasher).WithEmailVerification(verify)
+
+	mockRepo.On("FindByID", mock.Anything, 7).Return(nil, nil)
+
+	// ACT
+	err := authService.ResendVerification(context.Background(), 7)
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const testIP = "203.0.113.7"
+
+var (
+	throttleNow         = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	throttleResetBefore = throttleNow.Add(-services.DefaultLoginThrottlePolicy.Window)
+)
+
+// newThrottledAuthService arma un AuthService con el bloqueo de logins y un reloj fijo
+func newThrottledAuthService(userRepo *mocks.MockUserRepository, throttleRepo *mocks.MockLoginThrottleRepository) *services.AuthService {
+	throttle := services.NewLoginThrottle(throttleRepo, services.DefaultLoginThrottlePolicy).
+		WithClock(func() time.Time { return throttleNow })
+	return services.NewAuthService(userRepo, testHasher).WithLoginThrottle(throttle)
+}
+
+func loginAs(authService *services.AuthService, password string) (*models.User, error) {
+	return authService.Login(context.Background(), &models.Credentials{Email: testEmail, Password: password}, models.ClientInfo{IPAddress: testIP})
+}
+
+// retryAfter extrae la espera de un error de dominio
+func retryAfter(t *testing.T, err error) time.Duration {
+	t.Helper()
+	var svcErr *services.Error
+	require.True(t, errors.As(err, &svcErr))
+	return svcErr.RetryAfter
+}
+
+// TestLoginThrottle_CuentaBloqueada: con la cuenta bloqueada ni se busca al usuario
+func TestLoginThrottle_CuentaBloqueada(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	mockEvents := new(mocks.MockEvents)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo).WithEvents(mockEvents)
+
+	lockedUntil := throttleNow.Add(10 * time.Minute)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, throttleNow, throttleResetBefore).Return(&models.LoginThrottle{
+		Failures:      10,
+		LastFailureAt: throttleNow.Add(-5 * time.Minute),
+		LockedUntil:   &lockedUntil,
+	}, nil, nil)
+	mockEvents.On("LoginFailed", services.LoginFailureThrottled).Return().Once()
+
+	user, err := loginAs(authService, testPassword)
+
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, services.ErrTooManyTries)
+	assert.Equal(t, 10*time.Minute, retryAfter(t, err))
+	assert.Contains(t, err.Error(), "10 minutos")
+	mockUserRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+	mockThrottleRepo.AssertNotCalled(t, "Reserve", mock.Anything, models.ThrottleScopeIP, mock.Anything, mock.Anything, mock.Anything)
+	mockEvents.AssertExpectations(t)
+}
+
+// TestLoginThrottle_DemoraProgresiva: pasados los intentos libres hay que esperar
+// cada vez más entre intentos
+func TestLoginThrottle_DemoraProgresiva(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		want     time.Duration
+	}{
+		{"dentro de los intentos libres", 3, 0},
+		{"primer fallo extra", 4, time.Second},
+		{"se duplica", 6, 4 * time.Second},
+		{"tope de la demora", 9, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(mocks.MockUserRepository)
+			mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+			authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+			mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, mock.Anything, mock.Anything).Return(&models.LoginThrottle{
+				Failures:      tt.failures,
+				LastFailureAt: throttleNow,
+			}, &models.LoginThrottle{Failures: tt.failures + 1}, nil)
+			mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, testIP, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+			mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword)}, nil)
+			mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil)
+			mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil)
+
+			_, err := loginAs(authService, testPassword)
+
+			if tt.want == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, services.ErrTooManyTries)
+			assert.Equal(t, tt.want, retryAfter(t, err))
+		})
+	}
+}
+
+// TestLoginThrottle_FallosViejosNoCuentan: un fallo fuera de la ventana no demora
+func TestLoginThrottle_FallosViejosNoCuentan(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	lockedUntil := throttleNow.Add(time.Hour)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, mock.Anything, mock.Anything).Return(&models.LoginThrottle{
+		Failures:      20,
+		LastFailureAt: throttleNow.Add(-2 * time.Hour),
+		LockedUntil:   &lockedUntil,
+	}, &models.LoginThrottle{Failures: 1}, nil)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, testIP, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword)}, nil)
+	mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil)
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil)
+
+	user, err := loginAs(authService, testPassword)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+}
+
+// TestLoginThrottle_IPBloqueada: una IP que falló contra muchas cuentas queda
+// bloqueada, y el intento rechazado no cuenta contra la cuenta
+func TestLoginThrottle_IPBloqueada(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	lockedUntil := throttleNow.Add(90 * time.Second)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, testIP, mock.Anything, mock.Anything).Return(&models.LoginThrottle{
+		Failures:      50,
+		LastFailureAt: throttleNow,
+		LockedUntil:   &lockedUntil,
+	}, nil, nil)
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+
+	_, err := loginAs(authService, testPassword)
+
+	assert.ErrorIs(t, err, services.ErrTooManyTries)
+	assert.Equal(t, 90*time.Second, retryAfter(t, err))
+	assert.Contains(t, err.Error(), "2 minutos")
+	mockUserRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+	mockThrottleRepo.AssertExpectations(t)
+}
+
+// TestLoginThrottle_FalloBloqueaAlLlegarAlUmbral: el intento ya se contó en la
+// cuenta y en la IP; si la cuenta llegó al umbral, el fallo la bloquea
+func TestLoginThrottle_FalloBloqueaAlLlegarAlUmbral(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	policy := services.DefaultLoginThrottlePolicy
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, throttleNow, throttleResetBefore).
+		Return(nil, &models.LoginThrottle{Failures: policy.AccountLockAfter}, nil)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, testIP, throttleNow, throttleResetBefore).
+		Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword)}, nil)
+	mockThrottleRepo.On("Lock", mock.Anything, models.ThrottleScopeAccount, testEmail, throttleNow.Add(policy.LockDuration)).Return(nil)
+
+	_, err := loginAs(authService, "wrongpassword")
+
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "Lock", mock.Anything, models.ThrottleScopeIP, mock.Anything, mock.Anything)
+	mockThrottleRepo.AssertNotCalled(t, "Release", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestLoginThrottle_IntentosSimultaneos: la decisión se toma con el estado que
+// ve la reserva, así que de varios intentos a la vez con la demora a punto de
+// empezar pasa uno solo
+func TestLoginThrottle_IntentosSimultaneos(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	// La base serializa las reservas: la primera ve los 3 fallos libres y suma el
+	// cuarto, las siguientes ya ven ese cuarto fallo y su demora
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, mock.Anything, mock.Anything).
+		Return(&models.LoginThrottle{Failures: 3, LastFailureAt: throttleNow.Add(-time.Minute)}, &models.LoginThrottle{Failures: 4}, nil).Once()
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, mock.Anything, mock.Anything).
+		Return(&models.LoginThrottle{Failures: 4, LastFailureAt: throttleNow}, nil, nil)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, testIP, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword)}, nil)
+
+	const attempts = 10
+	errs := make(chan error, attempts)
+	var wg sync.WaitGroup
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := loginAs(authService, "wrongpassword")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	throttled := 0
+	for err := range errs {
+		if errors.Is(err, services.ErrTooManyTries) {
+			throttled++
+		} else {
+			assert.ErrorIs(t, err, services.ErrUnauthorized)
+		}
+	}
+	assert.Equal(t, attempts-1, throttled)
+	mockUserRepo.AssertNumberOfCalls(t, "FindByEmail", 1)
+}
+
+// countingHasher cuenta las comparaciones para verificar el camino del email inexistente
+type countingHasher struct {
+	auth.PasswordHasher
+	compares int
+}
+
+func (h *countingHasher) Compare(hash string, password string) (bool, error) {
+	h.compares++
+	return h.PasswordHasher.Compare(hash, password)
+}
+
+// TestLoginThrottle_EmailInexistente: responde igual que una contraseña incorrecta,
+// compara contra un hash de bcrypt (mismo tiempo) y cuenta el fallo para ese email
+func TestLoginThrottle_EmailInexistente(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	hasher := &countingHasher{PasswordHasher: testHasher}
+	throttle := services.NewLoginThrottle(mockThrottleRepo, services.DefaultLoginThrottlePolicy)
+	authService := services.NewAuthService(mockUserRepo, hasher).WithLoginThrottle(throttle)
+
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, testEmail, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: 1}, nil).Once()
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, testIP, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: 1}, nil).Once()
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, nil)
+
+	_, err := loginAs(authService, testPassword)
+
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+	assert.Equal(t, "credenciales inválidas", err.Error())
+	assert.Equal(t, 1, hasher.compares)
+	mockThrottleRepo.AssertExpectations(t)
+}
+
+// TestLoginThrottle_ExitoReiniciaLaCuenta: un login correcto olvida los fallos de
+// la cuenta; a la IP solo se le descuenta este intento
+func TestLoginThrottle_ExitoReiniciaLaCuenta(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	mockThrottleRepo.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword)}, nil)
+	mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil).Once()
+
+	user, err := loginAs(authService, testPassword)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, user)
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "Reset", mock.Anything, models.ThrottleScopeIP, mock.Anything)
+}
+
+// TestLoginThrottle_ConDosPasosSoloDescuenta: con 2FA la contraseña correcta no
+// reinicia los fallos, solo descuenta este intento de la cuenta y de la IP
+func TestLoginThrottle_ConDosPasosSoloDescuenta(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	mockThrottleRepo.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword), MFAEnabled: true}, nil)
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil).Once()
+
+	_, err := loginAs(authService, testPassword)
+
+	// Sin TwoFactor configurado el login no puede seguir, pero el intento ya se descontó
+	assert.Error(t, err)
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "Reset", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestLoginThrottle_ErrorDeLaBaseLiberaElIntento: si no se puede buscar al usuario
+// el intento no cuenta como fallo, se devuelve a la cuenta y a la IP
+func TestLoginThrottle_ErrorDeLaBaseLiberaElIntento(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	mockThrottleRepo.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, errors.New("db caída"))
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil).Once()
+
+	_, err := loginAs(authService, testPassword)
+
+	assert.EqualError(t, err, "db caída")
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "Lock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestLoginThrottle_ErrorDelHasherLiberaElIntento: un hash guardado corrupto
+// tampoco es un fallo del usuario
+func TestLoginThrottle_ErrorDelHasherLiberaElIntento(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	corrupt := "$2a$10$" + strings.Repeat("!", 53)
+	mockThrottleRepo.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 1, Email: testEmail, Password: corrupt}, nil)
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil).Once()
+
+	_, err := loginAs(authService, testPassword)
+
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, services.ErrUnauthorized)
+	mockThrottleRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertNotCalled(t, "Lock", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestLoginThrottle_ErrorAlBloquearNoCambiaLaRespuesta: si no se puede bloquear
+// la cuenta, el cliente igual recibe "credenciales inválidas"
+func TestLoginThrottle_ErrorAlBloquearNoCambiaLaRespuesta(t *testing.T) {
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newThrottledAuthService(mockUserRepo, mockThrottleRepo)
+
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeAccount, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: services.DefaultLoginThrottlePolicy.AccountLockAfter}, nil)
+	mockThrottleRepo.On("Reserve", mock.Anything, models.ThrottleScopeIP, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: 1}, nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(nil, nil)
+	mockThrottleRepo.On("Lock", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db caída"))
+
+	_, err := loginAs(authService, testPassword)
+
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+}
+
+// TestUnlockUser prueba que un admin desbloquee a otro usuario y un usuario común no
+func TestUnlockUser(t *testing.T) {
+	tests := []struct {
+		name      string
+		actorRole string
+		wantErr   error
+	}{
+		{"admin", models.RoleAdmin, nil},
+		{"usuario común", models.RoleUser, services.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockUserRepo := new(mocks.MockUserRepository)
+			mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+			throttle := services.NewLoginThrottle(mockThrottleRepo, services.DefaultLoginThrottlePolicy)
+			adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository)).WithLoginThrottle(throttle)
+
+			mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: tt.actorRole}, nil)
+			mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Email: testEmail, Role: models.RoleUser}, nil)
+			mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil)
+
+			user, err := adminService.UnlockUser(context.Background(), 1, 2)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockThrottleRepo.AssertNotCalled(t, "Reset", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 2, user.ID)
+			mockThrottleRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestLoginThrottle_Prune borra lo que quedó fuera de la ventana
+func TestLoginThrottle_Prune(t *testing.T) {
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	throttle := services.NewLoginThrottle(mockThrottleRepo, services.DefaultLoginThrottlePolicy).
+		WithClock(func() time.Time { return throttleNow })
+
+	mockThrottleRepo.On("DeleteStale", mock.Anything, throttleNow.Add(-time.Hour)).Return(nil).Once()
+
+	assert.NoError(t, throttle.Prune(context.Background()))
+	mockThrottleRepo.AssertExpectations(t)
+}
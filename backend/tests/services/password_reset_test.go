@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/mail"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var resetNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// testResetConfig es la configuración de los links de restablecimiento en los tests
+var testResetConfig = services.PasswordResetConfig{
+	TTL: time.Hour,
+	URL: "https://app.example.com/reset-password",
+}
+
+// waitForMail espera el mail que se manda en segundo plano
+func waitForMail(t *testing.T, mailer *mocks.MockMailer) mail.Message {
+	t.Helper()
+	select {
+	case msg := <-mailer.Sent:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("no se envió ningún mail")
+		return mail.Message{}
+	}
+}
+
+// TestForgotPassword_EnviaElLink prueba que el mail lleve un token cuyo hash es el guardado
+func TestForgotPassword_EnviaElLink(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockMailer := mocks.NewMockMailer()
+	reset := services.NewPasswordReset(mockTokenRepo, new(mocks.MockSessionRepository), mockMailer, testResetConfig).
+		WithClock(func() time.Time { return resetNow })
+	authService := services.NewAuthService(mockUserRepo, testHasher).WithPasswordReset(reset)
+
+	var storedHash string
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).Return(&models.User{ID: 7, Email: testEmail, Username: testUsername}, nil)
+	mockTokenRepo.On("DeleteForUser", mock.Anything, 7, models.TokenPurposePasswordReset).Return(nil)
+	mockTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.UserToken) bool {
+		return token.UserID == 7 && token.Purpose == models.TokenPurposePasswordReset && token.ExpiresAt.Equal(resetNow.Add(time.Hour))
+	}), mock.Anything).Run(func(args mock.Arguments) {
+		storedHash = args.String(2)
+	}).Return(nil)
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	err := authService.ForgotPassword(context.Background(), &models.ForgotPasswordRequest{Email: "  TEST@example.com "})
+
+	// ASSERT
+	require.NoError(t, err)
+	msg := waitForMail(t, mockMailer)
+	assert.Equal(t, testEmail, msg.To)
+	assert.Contains(t, msg.Body, "1 hora")
+
+	link := regexp.MustCompile(`https://\S+`).FindString(msg.Body)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	assert.Equal(t, "/reset-password", parsed.Path)
+	token := parsed.Query().Get("token")
+	assert.NotEmpty(t, token)
+	// En la base nunca queda el token, solo su hash
+	assert.Equal(t, auth.HashToken(token), storedHash)
+	mockTokenRepo.AssertExpectations(t)
+}
+
+// TestForgotPassword_EmailInexistente: responde igual pero no manda nada
+func TestForgotPassword_EmailInexistente(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockMailer := mocks.NewMockMailer()
+	reset := services.NewPasswordReset(mockTokenRepo, new(mocks.MockSessionRepository), mockMailer, testResetConfig)
+	authService := services.NewAuthService(mockUserRepo, testHasher).WithPasswordReset(reset)
+
+	looked := make(chan struct{})
+	mockUserRepo.On("FindByEmail", mock.Anything, "nadie@example.com").Run(func(mock.Arguments) { close(looked) }).Return(nil, nil)
+
+	// ACT
+	err := authService.ForgotPassword(context.Background(), &models.ForgotPasswordRequest{Email: "nadie@example.com"})
+
+	// ASSERT
+	require.NoError(t, err)
+	<-looked
+	select {
+	case <-mockMailer.Sent:
+		t.Fatal("no debería mandarse un mail")
+	case <-time.After(50 * time.Millisecond):
+	}
+	mockTokenRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestForgotPassword_EmailVacio prueba que se valide el email
+func TestForgotPassword_EmailVacio(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	reset := services.NewPasswordReset(new(mocks.MockUserTokenRepository), new(mocks.MockSessionRepository), mocks.NewMockMailer(), testResetConfig)
+	authService := services.NewAuthService(mockUserRepo, testHasher).WithPasswordReset(reset)
+
+	// ACT
+	err := authService.ForgotPassword(context.Background(), &models.ForgotPasswordRequest{Email: " "})
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrValidation)
+	mockUserRepo.AssertNotCalled(t, "FindByEmail", mock.Anything, mock.Anything)
+}
+
+// TestResetPassword_Success prueba que se cambie la contraseña y se cierren las sesiones
+func TestResetPassword_Success(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockMailer := mocks.NewMockMailer()
+	reset := services.NewPasswordReset(mockTokenRepo, mockSessionRepo, mockMailer, testResetConfig).
+		WithClock(func() time.Time { return resetNow })
+	authService := services.NewAuthService(mockUserRepo, testHasher).WithPasswordReset(reset)
+
+	mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposePasswordReset, auth.HashToken("token-del-mail"), resetNow).
+		Return(&models.UserToken{UserID: 7, Purpose: models.TokenPurposePasswordReset}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail, Username: testUsername}, nil)
+	var newHash string
+	mockUserRepo.On("UpdatePassword", mock.Anything, 7, mock.AnythingOfType("string")).Run(func(args mock.Arguments) {
+		newHash = args.String(2)
+	}).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 7).Return(nil).Once()
+	mockTokenRepo.On("DeleteForUser", mock.Anything, 7, models.TokenPurposePasswordReset).Return(nil)
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	err := authService.ResetPassword(context.Background(), &models.ResetPasswordRequest{Token: "token-del-mail", Password: "nueva-clave"})
+
+	// ASSERT
+	require.NoError(t, err)
+	ok, err := testHasher.Compare(newHash, "nueva-clave")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	mockSessionRepo.AssertExpectations(t)
+
+	// Se avisa al dueño de la cuenta
+	msg := waitForMail(t, mockMailer)
+	assert.Equal(t, testEmail, msg.To)
+	assert.Equal(t, "Tu contraseña fue cambiada", msg.Subject)
+}
+
+// TestResetPassword_RevocaTokensDeAPI prueba que restablecer la contraseña revoque los tokens de API
+func TestResetPassword_RevocaTokensDeAPI(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockAPITokenRepo := new(mocks.MockAPITokenRepository)
+	mockMailer := mocks.NewMockMailer()
+	reset := services.NewPasswordReset(mockTokenRepo, mockSessionRepo, mockMailer, testResetConfig).
+		WithAPITokenRepository(mockAPITokenRepo)
+	authService := services.NewAuthService(mockUserRepo, testHasher).WithPasswordReset(reset)
+
+	mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposePasswordReset, auth.HashToken("token-del-mail"), mock.Anything).
+		Return(&models.UserToken{UserID: 7, Purpose: models.TokenPurposePasswordReset}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail}, nil)
+	mockUserRepo.On("UpdatePassword", mock.Anything, 7, mock.AnythingOfType("string")).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 7).Return(nil)
+	mockAPITokenRepo.On("RevokeAllForUser", mock.Anything, 7).Return(nil).Once()
+	mockTokenRepo.On("DeleteForUser", mock.Anything, 7, models.TokenPurposePasswordReset).Return(nil)
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+
+	// ACT
+	err := authService.ResetPassword(context.Background(), &models.ResetPasswordRequest{Token: "token-del-mail", Password: "nueva-clave"})
+
+	// ASSERT
+	require.NoError(t, err)
+	mockAPITokenRepo.AssertExpectations(t)
+	waitForMail(t, mockMailer)
+}
+
+// TestResetPassword_TokenInvalido: vencido, usado o inexistente dan el mismo error
+func TestResetPassword_TokenInvalido(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	reset := services.NewPasswordReset(mockTokenRepo, mockSessionRepo, mocks.NewMockMailer(), testResetConfig)
+	authService := services.NewAuthService(mockUserRepo, testHasher).WithPasswordReset(reset)
+
+	mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposePasswordReset, mock.Anything, mock.Anything).Return(nil, nil)
+
+	// ACT
+	err := authService.ResetPassword(context.Background(), &models.ResetPasswordRequest{Token: "usado", Password: "nueva-clave"})
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrValidation)
+	assert.Equal(t, services.ErrInvalidResetToken, err.Error())
+	mockUserRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+	mockSessionRepo.AssertNotCalled(t, "RevokeAllForUser", mock.Anything, mock.Anything)
+}
+
+// TestResetPassword_Validaciones prueba que no se consuma el token con datos inválidos,
+// así el usuario puede reintentar con el mismo link
+func TestResetPassword_Validaciones(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   models.ResetPasswordRequest
+		field string
+	}{
+		{"sin token", models.ResetPasswordRequest{Password: "nueva-clave"}, "token"},
+		{"contraseña corta", models.ResetPasswordRequest{Token: "token-del-mail", Password: "123"}, "password"},
+		{"contraseña de más de 72 bytes", models.ResetPasswordRequest{Token: "token-del-mail", Password: strings.Repeat("a", 73)}, "password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			mockTokenRepo := new(mocks.MockUserTokenRepository)
+			reset := services.NewPasswordReset(mockTokenRepo, new(mocks.MockSessionRepository), mocks.NewMockMailer(), testResetConfig)
+			authService := services.NewAuthService(new(mocks.MockUserRepository), testHasher).WithPasswordReset(reset)
+
+			// ACT
+			err := authService.ResetPassword(context.Background(), &tt.req)
+
+			// ASSERT
+			var svcErr *services.Error
+			require.ErrorAs(t, err, &svcErr)
+			assert.Equal(t, tt.field, svcErr.Field)
+			mockTokenRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestResetPassword_DesbloqueaLaCuenta: quien recupera la cuenta por mail ya no espera el bloqueo
+func TestResetPassword_DesbloqueaLaCuenta(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockSessionRepo := new(mocks.MockSessionRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	mockMailer := mocks.NewMockMailer()
+	reset := services.NewPasswordReset(mockTokenRepo, mockSessionRepo, mockMailer, testResetConfig)
+	authService := services.NewAuthService(mockUserRepo, testHasher).
+		WithPasswordReset(reset).
+		WithLoginThrottle(services.NewLoginThrottle(mockThrottleRepo, services.DefaultLoginThrottlePolicy))
+
+	mockTokenRepo.On("Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&models.UserToken{UserID: 7}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 7).Return(&models.User{ID: 7, Email: testEmail}, nil)
+	mockUserRepo.On("UpdatePassword", mock.Anything, 7, mock.Anything).Return(nil)
+	mockSessionRepo.On("RevokeAllForUser", mock.Anything, 7).Return(nil)
+	mockTokenRepo.On("DeleteForUser", mock.Anything, 7, models.TokenPurposePasswordReset).Return(nil)
+	mockMailer.On("Send", mock.Anything, mock.Anything).Return(nil)
+	mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+
+	// ACT
+	err := authService.ResetPassword(context.Background(), &models.ResetPasswordRequest{Token: "token-del-mail", Password: "nueva-clave"})
+
+	// ASSERT
+	require.NoError(t, err)
+	mockThrottleRepo.AssertExpectations(t)
+	waitForMail(t, mockMailer)
+}
+
+// TestPasswordReset_Prune borra los links vencidos
+func TestPasswordReset_Prune(t *testing.T) {
+	// ARRANGE
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	reset := services.NewPasswordReset(mockTokenRepo, new(mocks.MockSessionRepository), mocks.NewMockMailer(), testResetConfig).
+		WithClock(func() time.Time { return resetNow })
+
+	mockTokenRepo.On("DeleteExpired", mock.Anything, resetNow).Return(nil).Once()
+
+	// ACT
+	err := reset.Prune(context.Background())
+
+	// ASSERT
+	assert.NoError(t, err)
+	mockTokenRepo.AssertExpectations(t)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRolePolicy_Can recorre las reglas de autorización por rol
+func TestRolePolicy_Can(t *testing.T) {
+	policy := services.NewRolePolicy()
+	bannedAt := time.Now()
+
+	user := &models.User{ID: 1, Role: models.RoleUser}
+	moderator := &models.User{ID: 2, Role: models.RoleModerator}
+	admin := &models.User{ID: 3, Role: models.RoleAdmin}
+	banned := &models.User{ID: 4, Role: models.RoleAdmin, BannedAt: &bannedAt}
+
+	tests := []struct {
+		name   string
+		actor  *models.User
+		action services.Action
+		target services.Target
+		want   bool
+	}{
+		{"autor borra su post", user, services.ActionDeletePost, services.Target{OwnerID: 1}, true},
+		{"usuario no borra post ajeno", user, services.ActionDeletePost, services.Target{OwnerID: 9}, false},
+		{"moderador borra post ajeno", moderator, services.ActionDeletePost, services.Target{OwnerID: 9}, true},
+		{"moderador borra comentario ajeno", moderator, services.ActionDeleteComment, services.Target{OwnerID: 9}, true},
+		{"usuario no cierra posts", user, services.ActionLockThread, services.Target{OwnerID: 1}, false},
+		{"moderador cierra posts", moderator, services.ActionLockThread, services.Target{OwnerID: 9}, true},
+		{"moderador suspende usuario", moderator, services.ActionBanUser, services.Target{OwnerID: 9, OwnerRole: models.RoleUser}, true},
+		{"moderador no suspende moderador", moderator, services.ActionBanUser, services.Target{OwnerID: 9, OwnerRole: models.RoleModerator}, false},
+		{"admin suspende moderador", admin, services.ActionBanUser, services.Target{OwnerID: 9, OwnerRole: models.RoleModerator}, true},
+		{"nadie se suspende a sí mismo", admin, services.ActionBanUser, services.Target{OwnerID: 3, OwnerRole: models.RoleUser}, false},
+		{"moderador no asigna roles", moderator, services.ActionAssignRole, services.Target{OwnerID: 9}, false},
+		{"admin asigna roles", admin, services.ActionAssignRole, services.Target{OwnerID: 9}, true},
+		{"admin no cambia su propio rol", admin, services.ActionAssignRole, services.Target{OwnerID: 3}, false},
+		{"suspendido no hace nada", banned, services.ActionDeletePost, services.Target{OwnerID: 4}, false},
+		{"sin usuario no hace nada", nil, services.ActionDeletePost, services.Target{OwnerID: 1}, false},
+		{"acción desconocida", admin, services.Action("otra"), services.Target{OwnerID: 9}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Can(tt.actor, tt.action, tt.target))
+		})
+	}
+}
@@ -1,17 +1,28 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/repository"
 	"ingsw3-tp08/internal/services"
 	"ingsw3-tp08/tests/mocks"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// anyPage acepta cualquier página en las expectativas de los mocks
+var anyPage = mock.AnythingOfType("pagination.Params")
+
 // TestCreatePost_Success prueba la creación exitosa de un post
 func TestCreatePost_Success(t *testing.T) {
 	// ARRANGE
@@ -25,11 +36,11 @@ func TestCreatePost_Success(t *testing.T) {
 		Email:    "test@example.com",
 		Username: "testuser",
 	}
-	mockUserRepo.On("FindByID", 1).Return(existingUser, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(existingUser, nil)
 	// ← FIN
 
 	// Configurar mock: Create debe ejecutarse correctamente
-	mockRepo.On("Create", mock.AnythingOfType("*models.Post")).Return(nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Post")).Return(nil)
 
 	req := &models.CreatePostRequest{
 		Title:   "Test Post",
@@ -37,7 +48,7 @@ func TestCreatePost_Success(t *testing.T) {
 	}
 
 	// ACT
-	post, err := postService.CreatePost(req, 1)
+	post, err := postService.CreatePost(context.Background(), req, 1)
 
 	// ASSERT
 	assert.NoError(t, err)
@@ -50,6 +61,57 @@ func TestCreatePost_Success(t *testing.T) {
 	mockUserRepo.AssertExpectations(t) // ← AGREGAR ESTO TAMBIÉN
 }
 
+// TestCreatePost_EmiteEvento: solo un post guardado cuenta para las métricas
+func TestCreatePost_EmiteEvento(t *testing.T) {
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockEvents := new(mocks.MockEvents)
+	postService := services.NewPostService(mockRepo, mockUserRepo).WithEvents(mockEvents)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "testuser"}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Post")).Return(nil).Once()
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Post")).Return(errors.New("db caída")).Once()
+	mockEvents.On("PostCreated").Return().Once()
+
+	req := &models.CreatePostRequest{Title: "Test Post", Content: "This is a test post"}
+
+	_, err := postService.CreatePost(context.Background(), req, 1)
+	assert.NoError(t, err)
+
+	_, err = postService.CreatePost(context.Background(), req, 1)
+	assert.Error(t, err)
+
+	mockEvents.AssertExpectations(t)
+}
+
+// TestCreatePost_AbreSpan: el span del service es padre de lo que haga el repositorio
+func TestCreatePost_AbreSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
+
+	var repoSpan trace.SpanContext
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "testuser"}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Post")).
+		Run(func(args mock.Arguments) {
+			repoSpan = trace.SpanContextFromContext(args.Get(0).(context.Context))
+		}).
+		Return(nil)
+
+	_, err := postService.CreatePost(context.Background(), &models.CreatePostRequest{Title: "Test Post", Content: "Contenido"}, 1)
+
+	assert.NoError(t, err)
+	spans := recorder.Ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "PostService.CreatePost", spans[0].Name())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), repoSpan.SpanID())
+}
+
 // TestCreatePost_UserNotFound: el userId no existe -> error
 func TestCreatePost_UserNotFound(t *testing.T) {
 	// ARRANGE
@@ -58,7 +120,7 @@ func TestCreatePost_UserNotFound(t *testing.T) {
 	postService := services.NewPostService(mockRepo, mockUserRepo)
 
 	// Configurar mock: FindByID del user devuelve nil (no existe)
-	mockUserRepo.On("FindByID", 999).Return(nil, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	req := &models.CreatePostRequest{
 		Title:   "Test Post",
@@ -66,7 +128,7 @@ func TestCreatePost_UserNotFound(t *testing.T) {
 	}
 
 	// ACT
-	post, err := postService.CreatePost(req, 999)
+	post, err := postService.CreatePost(context.Background(), req, 999)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -86,10 +148,10 @@ func TestCreatePost_RepoError(t *testing.T) {
 
 	// Usuario existe
 	existingUser := &models.User{ID: 1, Email: "u@u.com", Username: "u"}
-	mockUserRepo.On("FindByID", 1).Return(existingUser, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(existingUser, nil)
 
 	// El repo Create falla
-	mockRepo.On("Create", mock.AnythingOfType("*models.Post")).Return(errors.New("db error"))
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Post")).Return(errors.New("db error"))
 
 	req := &models.CreatePostRequest{
 		Title:   "Test Post",
@@ -97,7 +159,7 @@ func TestCreatePost_RepoError(t *testing.T) {
 	}
 
 	// ACT
-	post, err := postService.CreatePost(req, 1)
+	post, err := postService.CreatePost(context.Background(), req, 1)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -121,12 +183,17 @@ func TestCreatePost_TitleVacio(t *testing.T) {
 	}
 
 	// ACT
-	post, err := postService.CreatePost(req, 1)
+	post, err := postService.CreatePost(context.Background(), req, 1)
 
 	// ASSERT
 	assert.Error(t, err)
 	assert.Nil(t, post)
 	assert.Equal(t, "el título es requerido", err.Error())
+	assert.ErrorIs(t, err, services.ErrValidation)
+
+	var domainErr *services.Error
+	assert.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, "title", domainErr.Field)
 	// No debe llamar al repo ni al userRepo
 	mockRepo.AssertNotCalled(t, "Create")
 	mockUserRepo.AssertNotCalled(t, "FindByID")
@@ -145,7 +212,7 @@ func TestCreatePost_ContentVacio(t *testing.T) {
 	}
 
 	// ACT
-	post, err := postService.CreatePost(req, 1)
+	post, err := postService.CreatePost(context.Background(), req, 1)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -172,16 +239,18 @@ func TestDeletePost_Success(t *testing.T) {
 	}
 
 	// Configurar mocks
-	mockRepo.On("FindByID", 1).Return(existingPost, nil)
-	mockRepo.On("Delete", 1).Return(nil)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(existingPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleUser}, nil)
+	mockRepo.On("Delete", mock.Anything, 1).Return(nil)
 
 	// ACT: El usuario 1 elimina su propio post
-	err := postService.DeletePost(1, 1)
+	err := postService.DeletePost(context.Background(), 1, 1)
 
 	// ASSERT
 	assert.NoError(t, err)
 
 	mockRepo.AssertExpectations(t)
+	mockUserRepo.AssertExpectations(t)
 }
 
 // TestDeletePost_PostNoExiste prueba eliminar post inexistente
@@ -192,10 +261,10 @@ func TestDeletePost_PostNoExiste(t *testing.T) {
 	postService := services.NewPostService(mockRepo, mockUserRepo)
 
 	// Post no existe
-	mockRepo.On("FindByID", 999).Return(nil, nil)
+	mockRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	// ACT
-	err := postService.DeletePost(999, 1)
+	err := postService.DeletePost(context.Background(), 999, 1)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -220,14 +289,16 @@ func TestDeletePost_NoEsAutor(t *testing.T) {
 		Username: "testuser",
 	}
 
-	mockRepo.On("FindByID", 1).Return(existingPost, nil)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(existingPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleUser}, nil)
 
 	// ACT: El usuario 2 intenta eliminar el post del usuario 1
-	err := postService.DeletePost(1, 2)
+	err := postService.DeletePost(context.Background(), 1, 2)
 
 	// ASSERT
 	assert.Error(t, err)
 	assert.Equal(t, "no tienes permiso para eliminar este post", err.Error())
+	assert.ErrorIs(t, err, services.ErrForbidden)
 
 	// NO debe llamar a Delete porque no tiene permiso
 	mockRepo.AssertNotCalled(t, "Delete")
@@ -255,12 +326,13 @@ func TestDeleteComment_Success(t *testing.T) {
 	}
 
 	// Configurar mocks
-	mockRepo.On("FindByID", 1).Return(existingPost, nil)
-	mockUserRepo.On("FindByID", 1).Return(existingUser, nil)
-	mockRepo.On("DeleteComment", 1, 10, 1).Return(nil)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(existingPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(existingUser, nil)
+	mockRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, UserID: 1}, nil)
+	mockRepo.On("DeleteComment", mock.Anything, 1, 10).Return(nil)
 
 	// ACT: El usuario 1 elimina su propio comentario
-	err := postService.DeleteComment(1, 10, 1)
+	err := postService.DeleteComment(context.Background(), 1, 10, 1)
 
 	// ASSERT
 	assert.NoError(t, err)
@@ -276,10 +348,10 @@ func TestDeleteComment_PostNoExiste(t *testing.T) {
 	postService := services.NewPostService(mockRepo, mockUserRepo)
 
 	// Post no existe
-	mockRepo.On("FindByID", 999).Return(nil, nil)
+	mockRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	// ACT
-	err := postService.DeleteComment(999, 10, 1)
+	err := postService.DeleteComment(context.Background(), 999, 10, 1)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -303,11 +375,11 @@ func TestDeleteComment_UsuarioNoExiste(t *testing.T) {
 		Username: "testuser",
 	}
 
-	mockRepo.On("FindByID", 1).Return(existingPost, nil)
-	mockUserRepo.On("FindByID", 999).Return(nil, nil)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(existingPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	// ACT
-	err := postService.DeleteComment(1, 10, 999)
+	err := postService.DeleteComment(context.Background(), 1, 10, 999)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -335,21 +407,59 @@ func TestDeleteComment_NoEsAutor(t *testing.T) {
 		Username: "otheruser",
 	}
 
-	mockRepo.On("FindByID", 1).Return(existingPost, nil)
-	mockUserRepo.On("FindByID", 2).Return(existingUser, nil)
+	mockRepo.On("FindByID", mock.Anything, 1).Return(existingPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(existingUser, nil)
+	mockRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, UserID: 1}, nil)
+
+	// ACT: Usuario 2 intenta eliminar comentario del usuario 1
+	err := postService.DeleteComment(context.Background(), 1, 10, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Equal(t, "no tienes permiso para eliminar este comentario", err.Error())
+	mockRepo.AssertNotCalled(t, "DeleteComment", mock.Anything, 1, 10)
+}
+
+// TestDeleteComment_Moderador prueba que un moderador elimina comentarios ajenos
+func TestDeleteComment_Moderador(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
 
-	// Usuario 2 intenta eliminar comentario del usuario 1
-	mockRepo.On("DeleteComment", 1, 10, 2).Return(errors.New("no tienes permiso para eliminar este comentario o no existe"))
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 3).Return(&models.User{ID: 3, Role: models.RoleModerator}, nil)
+	mockRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, UserID: 1}, nil)
+	mockRepo.On("DeleteComment", mock.Anything, 1, 10).Return(nil)
 
 	// ACT
-	err := postService.DeleteComment(1, 10, 2)
+	err := postService.DeleteComment(context.Background(), 1, 10, 3)
 
 	// ASSERT
-	assert.Error(t, err)
-	assert.Equal(t, "no tienes permiso para eliminar este comentario o no existe", err.Error())
+	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
 
+// TestDeleteComment_ComentarioDeOtroPost prueba que no se borra un comentario por otro post
+func TestDeleteComment_ComentarioDeOtroPost(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleUser}, nil)
+	mockRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 2, UserID: 1}, nil)
+
+	// ACT
+	err := postService.DeleteComment(context.Background(), 1, 10, 1)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Equal(t, "comentario no encontrado", err.Error())
+	mockRepo.AssertNotCalled(t, "DeleteComment", mock.Anything, 1, 10)
+}
+
 // TestGetAllPosts_Success prueba obtener todos los posts
 func TestGetAllPosts_Success(t *testing.T) {
 	// ARRANGE
@@ -361,14 +471,14 @@ func TestGetAllPosts_Success(t *testing.T) {
 		{ID: 1, Title: "Post 1", Content: "Content 1", UserID: 1},
 		{ID: 2, Title: "Post 2", Content: "Content 2", UserID: 2},
 	}
-	mockPostRepo.On("FindAll").Return(mockPosts, nil)
+	mockPostRepo.On("FindAll", mock.Anything, anyPage).Return(mockPosts, nil)
 
 	// ACT
-	posts, err := postService.GetAllPosts()
+	posts, err := postService.GetAllPosts(context.Background(), pagination.Params{})
 
 	// ASSERT
 	assert.NoError(t, err)
-	assert.Len(t, posts, 2)
+	assert.Len(t, posts.Items, 2)
 	mockPostRepo.AssertExpectations(t)
 }
 
@@ -385,10 +495,10 @@ func TestGetPostByID_Success(t *testing.T) {
 		Content: "Test Content",
 		UserID:  1,
 	}
-	mockPostRepo.On("FindByID", 1).Return(mockPost, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(mockPost, nil)
 
 	// ACT
-	post, err := postService.GetPostByID(1)
+	post, err := postService.GetPostByID(context.Background(), 1)
 
 	// ASSERT
 	assert.NoError(t, err)
@@ -407,16 +517,16 @@ func TestCreateComment_Success(t *testing.T) {
 	mockPost := &models.Post{ID: 1, Title: "Post", UserID: 1}
 	mockUser := &models.User{ID: 2, Username: "commenter"}
 
-	mockPostRepo.On("FindByID", 1).Return(mockPost, nil)
-	mockUserRepo.On("FindByID", 2).Return(mockUser, nil)
-	mockPostRepo.On("CreateComment", mock.AnythingOfType("*models.Comment")).Return(nil)
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(mockPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(mockUser, nil)
+	mockPostRepo.On("CreateComment", mock.Anything, mock.AnythingOfType("*models.Comment")).Return(nil)
 
 	req := &models.CreateCommentRequest{
 		Content: "Great post!",
 	}
 
 	// ACT
-	comment, err := postService.CreateComment(1, req, 2)
+	comment, err := postService.CreateComment(context.Background(), 1, req, 2)
 
 	// ASSERT
 	assert.NoError(t, err)
@@ -428,6 +538,23 @@ func TestCreateComment_Success(t *testing.T) {
 	mockUserRepo.AssertExpectations(t)
 }
 
+func TestCreateComment_EmiteEvento(t *testing.T) {
+	mockPostRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockEvents := new(mocks.MockEvents)
+	postService := services.NewPostService(mockPostRepo, mockUserRepo).WithEvents(mockEvents)
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, Title: "Post", UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Username: "commenter"}, nil)
+	mockPostRepo.On("CreateComment", mock.Anything, mock.AnythingOfType("*models.Comment")).Return(nil)
+	mockEvents.On("CommentCreated").Return().Once()
+
+	_, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Great post!"}, 2)
+
+	assert.NoError(t, err)
+	mockEvents.AssertExpectations(t)
+}
+
 // TestGetCommentsByPostID_Success prueba obtener comentarios de un post
 func TestGetCommentsByPostID_Success(t *testing.T) {
 	// ARRANGE
@@ -441,15 +568,15 @@ func TestGetCommentsByPostID_Success(t *testing.T) {
 		{ID: 2, PostID: 1, UserID: 2, Content: "Comment 2"},
 	}
 
-	mockPostRepo.On("FindByID", 1).Return(mockPost, nil)
-	mockPostRepo.On("FindCommentsByPostID", 1).Return(mockComments, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(mockPost, nil)
+	mockPostRepo.On("FindCommentsByPostID", mock.Anything, 1, anyPage).Return(mockComments, nil)
 
 	// ACT
-	comments, err := postService.GetCommentsByPostID(1)
+	comments, err := postService.GetCommentsByPostID(context.Background(), 1, pagination.Params{})
 
 	// ASSERT
 	assert.NoError(t, err)
-	assert.Len(t, comments, 2)
+	assert.Len(t, comments.Items, 2)
 	mockPostRepo.AssertExpectations(t)
 }
 
@@ -462,15 +589,16 @@ func TestGetAllPosts_Empty(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
-	mockPostRepo.On("FindAll").Return(nil, nil)
+	mockPostRepo.On("FindAll", mock.Anything, anyPage).Return(nil, nil)
 
 	// ACT
-	posts, err := postService.GetAllPosts()
+	posts, err := postService.GetAllPosts(context.Background(), pagination.Params{})
 
 	// ASSERT
 	assert.NoError(t, err)
-	assert.NotNil(t, posts)
-	assert.Len(t, posts, 0)
+	assert.NotNil(t, posts.Items)
+	assert.Len(t, posts.Items, 0)
+	assert.Empty(t, posts.NextCursor)
 	mockPostRepo.AssertExpectations(t)
 }
 
@@ -484,7 +612,7 @@ func TestGetPostByID_InvalidID(t *testing.T) {
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
 	// ACT
-	post, err := postService.GetPostByID(0)
+	post, err := postService.GetPostByID(context.Background(), 0)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -500,10 +628,10 @@ func TestGetPostByID_NotFound(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
-	mockPostRepo.On("FindByID", 999).Return(nil, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	// ACT
-	post, err := postService.GetPostByID(999)
+	post, err := postService.GetPostByID(context.Background(), 999)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -526,7 +654,7 @@ func TestCreateComment_EmptyContent(t *testing.T) {
 	}
 
 	// ACT
-	comment, err := postService.CreateComment(1, req, 1)
+	comment, err := postService.CreateComment(context.Background(), 1, req, 1)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -543,14 +671,14 @@ func TestCreateComment_PostNotFound(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
-	mockPostRepo.On("FindByID", 999).Return(nil, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	req := &models.CreateCommentRequest{
 		Content: "Great post!",
 	}
 
 	// ACT
-	comment, err := postService.CreateComment(999, req, 1)
+	comment, err := postService.CreateComment(context.Background(), 999, req, 1)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -568,15 +696,15 @@ func TestCreateComment_UserNotFound(t *testing.T) {
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
 	mockPost := &models.Post{ID: 1, Title: "Post", UserID: 1}
-	mockPostRepo.On("FindByID", 1).Return(mockPost, nil)
-	mockUserRepo.On("FindByID", 999).Return(nil, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(mockPost, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	req := &models.CreateCommentRequest{
 		Content: "Great post!",
 	}
 
 	// ACT
-	comment, err := postService.CreateComment(1, req, 999)
+	comment, err := postService.CreateComment(context.Background(), 1, req, 999)
 
 	// ASSERT
 	assert.Error(t, err)
@@ -596,10 +724,10 @@ func TestGetCommentsByPostID_PostNotFound(t *testing.T) {
 	mockUserRepo := new(mocks.MockUserRepository)
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
-	mockPostRepo.On("FindByID", 999).Return(nil, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 999).Return(nil, nil)
 
 	// ACT
-	comments, err := postService.GetCommentsByPostID(999)
+	comments, err := postService.GetCommentsByPostID(context.Background(), 999, pagination.Params{})
 
 	// ASSERT
 	assert.Error(t, err)
@@ -617,15 +745,722 @@ func TestGetCommentsByPostID_Empty(t *testing.T) {
 	postService := services.NewPostService(mockPostRepo, mockUserRepo)
 
 	mockPost := &models.Post{ID: 1, Title: "Post", UserID: 1}
-	mockPostRepo.On("FindByID", 1).Return(mockPost, nil)
-	mockPostRepo.On("FindCommentsByPostID", 1).Return(nil, nil)
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(mockPost, nil)
+	mockPostRepo.On("FindCommentsByPostID", mock.Anything, 1, anyPage).Return(nil, nil)
+
+	// ACT
+	comments, err := postService.GetCommentsByPostID(context.Background(), 1, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, comments.Items)
+	assert.Len(t, comments.Items, 0)
+	mockPostRepo.AssertExpectations(t)
+}
+
+// strPtr devuelve un puntero a un string (para los campos opcionales de PATCH)
+func strPtr(s string) *string {
+	return &s
+}
+
+// editablePost devuelve un post del usuario 1 en su primera versión
+func editablePost() *models.Post {
+	return &models.Post{
+		ID:      1,
+		Title:   "Titulo original",
+		Content: "Linea 1\nLinea 2",
+		UserID:  1,
+		Version: 1,
+	}
+}
+
+// TestUpdatePost_Success prueba la edición completa de un post
+func TestUpdatePost_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(p *models.Post) bool {
+		return p.Title == "Titulo nuevo" && p.Content == "Contenido nuevo"
+	})).Return(nil)
+
+	req := &models.UpdatePostRequest{
+		Title:   strPtr("  Titulo nuevo  "),
+		Content: strPtr("Contenido nuevo"),
+	}
+
+	// ACT
+	post, err := postService.UpdatePost(context.Background(), 1, req, 1)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, "Titulo nuevo", post.Title)
+	assert.Equal(t, "Contenido nuevo", post.Content)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdatePost_Parcial prueba que PATCH solo cambie los campos enviados
+func TestUpdatePost_Parcial(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*models.Post")).Return(nil)
+
+	// ACT
+	post, err := postService.UpdatePost(context.Background(), 1, &models.UpdatePostRequest{Content: strPtr("Solo el contenido")}, 1)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, "Titulo original", post.Title)
+	assert.Equal(t, "Solo el contenido", post.Content)
+}
+
+// TestUpdatePost_NoEsAutor prueba que solo el autor pueda editar (REGLA DE NEGOCIO)
+func TestUpdatePost_NoEsAutor(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+
+	// ACT
+	post, err := postService.UpdatePost(context.Background(), 1, &models.UpdatePostRequest{Title: strPtr("Hackeado")}, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, post)
+	assert.Equal(t, "no tienes permiso para editar este post", err.Error())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestUpdatePost_PostNoExiste prueba editar un post inexistente
+func TestUpdatePost_PostNoExiste(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 99).Return(nil, nil)
+
+	// ACT
+	post, err := postService.UpdatePost(context.Background(), 99, &models.UpdatePostRequest{Title: strPtr("Nuevo")}, 1)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, post)
+	assert.Equal(t, services.ErrPostNotFound, err.Error())
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// TestUpdatePost_TituloCorto prueba que la edición aplique las mismas validaciones que la creación
+func TestUpdatePost_TituloCorto(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+
+	// ACT
+	post, err := postService.UpdatePost(context.Background(), 1, &models.UpdatePostRequest{Title: strPtr("ab")}, 1)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, post)
+	assert.Equal(t, "el título debe tener al menos 3 caracteres", err.Error())
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestUpdatePost_SinCambios prueba que no se cree una revisión si nada cambió
+func TestUpdatePost_SinCambios(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+
+	// ACT
+	post, err := postService.UpdatePost(context.Background(), 1, &models.UpdatePostRequest{Title: strPtr("Titulo original")}, 1)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, 1, post.Version)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+// TestGetPostRevisions_Success prueba obtener el historial de un post
+func TestGetPostRevisions_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+	mockRepo.On("FindRevisions", mock.Anything, 1, anyPage).Return([]*models.PostRevision{{PostID: 1, Version: 1}}, nil)
+
+	// ACT
+	revisions, err := postService.GetPostRevisions(context.Background(), 1, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Len(t, revisions.Items, 1)
+}
+
+// TestGetPostRevisions_SinEdiciones prueba que devuelva lista vacía y no nil
+func TestGetPostRevisions_SinEdiciones(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+	mockRepo.On("FindRevisions", mock.Anything, 1, anyPage).Return(nil, nil)
+
+	// ACT
+	revisions, err := postService.GetPostRevisions(context.Background(), 1, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, revisions.Items)
+	assert.Empty(t, revisions.Items)
+}
+
+// TestGetPostRevisions_PostNoExiste prueba el historial de un post inexistente
+func TestGetPostRevisions_PostNoExiste(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 99).Return(nil, nil)
+
+	// ACT
+	revisions, err := postService.GetPostRevisions(context.Background(), 99, pagination.Params{})
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, revisions)
+	assert.Equal(t, services.ErrPostNotFound, err.Error())
+	mockRepo.AssertNotCalled(t, "FindRevisions", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestDiffPostVersions_ContraVersionActual prueba el diff entre una revisión y el post actual
+func TestDiffPostVersions_ContraVersionActual(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	current := editablePost()
+	current.Version = 2
+	current.Content = "Linea 1\nLinea 2 editada\nLinea 3"
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(current, nil)
+	mockRepo.On("FindRevision", mock.Anything, 1, 1).Return(&models.PostRevision{
+		PostID:  1,
+		Version: 1,
+		Title:   "Titulo original",
+		Content: "Linea 1\nLinea 2",
+	}, nil)
+
+	// ACT: sin from/to compara la versión actual con la anterior
+	diff, err := postService.DiffPostVersions(context.Background(), 1, 0, 0)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, 1, diff.From)
+	assert.Equal(t, 2, diff.To)
+	assert.Equal(t, []models.DiffLine{{Op: services.DiffEqual, Text: "Titulo original"}}, diff.Title)
+	assert.Equal(t, []models.DiffLine{
+		{Op: services.DiffEqual, Text: "Linea 1"},
+		{Op: services.DiffDelete, Text: "Linea 2"},
+		{Op: services.DiffInsert, Text: "Linea 2 editada"},
+		{Op: services.DiffInsert, Text: "Linea 3"},
+	}, diff.Content)
+}
+
+// TestDiffPostVersions_VersionInexistente prueba pedir una versión que no existe
+func TestDiffPostVersions_VersionInexistente(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(editablePost(), nil)
+
+	// ACT: el post nunca fue editado, no hay versión 0 ni 5
+	diff, err := postService.DiffPostVersions(context.Background(), 1, 0, 5)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, diff)
+	assert.Equal(t, services.ErrRevisionNotFound, err.Error())
+}
+
+// TestDiffPostVersions_RevisionFaltante prueba una versión válida que no está en el historial
+func TestDiffPostVersions_RevisionFaltante(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	current := editablePost()
+	current.Version = 3
+	mockRepo.On("FindByID", mock.Anything, 1).Return(current, nil)
+	mockRepo.On("FindRevision", mock.Anything, 1, 1).Return(nil, nil)
+
+	// ACT
+	diff, err := postService.DiffPostVersions(context.Background(), 1, 1, 3)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, diff)
+	assert.Equal(t, services.ErrRevisionNotFound, err.Error())
+}
+
+// TestGetAllPosts_PrimeraPagina prueba que se pida una fila extra para detectar si hay otra página
+func TestGetAllPosts_PrimeraPagina(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockPostRepo, new(mocks.MockUserRepository))
+
+	created := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	mockPosts := []*models.Post{
+		{ID: 3, Title: "Post 3", CreatedAt: created.Add(2 * time.Minute)},
+		{ID: 2, Title: "Post 2", CreatedAt: created.Add(time.Minute)},
+		{ID: 1, Title: "Post 1", CreatedAt: created},
+	}
+	mockPostRepo.On("FindAll", mock.Anything, pagination.Params{Limit: 2}).Return(mockPosts, nil)
+
+	// ACT
+	page, err := postService.GetAllPosts(context.Background(), pagination.Params{Limit: 2})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.NotEmpty(t, page.NextCursor)
+
+	cursor, err := pagination.Decode(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cursor.ID)
+	assert.True(t, created.Add(time.Minute).Equal(cursor.CreatedAt))
+}
+
+// TestGetAllPosts_LimitePorDefecto prueba que sin limit se use el tamaño de página por defecto
+func TestGetAllPosts_LimitePorDefecto(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockPostRepo, new(mocks.MockUserRepository))
+
+	mockPostRepo.On("FindAll", mock.Anything, pagination.Params{Limit: pagination.DefaultLimit}).Return(nil, nil)
 
 	// ACT
-	comments, err := postService.GetCommentsByPostID(1)
+	_, err := postService.GetAllPosts(context.Background(), pagination.Params{})
 
 	// ASSERT
 	assert.NoError(t, err)
-	assert.NotNil(t, comments)
-	assert.Len(t, comments, 0)
 	mockPostRepo.AssertExpectations(t)
 }
+
+// TestGetCommentsByPostID_SiguientePagina prueba que el cursor recibido llegue al repositorio
+func TestGetCommentsByPostID_SiguientePagina(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockPostRepo, new(mocks.MockUserRepository))
+
+	after := &pagination.Cursor{CreatedAt: time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC), ID: 10}
+	params := pagination.Params{Limit: 5, After: after}
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockPostRepo.On("FindCommentsByPostID", mock.Anything, 1, params).Return([]*models.Comment{{ID: 11, PostID: 1}}, nil)
+
+	// ACT
+	page, err := postService.GetCommentsByPostID(context.Background(), 1, params)
+
+	// ASSERT: última página, no hay cursor siguiente
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Empty(t, page.NextCursor)
+	mockPostRepo.AssertExpectations(t)
+}
+
+// intPtr devuelve un puntero a un int (para parent_id)
+func intPtr(n int) *int {
+	return &n
+}
+
+// TestCreateComment_Respuesta prueba responder a un comentario
+func TestCreateComment_Respuesta(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockPostRepo, mockUserRepo)
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Username: "replier"}, nil)
+	mockPostRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, Depth: 0}, nil)
+	mockPostRepo.On("CreateComment", mock.Anything, mock.MatchedBy(func(c *models.Comment) bool {
+		return c.ParentID != nil && *c.ParentID == 10
+	})).Return(nil)
+
+	// ACT
+	comment, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Respuesta", ParentID: intPtr(10)}, 2)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, 10, *comment.ParentID)
+	mockPostRepo.AssertExpectations(t)
+}
+
+// TestCreateComment_PadreDeOtroPost prueba que no se pueda responder a un comentario de otro post
+func TestCreateComment_PadreDeOtroPost(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockPostRepo, mockUserRepo)
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2}, nil)
+	mockPostRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 7}, nil)
+
+	// ACT
+	comment, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Respuesta", ParentID: intPtr(10)}, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, comment)
+	assert.Equal(t, services.ErrParentNotFound, err.Error())
+	mockPostRepo.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+}
+
+// TestCreateComment_PadreEliminado prueba que no se pueda responder a un comentario eliminado
+func TestCreateComment_PadreEliminado(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockPostRepo, mockUserRepo)
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2}, nil)
+	mockPostRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, Deleted: true}, nil)
+
+	// ACT
+	comment, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Respuesta", ParentID: intPtr(10)}, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, comment)
+	assert.Equal(t, "no se puede responder a un comentario eliminado", err.Error())
+}
+
+// TestCreateComment_ProfundidadMaxima prueba el límite de anidamiento configurable (REGLA DE NEGOCIO)
+func TestCreateComment_ProfundidadMaxima(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockPostRepo, mockUserRepo).WithMaxCommentDepth(2)
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2}, nil)
+	mockPostRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, Depth: 2}, nil)
+
+	// ACT
+	comment, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Muy adentro", ParentID: intPtr(10)}, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, comment)
+	assert.Equal(t, "se alcanzó la profundidad máxima de respuestas (2)", err.Error())
+	mockPostRepo.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+}
+
+// TestGetCommentsByPostID_Eliminado prueba que un comentario eliminado se muestre como "[deleted]"
+func TestGetCommentsByPostID_Eliminado(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockPostRepo, new(mocks.MockUserRepository))
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockPostRepo.On("FindCommentsByPostID", mock.Anything, 1, anyPage).Return([]*models.Comment{
+		{ID: 1, PostID: 1, UserID: 3, Username: "autor", Deleted: true},
+		{ID: 2, PostID: 1, ParentID: intPtr(1), UserID: 4, Username: "otro", Content: "Respuesta", Depth: 1},
+	}, nil)
+
+	// ACT
+	page, err := postService.GetCommentsByPostID(context.Background(), 1, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, models.DeletedCommentContent, page.Items[0].Content)
+	assert.Empty(t, page.Items[0].Username)
+	assert.Equal(t, "Respuesta", page.Items[1].Content)
+}
+
+// TestGetCommentTree_Success prueba armar el árbol de respuestas
+func TestGetCommentTree_Success(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockPostRepo, new(mocks.MockUserRepository))
+
+	roots := []*models.Comment{
+		{ID: 1, PostID: 1, Content: "Raíz 1"},
+		{ID: 5, PostID: 1, Content: "Raíz 2"},
+	}
+	replies := []*models.Comment{
+		{ID: 2, PostID: 1, ParentID: intPtr(1), Depth: 1, Content: "1.1"},
+		{ID: 3, PostID: 1, ParentID: intPtr(2), Depth: 2, Content: "1.1.1"},
+		{ID: 4, PostID: 1, ParentID: intPtr(1), Depth: 1, Content: "1.2"},
+	}
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1}, nil)
+	mockPostRepo.On("FindRootComments", mock.Anything, 1, anyPage).Return(roots, nil)
+	mockPostRepo.On("FindReplies", mock.Anything, 1, []int{1, 5}).Return(replies, nil)
+
+	// ACT
+	page, err := postService.GetCommentTree(context.Background(), 1, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Len(t, page.Items[0].Replies, 2)
+	assert.Equal(t, "1.1.1", page.Items[0].Replies[0].Replies[0].Content)
+	assert.Empty(t, page.Items[1].Replies)
+	mockPostRepo.AssertExpectations(t)
+}
+
+// TestGetCommentTree_PostNoExiste prueba el árbol de un post inexistente
+func TestGetCommentTree_PostNoExiste(t *testing.T) {
+	// ARRANGE
+	mockPostRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockPostRepo, new(mocks.MockUserRepository))
+
+	mockPostRepo.On("FindByID", mock.Anything, 99).Return(nil, nil)
+
+	// ACT
+	page, err := postService.GetCommentTree(context.Background(), 99, pagination.Params{})
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, page)
+	assert.Equal(t, services.ErrPostNotFound, err.Error())
+	mockPostRepo.AssertNotCalled(t, "FindRootComments", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestSetPostLocked_Moderador prueba que un moderador cierre un post ajeno
+func TestSetPostLocked_Moderador(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Role: models.RoleModerator}, nil)
+	mockRepo.On("SetLocked", mock.Anything, 1, true).Return(nil)
+
+	// ACT
+	post, err := postService.SetPostLocked(context.Background(), 1, true, 2)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.True(t, post.Locked)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestSetPostLocked_AutorSinPermiso prueba que el autor no pueda cerrar su propio post
+func TestSetPostLocked_AutorSinPermiso(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleUser}, nil)
+
+	// ACT
+	post, err := postService.SetPostLocked(context.Background(), 1, true, 1)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, post)
+	assert.Equal(t, "no tienes permiso para cerrar este post", err.Error())
+	mockRepo.AssertNotCalled(t, "SetLocked", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestModeracion_ExigeMFA prueba que un rol que exige 2FA no modere sin activarlo
+func TestModeracion_ExigeMFA(t *testing.T) {
+	tests := []struct {
+		name     string
+		moderate func(postService *services.PostService) error
+	}{
+		{"borrar post ajeno", func(postService *services.PostService) error {
+			return postService.DeletePost(context.Background(), 1, 3)
+		}},
+		{"borrar comentario ajeno", func(postService *services.PostService) error {
+			return postService.DeleteComment(context.Background(), 1, 10, 3)
+		}},
+		{"cerrar post", func(postService *services.PostService) error {
+			_, err := postService.SetPostLocked(context.Background(), 1, true, 3)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			mockRepo := new(mocks.MockPostRepository)
+			mockUserRepo := new(mocks.MockUserRepository)
+			postService := services.NewPostService(mockRepo, mockUserRepo).WithMFARequiredRoles(models.RoleModerator)
+
+			mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+			mockRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, UserID: 1}, nil).Maybe()
+			mockUserRepo.On("FindByID", mock.Anything, 3).Return(&models.User{ID: 3, Role: models.RoleModerator}, nil)
+
+			// ACT
+			err := tt.moderate(postService)
+
+			// ASSERT
+			assert.ErrorIs(t, err, services.ErrMFASetupRequired)
+			mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "SetLocked", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestModeracion_ConMFA prueba que con 2FA activado el moderador pueda moderar
+func TestModeracion_ConMFA(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo).WithMFARequiredRoles(models.RoleModerator)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 3).Return(&models.User{ID: 3, Role: models.RoleModerator, MFAEnabled: true}, nil)
+	mockRepo.On("Delete", mock.Anything, 1).Return(nil).Once()
+
+	// ACT
+	err := postService.DeletePost(context.Background(), 1, 3)
+
+	// ASSERT
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestDeletePost_PropioSinMFA prueba que borrar lo propio no exija 2FA aunque el rol lo requiera
+func TestDeletePost_PropioSinMFA(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo).WithMFARequiredRoles(models.RoleModerator)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 3}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 3).Return(&models.User{ID: 3, Role: models.RoleModerator}, nil)
+	mockRepo.On("Delete", mock.Anything, 1).Return(nil).Once()
+
+	// ACT
+	err := postService.DeletePost(context.Background(), 1, 3)
+
+	// ASSERT
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateComment_PostCerrado prueba que no se comente un post cerrado
+func TestCreateComment_PostCerrado(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1, Locked: true}, nil)
+
+	// ACT
+	comment, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Hola"}, 2)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, comment)
+	assert.Equal(t, "el post está cerrado a nuevos comentarios", err.Error())
+	mockRepo.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+}
+
+// TestDeleteComment_BorradoConcurrente prueba que si otro request borró el comentario se informe 404
+func TestDeleteComment_BorradoConcurrente(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo)
+
+	mockRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleUser}, nil)
+	mockRepo.On("FindCommentByID", mock.Anything, 10).Return(&models.Comment{ID: 10, PostID: 1, UserID: 1}, nil)
+	mockRepo.On("DeleteComment", mock.Anything, 1, 10).Return(repository.ErrNotFound)
+
+	// ACT
+	err := postService.DeleteComment(context.Background(), 1, 10, 1)
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrNotFound)
+	assert.Equal(t, services.ErrCommentNotFound, err.Error())
+}
+
+// TestGetAllPosts_ErrorDeBaseDeDatos prueba que un error de infraestructura no se disfrace de error de dominio
+func TestGetAllPosts_ErrorDeBaseDeDatos(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockPostRepository)
+	postService := services.NewPostService(mockRepo, new(mocks.MockUserRepository))
+
+	mockRepo.On("FindAll", mock.Anything, anyPage).Return(nil, errors.New("connection refused"))
+
+	// ACT
+	page, err := postService.GetAllPosts(context.Background(), pagination.Params{})
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, page)
+
+	var domainErr *services.Error
+	assert.False(t, errors.As(err, &domainErr))
+}
+
+// TestCreatePost_EmailSinVerificar: con la exigencia activa, no se guarda el post
+func TestCreatePost_EmailSinVerificar(t *testing.T) {
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo).WithRequireVerifiedEmail(true)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "testuser"}, nil)
+
+	post, err := postService.CreatePost(context.Background(), &models.CreatePostRequest{Title: "Test Post", Content: "Contenido"}, 1)
+
+	assert.Nil(t, post)
+	assert.ErrorIs(t, err, services.ErrEmailNotVerified)
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+// TestCreatePost_EmailVerificado: con la exigencia activa, un usuario verificado publica
+func TestCreatePost_EmailVerificado(t *testing.T) {
+	mockRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockRepo, mockUserRepo).WithRequireVerifiedEmail(true)
+
+	verifiedAt := time.Now()
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Username: "testuser", EmailVerifiedAt: &verifiedAt}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Post")).Return(nil)
+
+	post, err := postService.CreatePost(context.Background(), &models.CreatePostRequest{Title: "Test Post", Content: "Contenido"}, 1)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, post)
+}
+
+// TestCreateComment_EmailSinVerificar: con la exigencia activa, no se guarda el comentario
+func TestCreateComment_EmailSinVerificar(t *testing.T) {
+	mockPostRepo := new(mocks.MockPostRepository)
+	mockUserRepo := new(mocks.MockUserRepository)
+	postService := services.NewPostService(mockPostRepo, mockUserRepo).WithRequireVerifiedEmail(true)
+
+	mockPostRepo.On("FindByID", mock.Anything, 1).Return(&models.Post{ID: 1, Title: "Post", UserID: 1}, nil)
+	mockUserRepo.On("FindByID", mock.Anything, 2).Return(&models.User{ID: 2, Username: "commenter"}, nil)
+
+	comment, err := postService.CreateComment(context.Background(), 1, &models.CreateCommentRequest{Content: "Great post!"}, 2)
+
+	assert.Nil(t, comment)
+	assert.ErrorIs(t, err, services.ErrEmailNotVerified)
+	mockPostRepo.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/pagination"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// TestSearch_Success prueba una búsqueda con resultados de posts y comentarios
+func TestSearch_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSearchRepository)
+	searchService := services.NewSearchService(mockRepo)
+
+	results := []*models.SearchResult{
+		{Type: models.SearchTypePost, ID: 1, PostID: 1, Snippet: "Aprendiendo <mark>Go</mark>", Rank: 0.9},
+		{Type: models.SearchTypeComment, ID: 7, PostID: 1, Snippet: "Me gusta <mark>Go</mark>", Rank: 0.4},
+	}
+	mockRepo.On("Search", mock.Anything, models.SearchQuery{Text: "go"}, pagination.Params{Limit: pagination.DefaultLimit}).Return(results, nil)
+
+	// ACT
+	page, err := searchService.Search(context.Background(), models.SearchQuery{Text: "  go  "}, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, "Aprendiendo <mark>Go</mark>", page.Items[0].Snippet)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestSearch_EscapaHTML prueba que el contenido del usuario no llegue como HTML (SEGURIDAD)
+func TestSearch_EscapaHTML(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSearchRepository)
+	searchService := services.NewSearchService(mockRepo)
+
+	results := []*models.SearchResult{
+		{Type: models.SearchTypePost, ID: 1, Snippet: `<script>alert(1)</script> <mark>hola</mark>`},
+	}
+	mockRepo.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(results, nil)
+
+	// ACT
+	page, err := searchService.Search(context.Background(), models.SearchQuery{Text: "hola"}, pagination.Params{})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, "&lt;script&gt;alert(1)&lt;/script&gt; <mark>hola</mark>", page.Items[0].Snippet)
+}
+
+// TestSearch_SiguientePagina prueba que el cursor incluya la relevancia y el tipo de resultado
+func TestSearch_SiguientePagina(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSearchRepository)
+	searchService := services.NewSearchService(mockRepo)
+
+	created := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	results := []*models.SearchResult{
+		{Type: models.SearchTypePost, ID: 3, Rank: 0.8, CreatedAt: created},
+		{Type: models.SearchTypeComment, ID: 9, Rank: 0.5, CreatedAt: created},
+	}
+	mockRepo.On("Search", mock.Anything, mock.Anything, pagination.Params{Limit: 1}).Return(results, nil)
+
+	// ACT
+	page, err := searchService.Search(context.Background(), models.SearchQuery{Text: "go"}, pagination.Params{Limit: 1})
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	cursor, err := pagination.Decode(page.NextCursor)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cursor.ID)
+	assert.Equal(t, float32(0.8), cursor.Rank)
+	assert.Equal(t, models.SearchTypePost, cursor.Kind)
+}
+
+// TestSearch_Validaciones prueba los criterios inválidos
+func TestSearch_Validaciones(t *testing.T) {
+	from := time.Date(2025, 5, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		query    models.SearchQuery
+		expected string
+	}{
+		{"texto vacío", models.SearchQuery{Text: "   "}, "el texto a buscar es requerido"},
+		{"texto largo", models.SearchQuery{Text: strings.Repeat("a", services.MaxSearchLength+1)}, "el texto a buscar es demasiado largo"},
+		{"tipo inválido", models.SearchQuery{Text: "go", Type: "user"}, "tipo de búsqueda inválido: usar post o comment"},
+		{"rango invertido", models.SearchQuery{Text: "go", From: &from, To: &to}, "rango de fechas inválido"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// ARRANGE
+			mockRepo := new(mocks.MockSearchRepository)
+			searchService := services.NewSearchService(mockRepo)
+
+			// ACT
+			page, err := searchService.Search(context.Background(), tt.query, pagination.Params{})
+
+			// ASSERT
+			assert.Error(t, err)
+			assert.Nil(t, page)
+			assert.Equal(t, tt.expected, err.Error())
+			mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestSearch_RepositoryError prueba un error de la base de datos
+func TestSearch_RepositoryError(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSearchRepository)
+	searchService := services.NewSearchService(mockRepo)
+
+	mockRepo.On("Search", mock.Anything, mock.Anything, mock.Anything).Return(nil, assert.AnError)
+
+	// ACT
+	page, err := searchService.Search(context.Background(), models.SearchQuery{Text: "go"}, pagination.Params{})
+
+	// ASSERT
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Nil(t, page)
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	testSessionID    = "sesion-1"
+	testRefreshToken = "refresh-token-de-prueba"
+	testRefreshTTL   = 24 * time.Hour
+)
+
+var testClient = models.ClientInfo{UserAgent: "Firefox", IPAddress: "10.0.0.1"}
+
+// newTestTokens crea un firmador HS256 con un secreto fijo para tests
+func newTestTokens(t *testing.T) *auth.TokenManager {
+	t.Helper()
+	tokens, err := auth.NewHS256TokenManager([]byte("test-secret-de-al-menos-32-bytes!!"), "test", time.Minute)
+	if err != nil {
+		t.Fatalf("no se pudo crear el TokenManager: %v", err)
+	}
+	return tokens
+}
+
+// activeSession devuelve la fila vigente del refresh token de prueba
+func activeSession() *models.Session {
+	return &models.Session{
+		TokenID:   10,
+		ID:        testSessionID,
+		UserID:    1,
+		StartedAt: time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+// TestStart_Success prueba que iniciar sesión emita tokens y guarde solo el hash
+func TestStart_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	tokens := newTestTokens(t)
+	sessionService := services.NewSessionService(mockRepo, tokens, testRefreshTTL)
+
+	var storedHash string
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.Session"), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+		Return(nil)
+
+	// ACT
+	pair, err := sessionService.Start(context.Background(), 1, testClient)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, pair)
+	assert.NotEmpty(t, pair.SessionID)
+	assert.NotEmpty(t, pair.RefreshToken)
+	assert.Equal(t, "Bearer", pair.TokenType)
+
+	// En la base se guarda el hash, nunca el refresh token
+	assert.Equal(t, auth.HashToken(pair.RefreshToken), storedHash)
+
+	// El token de acceso lleva el usuario y la sesión
+	principal, err := tokens.Authenticate(context.Background(), pair.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, principal.UserID)
+	assert.Equal(t, pair.SessionID, principal.SessionID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestRefresh_Success prueba la rotación del refresh token
+func TestRefresh_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	current := activeSession()
+	mockRepo.On("FindByTokenHash", mock.Anything, auth.HashToken(testRefreshToken)).Return(current, nil)
+	mockRepo.On("Rotate", mock.Anything, current, mock.MatchedBy(func(next *models.Session) bool {
+		return next.ID == testSessionID && next.UserID == 1 && next.StartedAt.Equal(current.StartedAt)
+	}), mock.AnythingOfType("string")).Return(nil)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), testRefreshToken, testClient)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, pair)
+	assert.Equal(t, testSessionID, pair.SessionID)
+	assert.NotEqual(t, testRefreshToken, pair.RefreshToken)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestRefresh_TokenVacio prueba que falle sin refresh token
+func TestRefresh_TokenVacio(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), "", testClient)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, pair)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err.Error())
+	mockRepo.AssertNotCalled(t, "FindByTokenHash", mock.Anything, mock.Anything)
+}
+
+// TestRefresh_TokenDesconocido prueba que falle con un token que no existe
+func TestRefresh_TokenDesconocido(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	mockRepo.On("FindByTokenHash", mock.Anything, auth.HashToken(testRefreshToken)).Return(nil, nil)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), testRefreshToken, testClient)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, pair)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err.Error())
+}
+
+// TestRefresh_SesionRevocada prueba que un token de una sesión cerrada no sirva
+func TestRefresh_SesionRevocada(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	revokedAt := time.Now()
+	current := activeSession()
+	current.RevokedAt = &revokedAt
+	mockRepo.On("FindByTokenHash", mock.Anything, auth.HashToken(testRefreshToken)).Return(current, nil)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), testRefreshToken, testClient)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, pair)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err.Error())
+	mockRepo.AssertNotCalled(t, "Rotate", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestRefresh_TokenExpirado prueba que un refresh token vencido no sirva
+func TestRefresh_TokenExpirado(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	current := activeSession()
+	current.ExpiresAt = time.Now().Add(-time.Minute)
+	mockRepo.On("FindByTokenHash", mock.Anything, auth.HashToken(testRefreshToken)).Return(current, nil)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), testRefreshToken, testClient)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, pair)
+	assert.Equal(t, services.ErrInvalidRefreshToken, err.Error())
+}
+
+// TestRefresh_ReutilizacionRevocaSesion prueba la detección de reutilización (REGLA DE SEGURIDAD)
+func TestRefresh_ReutilizacionRevocaSesion(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	rotatedAt := time.Now().Add(-time.Minute)
+	current := activeSession()
+	current.RotatedAt = &rotatedAt // Ya fue canjeado antes
+	mockRepo.On("FindByTokenHash", mock.Anything, auth.HashToken(testRefreshToken)).Return(current, nil)
+	mockRepo.On("Revoke", mock.Anything, 1, testSessionID).Return(true, nil)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), testRefreshToken, testClient)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, pair)
+	assert.Equal(t, services.ErrRefreshTokenReused, err.Error())
+	mockRepo.AssertExpectations(t)
+}
+
+// TestRefresh_RotacionConcurrente prueba que dos canjes simultáneos revoquen la sesión
+func TestRefresh_RotacionConcurrente(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	current := activeSession()
+	mockRepo.On("FindByTokenHash", mock.Anything, auth.HashToken(testRefreshToken)).Return(current, nil)
+	mockRepo.On("Rotate", mock.Anything, current, mock.Anything, mock.Anything).Return(repository.ErrSessionAlreadyRotated)
+	mockRepo.On("Revoke", mock.Anything, 1, testSessionID).Return(true, nil)
+
+	// ACT
+	pair, err := sessionService.Refresh(context.Background(), testRefreshToken, testClient)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, pair)
+	assert.Equal(t, services.ErrRefreshTokenReused, err.Error())
+	mockRepo.AssertExpectations(t)
+}
+
+// TestLogout_Success prueba cerrar la sesión actual
+func TestLogout_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	mockRepo.On("Revoke", mock.Anything, 1, testSessionID).Return(true, nil)
+
+	// ACT
+	err := sessionService.Logout(context.Background(), 1, testSessionID)
+
+	// ASSERT
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestLogout_SesionNoExiste prueba que falle si la sesión no es del usuario o no existe
+func TestLogout_SesionNoExiste(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	mockRepo.On("Revoke", mock.Anything, 1, "otra").Return(false, nil)
+
+	// ACT
+	err := sessionService.Logout(context.Background(), 1, "otra")
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Equal(t, services.ErrSessionNotFound, err.Error())
+}
+
+// TestLogoutAll_Success prueba cerrar todas las sesiones
+func TestLogoutAll_Success(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	mockRepo.On("RevokeAllForUser", mock.Anything, 1).Return(nil)
+
+	// ACT
+	err := sessionService.LogoutAll(context.Background(), 1)
+
+	// ASSERT
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestListSessions_MarcaSesionActual prueba que se marque el dispositivo actual
+func TestListSessions_MarcaSesionActual(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	mockRepo.On("FindActiveByUserID", mock.Anything, 1).Return([]*models.Session{
+		{ID: testSessionID, UserID: 1},
+		{ID: "otra", UserID: 1},
+	}, nil)
+
+	// ACT
+	sessions, err := sessionService.ListSessions(context.Background(), 1, testSessionID)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	assert.True(t, sessions[0].Current)
+	assert.False(t, sessions[1].Current)
+}
+
+// TestListSessions_SinSesiones prueba que devuelva lista vacía y no nil
+func TestListSessions_SinSesiones(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	sessionService := services.NewSessionService(mockRepo, newTestTokens(t), testRefreshTTL)
+
+	mockRepo.On("FindActiveByUserID", mock.Anything, 1).Return(nil, nil)
+
+	// ACT
+	sessions, err := sessionService.ListSessions(context.Background(), 1, testSessionID)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.NotNil(t, sessions)
+	assert.Empty(t, sessions)
+}
+
+// TestAuthenticate_SesionActiva prueba que un token de una sesión abierta sea válido
+func TestAuthenticate_SesionActiva(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	tokens := newTestTokens(t)
+	sessionService := services.NewSessionService(mockRepo, tokens, testRefreshTTL)
+
+	accessToken, _, err := tokens.Issue(1, testSessionID)
+	assert.NoError(t, err)
+	mockRepo.On("IsActive", mock.Anything, testSessionID).Return(true, nil)
+
+	// ACT
+	principal, err := sessionService.Authenticate(context.Background(), accessToken)
+
+	// ASSERT
+	assert.NoError(t, err)
+	assert.Equal(t, 1, principal.UserID)
+	assert.Equal(t, testSessionID, principal.SessionID)
+}
+
+// TestAuthenticate_SesionCerrada prueba que un logout invalide tokens de acceso vigentes
+func TestAuthenticate_SesionCerrada(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	tokens := newTestTokens(t)
+	sessionService := services.NewSessionService(mockRepo, tokens, testRefreshTTL)
+
+	accessToken, _, err := tokens.Issue(1, testSessionID)
+	assert.NoError(t, err)
+	mockRepo.On("IsActive", mock.Anything, testSessionID).Return(false, nil)
+
+	// ACT
+	principal, err := sessionService.Authenticate(context.Background(), accessToken)
+
+	// ASSERT
+	assert.Error(t, err)
+	assert.Nil(t, principal)
+	assert.Equal(t, services.ErrSessionRevoked, err.Error())
+}
+
+// TestAuthenticate_TokenSinSesion prueba que se rechacen tokens sin sesión asociada
+func TestAuthenticate_TokenSinSesion(t *testing.T) {
+	// ARRANGE
+	mockRepo := new(mocks.MockSessionRepository)
+	tokens := newTestTokens(t)
+	sessionService := services.NewSessionService(mockRepo, tokens, testRefreshTTL)
+
+	accessToken, _, err := tokens.Issue(1, "")
+	assert.NoError(t, err)
+
+	// ACT
+	principal, err := sessionService.Authenticate(context.Background(), accessToken)
+
+	// ASSERT
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+	assert.Nil(t, principal)
+	mockRepo.AssertNotCalled(t, "IsActive", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ingsw3-tp08/internal/auth"
+	"ingsw3-tp08/internal/models"
+	"ingsw3-tp08/internal/repository"
+	"ingsw3-tp08/internal/services"
+	"ingsw3-tp08/tests/mocks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var mfaNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+const mfaSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// newTestSecretBox crea el cifrador de secretos TOTP con una clave fija
+func newTestSecretBox(t *testing.T) *auth.SecretBox {
+	t.Helper()
+	box, err := auth.NewSecretBox([]byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	return box
+}
+
+// newMFAAuthService arma un AuthService con 2FA y bloqueo de logins, con el reloj en mfaNow
+func newMFAAuthService(userRepo *mocks.MockUserRepository, tokenRepo *mocks.MockUserTokenRepository, mfaRepo *mocks.MockTwoFactorRepository, throttleRepo *mocks.MockLoginThrottleRepository, box *auth.SecretBox) *services.AuthService {
+	clock := func() time.Time { return mfaNow }
+	mfa := services.NewTwoFactor(mfaRepo, tokenRepo, box, services.TwoFactorConfig{
+		Issuer:       "ingsw3-tp08",
+		ChallengeTTL: 5 * time.Minute,
+	}).WithClock(clock)
+	throttle := services.NewLoginThrottle(throttleRepo, services.DefaultLoginThrottlePolicy).WithClock(clock)
+	return services.NewAuthService(userRepo, testHasher).WithLoginThrottle(throttle).WithTwoFactor(mfa)
+}
+
+// confirmedTOTP devuelve un TOTP activo del usuario 1 con el secreto de prueba cifrado
+func confirmedTOTP(t *testing.T, box *auth.SecretBox, lastUsedStep int64) *models.TOTPCredential {
+	t.Helper()
+	sealed, err := box.Seal(mfaSecret)
+	require.NoError(t, err)
+	confirmedAt := mfaNow.Add(-24 * time.Hour)
+	return &models.TOTPCredential{UserID: 1, Secret: sealed, ConfirmedAt: &confirmedAt, LastUsedStep: lastUsedStep}
+}
+
+// currentCode es el código que mostraría la app en mfaNow
+func currentCode(t *testing.T) string {
+	code, err := auth.TOTPCode(mfaSecret, auth.TOTPStep(mfaNow))
+	require.NoError(t, err)
+	return code
+}
+
+// expectValidChallenge deja vigente el desafío "desafio" del usuario 1
+func expectValidChallenge(userRepo *mocks.MockUserRepository, tokenRepo *mocks.MockUserTokenRepository, throttleRepo *mocks.MockLoginThrottleRepository) {
+	challenge := &models.UserToken{UserID: 1, Purpose: models.TokenPurposeMFAChallenge}
+	tokenRepo.On("Find", mock.Anything, models.TokenPurposeMFAChallenge, auth.HashToken("desafio"), mfaNow).Return(challenge, nil)
+	userRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Email: testEmail, MFAEnabled: true}, nil)
+	throttleRepo.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: 1, LastFailureAt: mfaNow}, nil).Maybe()
+}
+
+// TestBeginTOTPEnrollment_GuardaElSecretoCifrado prueba que la base nunca vea el secreto en claro
+func TestBeginTOTPEnrollment_GuardaElSecretoCifrado(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(mockUserRepo, new(mocks.MockUserTokenRepository), mockMFARepo, new(mocks.MockLoginThrottleRepository), box)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Email: testEmail}, nil)
+	var sealed string
+	mockMFARepo.On("SavePendingTOTP", mock.Anything, 1, mock.Anything).Run(func(args mock.Arguments) {
+		sealed = args.String(2)
+	}).Return(nil)
+
+	// ACT
+	enrollment, err := authService.BeginTOTPEnrollment(context.Background(), 1)
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.NotEqual(t, enrollment.Secret, sealed)
+	opened, err := box.Open(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, enrollment.Secret, opened)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/ingsw3-tp08:")
+	assert.Contains(t, enrollment.URI, "secret="+enrollment.Secret)
+}
+
+// TestBeginTOTPEnrollment_YaActivado prueba que no se pise un segundo factor en uso
+func TestBeginTOTPEnrollment_YaActivado(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	authService := newMFAAuthService(mockUserRepo, new(mocks.MockUserTokenRepository), mockMFARepo, new(mocks.MockLoginThrottleRepository), newTestSecretBox(t))
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Email: testEmail, MFAEnabled: true}, nil)
+
+	// ACT
+	enrollment, err := authService.BeginTOTPEnrollment(context.Background(), 1)
+
+	// ASSERT
+	assert.Nil(t, enrollment)
+	assert.ErrorIs(t, err, services.ErrConflict)
+	mockMFARepo.AssertNotCalled(t, "SavePendingTOTP", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestConfirmTOTPEnrollment_Success prueba que se activen el TOTP y diez códigos de recuperación
+func TestConfirmTOTPEnrollment_Success(t *testing.T) {
+	// ARRANGE
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), mockMFARepo, new(mocks.MockLoginThrottleRepository), box)
+
+	pending := confirmedTOTP(t, box, 0)
+	pending.ConfirmedAt = nil
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(pending, nil)
+	var hashes []string
+	mockMFARepo.On("ConfirmTOTP", mock.Anything, 1, auth.TOTPStep(mfaNow), mock.Anything).Run(func(args mock.Arguments) {
+		hashes = args.Get(3).([]string)
+	}).Return(nil)
+
+	// ACT
+	codes, err := authService.ConfirmTOTPEnrollment(context.Background(), 1, &models.MFACodeRequest{Code: currentCode(t)})
+
+	// ASSERT
+	require.NoError(t, err)
+	require.Len(t, codes.Codes, 10)
+	require.Len(t, hashes, 10)
+	assert.Equal(t, auth.HashToken(auth.NormalizeRecoveryCode(codes.Codes[0])), hashes[0])
+}
+
+// TestConfirmTOTPEnrollment_CodigoIncorrecto prueba que un código equivocado no active nada
+func TestConfirmTOTPEnrollment_CodigoIncorrecto(t *testing.T) {
+	// ARRANGE
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), mockMFARepo, new(mocks.MockLoginThrottleRepository), box)
+
+	pending := confirmedTOTP(t, box, 0)
+	pending.ConfirmedAt = nil
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(pending, nil)
+
+	// ACT
+	codes, err := authService.ConfirmTOTPEnrollment(context.Background(), 1, &models.MFACodeRequest{Code: "000000"})
+
+	// ASSERT
+	assert.Nil(t, codes)
+	assert.ErrorIs(t, err, services.ErrValidation)
+	assert.Equal(t, services.ErrInvalidMFACode, err.Error())
+	mockMFARepo.AssertNotCalled(t, "ConfirmTOTP", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestConfirmTOTPEnrollment_ConfirmadoEnParalelo prueba que una confirmación concurrente sea un conflicto
+func TestConfirmTOTPEnrollment_ConfirmadoEnParalelo(t *testing.T) {
+	// ARRANGE
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), mockMFARepo, new(mocks.MockLoginThrottleRepository), box)
+
+	pending := confirmedTOTP(t, box, 0)
+	pending.ConfirmedAt = nil
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(pending, nil)
+	mockMFARepo.On("ConfirmTOTP", mock.Anything, 1, mock.Anything, mock.Anything).Return(repository.ErrTOTPNotPending)
+
+	// ACT
+	codes, err := authService.ConfirmTOTPEnrollment(context.Background(), 1, &models.MFACodeRequest{Code: currentCode(t)})
+
+	// ASSERT
+	assert.Nil(t, codes)
+	assert.ErrorIs(t, err, services.ErrConflict)
+}
+
+// TestLogin_ConMFADevuelveDesafio prueba que la contraseña sola no abra sesión
+// ni reinicie los intentos fallidos
+func TestLogin_ConMFADevuelveDesafio(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	authService := newMFAAuthService(mockUserRepo, mockTokenRepo, new(mocks.MockTwoFactorRepository), mockThrottleRepo, newTestSecretBox(t))
+
+	mockThrottleRepo.On("Reserve", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, &models.LoginThrottle{Failures: 1, LastFailureAt: mfaNow}, nil)
+	mockThrottleRepo.On("Release", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockUserRepo.On("FindByEmail", mock.Anything, testEmail).
+		Return(&models.User{ID: 1, Email: testEmail, Password: hashForTest(t, testPassword), MFAEnabled: true}, nil)
+	mockTokenRepo.On("DeleteForUser", mock.Anything, 1, models.TokenPurposeMFAChallenge).Return(nil)
+	mockTokenRepo.On("Create", mock.Anything, mock.MatchedBy(func(token *models.UserToken) bool {
+		return token.Purpose == models.TokenPurposeMFAChallenge && token.ExpiresAt.Equal(mfaNow.Add(5*time.Minute))
+	}), mock.Anything).Return(nil)
+
+	// ACT
+	user, err := loginAs(authService, testPassword)
+
+	// ASSERT
+	assert.Nil(t, user)
+	var mfaErr *services.MFARequired
+	require.True(t, errors.As(err, &mfaErr))
+	assert.True(t, mfaErr.Challenge.MFARequired)
+	assert.NotEmpty(t, mfaErr.Challenge.Token)
+	mockThrottleRepo.AssertNotCalled(t, "Reset", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestVerifyMFA_Success prueba el segundo paso con el código de la app
+func TestVerifyMFA_Success(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(mockUserRepo, mockTokenRepo, mockMFARepo, mockThrottleRepo, box)
+
+	expectValidChallenge(mockUserRepo, mockTokenRepo, mockThrottleRepo)
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(confirmedTOTP(t, box, 0), nil)
+	mockMFARepo.On("UseTOTPStep", mock.Anything, 1, auth.TOTPStep(mfaNow)).Return(true, nil).Once()
+	mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposeMFAChallenge, auth.HashToken("desafio"), mfaNow).
+		Return(&models.UserToken{UserID: 1}, nil).Once()
+	mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil).Once()
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil).Once()
+
+	// ACT
+	user, err := authService.VerifyMFA(context.Background(), &models.MFALoginRequest{Token: "desafio", Code: currentCode(t)}, models.ClientInfo{IPAddress: testIP})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	mockTokenRepo.AssertExpectations(t)
+	mockThrottleRepo.AssertExpectations(t)
+}
+
+// TestVerifyMFA_CodigoReusado prueba que un código ya aceptado no sirva de nuevo
+func TestVerifyMFA_CodigoReusado(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(mockUserRepo, mockTokenRepo, mockMFARepo, mockThrottleRepo, box)
+
+	expectValidChallenge(mockUserRepo, mockTokenRepo, mockThrottleRepo)
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(confirmedTOTP(t, box, auth.TOTPStep(mfaNow)), nil)
+
+	// ACT
+	user, err := authService.VerifyMFA(context.Background(), &models.MFALoginRequest{Token: "desafio", Code: currentCode(t)}, models.ClientInfo{IPAddress: testIP})
+
+	// ASSERT
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+	mockMFARepo.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+	mockTokenRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestVerifyMFA_CodigoDeRecuperacion prueba que se acepte un código de recuperación como lo tipee el usuario
+func TestVerifyMFA_CodigoDeRecuperacion(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(mockUserRepo, mockTokenRepo, mockMFARepo, mockThrottleRepo, box)
+
+	expectValidChallenge(mockUserRepo, mockTokenRepo, mockThrottleRepo)
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(confirmedTOTP(t, box, 0), nil)
+	mockMFARepo.On("UseRecoveryCode", mock.Anything, 1, auth.HashToken("abcdefghijklmnop")).Return(true, nil).Once()
+	mockTokenRepo.On("Consume", mock.Anything, models.TokenPurposeMFAChallenge, auth.HashToken("desafio"), mfaNow).
+		Return(&models.UserToken{UserID: 1}, nil)
+	mockThrottleRepo.On("Reset", mock.Anything, models.ThrottleScopeAccount, testEmail).Return(nil)
+	mockThrottleRepo.On("Release", mock.Anything, models.ThrottleScopeIP, testIP).Return(nil)
+
+	// ACT
+	user, err := authService.VerifyMFA(context.Background(), &models.MFALoginRequest{Token: "desafio", Code: "ABCD-EFGH-IJKL-MNOP"}, models.ClientInfo{IPAddress: testIP})
+
+	// ASSERT
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	mockMFARepo.AssertExpectations(t)
+}
+
+// TestVerifyMFA_CodigoIncorrectoCuentaComoFallo prueba que adivinar códigos sume
+// intentos fallidos y no consuma el desafío
+func TestVerifyMFA_CodigoIncorrectoCuentaComoFallo(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	mockThrottleRepo := new(mocks.MockLoginThrottleRepository)
+	mockEvents := new(mocks.MockEvents)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(mockUserRepo, mockTokenRepo, mockMFARepo, mockThrottleRepo, box).WithEvents(mockEvents)
+
+	expectValidChallenge(mockUserRepo, mockTokenRepo, mockThrottleRepo)
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(confirmedTOTP(t, box, 0), nil)
+	mockEvents.On("LoginFailed", services.LoginFailureBadMFACode).Return().Once()
+
+	// ACT
+	user, err := authService.VerifyMFA(context.Background(), &models.MFALoginRequest{Token: "desafio", Code: "000000"}, models.ClientInfo{IPAddress: testIP})
+
+	// ASSERT
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+	assert.Equal(t, services.ErrInvalidMFACode, err.Error())
+	mockThrottleRepo.AssertExpectations(t)
+	mockEvents.AssertExpectations(t)
+	mockTokenRepo.AssertNotCalled(t, "Consume", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestVerifyMFA_DesafioVencido prueba que sin desafío vigente haya que empezar de nuevo
+func TestVerifyMFA_DesafioVencido(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	mockTokenRepo := new(mocks.MockUserTokenRepository)
+	authService := newMFAAuthService(mockUserRepo, mockTokenRepo, new(mocks.MockTwoFactorRepository), new(mocks.MockLoginThrottleRepository), newTestSecretBox(t))
+
+	mockTokenRepo.On("Find", mock.Anything, models.TokenPurposeMFAChallenge, mock.Anything, mfaNow).Return(nil, nil)
+
+	// ACT
+	user, err := authService.VerifyMFA(context.Background(), &models.MFALoginRequest{Token: "viejo", Code: "123456"}, models.ClientInfo{IPAddress: testIP})
+
+	// ASSERT
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, services.ErrUnauthorized)
+	assert.Equal(t, services.ErrInvalidMFAChallenge, err.Error())
+	mockUserRepo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+}
+
+// TestDisableTOTP_PideCodigo prueba que desactivar el segundo factor exija un código válido
+func TestDisableTOTP_PideCodigo(t *testing.T) {
+	// ARRANGE
+	mockMFARepo := new(mocks.MockTwoFactorRepository)
+	box := newTestSecretBox(t)
+	authService := newMFAAuthService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), mockMFARepo, new(mocks.MockLoginThrottleRepository), box)
+
+	mockMFARepo.On("FindTOTP", mock.Anything, 1).Return(confirmedTOTP(t, box, 0), nil)
+
+	// ACT: con un código incorrecto no se desactiva
+	err := authService.DisableTOTP(context.Background(), 1, &models.MFACodeRequest{Code: "000000"})
+
+	// ASSERT
+	assert.ErrorIs(t, err, services.ErrValidation)
+	mockMFARepo.AssertNotCalled(t, "DeleteTOTP", mock.Anything, mock.Anything)
+
+	// ARRANGE
+	mockMFARepo.On("UseTOTPStep", mock.Anything, 1, auth.TOTPStep(mfaNow)).Return(true, nil)
+	mockMFARepo.On("DeleteTOTP", mock.Anything, 1).Return(nil).Once()
+
+	// ACT: con el código correcto sí
+	err = authService.DisableTOTP(context.Background(), 1, &models.MFACodeRequest{Code: currentCode(t)})
+
+	// ASSERT
+	require.NoError(t, err)
+	mockMFARepo.AssertExpectations(t)
+}
+
+// TestAdminService_ExigeMFA prueba que un admin sin 2FA no pueda administrar usuarios
+func TestAdminService_ExigeMFA(t *testing.T) {
+	// ARRANGE
+	mockUserRepo := new(mocks.MockUserRepository)
+	adminService := services.NewAdminService(mockUserRepo, new(mocks.MockSessionRepository)).
+		WithMFARequiredRoles(models.RoleAdmin)
+
+	mockUserRepo.On("FindByID", mock.Anything, 1).Return(&models.User{ID: 1, Role: models.RoleAdmin}, nil)
+
+	// ACT
+	user, err := adminService.AssignRole(context.Background(), 1, 2, models.RoleModerator)
+
+	// ASSERT
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, services.ErrMFASetupRequired)
+	mockUserRepo.AssertNotCalled(t, "FindByID", mock.Anything, 2)
+	mockUserRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -43,13 +43,17 @@ describe('Authentication Flow', () => {
             body: {
                 id: 1,
                 email: 'test@example.com',
-                username: 'testuser'
+                username: 'testuser',
+                session_id: 'sesion-1',
+                access_token: 'access-token',
+                token_type: 'Bearer',
+                refresh_token: 'refresh-token'
             }
         }).as('loginRequest')
 
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         })
 
         cy.get('input#email').type('test@example.com')
@@ -73,9 +77,23 @@ describe('Authentication Flow', () => {
             }
         }).as('registerRequest')
 
+        // El registro no abre sesión: la app inicia sesión a continuación
+        cy.intercept('POST', '**/api/auth/login', {
+            statusCode: 200,
+            body: {
+                id: 2,
+                email: 'newuser@example.com',
+                username: 'newuser',
+                session_id: 'sesion-1',
+                access_token: 'access-token',
+                token_type: 'Bearer',
+                refresh_token: 'refresh-token'
+            }
+        }).as('loginRequest')
+
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         })
 
         // Cambiar a modo registro
@@ -87,6 +105,10 @@ describe('Authentication Flow', () => {
         cy.get('button[type="submit"]').click()
 
         cy.wait('@registerRequest')
+        cy.wait('@loginRequest').its('request.body').should('deep.equal', {
+            email: 'newuser@example.com',
+            password: '123456'
+        })
 
         // Verificar que muestra la app
         cy.contains('Hola, @newuser').should('be.visible')
@@ -5,19 +5,27 @@ describe('Comments Management', () => {
 
         cy.intercept('POST', '**/api/auth/login', {
             statusCode: 200,
-            body: { id: 1, email: 'test@example.com', username: 'testuser' }
+            body: {
+                id: 1,
+                email: 'test@example.com',
+                username: 'testuser',
+                session_id: 'sesion-1',
+                access_token: 'access-token',
+                token_type: 'Bearer',
+                refresh_token: 'refresh-token'
+            }
         })
 
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: [{
+            body: { items: [{
                 id: 1,
                 title: 'Post de prueba',
                 content: 'Contenido del post',
                 user_id: 1,
                 username: 'testuser',
                 created_at: new Date().toISOString()
-            }]
+            }] }
         })
 
         cy.get('input#email').type('test@example.com')
@@ -40,7 +48,7 @@ describe('Comments Management', () => {
 
         cy.intercept('GET', '**/api/posts/1/comments', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         })
 
         cy.contains('Post de prueba').click()
@@ -64,7 +72,7 @@ describe('Comments Management', () => {
 
         cy.intercept('GET', '**/api/posts/1/comments', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         }).as('getComments')
 
         cy.intercept('POST', '**/api/posts/1/comments', {
@@ -85,7 +93,10 @@ describe('Comments Management', () => {
         cy.get('textarea[placeholder*="comentario"]').type('Mi comentario')
         cy.contains('button', 'Comentar').click()
 
-        cy.wait('@createComment')
+        cy.wait('@createComment').then(({ request }) => {
+            expect(request.headers).to.have.property('authorization', 'Bearer access-token')
+            expect(request.headers).not.to.have.property('x-user-id')
+        })
     })
 
     it('debería deshabilitar botón comentar si está vacío', () => {
@@ -103,7 +114,7 @@ describe('Comments Management', () => {
 
         cy.intercept('GET', '**/api/posts/1/comments', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         })
 
         cy.contains('Post de prueba').click()
@@ -129,7 +140,7 @@ describe('Comments Management', () => {
 
         cy.intercept('GET', '**/api/posts/1/comments', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         })
 
         cy.contains('Post de prueba').click()
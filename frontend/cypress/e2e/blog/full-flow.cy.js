@@ -8,7 +8,20 @@ describe('Full User Flow', () => {
             body: { id: 1, email: 'nuevo@example.com', username: 'nuevo' }
         }).as('register')
 
-        cy.intercept('GET', '**/api/posts', { statusCode: 200, body: [] })
+        cy.intercept('POST', '**/api/auth/login', {
+            statusCode: 200,
+            body: {
+                id: 1,
+                email: 'nuevo@example.com',
+                username: 'nuevo',
+                session_id: 'sesion-1',
+                access_token: 'access-token',
+                token_type: 'Bearer',
+                refresh_token: 'refresh-token'
+            }
+        }).as('login')
+
+        cy.intercept('GET', '**/api/posts', { statusCode: 200, body: { items: [] } })
 
         cy.contains('¿No tienes cuenta? Regístrate').click()
         cy.get('input#email').type('nuevo@example.com')
@@ -17,6 +30,7 @@ describe('Full User Flow', () => {
         cy.get('button[type="submit"]').click()
 
         cy.wait('@register')
+        cy.wait('@login')
         cy.contains('Hola, @nuevo').should('be.visible')
 
         // 2. CREAR POST
@@ -34,21 +48,21 @@ describe('Full User Flow', () => {
 
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: [{
+            body: { items: [{
                 id: 1,
                 title: 'Mi primer post',
                 content: 'Contenido inicial',
                 user_id: 1,
                 username: 'nuevo',
                 created_at: new Date().toISOString()
-            }]
+            }] }
         })
 
         cy.get('input[placeholder*="título"]').type('Mi primer post')
         cy.get('textarea[placeholder*="compartir"]').type('Contenido inicial')
         cy.contains('button', 'Publicar Post').click()
 
-        cy.wait('@createPost')
+        cy.wait('@createPost').its('request.headers.authorization').should('eq', 'Bearer access-token')
         cy.contains('Mi primer post').should('be.visible')
 
         // 3. VER DETALLE Y COMENTAR
@@ -66,7 +80,7 @@ describe('Full User Flow', () => {
 
         cy.intercept('GET', '**/api/posts/1/comments', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         })
 
         cy.intercept('POST', '**/api/posts/1/comments', {
@@ -79,16 +93,21 @@ describe('Full User Flow', () => {
                 content: 'Gran post!',
                 created_at: new Date().toISOString()
             }
-        })
+        }).as('createComment')
 
         cy.contains('Mi primer post').click()
         cy.get('textarea[placeholder*="comentario"]').type('Gran post!')
         cy.contains('button', 'Comentar').click()
+        cy.wait('@createComment').its('request.headers.authorization').should('eq', 'Bearer access-token')
 
         // 4. LOGOUT
+        cy.intercept('POST', '**/api/auth/logout', { statusCode: 204 }).as('logout')
+
         cy.contains('← Volver').click()
         cy.contains('Cerrar Sesión').click()
 
+        cy.wait('@logout').its('request.headers.authorization').should('eq', 'Bearer access-token')
+
         cy.get('h2').should('contain', 'Iniciar Sesión')
     })
 })
\ No newline at end of file
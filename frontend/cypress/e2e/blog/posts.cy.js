@@ -8,13 +8,17 @@ describe('Posts Management', () => {
             body: {
                 id: 1,
                 email: 'test@example.com',
-                username: 'testuser'
+                username: 'testuser',
+                session_id: 'sesion-1',
+                access_token: 'access-token',
+                token_type: 'Bearer',
+                refresh_token: 'refresh-token'
             }
         })
 
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: []
+            body: { items: [] }
         }).as('getPosts')
 
         cy.get('input#email').type('test@example.com')
@@ -43,27 +47,69 @@ describe('Posts Management', () => {
 
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: [{
+            body: { items: [{
                 id: 1,
                 title: 'Mi primer post',
                 content: 'Contenido de prueba',
                 user_id: 1,
                 username: 'testuser',
                 created_at: new Date().toISOString()
-            }]
+            }] }
         })
 
         cy.get('input[placeholder*="título"]').type('Mi primer post')
         cy.get('textarea[placeholder*="compartir"]').type('Contenido de prueba')
         cy.contains('button', 'Publicar Post').click()
 
-        cy.wait('@createPost')
+        // El post se crea con el token de la sesión, no con X-User-ID
+        cy.wait('@createPost').then(({ request }) => {
+            expect(request.headers).to.have.property('authorization', 'Bearer access-token')
+            expect(request.headers).not.to.have.property('x-user-id')
+        })
 
         // Verificar que el post aparece en la lista
         cy.contains('Mi primer post').should('be.visible')
         cy.contains('Contenido de prueba').should('be.visible')
     })
 
+    it('debería renovar el token vencido y reintentar', () => {
+        cy.intercept('POST', '**/api/posts', {
+            statusCode: 201,
+            body: {
+                id: 1,
+                title: 'Post tras renovar',
+                content: 'Contenido',
+                user_id: 1,
+                username: 'testuser',
+                created_at: new Date().toISOString()
+            }
+        }).as('createPost')
+
+        // El primer intento recibe 401: el token de acceso venció
+        cy.intercept({ method: 'POST', url: '**/api/posts', times: 1 }, {
+            statusCode: 401,
+            body: { error: 'token inválido o expirado' }
+        }).as('expired')
+
+        cy.intercept('POST', '**/api/auth/refresh', {
+            statusCode: 200,
+            body: {
+                session_id: 'sesion-1',
+                access_token: 'new-access-token',
+                token_type: 'Bearer',
+                refresh_token: 'new-refresh-token'
+            }
+        }).as('refresh')
+
+        cy.get('input[placeholder*="título"]').type('Post tras renovar')
+        cy.get('textarea[placeholder*="compartir"]').type('Contenido')
+        cy.contains('button', 'Publicar Post').click()
+
+        cy.wait('@expired')
+        cy.wait('@refresh').its('request.body').should('deep.equal', { refresh_token: 'refresh-token' })
+        cy.wait('@createPost').its('request.headers.authorization').should('eq', 'Bearer new-access-token')
+    })
+
     it('debería mostrar error al crear post sin título', () => {
         cy.get('textarea[placeholder*="compartir"]').type('Solo contenido')
         cy.contains('button', 'Publicar Post').click()
@@ -76,7 +122,7 @@ describe('Posts Management', () => {
     it('debería listar posts existentes', () => {
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: [
+            body: { items: [
                 {
                     id: 1,
                     title: 'Post 1',
@@ -93,7 +139,7 @@ describe('Posts Management', () => {
                     username: 'otheruser',
                     created_at: '2024-10-27T00:00:00Z'
                 }
-            ]
+            ] }
         })
 
         cy.visit('/')
@@ -110,14 +156,14 @@ describe('Posts Management', () => {
     it('no debería mostrar botón eliminar en posts de otros', () => {
         cy.intercept('GET', '**/api/posts', {
             statusCode: 200,
-            body: [{
+            body: { items: [{
                 id: 2,
                 title: 'Post de otro',
                 content: 'Contenido',
                 user_id: 2,
                 username: 'otheruser',
                 created_at: new Date().toISOString()
-            }]
+            }] }
         })
 
         cy.visit('/')
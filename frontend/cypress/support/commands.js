@@ -18,17 +18,21 @@ Cypress.Commands.add('login', (email, password) => {
 
 // Comando para interceptar API del backend
 Cypress.Commands.add('mockBackend', () => {
-    cy.intercept('POST', '**/api/login', {
+    cy.intercept('POST', '**/api/auth/login', {
         statusCode: 200,
         body: {
             id: 1,
             email: 'test@example.com',
-            username: 'testuser'
+            username: 'testuser',
+            session_id: 'sesion-1',
+            access_token: 'access-token',
+            token_type: 'Bearer',
+            refresh_token: 'refresh-token'
         }
     }).as('loginRequest')
 
     cy.intercept('GET', '**/api/posts', {
         statusCode: 200,
-        body: []
+        body: { items: [] }
     }).as('getPosts')
 })
\ No newline at end of file
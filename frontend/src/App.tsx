@@ -4,6 +4,7 @@ import { PostList } from './components/PostList/PostList';
 import { CreatePost } from './components/CreatePost/CreatePost';
 import { PostDetail } from './components/PostDetail/PostDetail';
 import { User } from './types';
+import { authService } from './services/authService';
 import './App.css';
 
 type View = 'list' | 'detail';
@@ -19,6 +20,8 @@ function App() {
   };
 
   const handleLogout = () => {
+    // Cierra la sesión en el backend; si falla, los tokens igual se olvidan
+    authService.logout().catch(() => {});
     setCurrentUser(null);
     setCurrentView('list');
     setSelectedPostId(null);
@@ -60,7 +63,7 @@ function App() {
       <main>
         {currentView === 'list' ? (
           <>
-            <CreatePost userId={currentUser.id} onPostCreated={handlePostCreated} />
+            <CreatePost onPostCreated={handlePostCreated} />
             <PostList 
               currentUserId={currentUser.id} 
               onRefresh={refreshPosts}
@@ -13,7 +13,7 @@ describe('CommentForm Component', () => {
     });
 
     test('renderiza el formulario correctamente', () => {
-        render(<CommentForm postId={1} userId={1} onCommentCreated={mockOnCommentCreated} />);
+        render(<CommentForm postId={1} onCommentCreated={mockOnCommentCreated} />);
 
         expect(screen.getByText('Agregar Comentario')).toBeInTheDocument();
         expect(screen.getByPlaceholderText(/escribe tu comentario/i)).toBeInTheDocument();
@@ -30,7 +30,7 @@ describe('CommentForm Component', () => {
             created_at: '2024-01-01'
         });
 
-        render(<CommentForm postId={1} userId={1} onCommentCreated={mockOnCommentCreated} />);
+        render(<CommentForm postId={1} onCommentCreated={mockOnCommentCreated} />);
 
         const textarea = screen.getByPlaceholderText(/escribe tu comentario/i);
         const submitButton = screen.getByRole('button', { name: /comentar/i });
@@ -41,8 +41,7 @@ describe('CommentForm Component', () => {
         await waitFor(() => {
             expect(mockedPostService.createComment).toHaveBeenCalledWith(
                 1,
-                { content: 'Great post!' },
-                1
+                { content: 'Great post!' }
             );
             expect(mockOnCommentCreated).toHaveBeenCalled();
         });
@@ -53,7 +52,7 @@ describe('CommentForm Component', () => {
             response: { data: { error: 'Error al crear comentario' } }
         });
 
-        render(<CommentForm postId={1} userId={1} onCommentCreated={mockOnCommentCreated} />);
+        render(<CommentForm postId={1} onCommentCreated={mockOnCommentCreated} />);
 
         fireEvent.change(screen.getByPlaceholderText(/escribe tu comentario/i), {
             target: { value: 'Test comment' }
@@ -68,7 +67,7 @@ describe('CommentForm Component', () => {
     });
 
     test('botón deshabilitado cuando el textarea está vacío', () => {
-        render(<CommentForm postId={1} userId={1} onCommentCreated={mockOnCommentCreated} />);
+        render(<CommentForm postId={1} onCommentCreated={mockOnCommentCreated} />);
 
         const submitButton = screen.getByRole('button', { name: /comentar/i });
         expect(submitButton).toBeDisabled();
@@ -4,11 +4,10 @@ import './CommentForm.css';
 
 interface CommentFormProps {
   postId: number;
-  userId: number;
   onCommentCreated: () => void;
 }
 
-export const CommentForm: React.FC<CommentFormProps> = ({ postId, userId, onCommentCreated }) => {
+export const CommentForm: React.FC<CommentFormProps> = ({ postId, onCommentCreated }) => {
   const [content, setContent] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
@@ -19,7 +18,7 @@ export const CommentForm: React.FC<CommentFormProps> = ({ postId, userId, onComm
     setLoading(true);
 
     try {
-      await postService.createComment(postId, { content }, userId);
+      await postService.createComment(postId, { content });
       setContent('');
       onCommentCreated();
     } catch (err: any) {
@@ -1,10 +1,21 @@
 import { render, screen, fireEvent, waitFor } from '@testing-library/react';
 import  CommentList from './CommentList';
 import axios from 'axios';
+import { setSession } from '../../services/authService';
 
 jest.mock('axios');
 const mockedAxios = axios as jest.Mocked<typeof axios>;
 
+// Sesión iniciada: los requests de escritura llevan este token de acceso
+const testTokens = {
+  session_id: 'sesion-1',
+  access_token: 'access-token',
+  token_type: 'Bearer',
+  expires_at: '2025-01-01T10:15:00Z',
+  refresh_token: 'refresh-token',
+  refresh_expires_at: '2025-01-31T10:00:00Z'
+};
+
 describe('CommentList Component', () => {
   const mockComments = [
     {
@@ -27,6 +38,7 @@ describe('CommentList Component', () => {
 
   beforeEach(() => {
     jest.clearAllMocks();
+    setSession(testTokens);
   });
 
   test('renderiza la lista de comentarios correctamente', async () => {
@@ -35,7 +47,7 @@ describe('CommentList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockComments
+      data: { items: mockComments }
     });
 
     render(<CommentList postId={1} currentUserId={1} />);
@@ -54,7 +66,7 @@ describe('CommentList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: []
+      data: { items: [] }
     });
 
     render(<CommentList postId={1} currentUserId={1} />);
@@ -70,7 +82,7 @@ describe('CommentList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockComments
+      data: { items: mockComments }
     });
 
     render(<CommentList postId={1} currentUserId={1} />);
@@ -90,7 +102,7 @@ describe('CommentList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockComments
+      data: { items: mockComments }
     });
     mockedAxios.delete.mockResolvedValueOnce({
       status: 200,
@@ -124,7 +136,7 @@ describe('CommentList Component', () => {
         'http://localhost:8080/api/posts/1/comments/1',
         {
           headers: {
-            'X-User-ID': '1'
+            Authorization: 'Bearer access-token'
           }
         }
       );
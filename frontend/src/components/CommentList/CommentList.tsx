@@ -41,7 +41,7 @@ const CommentList: React.FC<CommentListProps> = ({
 
     const handleDelete = async (commentId: number) => {
         try {
-            await deleteComment(postId, commentId, currentUserId);
+            await deleteComment(postId, commentId);
 
             // Actualizar lista de comentarios usando función de estado
             setComments(prev => prev.filter(c => c.id !== commentId));
@@ -13,7 +13,7 @@ describe('CreatePost Component', () => {
     });
 
     test('renderiza el formulario correctamente', () => {
-        render(<CreatePost userId={1} onPostCreated={mockOnPostCreated} />);
+        render(<CreatePost onPostCreated={mockOnPostCreated} />);
 
         expect(screen.getByText('Crear Nuevo Post')).toBeInTheDocument();
         expect(screen.getByPlaceholderText(/escribe un título/i)).toBeInTheDocument();
@@ -31,7 +31,7 @@ describe('CreatePost Component', () => {
             created_at: '2024-01-01'
         });
 
-        render(<CreatePost userId={1} onPostCreated={mockOnPostCreated} />);
+        render(<CreatePost onPostCreated={mockOnPostCreated} />);
 
         const titleInput = screen.getByPlaceholderText(/escribe un título/i);
         const contentInput = screen.getByPlaceholderText(/qué quieres compartir/i);
@@ -43,8 +43,7 @@ describe('CreatePost Component', () => {
 
         await waitFor(() => {
             expect(mockedPostService.createPost).toHaveBeenCalledWith(
-                { title: 'Test Post', content: 'Test Content' },
-                1
+                { title: 'Test Post', content: 'Test Content' }
             );
             expect(mockOnPostCreated).toHaveBeenCalled();
         });
@@ -55,7 +54,7 @@ describe('CreatePost Component', () => {
             response: { data: { error: 'Error al crear post' } }
         });
 
-        render(<CreatePost userId={1} onPostCreated={mockOnPostCreated} />);
+        render(<CreatePost onPostCreated={mockOnPostCreated} />);
 
         fireEvent.change(screen.getByPlaceholderText(/escribe un título/i), { target: { value: 'Test' } });
         fireEvent.change(screen.getByPlaceholderText(/qué quieres compartir/i), { target: { value: 'Content' } });
@@ -73,7 +72,7 @@ describe('CreatePost Component', () => {
             () => new Promise(resolve => setTimeout(resolve, 100))
         );
 
-        render(<CreatePost userId={1} onPostCreated={mockOnPostCreated} />);
+        render(<CreatePost onPostCreated={mockOnPostCreated} />);
 
         fireEvent.change(screen.getByPlaceholderText(/escribe un título/i), { target: { value: 'Test' } });
         fireEvent.change(screen.getByPlaceholderText(/qué quieres compartir/i), { target: { value: 'Content' } });
@@ -3,11 +3,10 @@ import { postService } from '../../services/postService';
 import './CreatePost.css';
 
 interface CreatePostProps {
-  userId: number;
   onPostCreated: () => void;
 }
 
-export const CreatePost: React.FC<CreatePostProps> = ({ userId, onPostCreated }) => {
+export const CreatePost: React.FC<CreatePostProps> = ({ onPostCreated }) => {
   const [title, setTitle] = useState('');
   const [content, setContent] = useState('');
   const [error, setError] = useState('');
@@ -19,7 +18,7 @@ export const CreatePost: React.FC<CreatePostProps> = ({ userId, onPostCreated })
     setLoading(true);
 
     try {
-      await postService.createPost({ title, content }, userId);
+      await postService.createPost({ title, content });
       
       // Limpiar formulario
       setTitle('');
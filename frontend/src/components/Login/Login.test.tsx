@@ -48,12 +48,21 @@ describe('Login Component', () => {
       created_at: '2025-01-01'
     };
 
+    // El login devuelve el usuario junto con los tokens de la sesión
     mockedAxios.post.mockResolvedValueOnce({
       status: 200,
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockUser
+      data: {
+        ...mockUser,
+        session_id: 'sesion-1',
+        access_token: 'access-token',
+        token_type: 'Bearer',
+        expires_at: '2025-01-01T10:15:00Z',
+        refresh_token: 'refresh-token',
+        refresh_expires_at: '2025-01-31T10:00:00Z'
+      }
     });
 
     render(<Login onLoginSuccess={mockOnLoginSuccess} />);
@@ -71,7 +71,6 @@ export const PostDetail: React.FC<PostDetailProps> = ({ postId, userId, onBack }
 
       <CommentForm 
         postId={postId} 
-        userId={userId} 
         onCommentCreated={handleCommentCreated} 
       />
 
@@ -1,10 +1,21 @@
 import { render, screen, fireEvent, waitFor } from '@testing-library/react';
 import { PostList } from './PostList';
 import axios from 'axios';
+import { setSession } from '../../services/authService';
 
 jest.mock('axios');
 const mockedAxios = axios as jest.Mocked<typeof axios>;
 
+// Sesión iniciada: los requests de escritura llevan este token de acceso
+const testTokens = {
+  session_id: 'sesion-1',
+  access_token: 'access-token',
+  token_type: 'Bearer',
+  expires_at: '2025-01-01T10:15:00Z',
+  refresh_token: 'refresh-token',
+  refresh_expires_at: '2025-01-31T10:00:00Z'
+};
+
 describe('PostList Component', () => {
   const mockPosts = [
     {
@@ -27,6 +38,7 @@ describe('PostList Component', () => {
 
   beforeEach(() => {
     jest.clearAllMocks();
+    setSession(testTokens);
   });
 
   test('renderiza la lista de posts correctamente', async () => {
@@ -35,7 +47,7 @@ describe('PostList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockPosts
+      data: { items: mockPosts }
     });
 
     render(<PostList currentUserId={1} />);
@@ -58,7 +70,7 @@ describe('PostList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: []
+      data: { items: [] }
     });
 
     render(<PostList currentUserId={1} />);
@@ -74,7 +86,7 @@ describe('PostList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockPosts
+      data: { items: mockPosts }
     });
 
     render(<PostList currentUserId={1} />);
@@ -96,7 +108,7 @@ describe('PostList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: mockPosts
+      data: { items: mockPosts }
     });
     mockedAxios.delete.mockResolvedValueOnce({
       status: 200,
@@ -110,7 +122,7 @@ describe('PostList Component', () => {
       statusText: 'OK',
       headers: {},
       config: { url: '' },
-      data: []
+      data: { items: [] }
     }); // Segunda llamada después de eliminar
 
     window.confirm = jest.fn(() => true); // Mock de confirm
@@ -131,7 +143,7 @@ describe('PostList Component', () => {
         'http://localhost:8080/api/posts/1',
         {
           headers: {
-            'X-User-ID': '1'
+            Authorization: 'Bearer access-token'
           }
         }
       );
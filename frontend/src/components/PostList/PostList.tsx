@@ -37,7 +37,7 @@ export const PostList: React.FC<PostListProps> = ({ currentUserId, onRefresh, on
         }
 
         try {
-            await postService.deletePost(postId, currentUserId);
+            await postService.deletePost(postId);
             loadPosts();
         } catch (err: any) {
             alert(err.response?.data?.error || 'Error al eliminar post');
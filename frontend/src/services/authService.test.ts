@@ -1,5 +1,5 @@
 import axios from 'axios';
-import { authService, getApiBaseUrl } from './authService';
+import { authService, getApiBaseUrl, setSession, clearSession, withAuth } from './authService';
 
 jest.mock('axios');
 const mockedAxios = axios as jest.Mocked<typeof axios>;
@@ -26,12 +26,39 @@ describe('getApiBaseUrl', () => {
   });
 });
 
+const testTokens = {
+  session_id: 'sesion-1',
+  access_token: 'access-token',
+  token_type: 'Bearer',
+  expires_at: '2025-01-01T10:15:00Z',
+  refresh_token: 'refresh-token',
+  refresh_expires_at: '2025-01-31T10:00:00Z'
+};
+
 describe('authService', () => {
   beforeEach(() => {
     jest.clearAllMocks();
+    clearSession();
   });
 
   describe('login', () => {
+    test('returns the user and keeps the tokens for later requests', async () => {
+      const mockUser = {
+        id: 1,
+        email: 'test@example.com',
+        username: 'testuser',
+        created_at: '2025-01-01'
+      };
+      mockedAxios.post.mockResolvedValueOnce({ data: { ...mockUser, ...testTokens } });
+
+      const result = await authService.login({ email: 'test@example.com', password: '123456' });
+      const request = jest.fn().mockResolvedValueOnce('ok');
+      await withAuth(request);
+
+      expect(result).toEqual(mockUser);
+      expect(request).toHaveBeenCalledWith({ headers: { Authorization: 'Bearer access-token' } });
+    });
+
     test('calls API correctly with credentials', async () => {
       const mockUser = {
         id: 1,
@@ -89,7 +116,7 @@ describe('authService', () => {
   });
 
   describe('register', () => {
-    test('calls API correctly with registration data', async () => {
+    test('calls API correctly with registration data and then logs in', async () => {
       const mockUser = {
         id: 1,
         email: 'newuser@example.com',
@@ -97,12 +124,19 @@ describe('authService', () => {
         created_at: '2025-01-01'
       };
 
+      mockedAxios.post.mockResolvedValueOnce({
+        status: 201,
+        statusText: 'Created',
+        headers: {},
+        config: { url: '' },
+        data: mockUser
+      });
       mockedAxios.post.mockResolvedValueOnce({
         status: 200,
         statusText: 'OK',
         headers: {},
         config: { url: '' },
-        data: mockUser
+        data: { ...mockUser, ...testTokens }
       });
 
       const result = await authService.register({
@@ -119,6 +153,14 @@ describe('authService', () => {
           username: 'newuser'
         }
       );
+      // Registering does not open a session, so it logs in right after
+      expect(mockedAxios.post).toHaveBeenLastCalledWith(
+        'http://localhost:8080/api/auth/login',
+        {
+          email: 'newuser@example.com',
+          password: '123456'
+        }
+      );
       expect(result).toEqual(mockUser);
     });
 
@@ -148,4 +190,85 @@ describe('authService', () => {
       ).rejects.toEqual(error);
     });
   });
+
+  describe('logout', () => {
+    test('closes the session in the backend and forgets the tokens', async () => {
+      setSession(testTokens);
+      mockedAxios.post.mockResolvedValueOnce({ data: {} });
+
+      await authService.logout();
+
+      expect(mockedAxios.post).toHaveBeenCalledWith(
+        'http://localhost:8080/api/auth/logout',
+        null,
+        { headers: { Authorization: 'Bearer access-token' } }
+      );
+      await expect(withAuth(jest.fn().mockRejectedValueOnce({ response: { status: 401 } })))
+        .rejects.toEqual({ response: { status: 401 } });
+      expect(mockedAxios.post).toHaveBeenCalledTimes(1);
+    });
+  });
+});
+
+describe('withAuth', () => {
+  beforeEach(() => {
+    jest.clearAllMocks();
+    setSession(testTokens);
+  });
+
+  test('sends the access token as a Bearer token', async () => {
+    const request = jest.fn().mockResolvedValueOnce('ok');
+
+    const result = await withAuth(request);
+
+    expect(result).toBe('ok');
+    expect(request).toHaveBeenCalledWith({ headers: { Authorization: 'Bearer access-token' } });
+  });
+
+  test('refreshes the session on 401 and retries once with the new token', async () => {
+    const request = jest.fn()
+      .mockRejectedValueOnce({ response: { status: 401 } })
+      .mockResolvedValueOnce('ok');
+    mockedAxios.post.mockResolvedValueOnce({
+      data: { ...testTokens, access_token: 'new-access-token', refresh_token: 'new-refresh-token' }
+    });
+
+    const result = await withAuth(request);
+
+    expect(result).toBe('ok');
+    expect(mockedAxios.post).toHaveBeenCalledWith(
+      'http://localhost:8080/api/auth/refresh',
+      { refresh_token: 'refresh-token' }
+    );
+    expect(request).toHaveBeenLastCalledWith({ headers: { Authorization: 'Bearer new-access-token' } });
+  });
+
+  test('simultaneous 401s share a single refresh', async () => {
+    const expired = () => jest.fn()
+      .mockRejectedValueOnce({ response: { status: 401 } })
+      .mockResolvedValueOnce('ok');
+    mockedAxios.post.mockResolvedValueOnce({ data: { ...testTokens, access_token: 'new-access-token' } });
+
+    await Promise.all([withAuth(expired()), withAuth(expired())]);
+
+    expect(mockedAxios.post).toHaveBeenCalledTimes(1);
+  });
+
+  test('forgets the session when the refresh token is no longer valid', async () => {
+    const request = jest.fn().mockRejectedValue({ response: { status: 401 } });
+    const refreshError = { response: { status: 401, data: { error: 'sesión inválida' } } };
+    mockedAxios.post.mockRejectedValueOnce(refreshError);
+
+    await expect(withAuth(request)).rejects.toEqual(refreshError);
+    // Without a session there is nothing left to refresh
+    await expect(withAuth(request)).rejects.toEqual({ response: { status: 401 } });
+    expect(mockedAxios.post).toHaveBeenCalledTimes(1);
+  });
+
+  test('does not refresh on other errors', async () => {
+    const error = { response: { status: 403 } };
+
+    await expect(withAuth(jest.fn().mockRejectedValueOnce(error))).rejects.toEqual(error);
+    expect(mockedAxios.post).not.toHaveBeenCalled();
+  });
 });
@@ -1,5 +1,5 @@
 import axios from 'axios';
-import { User, LoginRequest, RegisterRequest } from '../types';
+import { User, LoginRequest, RegisterRequest, LoginResponse, TokenPair } from '../types';
 
 // Configure backend URLs for each environment
 export const getApiBaseUrl = (hostname = window.location.hostname, envVar?: string) => {
@@ -28,16 +28,88 @@ export const getApiBaseUrl = (hostname = window.location.hostname, envVar?: stri
 const API_BASE_URL = getApiBaseUrl();
 const API_URL = `${API_BASE_URL}/api/auth`;
 
+// Tokens de la sesión actual. Quedan en memoria, igual que el usuario en App:
+// al recargar la página hay que iniciar sesión de nuevo.
+let session: { accessToken: string; refreshToken: string } | null = null;
+
+// Si varios requests reciben 401 a la vez, comparten una sola renovación
+let refreshing: Promise<void> | null = null;
+
+// Guardar los tokens de la sesión
+export const setSession = (tokens: TokenPair) => {
+  session = { accessToken: tokens.access_token, refreshToken: tokens.refresh_token };
+};
+
+// Olvidar los tokens de la sesión
+export const clearSession = () => {
+  session = null;
+};
+
+// Renovar el token de acceso con el refresh token (/api/auth/refresh).
+// Si la sesión ya no vale, se olvida y el error llega al componente.
+export const refreshSession = (): Promise<void> => {
+  if (!session) {
+    return Promise.reject(new Error('No hay una sesión iniciada'));
+  }
+  if (!refreshing) {
+    refreshing = axios
+      .post<TokenPair>(`${API_URL}/refresh`, { refresh_token: session.refreshToken })
+      .then((response) => setSession(response.data))
+      .catch((err) => {
+        clearSession();
+        throw err;
+      })
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
+const authConfig = () => ({
+  headers: { Authorization: `Bearer ${session ? session.accessToken : ''}` }
+});
+
+// Hacer un request autenticado con el token de acceso. Si el backend responde
+// 401 (el token venció), se renueva la sesión y se reintenta una sola vez.
+export const withAuth = async <T>(request: (config: { headers: Record<string, string> }) => Promise<T>): Promise<T> => {
+  try {
+    return await request(authConfig());
+  } catch (err: any) {
+    if (err?.response?.status !== 401 || !session) {
+      throw err;
+    }
+    await refreshSession();
+    return request(authConfig());
+  }
+};
+
 export const authService = {
-  // Login de usuario
+  // Login de usuario: guarda los tokens y devuelve el usuario
   async login(credentials: LoginRequest): Promise<User> {
-    const response = await axios.post<User>(`${API_URL}/login`, credentials);
-    return response.data;
+    const response = await axios.post<LoginResponse>(`${API_URL}/login`, credentials);
+    const {
+      session_id, access_token, token_type, expires_at, refresh_token, refresh_expires_at,
+      ...user
+    } = response.data;
+    setSession({ session_id, access_token, token_type, expires_at, refresh_token, refresh_expires_at });
+    return user;
   },
 
-  // Registro de usuario
+  // Registro de usuario: el registro no abre sesión, así que después se inicia
   async register(data: RegisterRequest): Promise<User> {
-    const response = await axios.post<User>(`${API_URL}/register`, data);
-    return response.data;
+    await axios.post<User>(`${API_URL}/register`, data);
+    return authService.login({ email: data.email, password: data.password });
+  },
+
+  // Cerrar la sesión en el backend y olvidar los tokens
+  async logout(): Promise<void> {
+    try {
+      if (session) {
+        await axios.post(`${API_URL}/logout`, null, authConfig());
+      }
+    } finally {
+      clearSession();
+    }
   }
 };
@@ -1,5 +1,6 @@
 import axios from 'axios';
 import { postService, deleteComment, getApiBaseUrl } from './postService';
+import { setSession } from './authService';
 
 jest.mock('axios');
 const mockedAxios = axios as jest.Mocked<typeof axios>;
@@ -26,9 +27,21 @@ describe('getApiBaseUrl', () => {
   });
 });
 
+// Sesión iniciada: los requests de escritura llevan este token de acceso
+const testTokens = {
+    session_id: 'sesion-1',
+    access_token: 'access-token',
+    token_type: 'Bearer',
+    expires_at: '2025-01-01T10:15:00Z',
+    refresh_token: 'refresh-token',
+    refresh_expires_at: '2025-01-31T10:00:00Z'
+};
+const authHeaders = { headers: { Authorization: 'Bearer access-token' } };
+
 describe('postService', () => {
     beforeEach(() => {
         jest.clearAllMocks();
+        setSession(testTokens);
     });
 
     describe('getAllPosts', () => {
@@ -42,7 +55,7 @@ describe('postService', () => {
                 statusText: 'OK',
                 headers: {},
                 config: { url: '' },
-                data: mockPosts
+                data: { items: mockPosts }
             });
 
             const result = await postService.getAllPosts();
@@ -51,6 +64,25 @@ describe('postService', () => {
             expect(result).toEqual(mockPosts);
         });
 
+        test('sigue next_cursor hasta la última página', async () => {
+            const firstPage = [
+                { id: 3, title: 'Post 3', content: 'Content 3', user_id: 1, username: 'user1', created_at: '2024-01-03' }
+            ];
+            const lastPage = [
+                { id: 2, title: 'Post 2', content: 'Content 2', user_id: 2, username: 'user2', created_at: '2024-01-02' }
+            ];
+            mockedAxios.get
+                .mockResolvedValueOnce({ data: { items: firstPage, next_cursor: 'cursor-1' } })
+                .mockResolvedValueOnce({ data: { items: lastPage } });
+
+            const result = await postService.getAllPosts();
+
+            expect(mockedAxios.get).toHaveBeenCalledTimes(2);
+            expect(mockedAxios.get).toHaveBeenNthCalledWith(1, 'http://localhost:8080/api/posts');
+            expect(mockedAxios.get).toHaveBeenNthCalledWith(2, 'http://localhost:8080/api/posts', { params: { cursor: 'cursor-1' } });
+            expect(result).toEqual([...firstPage, ...lastPage]);
+        });
+
         test('maneja errores de red', async () => {
             const error = new Error('Network Error');
             mockedAxios.get.mockRejectedValueOnce(error);
@@ -110,12 +142,35 @@ describe('postService', () => {
                 data: mockResponse
             });
 
-            const result = await postService.createPost(newPost, 1);
+            const result = await postService.createPost(newPost);
 
             expect(mockedAxios.post).toHaveBeenCalledWith(
                 'http://localhost:8080/api/posts',
                 newPost,
-                { headers: { 'X-User-ID': '1' } }
+                authHeaders
+            );
+            expect(result).toEqual(mockResponse);
+        });
+
+        test('renueva el token vencido y reintenta', async () => {
+            const newPost = { title: 'New Post', content: 'New Content' };
+            const mockResponse = { id: 1, ...newPost, user_id: 1, username: 'user1', created_at: '2024-01-01' };
+            mockedAxios.post
+                .mockRejectedValueOnce({ response: { status: 401 } })
+                .mockResolvedValueOnce({ data: { ...testTokens, access_token: 'new-access-token' } })
+                .mockResolvedValueOnce({ data: mockResponse });
+
+            const result = await postService.createPost(newPost);
+
+            expect(mockedAxios.post).toHaveBeenNthCalledWith(
+                2,
+                'http://localhost:8080/api/auth/refresh',
+                { refresh_token: 'refresh-token' }
+            );
+            expect(mockedAxios.post).toHaveBeenLastCalledWith(
+                'http://localhost:8080/api/posts',
+                newPost,
+                { headers: { Authorization: 'Bearer new-access-token' } }
             );
             expect(result).toEqual(mockResponse);
         });
@@ -131,11 +186,11 @@ describe('postService', () => {
                 data: {}
             });
 
-            await postService.deletePost(1, 1);
+            await postService.deletePost(1);
 
             expect(mockedAxios.delete).toHaveBeenCalledWith(
                 'http://localhost:8080/api/posts/1',
-                { headers: { 'X-User-ID': '1' } }
+                authHeaders
             );
         });
     });
@@ -159,12 +214,12 @@ describe('postService', () => {
                 data: mockResponse
             });
 
-            const result = await postService.createComment(1, comment, 1);
+            const result = await postService.createComment(1, comment);
 
             expect(mockedAxios.post).toHaveBeenCalledWith(
                 'http://localhost:8080/api/posts/1/comments',
                 comment,
-                { headers: { 'X-User-ID': '1' } }
+                authHeaders
             );
             expect(result).toEqual(mockResponse);
         });
@@ -181,7 +236,7 @@ describe('postService', () => {
                 statusText: 'OK',
                 headers: {},
                 config: { url: '' },
-                data: mockComments
+                data: { items: mockComments }
             });
 
             const result = await postService.getComments(1);
@@ -189,12 +244,30 @@ describe('postService', () => {
             expect(mockedAxios.get).toHaveBeenCalledWith('http://localhost:8080/api/posts/1/comments');
             expect(result).toEqual(mockComments);
         });
+
+        test('sigue next_cursor hasta la última página', async () => {
+            const firstPage = [
+                { id: 1, post_id: 1, user_id: 1, username: 'user1', content: 'Comment 1', created_at: '2024-01-01' }
+            ];
+            const lastPage = [
+                { id: 2, post_id: 1, user_id: 2, username: 'user2', content: 'Comment 2', created_at: '2024-01-02' }
+            ];
+            mockedAxios.get
+                .mockResolvedValueOnce({ data: { items: firstPage, next_cursor: 'cursor-1' } })
+                .mockResolvedValueOnce({ data: { items: lastPage } });
+
+            const result = await postService.getComments(1);
+
+            expect(mockedAxios.get).toHaveBeenNthCalledWith(2, 'http://localhost:8080/api/posts/1/comments', { params: { cursor: 'cursor-1' } });
+            expect(result).toEqual([...firstPage, ...lastPage]);
+        });
     });
 });
 
 describe('deleteComment', () => {
     beforeEach(() => {
         jest.clearAllMocks();
+        setSession(testTokens);
     });
 
     test('elimina un comentario correctamente', async () => {
@@ -206,11 +279,11 @@ describe('deleteComment', () => {
             data: {}
         });
 
-        await deleteComment(1, 5, 1);
+        await deleteComment(1, 5);
 
         expect(mockedAxios.delete).toHaveBeenCalledWith(
             'http://localhost:8080/api/posts/1/comments/5',
-            { headers: { 'X-User-ID': '1' } }
+            authHeaders
         );
     });
 });
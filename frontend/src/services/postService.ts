@@ -1,5 +1,6 @@
 import axios from 'axios';
-import { Post, CreatePostRequest, Comment, CreateCommentRequest } from '../types';
+import { Post, CreatePostRequest, Comment, CreateCommentRequest, Page } from '../types';
+import { withAuth } from './authService';
 
 // Configure backend URLs for each environment
 export const getApiBaseUrl = (hostname = window.location.hostname, envVar?: string) => {
@@ -28,20 +29,27 @@ export const getApiBaseUrl = (hostname = window.location.hostname, envVar?: stri
 const API_BASE_URL = getApiBaseUrl();
 const API_URL = `${API_BASE_URL}/api/posts`;
 
+// Leer un listado completo: el backend lo devuelve por páginas y cada una
+// trae el cursor de la siguiente
+const getAllPages = async <T>(url: string): Promise<T[]> => {
+  let response = await axios.get<Page<T>>(url);
+  const items = [...response.data.items];
+  while (response.data.next_cursor) {
+    response = await axios.get<Page<T>>(url, { params: { cursor: response.data.next_cursor } });
+    items.push(...response.data.items);
+  }
+  return items;
+};
+
 export const postService = {
   // Obtener todos los posts
   async getAllPosts(): Promise<Post[]> {
-    const response = await axios.get<Post[]>(API_URL);
-    return response.data;
+    return getAllPages<Post>(API_URL);
   },
 
-  // Crear un nuevo post
-  async createPost(data: CreatePostRequest, userId: number): Promise<Post> {
-    const response = await axios.post<Post>(API_URL, data, {
-      headers: {
-        'X-User-ID': userId.toString()
-      }
-    });
+  // Crear un nuevo post (autor: el usuario de la sesión)
+  async createPost(data: CreatePostRequest): Promise<Post> {
+    const response = await withAuth((config) => axios.post<Post>(API_URL, data, config));
     return response.data;
   },
 
@@ -52,38 +60,25 @@ export const postService = {
   },
 
   // Eliminar un post
-  async deletePost(id: number, userId: number): Promise<void> {
-    await axios.delete(`${API_URL}/${id}`, {
-      headers: {
-        'X-User-ID': userId.toString()
-      }
-    });
+  async deletePost(id: number): Promise<void> {
+    await withAuth((config) => axios.delete(`${API_URL}/${id}`, config));
   },
 
   // Obtener comentarios de un post
   async getComments(postId: number): Promise<Comment[]> {
-    const response = await axios.get<Comment[]>(`${API_URL}/${postId}/comments`);
-    return response.data;
+    return getAllPages<Comment>(`${API_URL}/${postId}/comments`);
   },
 
   // Crear comentario
-  async createComment(postId: number, data: CreateCommentRequest, userId: number): Promise<Comment> {
-    const response = await axios.post<Comment>(
-      `${API_URL}/${postId}/comments`,
-      data,
-      {
-        headers: {
-          'X-User-ID': userId.toString()
-        }
-      }
+  async createComment(postId: number, data: CreateCommentRequest): Promise<Comment> {
+    const response = await withAuth((config) =>
+      axios.post<Comment>(`${API_URL}/${postId}/comments`, data, config)
     );
     return response.data;
   }
 };
 
 // Eliminar comentario
-export const deleteComment = async (postId: number, commentId: number, userId: number) => {
-    return axios.delete(`${API_URL}/${postId}/comments/${commentId}`, {
-        headers: { 'X-User-ID': userId.toString() }
-    });
+export const deleteComment = async (postId: number, commentId: number) => {
+    return withAuth((config) => axios.delete(`${API_URL}/${postId}/comments/${commentId}`, config));
 };
@@ -25,6 +25,25 @@ export interface User {
     created_at: string;
   }
   
+  // Página de un listado: next_cursor falta en la última
+  export interface Page<T> {
+    items: T[];
+    next_cursor?: string;
+  }
+
+  // Tokens de la sesión que devuelven /api/auth/login y /api/auth/refresh
+  export interface TokenPair {
+    session_id: string;
+    access_token: string;
+    token_type: string;
+    expires_at: string;
+    refresh_token: string;
+    refresh_expires_at: string;
+  }
+
+  // /api/auth/login devuelve el usuario junto con los tokens
+  export type LoginResponse = User & TokenPair;
+
   export interface LoginRequest {
     email: string;
     password: string;